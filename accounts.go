@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// errAccounts is returned by an --accounts-file run when some accounts
+// failed; each was reported as it did.
+var errAccounts = errors.New("accounts failed")
+
+// accountIDRE matches an AWS account ID.
+var accountIDRE = regexp.MustCompile(`^\d{12}$`)
+
+// accountTarget is one row of an --accounts-file: an account, the role
+// assumed into it and the regions run in there, none meaning --regions.
+type accountTarget struct {
+	AccountID string
+	Role      string
+	Regions   []string
+}
+
+// roleARN is the ARN of the role to assume in partition. A role given as
+// a full ARN is used as it is.
+func (a accountTarget) roleARN(partition string) string {
+	if strings.HasPrefix(a.Role, "arn:") {
+		return a.Role
+	}
+	return fmt.Sprintf("arn:%s:iam::%s:role/%s", partition, a.AccountID, a.Role)
+}
+
+// loadAccounts reads an --accounts-file, e.g.
+//
+//	account_id,role_name,regions
+//	111111111111,LambdaRuntimeBump,us-east-1 eu-west-1
+//	222222222222,LambdaRuntimeBump,
+//
+// The header row is optional. Regions are separated by spaces or
+// semicolons, or by commas when the field is quoted.
+func loadAccounts(path string) ([]accountTarget, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("--accounts-file: %w", err)
+	}
+	defer f.Close()
+	accounts, err := parseAccounts(f)
+	if err != nil {
+		return nil, fmt.Errorf("--accounts-file %s: %w", path, err)
+	}
+	if len(accounts) == 0 {
+		return nil, fmt.Errorf("--accounts-file %s: no accounts", path)
+	}
+	return accounts, nil
+}
+
+func parseAccounts(r io.Reader) ([]accountTarget, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.Comment = '#'
+	var out []accountTarget
+	for first := true; ; first = false {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		for i := range rec {
+			rec[i] = strings.TrimSpace(rec[i])
+		}
+		if first && strings.EqualFold(rec[0], "account_id") {
+			continue
+		}
+		if len(rec) < 2 || len(rec) > 3 {
+			return nil, fmt.Errorf("line %d: want account_id,role_name[,regions], got %d fields", line, len(rec))
+		}
+		a := accountTarget{AccountID: rec[0], Role: rec[1]}
+		if !accountIDRE.MatchString(a.AccountID) {
+			return nil, fmt.Errorf("line %d: %q is not an account ID", line, a.AccountID)
+		}
+		if a.Role == "" {
+			return nil, fmt.Errorf("line %d: role_name is required", line)
+		}
+		if len(rec) == 3 {
+			a.Regions = strings.FieldsFunc(rec[2], func(r rune) bool { return r == ' ' || r == ';' || r == ',' })
+		}
+		if slices.ContainsFunc(out, func(b accountTarget) bool { return b.AccountID == a.AccountID }) {
+			return nil, fmt.Errorf("line %d: account %s is listed twice", line, a.AccountID)
+		}
+		out = append(out, a)
+	}
+}
+
+// forEachAccount calls run once per account of --accounts-file, with opts
+// changed to assume the account's role and use its regions. An account
+// that fails is reported and the others still run; the error then counts
+// the failures.
+func forEachAccount(ctx context.Context, opts *AWSOpts, run func(opts *AWSOpts) error) error {
+	accounts, err := loadAccounts(opts.AccountsFile)
+	if err != nil {
+		return err
+	}
+	var failed []string
+	for _, a := range accounts {
+		if ctx.Err() != nil {
+			return stopped(ctx)
+		}
+		o := *opts
+		if len(a.Regions) > 0 {
+			o.Regions = a.Regions
+		}
+		if len(o.Regions) == 0 {
+			fmt.Fprintf(os.Stderr, "Error: account %s: no regions in the file, and no --regions\n", a.AccountID)
+			failed = append(failed, a.AccountID)
+			continue
+		}
+		o.AssumeRole = a.roleARN(partitionOf(o.Regions[0]))
+		fmt.Fprintf(os.Stderr, "Account %s as %s in %s\n", a.AccountID, o.AssumeRole, strings.Join(o.Regions, ", "))
+		if err := run(&o); err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Error: account %s: %v\n", a.AccountID, err)
+			failed = append(failed, a.AccountID)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%w: %d of %d: %s", errAccounts, len(failed), len(accounts), strings.Join(failed, ", "))
+	}
+	return nil
+}
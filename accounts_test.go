@@ -0,0 +1,48 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseAccounts(t *testing.T) {
+	accounts, err := parseAccounts(strings.NewReader(`account_id,role_name,regions
+111111111111,LambdaRuntimeBump,us-east-1 eu-west-1
+# customer B
+222222222222,arn:aws:iam::222222222222:role/ops/Bump,"us-east-1,ap-southeast-2"
+333333333333,LambdaRuntimeBump
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accounts) != 3 {
+		t.Fatalf("got %d accounts, want 3", len(accounts))
+	}
+	if got := accounts[0].roleARN("aws"); got != "arn:aws:iam::111111111111:role/LambdaRuntimeBump" {
+		t.Errorf("role ARN = %s", got)
+	}
+	if got := accounts[0].roleARN(partitionOf("us-gov-west-1")); got != "arn:aws-us-gov:iam::111111111111:role/LambdaRuntimeBump" {
+		t.Errorf("GovCloud role ARN = %s", got)
+	}
+	if !slices.Equal(accounts[0].Regions, []string{"us-east-1", "eu-west-1"}) || !slices.Equal(accounts[1].Regions, []string{"us-east-1", "ap-southeast-2"}) {
+		t.Errorf("regions %v and %v", accounts[0].Regions, accounts[1].Regions)
+	}
+	if got := accounts[1].roleARN("aws"); got != "arn:aws:iam::222222222222:role/ops/Bump" {
+		t.Errorf("full role ARN changed to %s", got)
+	}
+	if accounts[2].Regions != nil {
+		t.Errorf("regions = %v, want none so --regions applies", accounts[2].Regions)
+	}
+
+	for _, bad := range []string{
+		"1111,Role\n",
+		"111111111111,\n",
+		"111111111111,Role\n111111111111,Other\n",
+		"111111111111\n",
+	} {
+		if _, err := parseAccounts(strings.NewReader(bad)); err == nil {
+			t.Errorf("parseAccounts(%q) succeeded", bad)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"update-lambda-runtime/pkg/inventory"
+)
+
+// alerter tells people about functions found on deprecated runtimes that
+// were not on one at the previous scan. The Slack, SNS and email notifiers
+// implement it.
+type alerter interface {
+	alertNew(ctx context.Context, accountID string, fns []functionResult) error
+}
+
+// buildAlerters returns the alerters enabled by opts.
+func buildAlerters(opts *AWSOpts, clients *clientFactory) []alerter {
+	var out []alerter
+	if opts.SlackWebhook != "" {
+		out = append(out, &slackNotifier{webhook: opts.SlackWebhook})
+	}
+	if opts.SNSTopicARN != "" {
+		out = append(out, &snsNotifier{clients: clients, topicARN: opts.SNSTopicARN})
+	}
+	if len(opts.EmailTo) > 0 {
+		out = append(out, &emailNotifier{clients: clients, region: opts.Regions[0], from: opts.EmailFrom, to: opts.EmailTo})
+	}
+	return out
+}
+
+// alertText describes fns, new on deprecated runtimes in accountID, in
+// plain text for chat and email.
+func alertText(accountID string, fns []functionResult) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d new Lambda functions on deprecated runtimes in %s:", len(fns), accountID)
+	for _, f := range fns {
+		fmt.Fprintf(&b, "\n• %s (%s) %s", f.Name, f.Region, f.Runtime)
+		if d, ok := inventory.DeprecationDate(f.Runtime); ok {
+			fmt.Fprintf(&b, ", deprecated %s", d.Format(time.DateOnly))
+		}
+	}
+	return b.String()
+}
+
+// knownDeprecated is the functions of an account that were on a deprecated
+// runtime at the last --alert-new scan, kept on disk so a restarted watch
+// does not alert about them again.
+type knownDeprecated struct {
+	path      string
+	functions map[string]bool // region/name
+	baseline  bool            // nothing recorded yet: the first scan only records
+}
+
+// alertsDir holds one knownDeprecated file per account.
+func alertsDir() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("locate cache dir: %w", err)
+	}
+	return filepath.Join(base, "update-lambda-runtime", "alerts"), nil
+}
+
+func loadKnownDeprecated(accountID string) (*knownDeprecated, error) {
+	dir, err := alertsDir()
+	if err != nil {
+		return nil, err
+	}
+	k := &knownDeprecated{path: filepath.Join(dir, accountID+".json"), functions: make(map[string]bool)}
+	b, err := os.ReadFile(k.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		k.baseline = true
+		return k, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	if err := json.Unmarshal(b, &names); err != nil {
+		return nil, fmt.Errorf("%s: %w", k.path, err)
+	}
+	for _, n := range names {
+		k.functions[n] = true
+	}
+	return k, nil
+}
+
+// update replaces the known functions with those of res on a deprecated
+// runtime now and returns the ones that were not known. Regions that were
+// not scanned or could not be listed keep what was known of them, so they
+// do not look new the next time they are.
+func (k *knownDeprecated) update(res *inventoryResult, now time.Time) []functionResult {
+	failed := make(map[string]bool)
+	for _, e := range res.Errors {
+		if e.Function == "" {
+			failed[e.Region] = true
+		}
+	}
+	next := make(map[string]bool)
+	for key := range k.functions {
+		if region, _, _ := strings.Cut(key, "/"); failed[region] || !slices.Contains(res.Regions, region) {
+			next[key] = true
+		}
+	}
+	var fresh []functionResult
+	for _, r := range res.Functions {
+		if r.Runtime == "" || r.Edge == edgeReplica || inventory.DeprecationStatus(r.Runtime, now) != inventory.Deprecated {
+			continue
+		}
+		key := r.Region + "/" + r.Name
+		next[key] = true
+		if !k.functions[key] {
+			fresh = append(fresh, r)
+		}
+	}
+	k.functions = next
+	return fresh
+}
+
+func (k *knownDeprecated) save() error {
+	if err := os.MkdirAll(filepath.Dir(k.path), 0o700); err != nil {
+		return err
+	}
+	names := make([]string, 0, len(k.functions))
+	for n := range k.functions {
+		names = append(names, n)
+	}
+	slices.Sort(names)
+	b, err := json.MarshalIndent(names, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(k.path, append(b, '\n'), 0o600)
+}
+
+// alertNewDeprecated compares a scan with the last one and sends alerts
+// about functions newly on deprecated runtimes. The first scan of an
+// account only records what is there. Failures are warnings: the watch
+// goes on.
+func alertNewDeprecated(ctx context.Context, opts *AWSOpts, res *inventoryResult) {
+	if res.AccountID == "" {
+		return
+	}
+	known, err := loadKnownDeprecated(res.AccountID)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "warning: alerts:", err)
+		return
+	}
+	fresh := known.update(res, time.Now())
+	if err := known.save(); err != nil {
+		fmt.Fprintln(os.Stderr, "warning: alerts: not recording what was seen:", err)
+	}
+	if known.baseline {
+		fmt.Fprintf(os.Stderr, "Recorded %d functions on deprecated runtimes in %s; alerting on new ones from the next scan\n", len(known.functions), res.AccountID)
+		return
+	}
+	if len(fresh) == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Alerting on %d new functions on deprecated runtimes\n", len(fresh))
+	clients := newClientFactory(opts.Profile, opts.APITimeout, opts.MaxRPS).assuming(opts.AssumeRole)
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), notifyTimeout)
+	defer cancel()
+	for _, a := range buildAlerters(opts, clients) {
+		if err := a.alertNew(ctx, res.AccountID, fresh); err != nil {
+			fmt.Fprintln(os.Stderr, "warning: alert failed:", err)
+		}
+	}
+}
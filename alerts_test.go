@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKnownDeprecatedUpdate(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	k := &knownDeprecated{functions: map[string]bool{
+		"us-east-1/old":       true,
+		"us-east-1/bumped":    true,
+		"eu-west-1/elsewhere": true,
+		"us-west-2/unlisted":  true,
+	}}
+	res := &inventoryResult{
+		Regions: []string{"us-east-1", "us-west-2"},
+		Functions: []functionResult{
+			{Region: "us-east-1", Name: "old", Runtime: "python3.8"},
+			{Region: "us-east-1", Name: "bumped", Runtime: "python3.12"},
+			{Region: "us-east-1", Name: "new", Runtime: "nodejs16.x"},
+			{Region: "us-east-1", Name: "image"},
+		},
+		Errors: []discoveryError{{Region: "us-west-2", Error: "AccessDenied"}},
+	}
+	fresh := k.update(res, now)
+	if len(fresh) != 1 || fresh[0].Name != "new" {
+		t.Errorf("fresh = %+v, want only new", fresh)
+	}
+	for key, want := range map[string]bool{
+		"us-east-1/old":       true,
+		"us-east-1/new":       true,
+		"us-east-1/bumped":    false, // moved off the deprecated runtime
+		"eu-west-1/elsewhere": true,  // not scanned
+		"us-west-2/unlisted":  true,  // listing failed
+	} {
+		if k.functions[key] != want {
+			t.Errorf("known[%s] = %v, want %v", key, k.functions[key], want)
+		}
+	}
+}
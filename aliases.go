@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+
+	"update-lambda-runtime/pkg/inventory"
+)
+
+// aliasWidth sizes the Alias column; longer names push the row out.
+const aliasWidth = 24
+
+// runAliases lists every alias of the selected functions with the runtime
+// of the published version it points to, one row per version when the
+// alias splits traffic. Bumping $LATEST leaves those versions on their old
+// runtime until a new version is published and the alias moved, so aliases
+// serving a deprecated runtime fail the command.
+func runAliases(ctx context.Context, opts *AWSOpts, w io.Writer) error {
+	if err := validateCommon(opts); err != nil {
+		return err
+	}
+	tbl := newFunctionTable(w, opts, runtimeNameWidth, aliasWidth, len("Version"), runtimeNameWidth)
+	printHeader(tbl, opts.ShowProfile, "Alias", "Version", "AliasRuntime", "Status")
+	now := time.Now()
+	counts := make(map[string]int)
+	err := eachFunction(ctx, opts, "aliases", func(ctx context.Context, cli *lambda.Client, r functionResult) {
+		aliases, err := functionAliases(ctx, cli, r.Name)
+		if err != nil {
+			counts["failed"]++
+			printRow(tbl, r.AccountID, r.Profile, r.Region, r.Name, r.Runtime, opts.ShowProfile, "-", "-", "-", "error: "+err.Error())
+			return
+		}
+		runtimes := map[string]string{"$LATEST": r.Runtime}
+		for _, a := range aliases {
+			for _, v := range a.versions {
+				rt, ok := runtimes[v.version]
+				if !ok {
+					cfg, err := cli.GetFunctionConfiguration(ctx, &lambda.GetFunctionConfigurationInput{
+						FunctionName: aws.String(r.Name),
+						Qualifier:    aws.String(v.version),
+					})
+					if err != nil {
+						counts["failed"]++
+						printRow(tbl, r.AccountID, r.Profile, r.Region, r.Name, r.Runtime, opts.ShowProfile, a.name, v.String(), "-", "error: "+err.Error())
+						continue
+					}
+					rt = string(cfg.Runtime)
+					runtimes[v.version] = rt
+				}
+				status := inventory.DeprecationStatus(rt, now)
+				counts[status]++
+				if inventory.Family(rt) == inventory.Family(r.Runtime) && slices.Compare(inventory.Version(rt), inventory.Version(r.Runtime)) < 0 {
+					status += ", behind $LATEST"
+				}
+				printRow(tbl, r.AccountID, r.Profile, r.Region, r.Name, r.Runtime, opts.ShowProfile, a.name, v.String(), rt, status)
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "\nSummary: %d deprecated, %d deprecating, %d supported, %d failed\n",
+		counts[inventory.Deprecated], counts[inventory.Deprecating], counts[inventory.Supported], counts["failed"])
+	if counts[inventory.Deprecated] > 0 {
+		return errors.New("aliases serving deprecated runtimes")
+	}
+	return nil
+}
+
+// aliasVersion is a version an alias routes to, with its share of traffic.
+type aliasVersion struct {
+	version string
+	weight  float64 // 1 for an alias without a routing config
+}
+
+func (v aliasVersion) String() string {
+	if v.weight == 1 {
+		return v.version
+	}
+	return fmt.Sprintf("%s (%s%%)", v.version, strconv.FormatFloat(math.Round(v.weight*1000)/10, 'f', -1, 64))
+}
+
+type functionAlias struct {
+	name     string
+	versions []aliasVersion
+}
+
+// functionAliases lists fn's aliases and the versions each routes to,
+// the primary version first.
+func functionAliases(ctx context.Context, cli *lambda.Client, fn string) ([]functionAlias, error) {
+	var out []functionAlias
+	pages := lambda.NewListAliasesPaginator(cli, &lambda.ListAliasesInput{FunctionName: aws.String(fn)})
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range page.Aliases {
+			fa := functionAlias{name: aws.ToString(a.Name)}
+			primary := aliasVersion{version: aws.ToString(a.FunctionVersion), weight: 1}
+			var extra []aliasVersion
+			if a.RoutingConfig != nil {
+				weights := a.RoutingConfig.AdditionalVersionWeights
+				for _, v := range slices.Sorted(maps.Keys(weights)) {
+					extra = append(extra, aliasVersion{version: v, weight: weights[v]})
+					primary.weight -= weights[v]
+				}
+			}
+			fa.versions = append([]aliasVersion{primary}, extra...)
+			out = append(out, fa)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// showAPIUsage is whether the API usage of the run is printed as it ends
+// (--api-usage).
+var showAPIUsage bool
+
+// apiOp is an operation of an AWS service, e.g. Lambda ListFunctions.
+type apiOp struct {
+	service, operation string
+}
+
+// apiOpUsage counts an operation's calls. A call is one SDK operation,
+// whose attempts beyond the first are retries; throttled counts attempts
+// the service throttled, and errors calls that failed after all of them.
+type apiOpUsage struct {
+	calls, retries, throttled, errors int
+}
+
+// apiCalls counts the AWS API calls of the whole process, across every
+// client factory and account, so the numbers behind --concurrency,
+// --max-rps and caching choices can be seen.
+var apiCalls = struct {
+	mu  sync.Mutex
+	ops map[apiOp]*apiOpUsage
+}{ops: make(map[apiOp]*apiOpUsage)}
+
+// isThrottle tells the errors the SDK's retryer treats as throttling.
+var isThrottle = retry.IsErrorThrottles(retry.DefaultThrottles)
+
+// countCalls records every API operation in apiCalls with its retries and
+// throttled attempts. It runs after the service metadata is registered,
+// to know the service, and around the SDK's retries, to see them.
+func countCalls(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("CountCalls",
+		func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+			out, md, err := next.HandleInitialize(ctx, in)
+			op := apiOp{awsmiddleware.GetServiceID(ctx), cmp.Or(awsmiddleware.GetOperationName(ctx), stack.ID())}
+			var retries, throttled int
+			if res, ok := retry.GetAttemptResults(md); ok {
+				retries = max(len(res.Results)-1, 0)
+				for _, a := range res.Results {
+					if a.Err != nil && isThrottle.IsErrorThrottle(a.Err) == aws.TrueTernary {
+						throttled++
+					}
+				}
+			}
+			apiCalls.mu.Lock()
+			u := apiCalls.ops[op]
+			if u == nil {
+				u = &apiOpUsage{}
+				apiCalls.ops[op] = u
+			}
+			u.calls++
+			u.retries += retries
+			u.throttled += throttled
+			if err != nil {
+				u.errors++
+			}
+			apiCalls.mu.Unlock()
+			return out, md, err
+		}), middleware.After)
+}
+
+// printAPIUsage writes the API calls counted so far, per service and
+// operation, busiest first, with their totals. It writes nothing unless
+// --api-usage is set.
+func printAPIUsage(w io.Writer) {
+	if !showAPIUsage {
+		return
+	}
+	apiCalls.mu.Lock()
+	defer apiCalls.mu.Unlock()
+	ops := slices.SortedFunc(maps.Keys(apiCalls.ops), func(a, b apiOp) int {
+		return cmp.Or(cmp.Compare(apiCalls.ops[b].calls, apiCalls.ops[a].calls), cmp.Compare(a.service, b.service), cmp.Compare(a.operation, b.operation))
+	})
+	var total apiOpUsage
+	serviceWidth, opWidth := len("Service"), len("Operation")
+	for _, op := range ops {
+		u := apiCalls.ops[op]
+		total.calls += u.calls
+		total.retries += u.retries
+		total.throttled += u.throttled
+		total.errors += u.errors
+		serviceWidth, opWidth = max(serviceWidth, len(op.service)), max(opWidth, len(op.operation))
+	}
+	fmt.Fprintf(w, "\nAWS API usage: %d calls, %d retries, %d throttled, %d errors\n", total.calls, total.retries, total.throttled, total.errors)
+	if len(ops) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "%-*s  %-*s  %6s  %7s  %9s  %6s\n", serviceWidth, "Service", opWidth, "Operation", "Calls", "Retries", "Throttled", "Errors")
+	for _, op := range ops {
+		u := apiCalls.ops[op]
+		fmt.Fprintf(w, "%-*s  %-*s  %6d  %7d  %9d  %6d\n", serviceWidth, op.service, opWidth, op.operation, u.calls, u.retries, u.throttled, u.errors)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/smithy-go/middleware"
+)
+
+func TestCountCalls(t *testing.T) {
+	// Lambda throttles the first two attempts and fails GetFunction.
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		switch {
+		case attempts <= 2:
+			w.Header().Set("X-Amzn-Errortype", "TooManyRequestsException")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"message": "Rate exceeded"}`))
+		case strings.HasPrefix(r.URL.Path, "/2015-03-31/functions/"):
+			w.Header().Set("X-Amzn-Errortype", "ResourceNotFoundException")
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "Function not found"}`))
+		default:
+			w.Write([]byte(`{"Functions": []}`))
+		}
+	}))
+	defer srv.Close()
+
+	apiCalls.mu.Lock()
+	clear(apiCalls.ops)
+	apiCalls.mu.Unlock()
+	cli := lambda.New(lambda.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(srv.URL),
+		Credentials:  credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+		Retryer: retry.NewStandard(func(o *retry.StandardOptions) {
+			o.Backoff = retry.BackoffDelayerFunc(func(int, error) (time.Duration, error) { return 0, nil })
+		}),
+		APIOptions: []func(*middleware.Stack) error{countCalls},
+	})
+	ctx := context.Background()
+	if _, err := cli.ListFunctions(ctx, &lambda.ListFunctionsInput{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.GetFunction(ctx, &lambda.GetFunctionInput{FunctionName: aws.String("gone")}); err == nil {
+		t.Fatal("GetFunction: want an error")
+	}
+	if _, err := cli.ListFunctions(ctx, &lambda.ListFunctionsInput{}); err != nil {
+		t.Fatal(err)
+	}
+
+	list, get := apiCalls.ops[apiOp{"Lambda", "ListFunctions"}], apiCalls.ops[apiOp{"Lambda", "GetFunction"}]
+	if list == nil || *list != (apiOpUsage{calls: 2, retries: 2, throttled: 2}) {
+		t.Errorf("ListFunctions %+v, want 2 calls with 2 throttled retries", list)
+	}
+	if get == nil || *get != (apiOpUsage{calls: 1, errors: 1}) {
+		t.Errorf("GetFunction %+v, want 1 failed call", get)
+	}
+
+	var b bytes.Buffer
+	printAPIUsage(&b)
+	if b.Len() != 0 {
+		t.Errorf("printed %q without --api-usage", b.String())
+	}
+	showAPIUsage = true
+	defer func() { showAPIUsage = false }()
+	printAPIUsage(&b)
+	if !strings.Contains(b.String(), "AWS API usage: 3 calls, 2 retries, 2 throttled, 1 errors") || !strings.Contains(b.String(), "ListFunctions") {
+		t.Errorf("printed\n%s", b.String())
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errNotApproved is returned by bump when --slack-approval ended without
+// an approval: the plan was rejected, or nobody answered in time.
+var errNotApproved = errors.New("plan not approved")
+
+const (
+	approveAction = "approve"
+	rejectAction  = "reject"
+
+	// approvalPlanLines caps the functions listed in the Slack message,
+	// which Slack truncates past 3000 characters a section.
+	approvalPlanLines = 40
+	// slackRequestMaxAge is how old a signed Slack request may be before
+	// it is refused as a possible replay.
+	slackRequestMaxAge = 5 * time.Minute
+)
+
+// slackApproval posts a run's plan to Slack with Approve and Reject buttons
+// and waits for one of approvers to press one. Slack delivers the press to
+// the Slack app's interactivity Request URL, which must reach listen; the
+// request is checked against the app's signing secret.
+type slackApproval struct {
+	webhook   string
+	listen    string
+	secret    string
+	approvers []string
+	timeout   time.Duration
+}
+
+// approvalDecision is an approver's answer to a plan.
+type approvalDecision struct {
+	approved    bool
+	user        string
+	responseURL string
+}
+
+func newSlackApproval(opts *AWSOpts) *slackApproval {
+	if opts.SlackApproval == "" {
+		return nil
+	}
+	return &slackApproval{
+		webhook:   opts.SlackApproval,
+		listen:    opts.SlackApprovalListen,
+		secret:    os.Getenv("SLACK_SIGNING_SECRET"),
+		approvers: opts.SlackApprovers,
+		timeout:   opts.SlackApprovalTimeout,
+	}
+}
+
+// await posts the plan and blocks until an approver answers, the timeout
+// passes or ctx ends. Only the last two return an error.
+func (a *slackApproval) await(ctx context.Context, runID, summary string, jobs []bumpJob) (approvalDecision, error) {
+	// Listen before posting, so a press cannot arrive before anyone hears it.
+	ln, err := net.Listen("tcp", a.listen)
+	if err != nil {
+		return approvalDecision{}, fmt.Errorf("slack approval listener: %w", err)
+	}
+	decided := make(chan approvalDecision, 1)
+	srv := &http.Server{Handler: a.handler(runID, decided, time.Now), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Fprintln(os.Stderr, "warning: slack approval server:", err)
+		}
+	}()
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	if err := postJSON(ctx, a.webhook, approvalMessage(runID, summary, jobs), nil); err != nil {
+		return approvalDecision{}, fmt.Errorf("slack approval: %w", err)
+	}
+	timer := time.NewTimer(a.timeout)
+	defer timer.Stop()
+	select {
+	case d := <-decided:
+		verdict := ":white_check_mark: Approved"
+		if !d.approved {
+			verdict = ":no_entry: Rejected"
+		}
+		// Replace the buttons, so nobody answers a settled plan.
+		msg := map[string]any{"replace_original": true, "text": fmt.Sprintf("%s by <@%s>: %s", verdict, d.user, summary)}
+		if err := postJSON(ctx, d.responseURL, msg, nil); err != nil {
+			fmt.Fprintln(os.Stderr, "warning: slack approval message not updated:", err)
+		}
+		return d, nil
+	case <-timer.C:
+		return approvalDecision{}, fmt.Errorf("%w: no answer within %s", errNotApproved, a.timeout)
+	case <-ctx.Done():
+		return approvalDecision{}, context.Cause(ctx)
+	}
+}
+
+// approvalMessage is the plan as Slack blocks: the summary, the functions
+// and their target runtimes, and the buttons, whose value ties a press to
+// runID.
+func approvalMessage(runID, summary string, jobs []bumpJob) map[string]any {
+	var b strings.Builder
+	for i, j := range jobs {
+		if i == approvalPlanLines {
+			fmt.Fprintf(&b, "…and %d more\n", len(jobs)-i)
+			break
+		}
+		r := j.result
+		fmt.Fprintf(&b, "%s  %s  %s → %s\n", r.Region, r.Name, r.Runtime, r.TargetRuntime)
+	}
+	button := func(action, text, style string) map[string]any {
+		return map[string]any{
+			"type":      "button",
+			"action_id": action,
+			"value":     runID,
+			"style":     style,
+			"text":      map[string]any{"type": "plain_text", "text": text},
+		}
+	}
+	return map[string]any{
+		"text": "Approval requested: " + summary,
+		"blocks": []map[string]any{
+			{"type": "section", "text": map[string]any{"type": "mrkdwn", "text": ":hourglass: *Approval requested:* " + summary}},
+			{"type": "section", "text": map[string]any{"type": "mrkdwn", "text": "```" + b.String() + "```"}},
+			{"type": "actions", "elements": []map[string]any{
+				button(approveAction, "Approve", "primary"),
+				button(rejectAction, "Reject", "danger"),
+			}},
+		},
+	}
+}
+
+// slackInteraction is the part of a block_actions payload the handler
+// reads.
+type slackInteraction struct {
+	Type string `json:"type"`
+	User struct {
+		ID string `json:"id"`
+	} `json:"user"`
+	ResponseURL string `json:"response_url"`
+	Actions     []struct {
+		ActionID string `json:"action_id"`
+		Value    string `json:"value"`
+	} `json:"actions"`
+}
+
+// handler takes Slack's interactivity requests and sends the first answer
+// from an approver to runID's plan on decided. Presses by anyone else are
+// answered with a note only they see.
+func (a *slackApproval) handler(runID string, decided chan<- approvalDecision, now func() time.Time) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, err := io.ReadAll(io.LimitReader(req.Body, 1<<20))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := verifySlackSignature(a.secret, req.Header, body, now()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var in slackInteraction
+		if err := json.Unmarshal([]byte(form.Get("payload")), &in); err != nil {
+			http.Error(w, "payload: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		if in.Type != "block_actions" {
+			return
+		}
+		for _, act := range in.Actions {
+			if act.Value != runID || (act.ActionID != approveAction && act.ActionID != rejectAction) {
+				continue
+			}
+			if !slices.Contains(a.approvers, in.User.ID) {
+				msg := map[string]any{"response_type": "ephemeral", "replace_original": false, "text": "You are not an approver for this run."}
+				if err := postJSON(req.Context(), in.ResponseURL, msg, nil); err != nil {
+					fmt.Fprintln(os.Stderr, "warning: slack approval:", err)
+				}
+				return
+			}
+			select {
+			case decided <- approvalDecision{approved: act.ActionID == approveAction, user: in.User.ID, responseURL: in.ResponseURL}:
+			default: // someone answered first
+			}
+			return
+		}
+	})
+}
+
+// verifySlackSignature checks a request against the Slack app's signing
+// secret, as Slack documents: an HMAC-SHA256 of "v0:timestamp:body".
+func verifySlackSignature(secret string, h http.Header, body []byte, now time.Time) error {
+	ts := h.Get("X-Slack-Request-Timestamp")
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return errors.New("missing or invalid X-Slack-Request-Timestamp")
+	}
+	if age := now.Sub(time.Unix(sec, 0)); age > slackRequestMaxAge || age < -slackRequestMaxAge {
+		return errors.New("stale request")
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%s:%s", ts, body)
+	want := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(want), []byte(h.Get("X-Slack-Signature"))) {
+		return errors.New("bad signature")
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlackApprovalHandler(t *testing.T) {
+	const secret = "s3cret"
+	now := time.Unix(1_700_000_000, 0)
+	var ephemeral int
+	responses := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ephemeral++
+	}))
+	defer responses.Close()
+
+	a := &slackApproval{secret: secret, approvers: []string{"U1"}}
+	decided := make(chan approvalDecision, 1)
+	h := a.handler("run-1", decided, func() time.Time { return now })
+	press := func(user, action, value, sig string) int {
+		payload, _ := json.Marshal(map[string]any{
+			"type":         "block_actions",
+			"user":         map[string]string{"id": user},
+			"response_url": responses.URL,
+			"actions":      []map[string]string{{"action_id": action, "value": value}},
+		})
+		body := url.Values{"payload": {string(payload)}}.Encode()
+		ts := strconv.FormatInt(now.Unix(), 10)
+		if sig == "" {
+			mac := hmac.New(sha256.New, []byte(secret))
+			mac.Write([]byte("v0:" + ts + ":" + body))
+			sig = "v0=" + hex.EncodeToString(mac.Sum(nil))
+		}
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("X-Slack-Request-Timestamp", ts)
+		req.Header.Set("X-Slack-Signature", sig)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := press("U1", approveAction, "run-1", "v0=00"); code != http.StatusUnauthorized {
+		t.Errorf("forged request: status %d, want %d", code, http.StatusUnauthorized)
+	}
+	if code := press("U2", approveAction, "run-1", ""); code != http.StatusOK || ephemeral != 1 {
+		t.Errorf("non-approver: status %d, %d notes, want 200 and one note", code, ephemeral)
+	}
+	press("U1", approveAction, "run-0", "")
+	if len(decided) != 0 {
+		t.Fatal("a press on another run's plan decided this one")
+	}
+	press("U1", rejectAction, "run-1", "")
+	press("U1", approveAction, "run-1", "")
+	if d := <-decided; d.approved || d.user != "U1" || d.responseURL != responses.URL {
+		t.Errorf("decision %+v, want the first answer, a rejection by U1", d)
+	}
+}
+
+func TestVerifySlackSignature(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	h := http.Header{}
+	h.Set("X-Slack-Request-Timestamp", strconv.FormatInt(now.Add(-10*time.Minute).Unix(), 10))
+	if err := verifySlackSignature("s", h, nil, now); err == nil || !strings.Contains(err.Error(), "stale") {
+		t.Errorf("err = %v, want a stale request refused", err)
+	}
+}
@@ -0,0 +1,341 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"debug/elf"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lamtypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"update-lambda-runtime/pkg/bump"
+	"update-lambda-runtime/pkg/inventory"
+)
+
+// directUploadMax is the largest zip UpdateFunctionCode accepts inline.
+const directUploadMax = 50 << 20
+
+// x86OnlyRuntimes never had an arm64 build in Lambda.
+var x86OnlyRuntimes = []string{
+	"python2.7", "python3.6", "python3.7",
+	"nodejs", "nodejs4.3", "nodejs6.10", "nodejs8.10", "nodejs10.x",
+	"java8", "go1.x", "ruby2.5", "provided",
+	"dotnetcore1.0", "dotnetcore2.0", "dotnetcore2.1",
+}
+
+// runArchBump moves the functions selected by opts to opts.ArchTarget.
+// Changing the architecture means re-uploading the function's code, so it
+// is only done for zip packages on managed runtimes: custom runtimes and
+// container images carry compiled code that has to be rebuilt first. A
+// function whose layers declare other architectures is left alone too.
+func runArchBump(ctx context.Context, opts *AWSOpts) error {
+	if err := validateCommon(opts); err != nil {
+		return err
+	}
+	target := lamtypes.Architecture(opts.ArchTarget)
+	if !slices.Contains(target.Values(), target) {
+		return fmt.Errorf("--target must be %s or %s", lamtypes.ArchitectureArm64, lamtypes.ArchitectureX8664)
+	}
+	ctx, span := tracer.Start(ctx, "arch-bump", runAttrs(opts),
+		trace.WithAttributes(attribute.String("lambda.target_architecture", opts.ArchTarget)))
+	defer span.End()
+
+	clients := newClientFactory(opts.Profile, opts.APITimeout, opts.MaxRPS)
+	// Only the policy's exclusions apply; its runtime mappings do not.
+	if err := setPolicy(ctx, span, clients, opts); err != nil {
+		return err
+	}
+	acctID, err := resolveAccountID(ctx, clients)
+	if err != nil {
+		return fmt.Errorf("resolve account id: %w", err)
+	}
+	inv, err := newDiscoverer(ctx, clients, opts)
+	if err != nil {
+		return err
+	}
+
+	waitCtx, stopPolling := waitContext(ctx)
+	defer stopPolling()
+	poller := newUpdateWaiter(opts)
+	go poller.run(waitCtx)
+
+	results := newResultCollector(os.Stdout)
+	finish := func(span trace.Span, r functionResult, o bump.Outcome) {
+		endSpan(span, o)
+		r.Outcome = o
+		results.add(r)
+	}
+	pace := newUpdatePace(opts.UpdatesPerMinute)
+	jobs := make(chan bumpJob)
+	var workers sync.WaitGroup
+	for range max(opts.Concurrency, 1) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for j := range jobs {
+				r := j.result
+				ctx, span := tracer.Start(ctx, "update", functionAttrs(r))
+				if ctx.Err() != nil || awaitPace(ctx, pace) != nil {
+					finish(span, r, bump.NotAttempted)
+					continue
+				}
+				p, o := startArchUpdate(ctx, results, j.cli, r.Name, target, opts.Timeout)
+				if p == nil {
+					finish(span, r, o)
+					continue
+				}
+				o = <-poller.track(p)
+				r.Failure = p.Failure(o)
+				finish(span, r, o)
+			}
+		}()
+	}
+
+	blocked := make(map[string]string) // function ARN → why it was left alone
+	layers := make(map[string][]lamtypes.Architecture)
+	for _, region := range opts.Regions {
+		cli, err := clients.Lambda(ctx, region)
+		if err != nil {
+			close(jobs)
+			return err
+		}
+		err = inv.stream(ctx, cli, region, func(f inventory.Function) {
+			r := functionResult{
+				AccountID:    acctID,
+				Profile:      opts.Profile,
+				Region:       region,
+				Name:         f.Name,
+				Runtime:      f.Runtime,
+				Architecture: f.Architecture,
+			}
+			if f.Architecture == opts.ArchTarget || opts.Policy.Excluded(f.Name) {
+				results.add(r)
+				return
+			}
+			if why := archBlocker(ctx, cli, f, target, layers); why != "" {
+				results.progressf("Skipping %s: %s\n", f.Name, why)
+				blocked[functionARN(r)] = why
+				results.add(r)
+				return
+			}
+			jobs <- bumpJob{cli: cli, result: r}
+		})
+		if err != nil && ctx.Err() == nil {
+			fmt.Fprintf(os.Stderr, "warning: %s: %v\n", region, err)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(jobs)
+	workers.Wait()
+
+	renderArchResults(os.Stdout, opts, results.snapshot(), blocked)
+	if ctx.Err() != nil {
+		return stopped(ctx)
+	}
+	return nil
+}
+
+// archBlocker returns why f cannot be moved to target as it is, or "" when
+// it can. Layer lookups are cached in layers across the run; a layer that
+// declares no architectures is assumed to work on any.
+func archBlocker(ctx context.Context, cli *lambda.Client, f inventory.Function, target lamtypes.Architecture, layers map[string][]lamtypes.Architecture) string {
+	switch {
+	case f.PackageType == string(lamtypes.PackageTypeImage):
+		return fmt.Sprintf("container image; push a %s image and deploy it instead", target)
+	case strings.HasPrefix(f.Runtime, "provided"):
+		return fmt.Sprintf("custom runtime; rebuild the bootstrap for %s and deploy it instead", target)
+	case target == lamtypes.ArchitectureArm64 && slices.Contains(x86OnlyRuntimes, f.Runtime):
+		return fmt.Sprintf("runtime %s has no arm64 build", f.Runtime)
+	}
+	for _, arn := range f.Layers {
+		archs, ok := layers[arn]
+		if !ok {
+			out, err := cli.GetLayerVersionByArn(ctx, &lambda.GetLayerVersionByArnInput{Arn: aws.String(arn)})
+			if err != nil {
+				return fmt.Sprintf("cannot check layer %s: %v", arn, err)
+			}
+			archs = out.CompatibleArchitectures
+			layers[arn] = archs
+		}
+		if len(archs) > 0 && !slices.Contains(archs, target) {
+			return fmt.Sprintf("layer %s does not support %s; %s", arn, target, layerArchHint(ctx, cli, arn, target))
+		}
+	}
+	return ""
+}
+
+// layerArchHint says which version of the layer arn belongs to supports
+// target, if any, for archBlocker's reason.
+func layerArchHint(ctx context.Context, cli *lambda.Client, arn string, target lamtypes.Architecture) string {
+	name := arn[:max(strings.LastIndex(arn, ":"), 0)]
+	out, err := cli.ListLayerVersions(ctx, &lambda.ListLayerVersionsInput{
+		LayerName:              aws.String(name),
+		CompatibleArchitecture: target,
+	})
+	switch {
+	case err != nil:
+		return "publish a version that does and attach it first"
+	case len(out.LayerVersions) == 0:
+		return fmt.Sprintf("no version of it does; publish one built for %s and attach it first", target)
+	}
+	// Versions are listed newest first.
+	return fmt.Sprintf("attach %s first, which does", aws.ToString(out.LayerVersions[0].LayerVersionArn))
+}
+
+// startArchUpdate re-uploads fn's current code for target. The upload is
+// conditional on the revision that was downloaded, so a deployment landing
+// in between fails the update instead of being overwritten.
+func startArchUpdate(ctx context.Context, log *resultCollector, cli *lambda.Client, fn string, target lamtypes.Architecture, timeout time.Duration) (*pendingUpdate, bump.Outcome) {
+	log.progressf("Moving %s to %s...\n", fn, target)
+	fail := func(err error) (*pendingUpdate, bump.Outcome) {
+		if ctx.Err() != nil {
+			return nil, bump.NotAttempted
+		}
+		log.progressf("  update error for %s: %v\n", fn, err)
+		return nil, bump.Failed
+	}
+	got, err := cli.GetFunction(ctx, &lambda.GetFunctionInput{FunctionName: aws.String(fn)})
+	if err != nil {
+		return fail(err)
+	}
+	if size := got.Configuration.CodeSize; size > directUploadMax {
+		return fail(fmt.Errorf("package is %d MB, over the %d MB direct upload limit", size>>20, directUploadMax>>20))
+	}
+	code, err := downloadCode(ctx, aws.ToString(got.Code.Location), directUploadMax)
+	if err != nil {
+		return fail(fmt.Errorf("download code: %w", err))
+	}
+	zr, err := zip.NewReader(bytes.NewReader(code), int64(len(code)))
+	if err != nil {
+		return fail(fmt.Errorf("read package: %w", err))
+	}
+	if bins := foreignBinaries(zr, string(target)); len(bins) > 0 {
+		more := ""
+		if len(bins) > 1 {
+			more = fmt.Sprintf(" and %d more", len(bins)-1)
+		}
+		hint := ""
+		if target == lamtypes.ArchitectureArm64 {
+			hint = " (pip install --platform manylinux2014_aarch64 --only-binary=:all:, npm install --cpu=arm64)"
+		}
+		return fail(fmt.Errorf("%s%s is native code for another architecture; rebuild the package for %s%s and deploy it instead", bins[0], more, target, hint))
+	}
+	out, err := cli.UpdateFunctionCode(ctx, &lambda.UpdateFunctionCodeInput{
+		FunctionName:  aws.String(fn),
+		ZipFile:       code,
+		Architectures: []lamtypes.Architecture{target},
+		RevisionId:    got.Configuration.RevisionId,
+	})
+	if err != nil {
+		return fail(err)
+	}
+	return newPendingUpdate(ctx, log, bump.Track(cli, fn, timeout, out.LastUpdateStatus, out.LastUpdateStatusReason)), ""
+}
+
+// foreignBinaries lists the Linux executables and shared libraries in a
+// package that are built for another architecture than arch. One with a
+// counterpart of the same name built for arch is left out: packages often
+// carry prebuilt binaries for several platforms and load the right one.
+func foreignBinaries(zr *zip.Reader, arch string) []string {
+	want := goMachine(arch)
+	native := make(map[string]bool)
+	var foreign []string
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if !isELF(f) {
+			continue
+		}
+		bin, err := readZipFile(f)
+		if err != nil {
+			continue
+		}
+		exe, err := elf.NewFile(bytes.NewReader(bin))
+		if err != nil {
+			continue
+		}
+		if exe.Machine == want {
+			native[path.Base(f.Name)] = true
+		} else {
+			foreign = append(foreign, f.Name)
+		}
+	}
+	return slices.DeleteFunc(foreign, func(name string) bool { return native[path.Base(name)] })
+}
+
+// isELF reports whether f starts with the ELF magic, so only binaries are
+// read in full.
+func isELF(f *zip.File) bool {
+	rc, err := f.Open()
+	if err != nil {
+		return false
+	}
+	defer rc.Close()
+	magic := make([]byte, len(elf.ELFMAG))
+	_, err = io.ReadFull(rc, magic)
+	return err == nil && string(magic) == elf.ELFMAG
+}
+
+// downloadCode fetches a deployment package of at most limit bytes from the
+// presigned URL GetFunction returns.
+func downloadCode(ctx context.Context, url string, limit int) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s", resp.Status)
+	}
+	code, err := io.ReadAll(io.LimitReader(resp.Body, int64(limit)+1))
+	if err == nil && len(code) > limit {
+		err = fmt.Errorf("package is over %d MB", limit>>20)
+	}
+	return code, err
+}
+
+// renderArchResults prints the arch run's table, with the reason in place
+// of the result for functions that were skipped, and the outcome summary.
+func renderArchResults(w io.Writer, opts *AWSOpts, rs []functionResult, blocked map[string]string) {
+	sortResults(rs)
+	runtimeWidth := len("CurrentRuntime")
+	for _, r := range rs {
+		runtimeWidth = max(runtimeWidth, len(r.Runtime))
+	}
+	fmt.Fprintln(w)
+	tbl := newFunctionTable(w, opts, runtimeWidth, len("Architecture"))
+	printHeader(tbl, opts.ShowProfile, "Architecture", "Result")
+	summary := &bumpSummary{}
+	for _, r := range rs {
+		result := "-"
+		if why, ok := blocked[functionARN(r)]; ok {
+			result = "skipped: " + why
+		}
+		if r.Outcome != "" {
+			result = string(r.Outcome)
+			summary.add(r)
+		}
+		printRow(tbl, r.AccountID, r.Profile, r.Region, r.Name, r.Runtime, opts.ShowProfile, r.Architecture, result)
+	}
+	summary.print(w)
+}
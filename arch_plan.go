@@ -0,0 +1,202 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	lamtypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+
+	"update-lambda-runtime/pkg/inventory"
+)
+
+// Lambda's duration prices per GB-second, first tier in us-east-1. Other
+// regions and the volume tiers differ by a few percent at most, and the
+// arm64 discount is the same everywhere, which is what ranks functions.
+const (
+	x86GBSecond   = 0.0000166667
+	arm64GBSecond = 0.0000133334
+)
+
+// usageWindow is how far back arch plan reads each function's metrics;
+// the usage is scaled to a 30-day month.
+const usageWindow = 14 * 24 * time.Hour
+
+// functionUsage is a function's recent use, from CloudWatch.
+type functionUsage struct {
+	invocations float64 // in usageWindow
+	durationMS  float64 // billed time summed over usageWindow
+}
+
+// monthlyCostDelta estimates what moving a function with memoryMB to target
+// changes its monthly duration bill by, in USD, assuming it runs as long
+// on either architecture. Requests cost the same on both.
+func monthlyCostDelta(u functionUsage, memoryMB int32, target lamtypes.Architecture) float64 {
+	month := 30 * 24 * time.Hour
+	gbSeconds := u.durationMS / 1000 * float64(memoryMB) / 1024 * float64(month) / float64(usageWindow)
+	if target == lamtypes.ArchitectureArm64 {
+		return gbSeconds * (arm64GBSecond - x86GBSecond)
+	}
+	return gbSeconds * (x86GBSecond - arm64GBSecond)
+}
+
+// runArchPlan shows what arch bump would do with the functions selected by
+// opts, without changing anything: which would move or be skipped, and for
+// each the estimated monthly cost change, so the largest savings can go
+// first.
+func runArchPlan(ctx context.Context, opts *AWSOpts, w io.Writer) error {
+	if err := validateCommon(opts); err != nil {
+		return err
+	}
+	target := lamtypes.Architecture(opts.ArchTarget)
+	if target != lamtypes.ArchitectureArm64 && target != lamtypes.ArchitectureX8664 {
+		return fmt.Errorf("--target must be %s or %s", lamtypes.ArchitectureArm64, lamtypes.ArchitectureX8664)
+	}
+	ctx, span := tracer.Start(ctx, "arch-plan", runAttrs(opts))
+	defer span.End()
+
+	clients := newClientFactory(opts.Profile, opts.APITimeout, opts.MaxRPS)
+	if err := setPolicy(ctx, span, clients, opts); err != nil {
+		return err
+	}
+	acctID, err := resolveAccountID(ctx, clients)
+	if err != nil {
+		return fmt.Errorf("resolve account id: %w", err)
+	}
+	inv, err := newDiscoverer(ctx, clients, opts)
+	if err != nil {
+		return err
+	}
+	usage := newUsageLookup(clients)
+
+	// Rows are held until discovery ends so the biggest savings come first.
+	type planRow struct {
+		region string
+		f      inventory.Function
+		usage  *functionUsage
+		delta  float64
+		plan   string
+	}
+	var rows []planRow
+	layers := make(map[string][]lamtypes.Architecture)
+	for _, region := range opts.Regions {
+		cli, err := clients.Lambda(ctx, region)
+		if err != nil {
+			return err
+		}
+		err = inv.stream(ctx, cli, region, func(f inventory.Function) {
+			if f.Architecture == opts.ArchTarget || opts.Policy.Excluded(f.Name) {
+				return
+			}
+			row := planRow{region: region, f: f, plan: "move"}
+			if why := archBlocker(ctx, cli, f, target, layers); why != "" {
+				row.plan = "skip: " + why
+			}
+			if u, err := usage.lookup(ctx, region, f.Name); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: no metrics for %s: %v\n", f.Name, err)
+			} else if f.MemorySize > 0 {
+				row.usage = &u
+				row.delta = monthlyCostDelta(u, f.MemorySize, target)
+			}
+			rows = append(rows, row)
+		})
+		if err != nil && ctx.Err() == nil {
+			fmt.Fprintf(os.Stderr, "warning: %s: %v\n", region, err)
+		}
+		if ctx.Err() != nil {
+			return stopped(ctx)
+		}
+	}
+	slices.SortStableFunc(rows, func(a, b planRow) int { return cmp.Compare(a.delta, b.delta) })
+
+	fmt.Fprintln(w)
+	tbl := newFunctionTable(w, opts, runtimeNameWidth, len("Architecture"), len("Memory"), len("Invocations/mo"), len("Monthly"))
+	printHeader(tbl, opts.ShowProfile, "Architecture", "Memory", "Invocations/mo", "Monthly", "Plan")
+	var moving int
+	var total float64
+	for _, row := range rows {
+		memory, invocations, monthly := "?", "?", "?"
+		if row.f.MemorySize > 0 {
+			memory = fmt.Sprintf("%d MB", row.f.MemorySize)
+		}
+		if row.usage != nil {
+			invocations = strconv.FormatFloat(row.usage.invocations*float64(30*24*time.Hour)/float64(usageWindow), 'f', 0, 64)
+			monthly = fmt.Sprintf("%+.2f", row.delta)
+		}
+		if row.plan == "move" {
+			moving++
+			total += row.delta
+		}
+		printRow(tbl, acctID, opts.Profile, row.region, row.f.Name, row.f.Runtime, opts.ShowProfile,
+			row.f.Architecture, memory, invocations, monthly, row.plan)
+	}
+	fmt.Fprintf(w, "\n%d functions would move to %s, changing their duration cost by about %+.2f USD a month (us-east-1 prices, same durations; requests cost the same)\n", moving, target, total)
+	return nil
+}
+
+// usageLookup reads functions' recent use from CloudWatch, with a client
+// per region made on first use.
+type usageLookup struct {
+	clients *clientFactory
+
+	mu   sync.Mutex
+	clis map[string]*cloudwatch.Client
+}
+
+func newUsageLookup(clients *clientFactory) *usageLookup {
+	return &usageLookup{clients: clients, clis: make(map[string]*cloudwatch.Client)}
+}
+
+func (l *usageLookup) client(ctx context.Context, region string) (*cloudwatch.Client, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if cli, ok := l.clis[region]; ok {
+		return cli, nil
+	}
+	cfg, err := l.clients.Config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cli := cloudwatch.NewFromConfig(cfg, func(o *cloudwatch.Options) {
+		o.Region = region
+	})
+	l.clis[region] = cli
+	return cli, nil
+}
+
+// lookup sums the function's invocations and duration over usageWindow.
+func (l *usageLookup) lookup(ctx context.Context, region, name string) (functionUsage, error) {
+	cli, err := l.client(ctx, region)
+	if err != nil {
+		return functionUsage{}, err
+	}
+	var u functionUsage
+	for metric, dst := range map[string]*float64{"Invocations": &u.invocations, "Duration": &u.durationMS} {
+		end := time.Now()
+		out, err := cli.GetMetricStatistics(ctx, &cloudwatch.GetMetricStatisticsInput{
+			Namespace:  aws.String("AWS/Lambda"),
+			MetricName: aws.String(metric),
+			Dimensions: []cwtypes.Dimension{{Name: aws.String("FunctionName"), Value: aws.String(name)}},
+			StartTime:  aws.Time(end.Add(-usageWindow)),
+			EndTime:    aws.Time(end),
+			Period:     aws.Int32(int32(usageWindow / time.Second)),
+			Statistics: []cwtypes.Statistic{cwtypes.StatisticSum},
+		})
+		if err != nil {
+			return functionUsage{}, err
+		}
+		for _, p := range out.Datapoints {
+			*dst += aws.ToFloat64(p.Sum)
+		}
+	}
+	return u, nil
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	lamtypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+func TestMonthlyCostDelta(t *testing.T) {
+	// A million 100 ms invocations at 1 GB in the two weeks: 100,000
+	// GB-seconds, or about 214,286 a month.
+	u := functionUsage{invocations: 1e6, durationMS: 1e8}
+	arm := monthlyCostDelta(u, 1024, lamtypes.ArchitectureArm64)
+	if want := -0.7143; math.Abs(arm-want) > 0.001 {
+		t.Errorf("to arm64 = %.4f, want %.4f", arm, want)
+	}
+	if back := monthlyCostDelta(u, 1024, lamtypes.ArchitectureX8664); back != -arm {
+		t.Errorf("to x86_64 = %.4f, want %.4f", back, -arm)
+	}
+	if got := monthlyCostDelta(functionUsage{}, 1024, lamtypes.ArchitectureArm64); got != 0 {
+		t.Errorf("unused function = %.4f, want 0", got)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"slices"
+	"testing"
+)
+
+// elfHeader is a bare 64-bit little-endian ELF header for machine, enough
+// for debug/elf to identify the architecture.
+func elfHeader(machine elf.Machine) []byte {
+	h := make([]byte, 64)
+	copy(h, elf.ELFMAG)
+	h[elf.EI_CLASS] = byte(elf.ELFCLASS64)
+	h[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	h[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+	binary.LittleEndian.PutUint16(h[16:], uint16(elf.ET_DYN))
+	binary.LittleEndian.PutUint16(h[18:], uint16(machine))
+	binary.LittleEndian.PutUint32(h[20:], uint32(elf.EV_CURRENT))
+	binary.LittleEndian.PutUint16(h[52:], 64) // e_ehsize
+	return h
+}
+
+func TestForeignBinaries(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range map[string][]byte{
+		"app.py": []byte("import numpy\n"),
+		"numpy/core/_multiarray_umath.cpython-312-x86_64-linux-gnu.so": elfHeader(elf.EM_X86_64),
+		"node_modules/a/prebuilds/linux-x64/a.node":                    elfHeader(elf.EM_X86_64),
+		"node_modules/a/prebuilds/linux-arm64/a.node":                  elfHeader(elf.EM_AARCH64),
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(content)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := foreignBinaries(zr, "arm64")
+	if want := []string{"numpy/core/_multiarray_umath.cpython-312-x86_64-linux-gnu.so"}; !slices.Equal(got, want) {
+		t.Errorf("arm64: foreign = %v, want %v", got, want)
+	}
+	if got := foreignBinaries(zr, "x86_64"); len(got) != 0 {
+		t.Errorf("x86_64: foreign = %v, want none", got)
+	}
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+
+	"update-lambda-runtime/pkg/report"
+)
+
+// sealSuffix is appended to a baseline's file name for the seal that
+// vouches for it.
+const sealSuffix = ".sig"
+
+// baselineSeal is the detached record that vouches for a baseline: the
+// digest of its inventory, when it was taken and by whom, and optionally a
+// KMS signature over all of them.
+type baselineSeal struct {
+	Version   int                `json:"version"`
+	File      string             `json:"file"` // base name of the inventory sealed
+	SHA256    string             `json:"sha256"`
+	CreatedAt time.Time          `json:"createdAt"`
+	CreatedBy string             `json:"createdBy,omitempty"` // principal that signed
+	Signature *baselineSignature `json:"signature,omitempty"`
+}
+
+// baselineSignature is a KMS signature of a seal's statement.
+type baselineSignature struct {
+	KeyID     string `json:"keyId"` // ARN of the KMS key
+	Algorithm string `json:"algorithm"`
+	Value     []byte `json:"value"`
+}
+
+// statement is what a seal's signature covers. Binding the time and the
+// signer as well as the digest means neither can be changed afterwards.
+func (s *baselineSeal) statement() []byte {
+	sum := sha256.Sum256(fmt.Appendf(nil, "update-lambda-runtime baseline v%d\nfile: %s\nsha256: %s\ncreated: %s\nby: %s\n",
+		s.Version, s.File, s.SHA256, s.CreatedAt.UTC().Format(time.RFC3339), s.CreatedBy))
+	return sum[:]
+}
+
+// kmsSigner is the part of the KMS API baselines use. *kms.Client
+// implements it; tests substitute a fake.
+type kmsSigner interface {
+	GetPublicKey(ctx context.Context, in *kms.GetPublicKeyInput, optFns ...func(*kms.Options)) (*kms.GetPublicKeyOutput, error)
+	Sign(ctx context.Context, in *kms.SignInput, optFns ...func(*kms.Options)) (*kms.SignOutput, error)
+	Verify(ctx context.Context, in *kms.VerifyInput, optFns ...func(*kms.Options)) (*kms.VerifyOutput, error)
+}
+
+// baselineAlgorithms are the signing algorithms of SHA-256 digests, in the
+// order one is picked from those a key supports.
+var baselineAlgorithms = []kmstypes.SigningAlgorithmSpec{
+	kmstypes.SigningAlgorithmSpecEcdsaSha256,
+	kmstypes.SigningAlgorithmSpecRsassaPssSha256,
+	kmstypes.SigningAlgorithmSpecRsassaPkcs1V15Sha256,
+}
+
+// newKMSClient returns a KMS client for key in its own region, or in
+// the profile's when key is an alias or bare ID.
+func newKMSClient(ctx context.Context, clients *clientFactory, key string) (*kms.Client, error) {
+	cfg, err := clients.Config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return kms.NewFromConfig(cfg, func(o *kms.Options) {
+		if a, err := arn.Parse(key); err == nil {
+			o.Region = a.Region
+		}
+	}), nil
+}
+
+// runBaselineCreate sweeps the inventory as report does and writes it to
+// opts.BaselineOut as JSON, with a seal beside it holding its SHA-256 and
+// the time, signed with opts.BaselineKey when one is given.
+func runBaselineCreate(ctx context.Context, opts *AWSOpts, w io.Writer) error {
+	if opts.BaselineOut == "" {
+		return errors.New("--out is required")
+	}
+	if len(opts.Regions) == 0 {
+		return errors.New("--regions is required")
+	}
+	if opts.BaselineKey != "" && opts.Profile == "" {
+		return errors.New("--kms-key needs --profile to sign with")
+	}
+	ctx, span := tracer.Start(ctx, "baseline", runAttrs(opts))
+	defer span.End()
+	inv, err := sweepInventory(ctx, opts)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := report.Write(&buf, inv, report.JSON); err != nil {
+		return err
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	seal := &baselineSeal{
+		Version:   1,
+		File:      filepath.Base(opts.BaselineOut),
+		SHA256:    hex.EncodeToString(sum[:]),
+		CreatedAt: inv.GeneratedAt.UTC().Truncate(time.Second),
+	}
+	if opts.BaselineKey != "" {
+		clients := newClientFactory(opts.Profile, opts.APITimeout, opts.MaxRPS)
+		stsCli, err := clients.STS(ctx)
+		if err != nil {
+			return err
+		}
+		who, err := stsCli.GetCallerIdentity(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("resolve signer: %w", err)
+		}
+		seal.CreatedBy = aws.ToString(who.Arn)
+		cli, err := newKMSClient(ctx, clients, opts.BaselineKey)
+		if err != nil {
+			return err
+		}
+		if err := seal.sign(ctx, cli, opts.BaselineKey); err != nil {
+			return err
+		}
+	}
+	sealed, err := json.MarshalIndent(seal, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(opts.BaselineOut, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	if err := os.WriteFile(opts.BaselineOut+sealSuffix, append(sealed, '\n'), 0o644); err != nil {
+		return err
+	}
+
+	var functions int
+	for _, a := range inv.Accounts {
+		for _, r := range a.Regions {
+			for _, rt := range r.Runtimes {
+				functions += len(rt.Functions)
+			}
+		}
+	}
+	fmt.Fprintf(w, "Baseline of %d functions across %d account(s) written to %s, sealed in %s\n", functions, len(inv.Accounts), opts.BaselineOut, opts.BaselineOut+sealSuffix)
+	fmt.Fprintf(w, "  sha256 %s\n", seal.SHA256)
+	if seal.Signature != nil {
+		fmt.Fprintf(w, "  signed by %s with %s (%s)\n", seal.CreatedBy, seal.Signature.KeyID, seal.Signature.Algorithm)
+	}
+	return nil
+}
+
+// sign signs s's statement with key, with the first of baselineAlgorithms
+// the key supports.
+func (s *baselineSeal) sign(ctx context.Context, cli kmsSigner, key string) error {
+	pub, err := cli.GetPublicKey(ctx, &kms.GetPublicKeyInput{KeyId: aws.String(key)})
+	if err != nil {
+		return fmt.Errorf("kms key %s: %w", key, err)
+	}
+	if pub.KeyUsage != kmstypes.KeyUsageTypeSignVerify {
+		return fmt.Errorf("kms key %s is for %s, not SIGN_VERIFY", key, pub.KeyUsage)
+	}
+	i := slices.IndexFunc(baselineAlgorithms, func(a kmstypes.SigningAlgorithmSpec) bool {
+		return slices.Contains(pub.SigningAlgorithms, a)
+	})
+	if i < 0 {
+		return fmt.Errorf("kms key %s cannot sign SHA-256 digests", key)
+	}
+	out, err := cli.Sign(ctx, &kms.SignInput{
+		KeyId:            pub.KeyId,
+		Message:          s.statement(),
+		MessageType:      kmstypes.MessageTypeDigest,
+		SigningAlgorithm: baselineAlgorithms[i],
+	})
+	if err != nil {
+		return fmt.Errorf("kms sign: %w", err)
+	}
+	s.Signature = &baselineSignature{KeyID: aws.ToString(out.KeyId), Algorithm: string(out.SigningAlgorithm), Value: out.Signature}
+	return nil
+}
+
+// runBaselineVerify checks the baseline at path against its seal: that the
+// inventory's digest is the one sealed and, for a signed seal, that KMS
+// confirms the signature, made with opts.BaselineKey when one is given.
+func runBaselineVerify(ctx context.Context, opts *AWSOpts, path string, w io.Writer) error {
+	b, err := os.ReadFile(path + sealSuffix)
+	if err != nil {
+		return fmt.Errorf("read seal: %w", err)
+	}
+	var seal baselineSeal
+	if err := json.Unmarshal(b, &seal); err != nil {
+		return fmt.Errorf("%s: %w", path+sealSuffix, err)
+	}
+	if seal.Version != 1 {
+		return fmt.Errorf("%s: unsupported seal version %d", path+sealSuffix, seal.Version)
+	}
+	var cli kmsSigner
+	if seal.Signature != nil {
+		if opts.Profile == "" {
+			return errors.New("the seal is signed; give --profile to check the signature with KMS")
+		}
+		c, err := newKMSClient(ctx, newClientFactory(opts.Profile, opts.APITimeout, opts.MaxRPS), seal.Signature.KeyID)
+		if err != nil {
+			return err
+		}
+		cli = c
+	}
+	return verifyBaseline(ctx, cli, path, &seal, opts.BaselineKey, w)
+}
+
+// verifyBaseline checks the inventory at path against seal, using cli for a
+// signed seal. A non-empty key is the key the seal must be signed with.
+func verifyBaseline(ctx context.Context, cli kmsSigner, path string, seal *baselineSeal, key string, w io.Writer) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	var problems []string
+	if got := hex.EncodeToString(h.Sum(nil)); got != seal.SHA256 {
+		problems = append(problems, fmt.Sprintf("sha256 is %s, not the %s sealed: the inventory was changed", got, seal.SHA256))
+	}
+	if filepath.Base(path) != seal.File {
+		fmt.Fprintf(w, "note: the baseline was sealed as %s\n", seal.File)
+	}
+	switch sig := seal.Signature; {
+	case sig == nil && key != "":
+		problems = append(problems, "the seal is not signed")
+	case sig == nil:
+	case key != "" && !sameKMSKey(key, sig.KeyID):
+		problems = append(problems, fmt.Sprintf("signed with %s, not %s", sig.KeyID, key))
+	default:
+		out, err := cli.Verify(ctx, &kms.VerifyInput{
+			KeyId:            aws.String(sig.KeyID),
+			Message:          seal.statement(),
+			MessageType:      kmstypes.MessageTypeDigest,
+			Signature:        sig.Value,
+			SigningAlgorithm: kmstypes.SigningAlgorithmSpec(sig.Algorithm),
+		})
+		var invalid *kmstypes.KMSInvalidSignatureException
+		switch {
+		case errors.As(err, &invalid) || (err == nil && !out.SignatureValid):
+			problems = append(problems, "the signature does not match: the seal was changed")
+		case err != nil:
+			return fmt.Errorf("kms verify: %w", err)
+		}
+	}
+
+	fmt.Fprintf(w, "Baseline %s taken %s\n", path, inZone(seal.CreatedAt, time.UTC).Format(time.DateTime+" MST"))
+	if len(problems) > 0 {
+		for _, p := range problems {
+			fmt.Fprintf(w, "  FAILED: %s\n", p)
+		}
+		return fmt.Errorf("baseline %s failed verification", path)
+	}
+	fmt.Fprintf(w, "  sha256 %s matches\n", seal.SHA256)
+	if seal.Signature != nil {
+		fmt.Fprintf(w, "  signature by %s with %s is valid\n", seal.CreatedBy, seal.Signature.KeyID)
+	} else {
+		fmt.Fprintln(w, "  not signed: the digest only shows the inventory matches its seal")
+	}
+	return nil
+}
+
+// sameKMSKey reports whether want, a key ARN or bare key ID, names the
+// key with ARN got.
+func sameKMSKey(want, got string) bool {
+	return want == got || strings.HasSuffix(got, ":key/"+want)
+}
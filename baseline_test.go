@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+)
+
+const testKeyARN = "arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+
+// fakeKMS signs with a local ECDSA key, as KMS does with an
+// ECC_NIST_P256 key.
+type fakeKMS struct {
+	key *ecdsa.PrivateKey
+}
+
+func (f *fakeKMS) GetPublicKey(ctx context.Context, in *kms.GetPublicKeyInput, optFns ...func(*kms.Options)) (*kms.GetPublicKeyOutput, error) {
+	return &kms.GetPublicKeyOutput{
+		KeyId:             aws.String(testKeyARN),
+		KeyUsage:          kmstypes.KeyUsageTypeSignVerify,
+		SigningAlgorithms: []kmstypes.SigningAlgorithmSpec{kmstypes.SigningAlgorithmSpecEcdsaSha384, kmstypes.SigningAlgorithmSpecEcdsaSha256},
+	}, nil
+}
+
+func (f *fakeKMS) Sign(ctx context.Context, in *kms.SignInput, optFns ...func(*kms.Options)) (*kms.SignOutput, error) {
+	sig, err := ecdsa.SignASN1(rand.Reader, f.key, in.Message)
+	if err != nil {
+		return nil, err
+	}
+	return &kms.SignOutput{KeyId: in.KeyId, Signature: sig, SigningAlgorithm: in.SigningAlgorithm}, nil
+}
+
+func (f *fakeKMS) Verify(ctx context.Context, in *kms.VerifyInput, optFns ...func(*kms.Options)) (*kms.VerifyOutput, error) {
+	if !ecdsa.VerifyASN1(&f.key.PublicKey, in.Message, in.Signature) {
+		return nil, &kmstypes.KMSInvalidSignatureException{}
+	}
+	return &kms.VerifyOutput{SignatureValid: true}, nil
+}
+
+func TestBaselineSignAndVerify(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli := &fakeKMS{key: key}
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	inventory := []byte(`{"generatedAt":"2026-10-01T00:00:00Z"}` + "\n")
+	if err := os.WriteFile(path, inventory, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(inventory)
+	newSeal := func() *baselineSeal {
+		s := &baselineSeal{
+			Version:   1,
+			File:      "baseline.json",
+			SHA256:    hex.EncodeToString(sum[:]),
+			CreatedAt: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+			CreatedBy: "arn:aws:iam::123456789012:user/auditor",
+		}
+		if err := s.sign(ctx, cli, "alias/baseline"); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	seal := newSeal()
+	if seal.Signature.Algorithm != string(kmstypes.SigningAlgorithmSpecEcdsaSha256) || seal.Signature.KeyID != testKeyARN {
+		t.Errorf("signature %+v, want ECDSA_SHA_256 by the key's ARN", seal.Signature)
+	}
+
+	var out bytes.Buffer
+	if err := verifyBaseline(ctx, cli, path, seal, "1234abcd-12ab-34cd-56ef-1234567890ab", &out); err != nil {
+		t.Fatalf("verify: %v\n%s", err, out.String())
+	}
+
+	tests := []struct {
+		name   string
+		change func(s *baselineSeal)
+		key    string
+		want   string
+	}{
+		{"backdated", func(s *baselineSeal) { s.CreatedAt = s.CreatedAt.AddDate(0, -1, 0) }, "", "signature does not match"},
+		{"resealed", func(s *baselineSeal) { s.SHA256 = strings.Repeat("0", 64) }, "", "the inventory was changed"},
+		{"other key", func(*baselineSeal) {}, "arn:aws:kms:us-east-1:123456789012:key/other", "not arn:aws:kms:us-east-1:123456789012:key/other"},
+		{"unsigned", func(s *baselineSeal) { s.Signature = nil }, testKeyARN, "the seal is not signed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSeal()
+			tt.change(s)
+			var out bytes.Buffer
+			if err := verifyBaseline(ctx, cli, path, s, tt.key, &out); err == nil || !strings.Contains(out.String(), tt.want) {
+				t.Errorf("err = %v, output:\n%s\nwant a failure saying %q", err, out.String(), tt.want)
+			}
+		})
+	}
+
+	if err := os.WriteFile(path, append(inventory, ' '), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if err := verifyBaseline(ctx, cli, path, seal, "", &out); err == nil || !strings.Contains(out.String(), "the inventory was changed") {
+		t.Errorf("tampered inventory: err = %v, output:\n%s", err, out.String())
+	}
+}
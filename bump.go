@@ -0,0 +1,278 @@
+package main
+
+import (
+	"archive/zip"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lamtypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
+
+	"update-lambda-runtime/pkg/bump"
+	"update-lambda-runtime/pkg/inventory"
+)
+
+// errInterrupted is returned by the flows when SIGINT/SIGTERM cancelled the
+// run part-way through, and errRunDeadline when --run-deadline did;
+// errKillSwitch wraps it too.
+var (
+	errInterrupted = errors.New("interrupted")
+	errRunDeadline = fmt.Errorf("%w: --run-deadline reached", errInterrupted)
+)
+
+// waitContextKey holds, with --run-deadline, the context that lasts
+// --run-deadline-grace past the deadline.
+type waitContextKey struct{}
+
+// waitContext derives the context waits for updates already issued run
+// under. It is ctx, except that with --run-deadline it lasts
+// --run-deadline-grace past the deadline, so updates in flight when the
+// deadline passes are seen through while no new ones start.
+func waitContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	grace, ok := ctx.Value(waitContextKey{}).(context.Context)
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	w, cancel := context.WithCancelCause(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(grace, func() { cancel(context.Cause(grace)) })
+	return w, func() {
+		stop()
+		cancel(context.Canceled)
+	}
+}
+
+// stopped is the error a flow returns when ctx ended the run early.
+func stopped(ctx context.Context) error {
+	if cause := context.Cause(ctx); errors.Is(cause, errInterrupted) {
+		return cause
+	}
+	return errInterrupted
+}
+
+// errDiscovery is returned, with a count, by the flows when regions or
+// functions could not be discovered; everything else was still handled.
+var errDiscovery = errors.New("discovery errors")
+
+// bumpSummary counts outcomes, and failures by class, across a bump run.
+type bumpSummary struct {
+	counts   map[bump.Outcome]int
+	failures map[string]int
+}
+
+func (s *bumpSummary) add(r functionResult) {
+	if s.counts == nil {
+		s.counts = make(map[bump.Outcome]int)
+		s.failures = make(map[string]int)
+	}
+	s.counts[r.Outcome]++
+	if r.Failure != "" {
+		s.failures[string(r.Failure)]++
+	}
+}
+
+func (s *bumpSummary) print(w io.Writer) {
+	fmt.Fprint(w, "\nSummary:")
+	for i, o := range bump.Outcomes {
+		sep := ","
+		if i == 0 {
+			sep = ""
+		}
+		fmt.Fprintf(w, "%s %d %s", sep, s.counts[o], o)
+	}
+	fmt.Fprintln(w)
+	if line := failureLine(s.failures); line != "" {
+		fmt.Fprintf(w, "Failures: %s\n", line)
+	}
+}
+
+// pendingUpdate is an update Lambda has accepted, tracked by the poller
+// until it settles. span covers the wait and parents the status polls.
+type pendingUpdate struct {
+	*bump.Update
+	log  *resultCollector
+	span trace.Span
+}
+
+// startUpdate issues j's runtime update, with its layer, environment and
+// handler changes, retrying the call as --max-attempts and --retry-delay
+// allow. Each retry waits on pace, when there is one, as the first call
+// did. It returns the pending update, or nil and the outcome when the
+// call itself failed, with the number of calls made and, for a failure,
+// its class.
+func startUpdate(ctx context.Context, log *resultCollector, j bumpJob, opts *AWSOpts, pace *rate.Limiter) (*pendingUpdate, int, bump.Outcome, bump.FailureClass) {
+	req := bump.Request{
+		Function:    j.result.Name,
+		Runtime:     j.result.TargetRuntime,
+		Layers:      j.layers,
+		Env:         j.env,
+		Handler:     j.handler,
+		Description: j.description,
+		RevisionID:  j.revision,
+		Rules:       j.rules,
+	}
+	var with []string
+	if req.Handler != "" {
+		with = append(with, "handler "+req.Handler)
+	}
+	if req.Layers != nil {
+		with = append(with, "layers "+strings.Join(req.Layers, ", "))
+	}
+	if req.Env != nil {
+		with = append(with, "updated environment")
+	}
+	if req.Description != nil {
+		with = append(with, "updated description")
+	}
+	if len(with) == 0 && req.Rules != nil {
+		// A worker's item: the rules are made on the configuration
+		// read when the update is.
+		with = append(with, "its layer, environment and description rules")
+	}
+	if len(with) > 0 {
+		log.progressf("Updating %s to %s with %s...\n", req.Function, req.Runtime, strings.Join(with, " and "))
+	} else {
+		log.progressf("Updating %s to %s...\n", req.Function, req.Runtime)
+	}
+	retry := bump.Retry{
+		MaxAttempts: opts.MaxAttempts,
+		Delay:       opts.RetryDelay,
+		OnRetry: func(attempt int, err error, wait time.Duration) {
+			log.progressf("  update error for %s (attempt %d of %d): %v; retrying in %s\n", req.Function, attempt, opts.MaxAttempts, err, wait)
+		},
+		Pace: func(ctx context.Context) error { return awaitPace(ctx, pace) },
+	}
+	u, attempts, err := retry.Start(ctx, j.cli, req, cmp.Or(j.timeout, opts.Timeout))
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, attempts, bump.NotAttempted, ""
+		}
+		log.progressf("  update error for %s: %v\n", req.Function, err)
+		return nil, attempts, bump.Failed, bump.Classify(err)
+	}
+	return newPendingUpdate(ctx, log, u), attempts, "", ""
+}
+
+// newPendingUpdate starts the wait span of u.
+func newPendingUpdate(ctx context.Context, log *resultCollector, u *bump.Update) *pendingUpdate {
+	_, span := tracer.Start(ctx, "wait")
+	return &pendingUpdate{Update: u, log: log, span: span}
+}
+
+// settled reports the outcome once the update has finished, failed to be
+// polled or run out of time.
+func (p *pendingUpdate) settled() (bump.Outcome, bool) {
+	o, ok := p.Settled()
+	switch {
+	case !ok:
+	case p.Err != nil:
+		p.log.progressf("  wait error for %s: %v\n", p.Function, p.Err)
+	case o == bump.Updated:
+		p.log.progressf("%s updated successfully\n", p.Function)
+	case o == bump.Failed:
+		p.log.progressf("%s update failed: %s\n", p.Function, p.Reason)
+	case o == bump.TimedOut:
+		p.log.progressf("Timed out waiting for %s\n", p.Function)
+	}
+	return o, ok
+}
+
+func (p *pendingUpdate) refresh(ctx context.Context) {
+	p.Refresh(trace.ContextWithSpan(ctx, p.span))
+}
+
+// await waits for p with the SDK waiter and reports how it settled.
+func (p *pendingUpdate) await(ctx context.Context, every time.Duration) bump.Outcome {
+	if p.Await(trace.ContextWithSpan(ctx, p.span), every) == bump.Interrupted {
+		p.log.progressf("Stopped waiting for %s; the update continues in AWS\n", p.Function)
+		return bump.Interrupted
+	}
+	o, _ := p.settled()
+	return o
+}
+
+// bumpJob is one function on the source runtime, queued for a worker.
+// layers, env and description are the function's new layer list,
+// environment and description, nil when they are left as they are, as
+// rules made them of the configuration at revision; handler is its new
+// handler, "" to keep it; distributions are the CloudFront distributions
+// of a Lambda@Edge function.
+type bumpJob struct {
+	cli           *lambda.Client
+	result        functionResult
+	layers        []string
+	env           map[string]string
+	description   *string
+	rules         *bump.Rules
+	revision      string
+	handler       string
+	distributions []string
+	logGroup      string        // where the function logs, if not /aws/lambda/<name>
+	timeout       time.Duration // wait for the update this long rather than --wait-timeout
+	opts          *AWSOpts      // the run's options, for the package checks
+	code          *zip.Reader   // the deployment package, once downloaded
+}
+
+// waitScaleStep is the package size that earns a function another
+// --wait-timeout with --scale-wait-timeout, and maxWaitScale the most
+// times --wait-timeout it may wait.
+const (
+	waitScaleStep = 50 << 20
+	maxWaitScale  = 4
+)
+
+// scaledWaitTimeout is how long --scale-wait-timeout waits for f's update:
+// base, and base again when f is attached to a VPC, is a container image
+// Lambda has to optimize again, and for every waitScaleStep of its package,
+// up to maxWaitScale times base.
+func scaledWaitTimeout(base time.Duration, f inventory.Function) time.Duration {
+	n := 1 + f.CodeSize/waitScaleStep
+	if f.VPC {
+		n++
+	}
+	if f.PackageType == string(lamtypes.PackageTypeImage) {
+		n++
+	}
+	return base * time.Duration(min(n, maxWaitScale))
+}
+
+// publishedVersionReason explains why a function qualified with qualifier,
+// which resolves to published version, cannot be bumped: a published
+// version's configuration is immutable, so runtimes change on $LATEST and
+// reach an alias through a new version.
+func publishedVersionReason(name, qualifier, version string) string {
+	if qualifier == version {
+		return fmt.Sprintf("%s:%s is a published version, and published versions cannot be changed; bump %s ($LATEST), then publish a new version and move your aliases to it", name, version, name)
+	}
+	return fmt.Sprintf("alias %s points at published version %s, and published versions cannot be changed; bump %s ($LATEST), then publish a new version and point %s at it", qualifier, version, name, qualifier)
+}
+
+// isDisabled reports whether the function name has a reserved concurrency
+// of 0, which stops every invocation: the way a function is switched off
+// on purpose.
+func isDisabled(ctx context.Context, cli *lambda.Client, name string) (bool, error) {
+	out, err := cli.GetFunctionConcurrency(ctx, &lambda.GetFunctionConcurrencyInput{FunctionName: aws.String(name)})
+	if err != nil {
+		return false, err
+	}
+	return out.ReservedConcurrentExecutions != nil && *out.ReservedConcurrentExecutions == 0, nil
+}
+
+// productionReason returns why the policy counts r as production, or ""
+// when it does not. Tags are only looked up when a tag rule decides it.
+func productionReason(ctx context.Context, cli tagsAPI, p *bump.Policy, r functionResult) (string, error) {
+	return p.Production.Match(r.AccountID, r.Name, func() (map[string]string, error) {
+		out, err := cli.ListTags(ctx, &lambda.ListTagsInput{Resource: aws.String(functionARN(r))})
+		if err != nil {
+			return nil, err
+		}
+		return out.Tags, nil
+	})
+}
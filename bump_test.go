@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"update-lambda-runtime/pkg/inventory"
+)
+
+func TestScaledWaitTimeout(t *testing.T) {
+	base := 5 * time.Minute
+	tests := []struct {
+		name string
+		f    inventory.Function
+		want time.Duration
+	}{
+		{"small zip", inventory.Function{CodeSize: 1 << 20, PackageType: "Zip"}, base},
+		{"VPC", inventory.Function{CodeSize: 1 << 20, VPC: true}, 2 * base},
+		{"120 MB", inventory.Function{CodeSize: 120 << 20}, 3 * base},
+		{"image", inventory.Function{PackageType: "Image"}, 2 * base},
+		{"capped", inventory.Function{CodeSize: 240 << 20, VPC: true}, 4 * base},
+	}
+	for _, tt := range tests {
+		if got := scaledWaitTimeout(base, tt.f); got != tt.want {
+			t.Errorf("%s: %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWaitContext(t *testing.T) {
+	// Without a grace period the wait ends with the run.
+	ctx, cancel := context.WithCancel(context.Background())
+	w, stop := waitContext(ctx)
+	defer stop()
+	cancel()
+	if w.Err() == nil {
+		t.Error("wait outlived its run with no grace period")
+	}
+
+	// With one it outlives the deadline until the grace period ends.
+	deadline, cancelDeadline := context.WithCancelCause(context.Background())
+	grace, cancelGrace := context.WithCancelCause(context.Background())
+	defer cancelGrace(nil)
+	w, stop = waitContext(context.WithValue(deadline, waitContextKey{}, grace))
+	defer stop()
+	cancelDeadline(errRunDeadline)
+	if w.Err() != nil {
+		t.Fatal("wait ended at the deadline, before its grace period")
+	}
+	cancelGrace(errRunDeadline)
+	<-w.Done()
+	if !errors.Is(context.Cause(w), errRunDeadline) {
+		t.Errorf("cause = %v, want %v", context.Cause(w), errRunDeadline)
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"update-lambda-runtime/pkg/inventory"
+)
+
+// inventoryCache keeps the inventory discovered by `list --all` on disk, one
+// file per profile and region, so repeated analysis runs can skip the API.
+// Files are NDJSON, a header line followed by one line per function, and are
+// written and read a record at a time.
+type inventoryCache struct {
+	dir string
+}
+
+type cacheHeader struct {
+	SavedAt   time.Time `json:"savedAt"`
+	AccountID string    `json:"accountId"`
+}
+
+func newInventoryCache(profile string) (*inventoryCache, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("locate cache dir: %w", err)
+	}
+	return &inventoryCache{dir: filepath.Join(base, "update-lambda-runtime", profile)}, nil
+}
+
+func (c *inventoryCache) path(region string) string {
+	return filepath.Join(c.dir, region+".ndjson")
+}
+
+// header reads only the header of a region's cache file.
+func (c *inventoryCache) header(region string) (cacheHeader, error) {
+	return c.read(region, nil)
+}
+
+// read decodes a region's cache file, calling visit for every function when
+// visit is non-nil.
+func (c *inventoryCache) read(region string, visit func(inventory.Function)) (cacheHeader, error) {
+	var hdr cacheHeader
+	f, err := os.Open(c.path(region))
+	if err != nil {
+		return hdr, err
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(bufio.NewReader(f))
+	if err := dec.Decode(&hdr); err != nil {
+		return hdr, fmt.Errorf("read cache header %s: %w", c.path(region), err)
+	}
+	if visit == nil {
+		return hdr, nil
+	}
+	for dec.More() {
+		var fn inventory.Function
+		if err := dec.Decode(&fn); err != nil {
+			return hdr, fmt.Errorf("read cache %s: %w", c.path(region), err)
+		}
+		visit(fn)
+	}
+	return hdr, nil
+}
+
+// streamCached is the cached counterpart of inventory.Stream: it applies the
+// same --function/--all selection to a region's cached inventory. A
+// --function that is not in the cache is still visited, with no runtime.
+func (c *inventoryCache) streamCached(region string, opts *AWSOpts, visit func(inventory.Function)) (cacheHeader, error) {
+	if opts.FunctionName == "" {
+		return c.read(region, visit)
+	}
+	found := inventory.Function{Name: opts.FunctionName}
+	hdr, err := c.read(region, func(f inventory.Function) {
+		if f.Name == opts.FunctionName {
+			found = f
+		}
+	})
+	if err != nil {
+		return hdr, err
+	}
+	visit(found)
+	return hdr, nil
+}
+
+// cacheWriter writes a region's inventory to a temp file that replaces the
+// cache only on commit, so an interrupted or failed listing never leaves a
+// truncated inventory behind.
+type cacheWriter struct {
+	f    *os.File
+	w    *bufio.Writer
+	enc  *json.Encoder
+	path string
+	err  error
+}
+
+func (c *inventoryCache) create(region, accountID string) (*cacheWriter, error) {
+	return c.createAt(region, cacheHeader{SavedAt: time.Now().UTC(), AccountID: accountID})
+}
+
+// createAt is create for an inventory taken at hdr.SavedAt rather than now,
+// such as an imported one.
+func (c *inventoryCache) createAt(region string, hdr cacheHeader) (*cacheWriter, error) {
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		return nil, err
+	}
+	f, err := os.CreateTemp(c.dir, region+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	w := bufio.NewWriter(f)
+	cw := &cacheWriter{f: f, w: w, enc: json.NewEncoder(w), path: c.path(region)}
+	cw.err = cw.enc.Encode(hdr)
+	return cw, nil
+}
+
+func (cw *cacheWriter) add(fn inventory.Function) {
+	if cw.err == nil {
+		cw.err = cw.enc.Encode(fn)
+	}
+}
+
+func (cw *cacheWriter) commit() error {
+	if cw.err == nil {
+		cw.err = cw.w.Flush()
+	}
+	if err := cw.f.Close(); cw.err == nil {
+		cw.err = err
+	}
+	if cw.err != nil {
+		os.Remove(cw.f.Name())
+		return cw.err
+	}
+	return os.Rename(cw.f.Name(), cw.path)
+}
+
+func (cw *cacheWriter) abort() {
+	cw.f.Close()
+	os.Remove(cw.f.Name())
+}
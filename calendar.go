@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+
+	"update-lambda-runtime/pkg/inventory"
+)
+
+// Phases past deprecation, in the order AWS applies them.
+const (
+	runtimeCreateBlocked = "create blocked"
+	runtimeUpdateBlocked = "update blocked"
+)
+
+// runDeprecations prints the runtime calendar, the soonest deprecation
+// first, after refreshing it from AWS when asked to. With --profile the
+// selected functions (all of them unless --function is given) are counted
+// per runtime, so runtimes the fleet still uses stand out; runtimes in use
+// that the calendar does not know are listed at the end.
+func runDeprecations(ctx context.Context, opts *AWSOpts, w io.Writer) error {
+	if opts.RefreshCalendar {
+		if err := refreshCalendar(ctx, opts.CalendarURL); err != nil {
+			fmt.Fprintln(os.Stderr, "warning: refresh runtime calendar:", err)
+		} else {
+			useCachedCalendar()
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Runtime calendar: %s\n", calendarSource)
+
+	var fleet map[string]int
+	if opts.Profile != "" {
+		if opts.FunctionName == "" {
+			opts.All = true
+		}
+		if err := validateCommon(opts); err != nil {
+			return err
+		}
+		fleet = make(map[string]int)
+		err := eachFunction(ctx, opts, "deprecations", func(_ context.Context, _ *lambda.Client, r functionResult) {
+			if r.Runtime != "" {
+				fleet[r.Runtime]++
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	entries := inventory.Calendar()
+	for _, rt := range slices.Sorted(maps.Keys(fleet)) {
+		if _, ok := inventory.Entry(rt); !ok {
+			entries = append(entries, inventory.Phases{Runtime: rt})
+		}
+	}
+	// Announced dates first, soonest first; the rest by name.
+	undated := func(p inventory.Phases) string { return cmp.Or(p.Deprecation, "9999-12-31") }
+	slices.SortStableFunc(entries, func(a, b inventory.Phases) int {
+		return cmp.Or(cmp.Compare(undated(a), undated(b)), cmp.Compare(a.Runtime, b.Runtime))
+	})
+
+	now := time.Now()
+	tbl := newTable(w, runtimeNameWidth, len("Deprecation"), len("BlockCreate"), len("BlockUpdate"), len(runtimeUpdateBlocked))
+	cols := []string{"Runtime", "Deprecation", "BlockCreate", "BlockUpdate", "Status"}
+	if fleet != nil {
+		cols = append(cols, "Functions")
+	}
+	tbl.header(cols...)
+	for _, e := range entries {
+		status := runtimePhase(e, now)
+		if _, ok := inventory.Entry(e.Runtime); !ok {
+			status = "unknown"
+		}
+		row := []string{e.Runtime, cmp.Or(e.Deprecation, "-"), cmp.Or(e.BlockCreate, "-"), cmp.Or(e.BlockUpdate, "-"), status}
+		if fleet != nil {
+			n := ""
+			if fleet[e.Runtime] > 0 {
+				n = strconv.Itoa(fleet[e.Runtime])
+			}
+			row = append(row, n)
+		}
+		tbl.row(row...)
+	}
+	return nil
+}
+
+// runtimePhase is how far p's runtime is through its deprecation at now.
+func runtimePhase(p inventory.Phases, now time.Time) string {
+	passed := func(d string) bool {
+		t, err := time.Parse(time.DateOnly, d)
+		return err == nil && !now.Before(t)
+	}
+	switch {
+	case passed(p.BlockUpdate):
+		return runtimeUpdateBlocked
+	case passed(p.BlockCreate):
+		return runtimeCreateBlocked
+	}
+	return inventory.DeprecationStatus(p.Runtime, now)
+}
+
+// runtimeCalendarURL is the Lambda runtimes documentation page whose
+// tables list every runtime's phase dates.
+const runtimeCalendarURL = "https://docs.aws.amazon.com/lambda/latest/dg/lambda-runtimes.html"
+
+// fetchedCalendar is the runtime calendar cached by deprecations --refresh.
+type fetchedCalendar struct {
+	FetchedAt time.Time          `json:"fetchedAt"`
+	Source    string             `json:"source"`
+	Runtimes  []inventory.Phases `json:"runtimes"`
+}
+
+func calendarCachePath() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("locate cache dir: %w", err)
+	}
+	return filepath.Join(base, "update-lambda-runtime", "runtime-calendar.json"), nil
+}
+
+// calendarSource says where the calendar in use came from.
+var calendarSource = "embedded"
+
+// useCachedCalendar has inventory use the last refreshed calendar, merged
+// into its own, if there is one. Every command calls it first, so
+// deprecation statuses everywhere follow the latest refresh.
+func useCachedCalendar() {
+	path, err := calendarCachePath()
+	if err != nil {
+		return
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	var fc fetchedCalendar
+	if err := json.Unmarshal(b, &fc); err != nil || len(fc.Runtimes) == 0 {
+		fmt.Fprintf(os.Stderr, "warning: ignoring unreadable runtime calendar cache %s\n", path)
+		return
+	}
+	inventory.WithCalendar(mergeCalendar(inventory.Calendar(), fc.Runtimes))
+	calendarSource = fmt.Sprintf("fetched %s from %s", fc.FetchedAt.Format(time.DateOnly), fc.Source)
+}
+
+// refreshCalendar downloads the calendar from url and caches it. url is
+// either the documentation page or a JSON document: a list of entries or
+// an object with a "runtimes" list, in the inventory.Phases format.
+func refreshCalendar(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return err
+	}
+	var runtimes []inventory.Phases
+	switch trimmed := bytes.TrimSpace(body); {
+	case bytes.HasPrefix(trimmed, []byte("[")):
+		err = json.Unmarshal(trimmed, &runtimes)
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		var doc fetchedCalendar
+		err = json.Unmarshal(trimmed, &doc)
+		runtimes = doc.Runtimes
+	default:
+		runtimes = parseCalendarHTML(string(body))
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", url, err)
+	}
+	if len(runtimes) == 0 {
+		return fmt.Errorf("%s: no runtimes found; the page layout may have changed", url)
+	}
+
+	path, err := calendarCachePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(fetchedCalendar{FetchedAt: time.Now().UTC(), Source: url, Runtimes: runtimes}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
+
+var (
+	tableRow     = regexp.MustCompile(`(?is)<tr[^>]*>(.*?)</tr>`)
+	tableCell    = regexp.MustCompile(`(?is)<t[dh][^>]*>(.*?)</t[dh]>`)
+	markup       = regexp.MustCompile(`<[^>]*>`)
+	runtimeIdent = regexp.MustCompile(`^[a-z]+[a-z0-9.]*$`)
+)
+
+// parseCalendarHTML reads the runtime tables of the documentation page.
+// Their rows are: name, identifier, operating system, then the
+// deprecation, block create and block update dates ("Apr 30, 2026", or
+// text such as "Not scheduled").
+func parseCalendarHTML(page string) []inventory.Phases {
+	var out []inventory.Phases
+	for _, row := range tableRow.FindAllStringSubmatch(page, -1) {
+		var cells []string
+		for _, c := range tableCell.FindAllStringSubmatch(row[1], -1) {
+			text := html.UnescapeString(markup.ReplaceAllString(c[1], ""))
+			cells = append(cells, strings.Join(strings.Fields(text), " "))
+		}
+		if len(cells) < 6 || !runtimeIdent.MatchString(cells[1]) {
+			continue
+		}
+		out = append(out, inventory.Phases{
+			Runtime:     cells[1],
+			Deprecation: docDate(cells[3]),
+			BlockCreate: docDate(cells[4]),
+			BlockUpdate: docDate(cells[5]),
+		})
+	}
+	return out
+}
+
+// docDate converts a documentation date to time.DateOnly, or "" when the
+// cell holds no date.
+func docDate(s string) string {
+	t, err := time.Parse("Jan 2, 2006", s)
+	if err != nil {
+		return ""
+	}
+	return t.Format(time.DateOnly)
+}
+
+// mergeCalendar returns base with every runtime in fetched replaced by
+// the fetched entry and new runtimes added; runtimes the source no longer
+// lists keep their base entry.
+func mergeCalendar(base, fetched []inventory.Phases) []inventory.Phases {
+	out := slices.Clone(base)
+	for _, f := range fetched {
+		if i := slices.IndexFunc(out, func(p inventory.Phases) bool { return p.Runtime == f.Runtime }); i >= 0 {
+			out[i] = f
+		} else {
+			out = append(out, f)
+		}
+	}
+	return out
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go/middleware"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
+)
+
+// stsRegion is used when the profile has no region of its own; STS is global
+// but the SDK still requires one.
+const stsRegion = "us-east-1"
+
+// clientFactory loads the shared config for a profile once and hands out
+// per-region clients that all share the same credential cache, so SSO or
+// credential_process profiles resolve credentials a single time per run.
+type clientFactory struct {
+	profile    string
+	role       string // ARN assumed with the profile's credentials, if any
+	apiTimeout time.Duration
+	limiter    *rate.Limiter
+
+	mu      sync.Mutex
+	cfg     *aws.Config
+	lambdas map[string]*lambda.Client
+	sts     *sts.Client
+}
+
+// newClientFactory builds the factory for a profile. A positive maxRPS caps
+// the rate of API requests across every client the factory hands out.
+func newClientFactory(profile string, apiTimeout time.Duration, maxRPS float64) *clientFactory {
+	f := &clientFactory{
+		profile:    profile,
+		apiTimeout: apiTimeout,
+		lambdas:    make(map[string]*lambda.Client),
+	}
+	if maxRPS > 0 {
+		f.limiter = rate.NewLimiter(rate.Limit(maxRPS), 1)
+	}
+	return f
+}
+
+// assuming makes every client f hands out act as the role roleARN, assumed
+// with the profile's credentials, as --accounts-file runs do. An empty ARN
+// leaves f as it is.
+func (f *clientFactory) assuming(roleARN string) *clientFactory {
+	f.role = roleARN
+	return f
+}
+
+// config returns the profile's base config, loading it on first use.
+// Callers must hold f.mu.
+func (f *clientFactory) config(ctx context.Context) (aws.Config, error) {
+	if f.cfg != nil {
+		return *f.cfg, nil
+	}
+	var loadOpts []func(*config.LoadOptions) error
+	// Inside Lambda there is no shared config and credentials come from the
+	// execution role, so the default profile is left unnamed: naming a
+	// profile that does not exist is an error.
+	if f.profile != lambdaProfile || os.Getenv("AWS_LAMBDA_RUNTIME_API") == "" {
+		loadOpts = append(loadOpts, config.WithSharedConfigProfile(f.profile))
+	}
+	// All are inserted at the front of the stack, so they run in reverse:
+	// the call span wraps everything, and time spent queued in the limiter
+	// shows on the span but does not count against the per-call timeout.
+	// countCalls goes at the back, once the service is known.
+	var apiOpts []func(*middleware.Stack) error
+	if f.apiTimeout > 0 {
+		apiOpts = append(apiOpts, callTimeout(f.apiTimeout))
+	}
+	if f.limiter != nil {
+		apiOpts = append(apiOpts, rateLimit(f.limiter))
+	}
+	apiOpts = append(apiOpts, traceCalls, countCalls)
+	loadOpts = append(loadOpts, config.WithAPIOptions(apiOpts))
+	cfg, err := config.LoadDefaultConfig(ctx, loadOpts...)
+	if err != nil {
+		return aws.Config{}, err
+	}
+	if f.role != "" {
+		stsCli := sts.NewFromConfig(cfg, func(o *sts.Options) {
+			if o.Region == "" {
+				o.Region = stsRegion
+			}
+		})
+		cfg.Credentials = aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(stsCli, f.role, func(o *stscreds.AssumeRoleOptions) {
+			o.RoleSessionName = "update-lambda-runtime"
+		}))
+	}
+	f.cfg = &cfg
+	return cfg, nil
+}
+
+// Config returns the profile's base config for building clients of
+// services that are only called a handful of times per run.
+func (f *clientFactory) Config(ctx context.Context) (aws.Config, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.config(ctx)
+}
+
+// defaultRegion is the region profile's shared config names, or
+// AWS_REGION, used when --regions is not given; "" when there is none.
+func defaultRegion(ctx context.Context, profile string) string {
+	cfg, err := newClientFactory(profile, 0, 0).Config(ctx)
+	if err != nil {
+		return ""
+	}
+	return cfg.Region
+}
+
+func (f *clientFactory) Lambda(ctx context.Context, region string) (*lambda.Client, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if cli, ok := f.lambdas[region]; ok {
+		return cli, nil
+	}
+	cfg, err := f.config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cli := lambda.NewFromConfig(cfg, func(o *lambda.Options) {
+		o.Region = region
+	})
+	f.lambdas[region] = cli
+	return cli, nil
+}
+
+func (f *clientFactory) STS(ctx context.Context) (*sts.Client, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.sts != nil {
+		return f.sts, nil
+	}
+	cfg, err := f.config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	f.sts = sts.NewFromConfig(cfg, func(o *sts.Options) {
+		if o.Region == "" {
+			o.Region = stsRegion
+		}
+	})
+	return f.sts, nil
+}
+
+// callTimeout bounds every API operation, retries included, so a hung
+// connection fails that call instead of stalling the whole run. It is
+// separate from --wait-timeout, which bounds waiting for an update.
+func callTimeout(d time.Duration) func(*middleware.Stack) error {
+	return func(stack *middleware.Stack) error {
+		return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("CallTimeout",
+			func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+				ctx, cancel := context.WithTimeout(ctx, d)
+				defer cancel()
+				return next.HandleInitialize(ctx, in)
+			}), middleware.Before)
+	}
+}
+
+// rateLimit makes every API operation wait for a token from l before it is
+// sent. Noticeable waits are recorded on the call's span.
+func rateLimit(l *rate.Limiter) func(*middleware.Stack) error {
+	return func(stack *middleware.Stack) error {
+		return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("RateLimit",
+			func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+				start := time.Now()
+				if err := l.Wait(ctx); err != nil {
+					return middleware.InitializeOutput{}, middleware.Metadata{}, err
+				}
+				if waited := time.Since(start); waited > time.Millisecond {
+					trace.SpanFromContext(ctx).AddEvent("rate limited",
+						trace.WithAttributes(attribute.Int64("wait_ms", waited.Milliseconds())))
+				}
+				return next.HandleInitialize(ctx, in)
+			}), middleware.Before)
+	}
+}
+
+// traceCalls wraps every API operation, retries and credential resolution
+// included, in a client span named after the operation. The attempt count
+// separates throttling and retries from a single slow call.
+func traceCalls(stack *middleware.Stack) error {
+	op := stack.ID()
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("Trace",
+		func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+			ctx, span := tracer.Start(ctx, op, trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(semconv.RPCMethod(op)))
+			defer span.End()
+			out, md, err := next.HandleInitialize(ctx, in)
+			if res, ok := retry.GetAttemptResults(md); ok {
+				span.SetAttributes(attribute.Int("aws.attempts", len(res.Results)))
+			}
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			return out, md, err
+		}), middleware.Before)
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+
+	"update-lambda-runtime/pkg/inventory"
+)
+
+// codeScanMax bounds the packages code-scan downloads: Lambda caps a
+// function's unzipped code at 250 MB, so its zip is never larger.
+const codeScanMax = 250 << 20
+
+// Risk ratings, from a package code-scan found nothing in to one that will
+// almost certainly break on the target runtime.
+const (
+	riskLow    = "low"
+	riskMedium = "medium"
+	riskHigh   = "high"
+)
+
+// codeFinding is one compatibility problem in a deployment package.
+type codeFinding struct {
+	Risk    string
+	File    string // path inside the package
+	Line    int    // 0 when the finding is about the whole file
+	Message string
+}
+
+func (f codeFinding) String() string {
+	loc := f.File
+	if f.Line > 0 {
+		loc += ":" + strconv.Itoa(f.Line)
+	}
+	return fmt.Sprintf("[%s] %s: %s", f.Risk, loc, f.Message)
+}
+
+// packageRisk is the highest risk among findings.
+func packageRisk(findings []codeFinding) string {
+	risk := riskLow
+	for _, f := range findings {
+		if f.Risk == riskHigh {
+			return riskHigh
+		}
+		if f.Risk == riskMedium {
+			risk = riskMedium
+		}
+	}
+	return risk
+}
+
+// runCodeScan downloads the package of every selected function the runtime
+// policy would bump and checks it against the target runtime, printing a
+// risk rating per function followed by the findings behind it.
+func runCodeScan(ctx context.Context, opts *AWSOpts, w io.Writer) error {
+	if err := validateCommon(opts); err != nil {
+		return err
+	}
+	tbl := newFunctionTable(w, opts, runtimeNameWidth, runtimeNameWidth, len("Risk"))
+	printHeader(tbl, opts.ShowProfile, "Target", "Risk", "Findings")
+	type scanned struct {
+		r        functionResult
+		target   string
+		findings []codeFinding
+	}
+	var details []scanned
+	counts := make(map[string]int)
+	err := eachFunction(ctx, opts, "code-scan", func(ctx context.Context, cli *lambda.Client, r functionResult) {
+		target, ok := opts.Policy.Target(r.Name, r.Runtime)
+		if !ok {
+			return
+		}
+		risk, summary := "-", ""
+		findings, err := scanFunctionCode(ctx, cli, opts, r.Name, r.Runtime, target)
+		switch {
+		case errors.Is(err, errNotScanned):
+			summary = err.Error()
+		case err != nil:
+			summary = "error: " + err.Error()
+			counts["failed"]++
+		default:
+			risk = packageRisk(findings)
+			counts[risk]++
+			summary = strconv.Itoa(len(findings))
+			if len(findings) > 0 {
+				details = append(details, scanned{r, target, findings})
+			}
+		}
+		printRow(tbl, r.AccountID, r.Profile, r.Region, r.Name, r.Runtime, opts.ShowProfile, target, risk, summary)
+	})
+	for _, d := range details {
+		fmt.Fprintf(w, "\n%s in %s (%s → %s, %s risk):\n", d.r.Name, d.r.Region, d.r.Runtime, d.target, packageRisk(d.findings))
+		slices.SortStableFunc(d.findings, func(a, b codeFinding) int {
+			return cmp.Compare(riskRank(b.Risk), riskRank(a.Risk))
+		})
+		for _, f := range d.findings {
+			fmt.Fprintf(w, "  %s\n", f)
+		}
+	}
+	fmt.Fprintf(w, "\nSummary: %d high, %d medium, %d low risk, %d failed\n", counts[riskHigh], counts[riskMedium], counts[riskLow], counts["failed"])
+	return err
+}
+
+func riskRank(r string) int {
+	return slices.Index([]string{riskLow, riskMedium, riskHigh}, r)
+}
+
+// errNotScanned is returned for functions code-scan has no checks for.
+var errNotScanned = errors.New("not scanned")
+
+// scanFunctionCode downloads fn's deployment package and runs the checks
+// for moving it from runtime from to runtime to.
+func scanFunctionCode(ctx context.Context, cli *lambda.Client, opts *AWSOpts, fn, from, to string) ([]codeFinding, error) {
+	var check func(zr *zip.Reader, f inventory.Function) ([]codeFinding, error)
+	switch {
+	case inventory.Family(to) == "python":
+		check = func(zr *zip.Reader, _ inventory.Function) ([]codeFinding, error) {
+			return pythonFindings(ctx, zr, from, to, opts.PythonPath)
+		}
+	case inventory.Family(to) == "nodejs":
+		check = func(zr *zip.Reader, _ inventory.Function) ([]codeFinding, error) {
+			return nodeFindings(zr, from, to)
+		}
+	case inventory.Family(to) == "ruby":
+		check = func(zr *zip.Reader, _ inventory.Function) ([]codeFinding, error) {
+			return rubyFindings(zr, from, to)
+		}
+	case inventory.Family(to) == "dotnet":
+		check = func(zr *zip.Reader, f inventory.Function) ([]codeFinding, error) {
+			return dotnetFindings(zr, f.Handler, from, to)
+		}
+	case goToProvided(from, to), providedUpgrade(from, to):
+		// The code is left as it is on a new OS, so its layers are
+		// checked with it.
+		check = func(zr *zip.Reader, f inventory.Function) ([]codeFinding, error) {
+			find := goFindings
+			if providedUpgrade(from, to) {
+				find = func(zr *zip.Reader, arch string) ([]codeFinding, error) { return customFindings(zr, arch, to) }
+			}
+			findings, err := find(zr, f.Architecture)
+			if err != nil {
+				return nil, err
+			}
+			layers, err := layerFindings(ctx, cli, f.Layers, to, f.Architecture)
+			return append(findings, layers...), err
+		}
+	default:
+		return nil, fmt.Errorf("%w: no checks for %s", errNotScanned, inventory.Family(to))
+	}
+	zr, f, err := functionCode(ctx, cli, fn)
+	if err != nil {
+		return nil, err
+	}
+	return check(zr, f)
+}
+
+// codeBlocker returns why j must not be bumped without --force, from the
+// package checks for its runtimes, or "" when nothing stops it.
+func codeBlocker(ctx context.Context, j bumpJob) (string, error) {
+	for _, check := range []func(context.Context, bumpJob) (string, error){sdkV2Blocker, bootstrapBlocker, dotnetBlocker} {
+		if why, err := check(ctx, j); why != "" || err != nil {
+			return why, err
+		}
+	}
+	return "", nil
+}
+
+// functionCode downloads fn's zip deployment package, and returns it with
+// the function's configuration.
+func functionCode(ctx context.Context, cli *lambda.Client, fn string) (*zip.Reader, inventory.Function, error) {
+	got, err := cli.GetFunction(ctx, &lambda.GetFunctionInput{FunctionName: aws.String(fn)})
+	if err != nil {
+		return nil, inventory.Function{}, err
+	}
+	if got.Code == nil || aws.ToString(got.Code.RepositoryType) != "S3" {
+		return nil, inventory.Function{}, fmt.Errorf("%w: container image", errNotScanned)
+	}
+	f := inventory.FromConfiguration(*got.Configuration)
+	code, err := downloadCode(ctx, aws.ToString(got.Code.Location), codeScanMax)
+	if err != nil {
+		return nil, f, fmt.Errorf("download code: %w", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(code), int64(len(code)))
+	if err != nil {
+		return nil, f, fmt.Errorf("read package: %w", err)
+	}
+	return zr, f, nil
+}
+
+// readZipFile returns the contents of f.
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// versionString formats a runtime version such as [3 12] as "3.12".
+func versionString(v []int) string {
+	parts := make([]string, len(v))
+	for i, n := range v {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, ".")
+}
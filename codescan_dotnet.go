@@ -0,0 +1,189 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"cmp"
+	"context"
+	"debug/pe"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"update-lambda-runtime/pkg/inventory"
+)
+
+// dotnetMajorChange reports whether moving from runtime from to runtime to
+// changes the .NET major version, as dotnet6 to dotnet8 does. Compiled
+// assemblies target one major, so such a bump usually needs a rebuild.
+func dotnetMajorChange(from, to string) bool {
+	if inventory.Family(from) != "dotnet" || inventory.Family(to) != "dotnet" {
+		return false
+	}
+	f, t := inventory.Version(from), inventory.Version(to)
+	return len(f) > 0 && len(t) > 0 && f[0] != t[0]
+}
+
+// dotnetHandlerError returns what is wrong with a .NET handler string, or
+// "". It is either Assembly::Namespace.Class::Method for a class library or
+// an assembly name for an executable assembly.
+func dotnetHandlerError(handler string) string {
+	parts := strings.Split(handler, "::")
+	switch {
+	case handler == "":
+		return "is empty"
+	case len(parts) == 1:
+		return ""
+	case len(parts) != 3 || slices.Contains(parts, ""):
+		return "is neither Assembly::Namespace.Class::Method nor an assembly name"
+	case !strings.Contains(parts[1], "."):
+		return fmt.Sprintf("names type %q without its namespace", parts[1])
+	}
+	return ""
+}
+
+// dotnetRuntimeConfig is the part of a *.runtimeconfig.json the checks read.
+type dotnetRuntimeConfig struct {
+	RuntimeOptions struct {
+		TFM         string `json:"tfm"`
+		RollForward string `json:"rollForward"`
+		Framework   struct {
+			Version string `json:"version"`
+		} `json:"framework"`
+		ConfigProperties map[string]any `json:"configProperties"`
+	} `json:"runtimeOptions"`
+}
+
+// dotnetFindings checks a .NET package for moving from runtime from to
+// runtime to. Changing the runtime does not recompile anything: assemblies
+// whose runtimeconfig pins the old major will not start without a rebuild,
+// and ReadyToRun or trimmed builds lose what they were built for.
+func dotnetFindings(zr *zip.Reader, handler, from, to string) ([]codeFinding, error) {
+	if !dotnetMajorChange(from, to) {
+		return nil, nil
+	}
+	if msg := dotnetHandlerError(handler); msg != "" {
+		return []codeFinding{{Risk: riskHigh, File: "handler", Message: fmt.Sprintf("%q %s", handler, msg)}}, nil
+	}
+	var findings []codeFinding
+	assembly, _, _ := strings.Cut(handler, "::")
+	if !slices.ContainsFunc(zr.File, func(f *zip.File) bool { return f.Name == assembly+".dll" }) {
+		findings = append(findings, codeFinding{Risk: riskHigh, File: assembly + ".dll", Message: "the handler's assembly is not in the package"})
+	}
+	target := inventory.Version(to)[0]
+	for _, f := range zr.File {
+		switch {
+		case strings.HasSuffix(f.Name, ".runtimeconfig.json"):
+			src, err := readZipFile(f)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", f.Name, err)
+			}
+			var rc dotnetRuntimeConfig
+			if err := json.Unmarshal(src, &rc); err != nil {
+				findings = append(findings, codeFinding{Risk: riskMedium, File: f.Name, Message: "unreadable: " + err.Error()})
+				continue
+			}
+			findings = append(findings, runtimeConfigFindings(f.Name, rc, target, to)...)
+		case path.Ext(f.Name) == ".dll" && !strings.Contains(f.Name, "/"):
+			dll, err := readZipFile(f)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", f.Name, err)
+			}
+			if readyToRun(dll) {
+				findings = append(findings, codeFinding{
+					Risk:    riskMedium,
+					File:    f.Name,
+					Message: fmt.Sprintf("ReadyToRun code for %s is not used on %s and is JIT-compiled instead, slowing cold starts until it is republished", from, to),
+				})
+			}
+		}
+	}
+	return findings, nil
+}
+
+func runtimeConfigFindings(name string, rc dotnetRuntimeConfig, target int, to string) []codeFinding {
+	opts := rc.RuntimeOptions
+	var findings []codeFinding
+	major, _, _ := strings.Cut(opts.Framework.Version, ".")
+	if n, err := strconv.Atoi(major); err == nil && n < target {
+		switch opts.RollForward {
+		case "Major", "LatestMajor":
+			findings = append(findings, codeFinding{
+				Risk:    riskMedium,
+				File:    name,
+				Message: fmt.Sprintf("built for %s and rolls forward to .NET %d untested; rebuild and redeploy to be sure", cmp.Or(opts.TFM, opts.Framework.Version), target),
+			})
+		default:
+			findings = append(findings, codeFinding{
+				Risk:    riskHigh,
+				File:    name,
+				Message: fmt.Sprintf("built for %s, so it will not start on %s; rebuild for net%d.0 and redeploy the code, not just the configuration", cmp.Or(opts.TFM, opts.Framework.Version), to, target),
+			})
+		}
+	}
+	// Trimmed builds switch off the runtime features they cut.
+	if supported, ok := opts.ConfigProperties["System.StartupHookProvider.IsSupported"].(bool); ok && !supported {
+		findings = append(findings, codeFinding{
+			Risk:    riskMedium,
+			File:    name,
+			Message: fmt.Sprintf("trimmed against the old framework's libraries; check the trim warnings when rebuilding for %s", to),
+		})
+	}
+	return findings
+}
+
+// readyToRun reports whether dll is a .NET assembly with ReadyToRun
+// (precompiled) code: its CLI header has a managed native header.
+func readyToRun(dll []byte) bool {
+	f, err := pe.NewFile(bytes.NewReader(dll))
+	if err != nil {
+		return false
+	}
+	var dirs []pe.DataDirectory
+	switch h := f.OptionalHeader.(type) {
+	case *pe.OptionalHeader32:
+		dirs = h.DataDirectory[:h.NumberOfRvaAndSizes]
+	case *pe.OptionalHeader64:
+		dirs = h.DataDirectory[:h.NumberOfRvaAndSizes]
+	}
+	if len(dirs) <= pe.IMAGE_DIRECTORY_ENTRY_COM_DESCRIPTOR {
+		return false
+	}
+	cli := dirs[pe.IMAGE_DIRECTORY_ENTRY_COM_DESCRIPTOR]
+	for _, s := range f.Sections {
+		if cli.VirtualAddress < s.VirtualAddress || cli.VirtualAddress >= s.VirtualAddress+s.VirtualSize {
+			continue
+		}
+		// The managed native header is the last entry of the 72-byte
+		// CLI header, at offset 64.
+		hdr := make([]byte, 72)
+		if _, err := s.ReadAt(hdr, int64(cli.VirtualAddress-s.VirtualAddress)); err != nil {
+			return false
+		}
+		return binary.LittleEndian.Uint32(hdr[64:]) != 0
+	}
+	return false
+}
+
+// dotnetBlocker returns why j must not be bumped without --force: its code
+// was built for the old .NET major and will not start on the new one, or
+// its handler cannot be loaded. It returns "" for functions that are safe
+// or not affected.
+func dotnetBlocker(ctx context.Context, j bumpJob) (string, error) {
+	if !dotnetMajorChange(j.result.Runtime, j.result.TargetRuntime) {
+		return "", nil
+	}
+	findings, err := scanFunctionCode(ctx, j.cli, j.opts, j.result.Name, j.result.Runtime, j.result.TargetRuntime)
+	if err != nil {
+		return "", err
+	}
+	i := slices.IndexFunc(findings, func(f codeFinding) bool { return f.Risk == riskHigh })
+	if i < 0 {
+		return "", nil
+	}
+	return fmt.Sprintf("needs a rebuild for %s: %s %s", j.result.TargetRuntime, findings[i].File, findings[i].Message), nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestDotnetHandlerError(t *testing.T) {
+	for handler, ok := range map[string]bool{
+		"Orders::Orders.Function::FunctionHandler": true,
+		"Orders":                            true,
+		"":                                  false,
+		"Orders::Function::FunctionHandler": false,
+		"Orders::Orders.Function":           false,
+		"Orders::::FunctionHandler":         false,
+	} {
+		if got := dotnetHandlerError(handler); (got == "") != ok {
+			t.Errorf("dotnetHandlerError(%q) = %q", handler, got)
+		}
+	}
+}
+
+func TestRuntimeConfigFindings(t *testing.T) {
+	tests := []struct {
+		config string
+		want   []string // risks
+	}{
+		{`{"runtimeOptions":{"tfm":"net8.0","framework":{"name":"Microsoft.NETCore.App","version":"8.0.0"}}}`, nil},
+		{`{"runtimeOptions":{"tfm":"net6.0","framework":{"name":"Microsoft.NETCore.App","version":"6.0.0"}}}`, []string{riskHigh}},
+		{`{"runtimeOptions":{"tfm":"net6.0","rollForward":"LatestMajor","framework":{"version":"6.0.0"}}}`, []string{riskMedium}},
+		{`{"runtimeOptions":{"tfm":"net8.0","framework":{"version":"8.0.0"},"configProperties":{"System.StartupHookProvider.IsSupported":false}}}`, []string{riskMedium}},
+	}
+	for _, tt := range tests {
+		var rc dotnetRuntimeConfig
+		if err := json.Unmarshal([]byte(tt.config), &rc); err != nil {
+			t.Fatal(err)
+		}
+		findings := runtimeConfigFindings("Orders.runtimeconfig.json", rc, 8, "dotnet8")
+		var got []string
+		for _, f := range findings {
+			got = append(got, f.Risk)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: findings %v, want risks %v", tt.config, findings, tt.want)
+		}
+	}
+}
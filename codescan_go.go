@@ -0,0 +1,75 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"debug/elf"
+	"fmt"
+	"slices"
+
+	lamtypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+
+	"update-lambda-runtime/pkg/inventory"
+)
+
+// goBootstrap is the executable the OS-only runtimes start, and so the
+// handler go1.x functions get when they move to one.
+const goBootstrap = "bootstrap"
+
+// goToProvided reports whether moving from runtime from to runtime to is
+// the go1.x migration to an OS-only runtime (provided.al2023), which runs
+// a bootstrap executable instead of the handler binary.
+func goToProvided(from, to string) bool {
+	return inventory.Family(from) == "go" && inventory.Family(to) == "provided"
+}
+
+// goFindings checks a go1.x package for an OS-only runtime on arch: it
+// needs a Linux executable for arch named bootstrap at its root, which
+// go1.x packages built for the handler's name do not have.
+func goFindings(zr *zip.Reader, arch string) ([]codeFinding, error) {
+	i := slices.IndexFunc(zr.File, func(f *zip.File) bool { return f.Name == goBootstrap })
+	if i < 0 {
+		return []codeFinding{{
+			Risk:    riskHigh,
+			File:    goBootstrap,
+			Message: "missing; rebuild the handler as bootstrap (GOOS=linux go build -tags lambda.norpc -o bootstrap) and redeploy",
+		}}, nil
+	}
+	f := zr.File[i]
+	bin, err := readZipFile(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", f.Name, err)
+	}
+	var findings []codeFinding
+	exe, err := elf.NewFile(bytes.NewReader(bin))
+	switch {
+	case err != nil:
+		findings = append(findings, codeFinding{Risk: riskHigh, File: f.Name, Message: "not a Linux executable; rebuild it with GOOS=linux"})
+	case exe.Machine != goMachine(arch):
+		findings = append(findings, codeFinding{
+			Risk:    riskHigh,
+			File:    f.Name,
+			Message: fmt.Sprintf("built for %s, but the function runs on %s; rebuild it with GOARCH=%s", exe.Machine, arch, goArch(arch)),
+		})
+	}
+	if f.Mode()&0o111 == 0 {
+		findings = append(findings, codeFinding{Risk: riskHigh, File: f.Name, Message: "not executable in the zip; package it where permissions are kept (or with build-lambda-zip on Windows)"})
+	}
+	return findings, nil
+}
+
+// goMachine is the ELF machine of executables for a Lambda architecture.
+func goMachine(arch string) elf.Machine {
+	if arch == string(lamtypes.ArchitectureArm64) {
+		return elf.EM_AARCH64
+	}
+	return elf.EM_X86_64
+}
+
+// goArch is GOARCH for a Lambda architecture.
+func goArch(arch string) string {
+	if arch == string(lamtypes.ArchitectureArm64) {
+		return "arm64"
+	}
+	return "amd64"
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"debug/elf"
+	"os"
+	"strings"
+	"testing"
+)
+
+// goPackage zips one file named name with the given content and mode.
+func goPackage(t *testing.T, name string, content []byte, mode os.FileMode) *zip.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	h := &zip.FileHeader{Name: name, Method: zip.Deflate}
+	h.SetMode(mode)
+	w, err := zw.CreateHeader(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(content)
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return zr
+}
+
+func TestGoFindings(t *testing.T) {
+	// goFindings reads no more than the ELF header's machine.
+	bin := elfHeader(elf.EM_X86_64)
+	arch, other := "x86_64", "arm64"
+	tests := []struct {
+		name string
+		zr   *zip.Reader
+		arch string
+		want string
+	}{
+		{"ready", goPackage(t, "bootstrap", bin, 0o755), arch, ""},
+		{"handler binary", goPackage(t, "main", bin, 0o755), arch, "missing"},
+		{"script", goPackage(t, "bootstrap", []byte("#!/bin/sh\n"), 0o755), arch, "not a Linux executable"},
+		{"other arch", goPackage(t, "bootstrap", bin, 0o755), other, "GOARCH=" + goArch(other)},
+		{"not executable", goPackage(t, "bootstrap", bin, 0o644), arch, "not executable"},
+	}
+	for _, tt := range tests {
+		findings, err := goFindings(tt.zr, tt.arch)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		switch {
+		case tt.want == "" && len(findings) > 0:
+			t.Errorf("%s: findings %v, want none", tt.name, findings)
+		case tt.want != "" && (len(findings) != 1 || !strings.Contains(findings[0].Message, tt.want)):
+			t.Errorf("%s: findings %v, want one saying %q", tt.name, findings, tt.want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"update-lambda-runtime/pkg/inventory"
+)
+
+// nodeSDKv2Dropped is the first Node.js major whose runtime no longer
+// bundles the AWS SDK for JavaScript v2 (aws-sdk), only v3.
+const nodeSDKv2Dropped = 18
+
+// nodeSDKv2 matches require, import and dynamic import of aws-sdk or one of
+// its client paths (aws-sdk/clients/s3).
+var nodeSDKv2 = regexp.MustCompile(`(?:require\s*\(|import\s*\(|from|import)\s*['"]aws-sdk(?:/[^'"]*)?['"]`)
+
+var nodeSources = []string{".js", ".cjs", ".mjs", ".ts"}
+
+// nodeLosesSDKv2 reports whether moving from runtime from to runtime to
+// removes the bundled AWS SDK v2.
+func nodeLosesSDKv2(from, to string) bool {
+	if inventory.Family(from) != "nodejs" || inventory.Family(to) != "nodejs" {
+		return false
+	}
+	f, t := inventory.Version(from), inventory.Version(to)
+	return len(f) > 0 && len(t) > 0 && f[0] < nodeSDKv2Dropped && t[0] >= nodeSDKv2Dropped
+}
+
+// nodeFindings checks a Node.js package for moving from runtime from to
+// runtime to. Code that loads aws-sdk without bundling it is high risk when
+// the target runtime no longer provides it.
+func nodeFindings(zr *zip.Reader, from, to string) ([]codeFinding, error) {
+	if !nodeLosesSDKv2(from, to) {
+		return nil, nil
+	}
+	if slices.ContainsFunc(zr.File, func(f *zip.File) bool { return f.Name == "node_modules/aws-sdk/package.json" }) {
+		return nil, nil // bundled with the function
+	}
+	var findings []codeFinding
+	for _, f := range zr.File {
+		if !slices.Contains(nodeSources, path.Ext(f.Name)) || strings.Contains("/"+f.Name, "/node_modules/") {
+			continue
+		}
+		src, err := readZipFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		sc := bufio.NewScanner(bytes.NewReader(src))
+		// Bundlers emit very long lines.
+		sc.Buffer(nil, 16<<20)
+		for line := 1; sc.Scan(); line++ {
+			if nodeSDKv2.Match(sc.Bytes()) {
+				findings = append(findings, codeFinding{
+					Risk:    riskHigh,
+					File:    f.Name,
+					Line:    line,
+					Message: fmt.Sprintf("loads aws-sdk (v2), which %s no longer includes; bundle it or move to AWS SDK v3", to),
+				})
+			}
+		}
+	}
+	return findings, nil
+}
+
+// sdkV2Blocker returns why j must not be bumped without --force: its code
+// loads the AWS SDK v2 the target runtime no longer bundles. It returns ""
+// for functions that are safe or not affected.
+func sdkV2Blocker(ctx context.Context, j bumpJob) (string, error) {
+	if !nodeLosesSDKv2(j.result.Runtime, j.result.TargetRuntime) {
+		return "", nil
+	}
+	findings, err := scanFunctionCode(ctx, j.cli, j.opts, j.result.Name, j.result.Runtime, j.result.TargetRuntime)
+	if err != nil {
+		return "", err
+	}
+	if len(findings) == 0 {
+		return "", nil
+	}
+	return fmt.Sprintf("loads aws-sdk v2, which %s does not bundle (%s)", j.result.TargetRuntime, findings[0].File), nil
+}
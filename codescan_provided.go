@@ -0,0 +1,145 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"debug/elf"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lamtypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+
+	"update-lambda-runtime/pkg/inventory"
+)
+
+// providedUpgrade reports whether moving from runtime from to runtime to
+// upgrades the OS under a custom runtime, as provided.al2 to
+// provided.al2023 does. The function's own code is unchanged, so what it
+// was built against has to still be there.
+func providedUpgrade(from, to string) bool {
+	return inventory.Family(from) == "provided" && inventory.Family(to) == "provided" &&
+		slices.Compare(inventory.Version(to), inventory.Version(from)) > 0
+}
+
+// al2Libraries are shared libraries of Amazon Linux 2 that Amazon Linux
+// 2023 no longer has, with what they are.
+var al2Libraries = map[string]string{
+	"libssl.so.10":        "OpenSSL 1.0",
+	"libcrypto.so.10":     "OpenSSL 1.0",
+	"libpython2.7.so.1.0": "Python 2.7",
+}
+
+// customFindings checks a custom runtime's package for a newer OS on arch:
+// its bootstrap must run there, and no executable or library in it may
+// link a library the new OS dropped. A package without a bootstrap may be
+// getting one from a layer, so that is only medium risk.
+func customFindings(zr *zip.Reader, arch, to string) ([]codeFinding, error) {
+	var findings []codeFinding
+	if !slices.ContainsFunc(zr.File, func(f *zip.File) bool { return f.Name == goBootstrap }) {
+		findings = append(findings, codeFinding{Risk: riskMedium, File: goBootstrap, Message: "not in the package; a layer must provide it"})
+	}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		bin, err := readZipFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		exe, err := elf.NewFile(bytes.NewReader(bin))
+		if err != nil {
+			if f.Name == goBootstrap && !bytes.HasPrefix(bin, []byte("#!")) {
+				findings = append(findings, codeFinding{Risk: riskHigh, File: f.Name, Message: "neither a Linux executable nor a script"})
+			}
+		} else {
+			findings = append(findings, elfFindings(f.Name, exe, arch, to)...)
+		}
+		if f.Name == goBootstrap && f.Mode()&0o111 == 0 {
+			findings = append(findings, codeFinding{Risk: riskHigh, File: f.Name, Message: "not executable in the zip; package it where permissions are kept"})
+		}
+	}
+	return findings, nil
+}
+
+// elfFindings checks one executable or shared library of a package.
+func elfFindings(name string, exe *elf.File, arch, to string) []codeFinding {
+	if exe.Machine != goMachine(arch) {
+		return []codeFinding{{
+			Risk:    riskHigh,
+			File:    name,
+			Message: fmt.Sprintf("built for %s, but the function runs on %s", exe.Machine, arch),
+		}}
+	}
+	libs, _ := exe.ImportedLibraries()
+	var findings []codeFinding
+	for _, lib := range libs {
+		if what, ok := al2Libraries[lib]; ok {
+			findings = append(findings, codeFinding{
+				Risk:    riskHigh,
+				File:    name,
+				Message: fmt.Sprintf("links %s (%s), which %s does not have; rebuild it on %s", lib, what, to, to),
+			})
+		}
+	}
+	return findings
+}
+
+// layerFindings checks the layers a function runs with against runtime to
+// and its architecture arch, from what each layer version declares. A
+// layer that declares nothing is assumed to work anywhere.
+func layerFindings(ctx context.Context, cli *lambda.Client, layers []string, to, arch string) ([]codeFinding, error) {
+	var findings []codeFinding
+	for _, arn := range layers {
+		out, err := cli.GetLayerVersionByArn(ctx, &lambda.GetLayerVersionByArnInput{Arn: aws.String(arn)})
+		if err != nil {
+			return nil, fmt.Errorf("layer %s: %w", arn, err)
+		}
+		if rts := out.CompatibleRuntimes; len(rts) > 0 && !slices.Contains(rts, lamtypes.Runtime(to)) {
+			findings = append(findings, codeFinding{
+				Risk:    riskHigh,
+				File:    arn,
+				Message: fmt.Sprintf("declares %s, not %s; publish a version built for it", joinRuntimes(rts), to),
+			})
+		}
+		if archs := out.CompatibleArchitectures; len(archs) > 0 && !slices.Contains(archs, lamtypes.Architecture(arch)) {
+			findings = append(findings, codeFinding{
+				Risk:    riskHigh,
+				File:    arn,
+				Message: fmt.Sprintf("does not support %s", arch),
+			})
+		}
+	}
+	return findings, nil
+}
+
+func joinRuntimes(rts []lamtypes.Runtime) string {
+	names := make([]string, len(rts))
+	for i, rt := range rts {
+		names[i] = string(rt)
+	}
+	return strings.Join(names, ", ")
+}
+
+// bootstrapBlocker returns why a function moving to an OS-only runtime, from
+// go1.x or an older OS-only one, cannot do so as deployed: its package or a
+// layer needs a rebuild. It returns "" for functions that are ready or not
+// affected.
+func bootstrapBlocker(ctx context.Context, j bumpJob) (string, error) {
+	from, to := j.result.Runtime, j.result.TargetRuntime
+	if !goToProvided(from, to) && !providedUpgrade(from, to) {
+		return "", nil
+	}
+	findings, err := scanFunctionCode(ctx, j.cli, j.opts, j.result.Name, from, to)
+	if err != nil {
+		return "", err
+	}
+	i := slices.IndexFunc(findings, func(f codeFinding) bool { return f.Risk == riskHigh })
+	if i < 0 {
+		return "", nil
+	}
+	return fmt.Sprintf("needs a rebuild for %s: %s %s", to, findings[i].File, findings[i].Message), nil
+}
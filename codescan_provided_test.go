@@ -0,0 +1,36 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestCustomFindings(t *testing.T) {
+	script := []byte("#!/bin/sh\nexec ./app\n")
+	tests := []struct {
+		name, file string
+		content    []byte
+		mode       os.FileMode
+		want       string // the only finding's risk, "" for none
+	}{
+		{"script", "bootstrap", script, 0o755, ""},
+		{"from a layer", "app.sh", script, 0o644, riskMedium},
+		{"not executable", "bootstrap", script, 0o644, riskHigh},
+		{"data", "bootstrap", []byte("PK\x03\x04"), 0o755, riskHigh},
+	}
+	for _, tt := range tests {
+		findings, err := customFindings(goPackage(t, tt.file, tt.content, tt.mode), "x86_64", "provided.al2023")
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		switch {
+		case tt.want == "" && len(findings) > 0:
+			t.Errorf("%s: findings %v, want none", tt.name, findings)
+		case tt.want != "" && (len(findings) != 1 || findings[0].Risk != tt.want):
+			t.Errorf("%s: findings %v, want one %s", tt.name, findings, tt.want)
+		}
+	}
+	if !providedUpgrade("provided.al2", "provided.al2023") || providedUpgrade("provided.al2023", "provided.al2") || providedUpgrade("python3.9", "provided.al2023") {
+		t.Error("providedUpgrade does not match only newer OS-only runtimes")
+	}
+}
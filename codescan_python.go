@@ -0,0 +1,193 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"update-lambda-runtime/pkg/inventory"
+)
+
+// pythonRemovedModules maps standard library modules to the Python version
+// that removed them.
+var pythonRemovedModules = map[string][]int{
+	"formatter": {3, 10},
+	"parser":    {3, 10},
+	"symbol":    {3, 10},
+	"binhex":    {3, 11},
+	"asynchat":  {3, 12},
+	"asyncore":  {3, 12},
+	"distutils": {3, 12},
+	"imp":       {3, 12},
+	"smtpd":     {3, 12},
+	// PEP 594 "dead batteries", and lib2to3.
+	"aifc": {3, 13}, "audioop": {3, 13}, "cgi": {3, 13}, "cgitb": {3, 13},
+	"chunk": {3, 13}, "crypt": {3, 13}, "imghdr": {3, 13}, "lib2to3": {3, 13},
+	"mailcap": {3, 13}, "msilib": {3, 13}, "nis": {3, 13}, "nntplib": {3, 13},
+	"ossaudiodev": {3, 13}, "pipes": {3, 13}, "sndhdr": {3, 13}, "spwd": {3, 13},
+	"sunau": {3, 13}, "telnetlib": {3, 13}, "uu": {3, 13}, "xdrlib": {3, 13},
+}
+
+var (
+	pythonImport = regexp.MustCompile(`^\s*(?:from\s+([\w.]+)\s+import\b|import\s+([\w.][\w., \t]*))`)
+	// C extensions are tagged with the interpreter they were built for,
+	// e.g. _speedups.cpython-39-x86_64-linux-gnu.so; abi3 ones are not.
+	pythonExtension = regexp.MustCompile(`\.cpython-(\d)(\d+)[^/]*\.so$`)
+)
+
+// pythonFindings checks a Python package for moving from runtime from to
+// runtime to: imports of standard library modules removed in between,
+// C extensions built for another Python version and, when python names an
+// interpreter of the target version, syntax errors.
+func pythonFindings(ctx context.Context, zr *zip.Reader, from, to, python string) ([]codeFinding, error) {
+	fromV, toV := inventory.Version(from), inventory.Version(to)
+	var findings []codeFinding
+	var sources []*zip.File
+	// Top-level package → its C extensions built for another version.
+	extensions := make(map[string][]string)
+	for _, f := range zr.File {
+		switch {
+		case strings.HasSuffix(f.Name, ".py"):
+			sources = append(sources, f)
+		case pythonExtension.MatchString(f.Name):
+			m := pythonExtension.FindStringSubmatch(f.Name)
+			if built := m[1] + "." + m[2]; built != versionString(toV) {
+				top, _, _ := strings.Cut(f.Name, "/")
+				extensions[top] = append(extensions[top], built)
+			}
+		}
+	}
+	for _, top := range slices.Sorted(maps.Keys(extensions)) {
+		built := extensions[top]
+		findings = append(findings, codeFinding{
+			Risk:    riskHigh,
+			File:    top,
+			Message: fmt.Sprintf("%d C extension(s) built for Python %s; rebuild the wheels for %s", len(built), built[0], versionString(toV)),
+		})
+	}
+
+	for _, f := range sources {
+		src, err := readZipFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		sc := bufio.NewScanner(bytes.NewReader(src))
+		sc.Buffer(nil, 1<<20)
+		for line := 1; sc.Scan(); line++ {
+			for _, mod := range importedModules(sc.Text()) {
+				removed, ok := pythonRemovedModules[mod]
+				if ok && slices.Compare(fromV, removed) < 0 && slices.Compare(removed, toV) <= 0 {
+					findings = append(findings, codeFinding{
+						Risk:    riskMedium,
+						File:    f.Name,
+						Line:    line,
+						Message: fmt.Sprintf("imports %s, removed in Python %s", mod, versionString(removed)),
+					})
+				}
+			}
+		}
+	}
+
+	if python != "" && len(sources) > 0 {
+		syntax, err := pythonSyntaxErrors(ctx, python, versionString(toV), sources)
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, syntax...)
+	}
+	return findings, nil
+}
+
+// importedModules returns the top-level modules an import statement on
+// line names.
+func importedModules(line string) []string {
+	m := pythonImport.FindStringSubmatch(line)
+	if m == nil {
+		return nil
+	}
+	if m[1] != "" {
+		if strings.HasPrefix(m[1], ".") { // relative import
+			return nil
+		}
+		top, _, _ := strings.Cut(m[1], ".")
+		return []string{top}
+	}
+	var mods []string
+	for _, part := range strings.Split(m[2], ",") {
+		fields := strings.Fields(part) // "a.b as c"
+		if len(fields) > 0 {
+			top, _, _ := strings.Cut(fields[0], ".")
+			mods = append(mods, top)
+		}
+	}
+	return mods
+}
+
+// pythonSyntaxCheck parses each file named on stdin and prints its syntax
+// errors, after the interpreter's version.
+const pythonSyntaxCheck = `import ast, sys
+print("%d.%d" % sys.version_info[:2])
+for p in sys.stdin.read().splitlines():
+    try:
+        ast.parse(open(p, "rb").read(), p)
+    except SyntaxError as e:
+        print("%s\t%s\t%s" % (p, e.lineno or 0, e.msg))
+`
+
+// pythonSyntaxErrors parses sources with the interpreter python, which
+// must be the target version.
+func pythonSyntaxErrors(ctx context.Context, python, version string, sources []*zip.File) ([]codeFinding, error) {
+	dir, err := os.MkdirTemp("", "code-scan-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	var list strings.Builder
+	for i, f := range sources {
+		src, err := readZipFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		name := filepath.Join(dir, strconv.Itoa(i)+".py")
+		if err := os.WriteFile(name, src, 0o600); err != nil {
+			return nil, err
+		}
+		list.WriteString(name + "\n")
+	}
+	cmd := exec.CommandContext(ctx, python, "-W", "ignore", "-c", pythonSyntaxCheck)
+	cmd.Stdin = strings.NewReader(list.String())
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("--python %s: %w", python, err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if lines[0] != version {
+		return nil, fmt.Errorf("--python %s is Python %s, not the target %s", python, lines[0], version)
+	}
+	var findings []codeFinding
+	for _, l := range lines[1:] {
+		parts := strings.SplitN(l, "\t", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		i, _ := strconv.Atoi(strings.TrimSuffix(filepath.Base(parts[0]), ".py"))
+		line, _ := strconv.Atoi(parts[1])
+		findings = append(findings, codeFinding{
+			Risk:    riskHigh,
+			File:    sources[i].Name,
+			Line:    line,
+			Message: "syntax error on Python " + version + ": " + parts[2],
+		})
+	}
+	return findings, nil
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"update-lambda-runtime/pkg/inventory"
+)
+
+// rubyChange is a breaking change made by a Ruby release: source lines
+// matching pattern stop working from that version on.
+type rubyChange struct {
+	version []int
+	pattern *regexp.Regexp
+	message string
+}
+
+// rubyChanges are the breaking changes code-scan looks for in Ruby source.
+var rubyChanges = []rubyChange{
+	{[]int{3, 0}, regexp.MustCompile(`\bURI\.(?:escape|encode|unescape|decode)\b`), "URI.escape and friends were removed in Ruby 3.0; use URI.encode_www_form_component or CGI.escape"},
+	{[]int{3, 2}, regexp.MustCompile(`\b(?:File|Dir)\.exists\?`), "File.exists? and Dir.exists? were removed in Ruby 3.2; use exist?"},
+	{[]int{3, 2}, regexp.MustCompile(`\b(?:Fixnum|Bignum)\b`), "Fixnum and Bignum were removed in Ruby 3.2; use Integer"},
+	{[]int{3, 2}, regexp.MustCompile(`\.(?:taint|untaint|tainted\?|trust|untrust|untrusted\?)\b`), "taint tracking was removed in Ruby 3.2"},
+}
+
+// rubyBundledGems are default gems that later Ruby releases only ship as
+// bundled gems: under Bundler, requiring one fails unless the Gemfile
+// lists it.
+var rubyBundledGems = []struct {
+	version []int
+	gems    []string
+}{
+	{[]int{3, 0}, []string{"rexml", "rss"}},
+	{[]int{3, 1}, []string{"net-ftp", "net-imap", "net-pop", "net-smtp", "matrix", "prime"}},
+	{[]int{3, 4}, []string{"base64", "bigdecimal", "csv", "drb", "getoptlong", "mutex_m", "nkf", "observer", "racc", "resolv-replace", "rinda"}},
+}
+
+// rubyRequire matches a require of a library, capturing its name.
+var rubyRequire = regexp.MustCompile(`^\s*require\s*\(?\s*['"]([\w/-]+)['"]`)
+
+// rubyGemDir matches the directory Bundler installs a Ruby version's gems
+// in, capturing the ABI version (3.3.0).
+var rubyGemDir = regexp.MustCompile(`(?:^|/)vendor/bundle/ruby/(\d+\.\d+\.\d+)/`)
+
+// rubyCrosses reports whether a bump from version f to version t passes
+// release v.
+func rubyCrosses(f, t, v []int) bool {
+	return slices.Compare(f, v) < 0 && slices.Compare(t, v) >= 0
+}
+
+// rubyJumpError returns why moving from runtime from to runtime to is not a
+// Ruby upgrade, or "".
+func rubyJumpError(from, to string) string {
+	if inventory.Family(from) != "ruby" || inventory.Family(to) != "ruby" {
+		return ""
+	}
+	if slices.Compare(inventory.Version(to), inventory.Version(from)) <= 0 {
+		return fmt.Sprintf("%s is not newer than %s", to, from)
+	}
+	return ""
+}
+
+// rubyGapNotes are the language changes between runtimes from and to that
+// no scan can rule out, for the bump's progress.
+func rubyGapNotes(from, to string) []string {
+	f, t := inventory.Version(from), inventory.Version(to)
+	var notes []string
+	if rubyCrosses(f, t, []int{3, 0}) {
+		notes = append(notes, "Ruby 3.0 separates keyword from positional arguments; methods passed a hash as keywords now raise ArgumentError")
+	}
+	if rubyCrosses(f, t, []int{3, 4}) {
+		notes = append(notes, "Ruby 3.4 freezes string literals with a warning and reads a block's it as its parameter")
+	}
+	return notes
+}
+
+// rubyFindings checks a Ruby package for moving from runtime from to runtime
+// to: gems installed for another Ruby (native extensions above all) are
+// high risk, as is source using what the releases in between removed;
+// requiring a default gem that became a bundled one without vendoring it
+// is medium risk.
+func rubyFindings(zr *zip.Reader, from, to string) ([]codeFinding, error) {
+	if rubyJumpError(from, to) != "" {
+		return nil, nil
+	}
+	f, t := inventory.Version(from), inventory.Version(to)
+	abi := versionString(t) + ".0"
+	var findings []codeFinding
+	vendored := make(map[string]bool)
+	wrongABI := make(map[string]bool)
+	for _, zf := range zr.File {
+		m := rubyGemDir.FindStringSubmatch(zf.Name)
+		if m == nil {
+			continue
+		}
+		if m[1] != abi && !wrongABI[m[1]] {
+			wrongABI[m[1]] = true
+			findings = append(findings, codeFinding{
+				Risk:    riskHigh,
+				File:    strings.TrimSuffix(m[0], "/"),
+				Message: fmt.Sprintf("gems installed for Ruby %s, which %s does not load; run bundle install for %s and redeploy", m[1], to, to),
+			})
+		}
+		if m[1] != abi && path.Ext(zf.Name) == ".so" {
+			findings = append(findings, codeFinding{Risk: riskHigh, File: zf.Name, Message: "native extension built for Ruby " + m[1]})
+		}
+		if gem, _, ok := strings.Cut(strings.TrimPrefix(zf.Name[len(m[0]):], "gems/"), "/"); ok {
+			vendored[gem[:max(strings.LastIndex(gem, "-"), 0)]] = true
+		}
+	}
+	var moved []string
+	for _, b := range rubyBundledGems {
+		if rubyCrosses(f, t, b.version) {
+			moved = append(moved, b.gems...)
+		}
+	}
+	for _, zf := range zr.File {
+		if path.Ext(zf.Name) != ".rb" || strings.HasPrefix(zf.Name, "vendor/") {
+			continue
+		}
+		src, err := readZipFile(zf)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", zf.Name, err)
+		}
+		sc := bufio.NewScanner(bytes.NewReader(src))
+		for line := 1; sc.Scan(); line++ {
+			for _, c := range rubyChanges {
+				if rubyCrosses(f, t, c.version) && c.pattern.Match(sc.Bytes()) {
+					findings = append(findings, codeFinding{Risk: riskHigh, File: zf.Name, Line: line, Message: c.message})
+				}
+			}
+			m := rubyRequire.FindSubmatch(sc.Bytes())
+			if m == nil {
+				continue
+			}
+			gem := strings.ReplaceAll(string(m[1]), "/", "-")
+			if slices.Contains(moved, gem) && !vendored[gem] {
+				findings = append(findings, codeFinding{
+					Risk:    riskMedium,
+					File:    zf.Name,
+					Line:    line,
+					Message: fmt.Sprintf("requires %s, a bundled gem on %s; add it to the Gemfile", gem, to),
+				})
+			}
+		}
+	}
+	return findings, nil
+}
+
+// extractPackage unpacks zr into dir for --ruby-pre-hook, refusing entries
+// that would land outside it.
+func extractPackage(zr *zip.Reader, dir string) error {
+	for _, f := range zr.File {
+		if !filepath.IsLocal(f.Name) {
+			return fmt.Errorf("%s: outside the package", f.Name)
+		}
+		dst := filepath.Join(dir, f.Name)
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(dst, 0o755); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return err
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, f.Mode().Perm()|0o600)
+		if err == nil {
+			_, err = io.Copy(out, rc)
+			err = errors.Join(err, out.Close())
+		}
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
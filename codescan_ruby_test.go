@@ -0,0 +1,80 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// rubyPackage zips files, path to content.
+func rubyPackage(t *testing.T, files map[string]string) *zip.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(files[name]))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return zr
+}
+
+func TestRubyFindings(t *testing.T) {
+	zr := rubyPackage(t, map[string]string{
+		"app.rb": "require 'json'\nrequire 'net/smtp'\nrequire 'rexml/document'\n" +
+			"def handler(event:, context:)\n  File.exists?('/tmp/x') ? URI.escape('a b') : 1\nend\n",
+		"vendor/bundle/ruby/2.7.0/gems/nokogiri-1.13.0/lib/nokogiri.rb":                      "",
+		"vendor/bundle/ruby/2.7.0/extensions/x86_64-linux/2.7.0/nokogiri-1.13.0/nokogiri.so": "",
+		"vendor/bundle/ruby/2.7.0/gems/rexml-3.2.5/lib/rexml/document.rb":                    "",
+	})
+	findings, err := rubyFindings(zr, "ruby2.7", "ruby3.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.String())
+	}
+	want := []string{
+		"[high] vendor/bundle/ruby/2.7.0: gems installed for Ruby 2.7.0, which ruby3.3 does not load; run bundle install for ruby3.3 and redeploy",
+		"[high] vendor/bundle/ruby/2.7.0/extensions/x86_64-linux/2.7.0/nokogiri-1.13.0/nokogiri.so: native extension built for Ruby 2.7.0",
+		"[medium] app.rb:2: requires net-smtp, a bundled gem on ruby3.3; add it to the Gemfile",
+		"[high] app.rb:5: URI.escape and friends were removed in Ruby 3.0; use URI.encode_www_form_component or CGI.escape",
+		"[high] app.rb:5: File.exists? and Dir.exists? were removed in Ruby 3.2; use exist?",
+	}
+	slices.Sort(got)
+	slices.Sort(want)
+	if !slices.Equal(got, want) {
+		t.Errorf("findings:\n%q\nwant:\n%q", got, want)
+	}
+
+	if rubyJumpError("ruby3.3", "ruby3.2") == "" || rubyJumpError("ruby2.7", "ruby3.3") != "" {
+		t.Error("rubyJumpError does not reject only downgrades")
+	}
+}
+
+func TestExtractPackage(t *testing.T) {
+	dir := t.TempDir()
+	if err := extractPackage(rubyPackage(t, map[string]string{"lib/app.rb": "puts 1\n"}), dir); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := os.ReadFile(filepath.Join(dir, "lib", "app.rb")); err != nil || string(b) != "puts 1\n" {
+		t.Errorf("lib/app.rb = %q, %v", b, err)
+	}
+	if err := extractPackage(rubyPackage(t, map[string]string{"../escape.rb": ""}), t.TempDir()); err == nil {
+		t.Error("extracted an entry outside the directory")
+	}
+}
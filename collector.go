@@ -0,0 +1,182 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"update-lambda-runtime/pkg/bump"
+)
+
+// functionResult is what a bump run did with one discovered function.
+// TargetRuntime and Outcome are empty for functions the runtime policy
+// left alone; Architecture is only filled in by arch runs. Edge marks
+// Lambda@Edge functions.
+type functionResult struct {
+	AccountID        string            `json:"accountId"`
+	Profile          string            `json:"profile"`
+	Region           string            `json:"region"`
+	Name             string            `json:"functionName"`
+	Runtime          string            `json:"runtime"`
+	Architecture     string            `json:"architecture,omitempty"`
+	State            string            `json:"state,omitempty"`             // list --show-state only
+	LastUpdateStatus string            `json:"lastUpdateStatus,omitempty"`  // list --show-state only
+	UpdateRuntimeOn  string            `json:"updateRuntimeOn,omitempty"`   // list --show-runtime-management only
+	RuntimeVersion   string            `json:"runtimeVersionArn,omitempty"` // pinned with Manual; list --show-runtime-management only
+	Edge             string            `json:"edge,omitempty"`
+	Handler          string            `json:"handler,omitempty"`
+	LastModifiedBy   string            `json:"lastModifiedBy,omitempty"`
+	Tags             map[string]string `json:"tags,omitempty"`    // list --show-tags only
+	Triggers         []functionTrigger `json:"triggers,omitzero"` // list --show-triggers only
+	TargetRuntime    string            `json:"targetRuntime,omitempty"`
+	Outcome          bump.Outcome      `json:"outcome,omitempty"`
+	Failure          bump.FailureClass `json:"failure,omitempty"`   // failed or timed out only
+	Detail           string            `json:"detail,omitempty"`    // the code blocker or verifier's reason that held it back, the disabled check or filter plugin that failed, or the distributions a failed Lambda@Edge deploy left on the new version
+	RequestID        string            `json:"requestId,omitempty"` // bump --no-wait only
+	Attempts         int               `json:"attempts,omitempty"`  // update calls made
+}
+
+// discoveryError is a region that could not be listed, or with --function
+// a function that could not be looked up in it.
+type discoveryError struct {
+	AccountID string `json:"accountId"`
+	Region    string `json:"region"`
+	Function  string `json:"functionName,omitempty"`
+	Error     string `json:"error"`
+}
+
+// inventoryResult is what a list run found: every function discovered in
+// each region, and the regions or functions that could not be discovered.
+type inventoryResult struct {
+	Profile    string           `json:"profile"`
+	AccountID  string           `json:"accountId"`
+	Regions    []string         `json:"regions"`
+	StartedAt  time.Time        `json:"startedAt"`
+	FinishedAt time.Time        `json:"finishedAt"`
+	Functions  []functionResult `json:"functions"`
+	Errors     []discoveryError `json:"errors,omitempty"`
+}
+
+// report turns the inventory into the run report list notifiers consume.
+func (res *inventoryResult) report(opts *AWSOpts) *runReport {
+	rep := newRunReport(opts, res.AccountID, res.StartedAt, false, res.Functions)
+	rep.Errors = res.Errors
+	return rep
+}
+
+// listSink is shown the functions and discovery errors of a list run as
+// they are found, so output can keep pace with a long scan.
+type listSink interface {
+	start() // once discovery is prepared and opts.Regions is final
+	listed(r functionResult)
+	failed(e discoveryError)
+}
+
+// resultCollector owns everything a bump run writes while it runs. Workers
+// hand it progress lines and results concurrently; it serializes the
+// progress lines and keeps the results for the run report. After
+// countUnchanged, functions the run has nothing to say about are only
+// counted in unlisted, so what it keeps grows with the functions the run
+// acts on rather than with the fleet.
+type resultCollector struct {
+	mu       sync.Mutex
+	w        io.Writer
+	results  []functionResult
+	errs     []discoveryError
+	mappings map[string]string // set by countUnchanged
+	unlisted int
+}
+
+func newResultCollector(w io.Writer) *resultCollector {
+	return &resultCollector{w: w}
+}
+
+func (c *resultCollector) progressf(format string, args ...any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintf(c.w, format, args...)
+}
+
+func (c *resultCollector) add(r functionResult) {
+	// Failures nobody classified count as timeouts or other failures.
+	switch {
+	case r.Outcome != bump.Failed && r.Outcome != bump.TimedOut:
+		r.Failure = ""
+	case r.Failure == "" && r.Outcome == bump.TimedOut:
+		r.Failure = bump.Timeout
+	case r.Failure == "":
+		r.Failure = bump.OtherFailure
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.mappings != nil && c.unchanged(r) {
+		c.unlisted++
+		return
+	}
+	c.results = append(c.results, r)
+}
+
+// countUnchanged makes c count, rather than keep, functions that are up
+// to date: left alone by the run, on a runtime mappings does not map and
+// that is not deprecated.
+func (c *resultCollector) countUnchanged(mappings map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.mappings = maps.Clone(mappings)
+	if c.mappings == nil {
+		c.mappings = make(map[string]string)
+	}
+}
+
+func (c *resultCollector) unchanged(r functionResult) bool {
+	if _, mapped := c.mappings[r.Runtime]; mapped || r.Outcome != "" || r.Edge != "" {
+		return false
+	}
+	return classify(r, time.Now()) == classUpToDate
+}
+
+// unlistedCount returns how many up-to-date functions were counted
+// without being kept.
+func (c *resultCollector) unlistedCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.unlisted
+}
+
+// discoveryFailed reports and records that region, or the function name
+// in it, could not be discovered in the account.
+func (c *resultCollector) discoveryFailed(accountID, region, name string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintf(c.w, "Error discovering %s: %v\n", cmp.Or(name, "functions in "+region), err)
+	c.errs = append(c.errs, discoveryError{AccountID: accountID, Region: region, Function: name, Error: err.Error()})
+}
+
+// discoveryErrors returns the discovery errors recorded so far.
+func (c *resultCollector) discoveryErrors() []discoveryError {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.errs)
+}
+
+// snapshot returns a copy of the results collected so far.
+func (c *resultCollector) snapshot() []functionResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.results)
+}
+
+// sortResults orders results by account, region and function name.
+func sortResults(rs []functionResult) {
+	slices.SortFunc(rs, func(a, b functionResult) int {
+		return cmp.Or(
+			cmp.Compare(a.AccountID, b.AccountID),
+			cmp.Compare(a.Region, b.Region),
+			cmp.Compare(a.Name, b.Name),
+		)
+	})
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"update-lambda-runtime/pkg/bump"
+)
+
+func TestDiscoveryErrorsReported(t *testing.T) {
+	var out bytes.Buffer
+	c := newResultCollector(&out)
+	c.add(functionResult{AccountID: "123456789012", Region: "us-east-1", Name: "api", Runtime: "python3.9", TargetRuntime: "python3.12", Outcome: bump.Updated})
+	c.discoveryFailed("123456789012", "eu-west-1", "", errors.New("AccessDeniedException"))
+	c.discoveryFailed("123456789012", "ap-south-1", "api", errors.New("throttled"))
+
+	opts := &AWSOpts{Regions: []string{"us-east-1", "eu-west-1", "ap-south-1"}, Policy: &bump.Policy{}}
+	rep := newRunReport(opts, "123456789012", time.Now(), false, c.snapshot())
+	rep.Errors = c.discoveryErrors()
+	writeBumpTable(&out, opts, rep)
+	got := out.String()
+	for _, want := range []string{
+		"Error discovering functions in eu-west-1: AccessDeniedException",
+		"Error discovering api: throttled",
+		"Discovery errors: 2",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output lacks %q:\n%s", want, got)
+		}
+	}
+	if n := strings.Count(got, "  error\n"); n != 2 {
+		t.Errorf("%d error rows, want 2:\n%s", n, got)
+	}
+
+	if h := rep.headline(); !strings.Contains(h, "completed with discovery errors") {
+		t.Errorf("headline = %q", h)
+	}
+	var md bytes.Buffer
+	if err := writePRComment(&md, rep); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(md.String(), "#### Discovery errors (2)") || !strings.HasPrefix(md.String(), "### :x:") {
+		t.Errorf("PR comment does not flag the errors:\n%s", md.String())
+	}
+}
+
+func TestFailureClasses(t *testing.T) {
+	var out bytes.Buffer
+	c := newResultCollector(&out)
+	for _, r := range []functionResult{
+		{Name: "a", Outcome: bump.Failed, Failure: bump.AccessDenied},
+		{Name: "b", Outcome: bump.Failed, Failure: bump.AccessDenied},
+		{Name: "c", Outcome: bump.TimedOut},
+		{Name: "d", Outcome: bump.Failed},
+		{Name: "e", Outcome: bump.Updated, Failure: bump.Throttling},
+	} {
+		c.add(r)
+	}
+	opts := &AWSOpts{Regions: []string{"us-east-1"}, Policy: &bump.Policy{}}
+	rep := newRunReport(opts, "123456789012", time.Now(), false, c.snapshot())
+	if got, want := failureLine(rep.Failures), "2 AccessDenied, 1 Timeout, 1 Other"; got != want {
+		t.Errorf("failures %q, want %q", got, want)
+	}
+	writeBumpTable(&out, opts, rep)
+	if !strings.Contains(out.String(), "failed (AccessDenied)") || !strings.Contains(out.String(), "\nFailures: 2 AccessDenied, 1 Timeout, 1 Other\n") {
+		t.Errorf("table does not classify the failures:\n%s", out.String())
+	}
+}
+
+func TestCountUnchanged(t *testing.T) {
+	var out bytes.Buffer
+	c := newResultCollector(&out)
+	c.countUnchanged(map[string]string{"python3.11": "python3.12"})
+	for _, r := range []functionResult{
+		{Name: "current", Runtime: "python3.12"},
+		{Name: "also-current", Runtime: "nodejs22.x"},
+		{Name: "excluded", Runtime: "python3.11"},
+		{Name: "deprecated", Runtime: "nodejs16.x"},
+		{Name: "bumped", Runtime: "python3.9", TargetRuntime: "python3.12", Outcome: bump.Updated},
+		{Name: "replica", Runtime: "nodejs22.x", Edge: edgeReplica},
+	} {
+		c.add(r)
+	}
+	if n := c.unlistedCount(); n != 2 {
+		t.Errorf("%d functions only counted, want the 2 up-to-date ones", n)
+	}
+	if rs := c.snapshot(); len(rs) != 4 {
+		t.Errorf("kept %d results, want 4", len(rs))
+	}
+
+	opts := &AWSOpts{Regions: []string{"us-east-1"}, Policy: &bump.Policy{}}
+	rep := newRunReport(opts, "123456789012", time.Now(), false, c.snapshot())
+	rep.Unlisted = c.unlistedCount()
+	writeBumpTable(&out, opts, rep)
+	if !strings.Contains(out.String(), "2 up-to-date functions not listed") {
+		t.Errorf("table does not say functions were left out:\n%s", out.String())
+	}
+}
+
+func TestRenderBump(t *testing.T) {
+	opts := &AWSOpts{Regions: []string{"us-east-1"}, Policy: &bump.Policy{}, Output: outputTable}
+	rep := newRunReport(opts, "123456789012", time.Now(), false, []functionResult{
+		{AccountID: "123456789012", Region: "us-east-1", Name: "b", Runtime: "python3.9", Outcome: bump.Updated},
+		{AccountID: "123456789012", Region: "us-east-1", Name: "a", Runtime: "python3.12"},
+	})
+	rep.RunID, rep.saved = "run-1", true
+
+	var out, errOut bytes.Buffer
+	if err := renderBump(&out, &errOut, opts, rep); err != nil {
+		t.Fatal(err)
+	}
+	if a, b := strings.Index(out.String(), " a "), strings.Index(out.String(), " b "); a < 0 || b < a {
+		t.Errorf("rows not sorted by name:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "undo run-1") || errOut.Len() > 0 {
+		t.Errorf("undo hint not in the table output:\n%s", out.String())
+	}
+
+	opts.Output = outputPRComment
+	out.Reset()
+	if err := renderBump(&out, &errOut, opts, rep); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), "undo run-1") || !strings.Contains(errOut.String(), "undo run-1") {
+		t.Errorf("with pr-comment the undo hint belongs on stderr; stdout:\n%s\nstderr:\n%s", out.String(), errOut.String())
+	}
+}
+
+func TestInventoryReport(t *testing.T) {
+	opts := &AWSOpts{Profile: "dev", Policy: &bump.Policy{}}
+	res := &inventoryResult{
+		AccountID: "123456789012",
+		Functions: []functionResult{{Name: "a", Runtime: "python3.9"}},
+		Errors:    []discoveryError{{Region: "eu-west-1", Error: "AccessDenied"}},
+	}
+	rep := res.report(opts)
+	if rep.AccountID != res.AccountID || len(rep.Results) != 1 || len(rep.Errors) != 1 {
+		t.Errorf("report = %+v", rep)
+	}
+
+	var out, errOut bytes.Buffer
+	tbl := newListTable(&out, &errOut, &AWSOpts{Regions: []string{"eu-west-1"}})
+	tbl.start()
+	tbl.listed(res.Functions[0])
+	tbl.failed(res.Errors[0])
+	if !strings.Contains(out.String(), "python3.9") || !strings.HasSuffix(out.String(), "error\n") {
+		t.Errorf("table:\n%s", out.String())
+	}
+	if !strings.Contains(errOut.String(), "functions in eu-west-1: AccessDenied") {
+		t.Errorf("stderr = %q", errOut.String())
+	}
+}
+
+func TestClassify(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		r    functionResult
+		want string
+	}{
+		{functionResult{Runtime: "python3.12"}, classUpToDate},
+		{functionResult{Runtime: "python3.9", TargetRuntime: "python3.12", Outcome: bump.Updated}, classUpToDate},
+		{functionResult{Runtime: "python3.9", TargetRuntime: "python3.12", Outcome: bump.Failed}, classNeedsBump},
+		{functionResult{Runtime: "python3.9", TargetRuntime: "python3.12", Outcome: bump.Skipped}, classSkipped},
+		{functionResult{Runtime: "python3.9", TargetRuntime: "python3.12", Outcome: bump.Disabled}, classSkipped},
+		{functionResult{Runtime: "nodejs16.x"}, classUnsupported},
+		{functionResult{}, classUnsupported},
+		{functionResult{Runtime: "python3.9", Edge: edgeReplica}, ""},
+	}
+	for _, tt := range tests {
+		if got := classify(tt.r, now); got != tt.want {
+			t.Errorf("classify(%+v) = %q, want %q", tt.r, got, tt.want)
+		}
+	}
+}
+
+func TestPlainOutput(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tbl := newTable(f, 4, 8)
+	tbl.header("A", "B", "C")
+	tbl.row("x", "y", "z")
+	got, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "A\tB\tC\nx\ty\tz\n"; string(got) != want {
+		t.Errorf("piped table = %q, want %q", got, want)
+	}
+
+	var buf bytes.Buffer
+	tbl = newTable(&buf, 4, 8)
+	tbl.row("x", "y", "z")
+	if want := "x     y         z\n"; buf.String() != want {
+		t.Errorf("buffered table = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestTreeTable(t *testing.T) {
+	var buf bytes.Buffer
+	tree := newTreeTable(&buf, []string{"deprecated", "supported"}, []int{runtimeNameWidth})
+	tree.row("111111111111", "dev", "us-east-1", "a", "python3.9", "deprecated")
+	tree.row("111111111111", "dev", "us-east-1", "b", "python3.12", "supported")
+	tree.row("111111111111", "dev", "eu-west-1", "c", "", "unknown")
+	tree.row("222222222222", "", "us-east-1", "d", "python3.9", "deprecated")
+	tree.finish()
+
+	out := buf.String()
+	for _, want := range []string{
+		"Account 111111111111 (profile dev)\n  Region us-east-1\n",
+		"    Subtotal: 2 functions (1 deprecated, 1 supported)\n  Region eu-west-1\n",
+		"    Subtotal: 1 functions (1 unknown)\n",
+		"  Total for 111111111111: 3 functions (1 deprecated, 1 supported, 1 unknown) in 2 regions\nAccount 222222222222\n",
+		"  Total for 222222222222: 1 functions (1 deprecated) in 1 region\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestInZone(t *testing.T) {
+	at := time.Date(2025, 1, 10, 20, 30, 0, 0, time.UTC)
+	if got := inZone(at, time.UTC).Format(time.DateTime); got != "2025-01-10 20:30:00" {
+		t.Errorf("without --timezone = %s, want the default zone", got)
+	}
+	bangkok, err := time.LoadLocation("Asia/Bangkok")
+	if err != nil {
+		t.Fatal(err)
+	}
+	displayZone = bangkok
+	defer func() { displayZone = nil }()
+	if got := inZone(at, time.UTC).Format("2006-01-02 15:04 MST"); got != "2025-01-11 03:30 +07" {
+		t.Errorf("with --timezone Asia/Bangkok = %s", got)
+	}
+}
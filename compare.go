@@ -0,0 +1,151 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"update-lambda-runtime/pkg/inventory"
+)
+
+// compareEnv is one account and region swept by compare.
+type compareEnv struct {
+	profile string
+	region  string
+	label   string // column header
+}
+
+// runCompare sweeps every profile across opts.Regions and prints one row
+// per function name found in more than one of those environments, with its
+// runtime in each. An environment lags when another one runs the function
+// on a newer runtime of the same family, e.g. prod on python3.9 while dev
+// is on python3.12. Profiles resolving to an account already swept are
+// skipped, as in report.
+func runCompare(ctx context.Context, opts *AWSOpts, w io.Writer) error {
+	if len(opts.Regions) == 0 {
+		return fmt.Errorf("--regions is required")
+	}
+	if err := validateRedact(opts.Redact); err != nil {
+		return err
+	}
+	profiles, err := sweptProfiles(opts)
+	if err != nil {
+		return err
+	}
+	ctx, span := tracer.Start(ctx, "compare", runAttrs(opts))
+	defer span.End()
+
+	var envs []compareEnv
+	runtimes := make(map[string]map[int]string) // function → env index → runtime
+	seen := make(map[string]string)             // account ID → first profile
+	for _, profile := range profiles {
+		fmt.Fprintf(os.Stderr, "Sweeping %s...\n", profile)
+		clients := newClientFactory(profile, opts.APITimeout, opts.MaxRPS)
+		id, err := resolveAccountID(ctx, clients)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %s: %v\n", profile, err)
+			continue
+		}
+		if first, ok := seen[id]; ok {
+			fmt.Fprintf(os.Stderr, "Skipping %s: account %s already swept with %s\n", profile, id, first)
+			continue
+		}
+		seen[id] = profile
+		for _, region := range opts.Regions {
+			env := len(envs)
+			envs = append(envs, compareEnv{profile: profile, region: region})
+			cli, err := clients.Lambda(ctx, region)
+			if err == nil {
+				err = inventory.Stream(ctx, cli, opts.FunctionName, func(f inventory.Function) {
+					if f.Runtime == "" {
+						return
+					}
+					if runtimes[f.Name] == nil {
+						runtimes[f.Name] = make(map[int]string)
+					}
+					runtimes[f.Name][env] = f.Runtime
+				})
+			}
+			if ctx.Err() != nil {
+				return stopped(ctx)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "warning: %s %s: %v\n", profile, region, err)
+			}
+		}
+	}
+	for i, e := range envs {
+		switch {
+		case len(seen) == 1:
+			envs[i].label = e.region
+		case len(opts.Regions) == 1:
+			envs[i].label = e.profile
+		default:
+			envs[i].label = e.profile + "/" + e.region
+		}
+	}
+
+	redact := newRedactor(opts.Redact)
+	nameWidth := len("FunctionName")
+	var names []string
+	for _, name := range slices.Sorted(maps.Keys(runtimes)) {
+		if len(runtimes[name]) < 2 && opts.FunctionName == "" {
+			continue
+		}
+		names = append(names, name)
+		nameWidth = max(nameWidth, len(redact.name(name)))
+	}
+	widths := []int{nameWidth}
+	header := []string{"FunctionName"}
+	for _, e := range envs {
+		widths = append(widths, max(len(e.label), runtimeNameWidth))
+		header = append(header, e.label)
+	}
+	tbl := newTable(w, widths...)
+	tbl.header(append(header, "Lagging")...)
+	lagging := 0
+	for _, name := range names {
+		behind := laggingEnvs(runtimes[name])
+		if len(behind) > 0 {
+			lagging++
+		} else if opts.LaggingOnly {
+			continue
+		}
+		cols := []string{redact.name(name)}
+		for i := range envs {
+			cols = append(cols, cmp.Or(runtimes[name][i], "-"))
+		}
+		var labels []string
+		for _, i := range behind {
+			labels = append(labels, envs[i].label)
+		}
+		tbl.row(append(cols, cmp.Or(strings.Join(labels, ", "), "-"))...)
+	}
+	fmt.Fprintf(w, "\nSummary: %d functions compared, %d lagging\n", len(names), lagging)
+	return nil
+}
+
+// laggingEnvs returns, in order, the environments whose runtime is older
+// than the newest one of the same family in byEnv.
+func laggingEnvs(byEnv map[int]string) []int {
+	newest := make(map[string][]int) // family → version
+	for _, rt := range byEnv {
+		f, v := inventory.Family(rt), inventory.Version(rt)
+		if slices.Compare(v, newest[f]) > 0 {
+			newest[f] = v
+		}
+	}
+	var behind []int
+	for _, env := range slices.Sorted(maps.Keys(byEnv)) {
+		rt := byEnv[env]
+		if slices.Compare(inventory.Version(rt), newest[inventory.Family(rt)]) < 0 {
+			behind = append(behind, env)
+		}
+	}
+	return behind
+}
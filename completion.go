@@ -0,0 +1,116 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"update-lambda-runtime/pkg/inventory"
+)
+
+// lambdaRegions are the commercial regions Lambda runs in, offered when
+// completing --regions. Any other region can still be typed out.
+var lambdaRegions = []string{
+	"af-south-1", "ap-east-1", "ap-northeast-1", "ap-northeast-2", "ap-northeast-3",
+	"ap-south-1", "ap-south-2", "ap-southeast-1", "ap-southeast-2", "ap-southeast-3",
+	"ap-southeast-4", "ap-southeast-5", "ap-southeast-7", "ca-central-1", "ca-west-1",
+	"eu-central-1", "eu-central-2", "eu-north-1", "eu-south-1", "eu-south-2",
+	"eu-west-1", "eu-west-2", "eu-west-3", "il-central-1", "me-central-1",
+	"me-south-1", "mx-central-1", "sa-east-1", "us-east-1", "us-east-2",
+	"us-west-1", "us-west-2",
+}
+
+// registerCompletions adds shell completion for the flags whose values are
+// easy to mistype: regions, runtime identifiers and profile names. The
+// completion command itself is cobra's default one.
+func registerCompletions(root *cobra.Command) {
+	root.RegisterFlagCompletionFunc("regions", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completeList(lambdaRegions, toComplete)
+	})
+	root.RegisterFlagCompletionFunc("profile", completeProfiles)
+	registerSubcommandCompletions(root)
+}
+
+// registerSubcommandCompletions completes the flags only some commands
+// have, under cmd and its subcommands.
+func registerSubcommandCompletions(cmd *cobra.Command) {
+	for _, cmd := range cmd.Commands() {
+		if cmd.Flags().Lookup("profiles") != nil {
+			cmd.RegisterFlagCompletionFunc("profiles", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+				profiles, _ := sharedProfiles()
+				return completeList(profiles, toComplete)
+			})
+		}
+		if cmd.Flags().Lookup("source-runtime") != nil {
+			cmd.RegisterFlagCompletionFunc("source-runtime", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+				return completeRuntimes(knownRuntimes(false), toComplete)
+			})
+			cmd.RegisterFlagCompletionFunc("target-runtime", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+				return completeRuntimes(knownRuntimes(true), toComplete)
+			})
+		}
+		registerSubcommandCompletions(cmd)
+	}
+}
+
+func completeProfiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	profiles, err := sharedProfiles()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return profiles, cobra.ShellCompDirectiveNoFileComp
+}
+
+// knownRuntimes lists the runtimes in the calendar. Targets leave out the
+// deprecated ones and add the latest keywords.
+func knownRuntimes(target bool) []string {
+	useCachedCalendar()
+	now := time.Now()
+	var out []string
+	for _, p := range inventory.Calendar() {
+		if target && inventory.DeprecationStatus(p.Runtime, now) == inventory.Deprecated {
+			continue
+		}
+		out = append(out, p.Runtime)
+		if target && !slices.Contains(out, inventory.LatestKeyword+"-"+inventory.Family(p.Runtime)) {
+			out = append(out, inventory.LatestKeyword+"-"+inventory.Family(p.Runtime))
+		}
+	}
+	if target {
+		out = append(out, inventory.LatestKeyword)
+	}
+	return out
+}
+
+// completeRuntimes offers runtimes, or, once what is typed only starts a
+// shorthand such as py312, the shorthands of runtimes with the identifier
+// each stands for.
+func completeRuntimes(runtimes []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if slices.ContainsFunc(runtimes, func(rt string) bool { return strings.HasPrefix(rt, toComplete) }) {
+		return runtimes, cobra.ShellCompDirectiveNoFileComp
+	}
+	var out []string
+	for alias, rt := range inventory.Shorthands() {
+		if strings.HasPrefix(alias, toComplete) && slices.Contains(runtimes, rt) {
+			out = append(out, alias+"\t"+rt)
+		}
+	}
+	slices.Sort(out)
+	return out, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeList completes the last item of a comma-separated list, keeping
+// the items already typed.
+func completeList(values []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	i := strings.LastIndex(toComplete, ",")
+	done := strings.Split(toComplete[:max(i, 0)], ",")
+	var out []string
+	for _, v := range values {
+		if !slices.Contains(done, v) {
+			out = append(out, toComplete[:i+1]+v)
+		}
+	}
+	return out, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
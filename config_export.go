@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+)
+
+// redactedValue replaces environment variable values in exported
+// configurations unless --include-env-values is given.
+const redactedValue = "REDACTED"
+
+// runExport writes the full configuration of every selected function, as
+// GetFunctionConfiguration returns it, to
+// <dir>/<time>/<account>/<region>/<function>.json. Each export gets its
+// own timestamped directory, so a dir kept over time is an archive of
+// point-in-time snapshots.
+func runExport(ctx context.Context, opts *AWSOpts, w io.Writer) error {
+	if err := validateCommon(opts); err != nil {
+		return err
+	}
+	if opts.ExportDir == "" {
+		return errors.New("--dir is required")
+	}
+	root := filepath.Join(opts.ExportDir, time.Now().UTC().Format("20060102T150405Z"))
+	tbl := newFunctionTable(w, opts, runtimeNameWidth)
+	printHeader(tbl, opts.ShowProfile, "File")
+	var exported, failed int
+	err := eachFunction(ctx, opts, "export", func(ctx context.Context, cli *lambda.Client, r functionResult) {
+		path := filepath.Join(root, r.AccountID, r.Region, r.Name+".json")
+		if err := exportConfiguration(ctx, cli, r.Name, path, opts.ExportEnvValues); err != nil {
+			failed++
+			printRow(tbl, r.AccountID, r.Profile, r.Region, r.Name, r.Runtime, opts.ShowProfile, "error: "+err.Error())
+			return
+		}
+		exported++
+		printRow(tbl, r.AccountID, r.Profile, r.Region, r.Name, r.Runtime, opts.ShowProfile, path)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "\nExported %d function configurations to %s, %d failed\n", exported, root, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d function configurations not exported", failed)
+	}
+	return nil
+}
+
+// exportConfiguration writes fn's configuration to path as indented JSON,
+// with environment variable values redacted unless envValues is set.
+func exportConfiguration(ctx context.Context, cli *lambda.Client, fn, path string, envValues bool) error {
+	out, err := cli.GetFunctionConfiguration(ctx, &lambda.GetFunctionConfigurationInput{FunctionName: aws.String(fn)})
+	if err != nil {
+		return err
+	}
+	b, err := configurationJSON(out, envValues)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o600)
+}
+
+// configurationJSON encodes a configuration in the field names the Lambda
+// API documents, leaving out the SDK's response metadata and the fields
+// the function does not set.
+func configurationJSON(out *lambda.GetFunctionConfigurationOutput, envValues bool) ([]byte, error) {
+	cfg := *out
+	if !envValues && cfg.Environment != nil && len(cfg.Environment.Variables) > 0 {
+		env := *cfg.Environment
+		env.Variables = make(map[string]string, len(out.Environment.Variables))
+		for k := range out.Environment.Variables {
+			env.Variables[k] = redactedValue
+		}
+		cfg.Environment = &env
+	}
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	delete(fields, "ResultMetadata")
+	b, err = json.MarshalIndent(dropNulls(fields), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// dropNulls removes the null fields of v's objects, at any depth.
+func dropNulls(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, x := range v {
+			if x == nil {
+				delete(v, k)
+			} else {
+				v[k] = dropNulls(x)
+			}
+		}
+	case []any:
+		for i, x := range v {
+			v[i] = dropNulls(x)
+		}
+	}
+	return v
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lamtypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+func TestConfigurationJSON(t *testing.T) {
+	out := &lambda.GetFunctionConfigurationOutput{
+		FunctionName: aws.String("orders"),
+		Runtime:      lamtypes.RuntimePython39,
+		MemorySize:   aws.Int32(256),
+		Environment:  &lamtypes.EnvironmentResponse{Variables: map[string]string{"DB_PASSWORD": "hunter2"}},
+		VpcConfig:    &lamtypes.VpcConfigResponse{SubnetIds: []string{"subnet-1"}},
+	}
+	for _, keep := range []bool{false, true} {
+		b, err := configurationJSON(out, keep)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got struct {
+			FunctionName string
+			Runtime      string
+			MemorySize   int
+			Environment  struct{ Variables map[string]string }
+		}
+		if err := json.Unmarshal(b, &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.FunctionName != "orders" || got.Runtime != "python3.9" || got.MemorySize != 256 {
+			t.Errorf("decoded %+v", got)
+		}
+		want := redactedValue
+		if keep {
+			want = "hunter2"
+		}
+		if v := got.Environment.Variables["DB_PASSWORD"]; v != want {
+			t.Errorf("env values kept %v: DB_PASSWORD = %q, want %q", keep, v, want)
+		}
+		if s := string(b); strings.Contains(s, "ResultMetadata") || strings.Contains(s, "null") {
+			t.Errorf("want no response metadata or unset fields:\n%s", s)
+		}
+	}
+	if out.Environment.Variables["DB_PASSWORD"] != "hunter2" {
+		t.Error("redacting changed the response")
+	}
+}
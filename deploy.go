@@ -0,0 +1,392 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	ebtypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lamtypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// Settings of the deployed enforcer function.
+const (
+	deployTimeout    = 900 // seconds, the Lambda maximum
+	deployMemory     = 512 // MB
+	deployPolicyName = "update-lambda-runtime"
+)
+
+// basicExecPolicy is the ARN of the managed policy letting the runner
+// write its logs, in partition.
+func basicExecPolicy(partition string) string {
+	return "arn:" + partition + ":iam::aws:policy/service-role/AWSLambdaBasicExecutionRole"
+}
+
+// scheduledCommands are the commands a schedule may run.
+var scheduledCommands = []string{"list", "bump", "report"}
+
+// runDeploySchedule packages a Linux build of this tool as a
+// provided.al2023 function in the first of --regions, gives it a role that
+// can discover and update functions, stores args (the command each run
+// executes) in SSM and invokes the function on an EventBridge schedule.
+// Every step is an upsert, so rerunning it updates the deployment.
+func runDeploySchedule(ctx context.Context, opts *AWSOpts, args []string) error {
+	if opts.Profile == "" || len(opts.Regions) == 0 {
+		return fmt.Errorf("--profile and --regions are required")
+	}
+	if err := checkScheduledArgs(args); err != nil {
+		return err
+	}
+	args = withHistoryTable(opts, args)
+	arch, binary, err := deployBinary(opts)
+	if err != nil {
+		return err
+	}
+	pkg, err := zipBootstrap(binary)
+	if err != nil {
+		return err
+	}
+
+	region := opts.Regions[0]
+	clients := newClientFactory(opts.Profile, opts.APITimeout, opts.MaxRPS)
+	acctID, err := resolveAccountID(ctx, clients)
+	if err != nil {
+		return fmt.Errorf("resolve account id: %w", err)
+	}
+	cfg, err := clients.Config(ctx)
+	if err != nil {
+		return err
+	}
+	cfg.Region = region
+
+	doc, err := json.Marshal(scheduledArgs{Args: args})
+	if err != nil {
+		return err
+	}
+	if _, err := ssm.NewFromConfig(cfg).PutParameter(ctx, &ssm.PutParameterInput{
+		Name:      aws.String(opts.DeployArgsParam),
+		Value:     aws.String(string(doc)),
+		Type:      ssmtypes.ParameterTypeString,
+		Overwrite: aws.Bool(true),
+	}); err != nil {
+		return fmt.Errorf("store args in %s: %w", opts.DeployArgsParam, err)
+	}
+	fmt.Fprintf(os.Stderr, "Stored the scheduled command in %s\n", opts.DeployArgsParam)
+
+	partition := partitionOf(region)
+	roleARN, err := ensureDeployRole(ctx, iam.NewFromConfig(cfg), opts.DeployName+"-role", partition, deployPolicy(partition, acctID, region, opts.DeployArgsParam, args))
+	if err != nil {
+		return fmt.Errorf("role: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Role %s ready\n", roleARN)
+
+	cli, err := clients.Lambda(ctx, region)
+	if err != nil {
+		return err
+	}
+	fnARN, err := ensureDeployFunction(ctx, cli, opts, roleARN, arch, pkg)
+	if err != nil {
+		return fmt.Errorf("function: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Function %s deployed\n", fnARN)
+
+	ruleName := opts.DeployName + "-schedule"
+	eb := eventbridge.NewFromConfig(cfg)
+	rule, err := eb.PutRule(ctx, &eventbridge.PutRuleInput{
+		Name:               aws.String(ruleName),
+		ScheduleExpression: aws.String(opts.DeploySchedule),
+		State:              ebtypes.RuleStateEnabled,
+		Description:        aws.String("Runs " + strings.Join(args, " ")),
+	})
+	if err != nil {
+		return fmt.Errorf("schedule: %w", err)
+	}
+	_, err = cli.AddPermission(ctx, &lambda.AddPermissionInput{
+		FunctionName: aws.String(opts.DeployName),
+		StatementId:  aws.String(ruleName),
+		Action:       aws.String("lambda:InvokeFunction"),
+		Principal:    aws.String("events.amazonaws.com"),
+		SourceArn:    rule.RuleArn,
+	})
+	var conflict *lamtypes.ResourceConflictException
+	if err != nil && !errors.As(err, &conflict) {
+		return fmt.Errorf("schedule permission: %w", err)
+	}
+	out, err := eb.PutTargets(ctx, &eventbridge.PutTargetsInput{
+		Rule:    aws.String(ruleName),
+		Targets: []ebtypes.Target{{Id: aws.String(opts.DeployName), Arn: aws.String(fnARN)}},
+	})
+	if err != nil {
+		return fmt.Errorf("schedule target: %w", err)
+	}
+	if out.FailedEntryCount > 0 {
+		return fmt.Errorf("schedule target: %s", aws.ToString(out.FailedEntries[0].ErrorMessage))
+	}
+	fmt.Printf("Scheduled %s (%s) to run %q %s\n", opts.DeployName, region, strings.Join(args, " "), opts.DeploySchedule)
+	return nil
+}
+
+// checkScheduledArgs verifies args starts with a command a schedule may run.
+func checkScheduledArgs(args []string) error {
+	if len(args) == 0 || !slices.Contains(scheduledCommands, args[0]) {
+		return fmt.Errorf("give the scheduled command after --, e.g. -- bump --all --regions us-east-1 (one of %v)", scheduledCommands)
+	}
+	return nil
+}
+
+// withHistoryTable makes a scheduled list or bump record its runtime
+// history in --history-table, given before --, unless the command names a
+// table itself. Without one it warns: the cache directory of a Lambda
+// function does not outlive the invocation, so stats would never see
+// the scheduled runs.
+func withHistoryTable(opts *AWSOpts, args []string) []string {
+	if args[0] == "report" {
+		return args
+	}
+	if _, ok := flagValue(args, "--history-table"); ok {
+		return args
+	}
+	if opts.HistoryTable == "" {
+		fmt.Fprintln(os.Stderr, "warning: the scheduled command keeps no runtime history stats can read; give --history-table before -- or in the command")
+		return args
+	}
+	return append(slices.Clone(args), "--history-table", opts.HistoryTable)
+}
+
+// flagValue returns the value of flag name in args, given as "name value"
+// or "name=value".
+func flagValue(args []string, name string) (string, bool) {
+	for i, a := range args {
+		if v, ok := strings.CutPrefix(a, name+"="); ok {
+			return v, true
+		}
+		if a == name && i+1 < len(args) {
+			return args[i+1], true
+		}
+	}
+	return "", false
+}
+
+// deployBinary returns the Lambda architecture and path of the binary to
+// package: --binary, or this executable when it already is a Linux build
+// for the requested architecture.
+func deployBinary(opts *AWSOpts) (lamtypes.Architecture, string, error) {
+	own := lamtypes.ArchitectureX8664
+	if runtime.GOARCH == "arm64" {
+		own = lamtypes.ArchitectureArm64
+	}
+	arch := lamtypes.Architecture(cmp.Or(opts.DeployArch, string(own)))
+	if !slices.Contains(arch.Values(), arch) {
+		return "", "", fmt.Errorf("--architecture must be one of %v", arch.Values())
+	}
+	if opts.DeployBinary != "" {
+		return arch, opts.DeployBinary, nil
+	}
+	if runtime.GOOS != "linux" || (runtime.GOARCH != "amd64" && runtime.GOARCH != "arm64") || arch != own {
+		return "", "", fmt.Errorf("pass --binary with a Linux %s build, e.g. GOOS=linux GOARCH=arm64 go build -o bootstrap", arch)
+	}
+	self, err := os.Executable()
+	return arch, self, err
+}
+
+// zipBootstrap packages binary as the "bootstrap" a provided.al2023
+// function executes.
+func zipBootstrap(binary string) ([]byte, error) {
+	bin, err := os.ReadFile(binary)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	hdr := &zip.FileHeader{Name: "bootstrap", Method: zip.Deflate}
+	hdr.SetMode(0o755)
+	w, err := zw.CreateHeader(hdr)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(bin); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// deployPolicy is the role's inline policy: discovery and updates across
+// every region, with the disabled, production and published-version checks
+// and the Lambda@Edge republishing a bump makes, reading the args
+// parameter and any ssm:// runtime policy the scheduled command names, and
+// writing its --history-table. Notifiers need their own permissions added.
+func deployPolicy(partition, acctID, region, argsParam string, args []string) string {
+	doc, _ := json.Marshal(deployPolicyDoc(partition, acctID, region, argsParam, args))
+	return string(doc)
+}
+
+// deployPolicyDoc is deployPolicy before encoding.
+func deployPolicyDoc(partition, acctID, region, argsParam string, args []string) map[string]any {
+	params := []string{ssmParameterARN(partition, acctID, region, argsParam)}
+	v, ok := flagValue(args, "--policy")
+	if !ok {
+		v, ok = flagValue(args, "--config") // its deprecated name
+	}
+	if ok {
+		if name, isSSM := strings.CutPrefix(v, ssmScheme); isSSM {
+			params = append(params, ssmParameterARN(partition, acctID, "*", name))
+		}
+	}
+	statements := []map[string]any{
+		{
+			"Effect": "Allow",
+			"Action": []string{
+				"lambda:ListFunctions", "lambda:GetFunction", "lambda:GetFunctionConfiguration",
+				"lambda:UpdateFunctionConfiguration", "lambda:ListTags", "lambda:GetFunctionConcurrency",
+				"lambda:ListVersionsByFunction", "lambda:ListAliases", "sts:GetCallerIdentity",
+			},
+			"Resource": "*",
+		},
+		{
+			"Effect": "Allow",
+			"Action": []string{
+				"cloudfront:ListDistributions", "cloudfront:GetDistributionConfig", "cloudfront:UpdateDistribution",
+				"lambda:PublishVersion", "lambda:EnableReplication*",
+			},
+			"Resource": "*",
+		},
+		{"Effect": "Allow", "Action": []string{"ssm:GetParameter"}, "Resource": params},
+	}
+	if table, ok := flagValue(args, "--history-table"); ok {
+		if !strings.HasPrefix(table, "arn:") {
+			table = fmt.Sprintf("arn:%s:dynamodb:%s:%s:table/%s", partition, region, acctID, table)
+		}
+		statements = append(statements, map[string]any{"Effect": "Allow", "Action": []string{"dynamodb:PutItem"}, "Resource": table})
+	}
+	return map[string]any{
+		"Version":   "2012-10-17",
+		"Statement": statements,
+	}
+}
+
+func ssmParameterARN(partition, acctID, region, name string) string {
+	return fmt.Sprintf("arn:%s:ssm:%s:%s:parameter/%s", partition, region, acctID, strings.TrimPrefix(name, "/"))
+}
+
+// lambdaTrustPolicy lets Lambda assume the execution role.
+const lambdaTrustPolicy = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":"lambda.amazonaws.com"},"Action":"sts:AssumeRole"}]}`
+
+// ensureDeployRole creates the execution role in partition, or refreshes
+// the inline policy of an existing one, and returns its ARN.
+func ensureDeployRole(ctx context.Context, cli *iam.Client, name, partition, policy string) (string, error) {
+	var roleARN string
+	created, err := cli.CreateRole(ctx, &iam.CreateRoleInput{
+		RoleName:                 aws.String(name),
+		AssumeRolePolicyDocument: aws.String(lambdaTrustPolicy),
+		Description:              aws.String("Execution role of the scheduled update-lambda-runtime function"),
+	})
+	var exists *iamtypes.EntityAlreadyExistsException
+	switch {
+	case errors.As(err, &exists):
+		got, err := cli.GetRole(ctx, &iam.GetRoleInput{RoleName: aws.String(name)})
+		if err != nil {
+			return "", err
+		}
+		roleARN = aws.ToString(got.Role.Arn)
+	case err != nil:
+		return "", err
+	default:
+		roleARN = aws.ToString(created.Role.Arn)
+	}
+	if _, err := cli.AttachRolePolicy(ctx, &iam.AttachRolePolicyInput{
+		RoleName:  aws.String(name),
+		PolicyArn: aws.String(basicExecPolicy(partition)),
+	}); err != nil {
+		return "", err
+	}
+	_, err = cli.PutRolePolicy(ctx, &iam.PutRolePolicyInput{
+		RoleName:       aws.String(name),
+		PolicyName:     aws.String(deployPolicyName),
+		PolicyDocument: aws.String(policy),
+	})
+	return roleARN, err
+}
+
+// ensureDeployFunction creates the function, or updates the code and
+// configuration of an existing one, and returns its ARN.
+func ensureDeployFunction(ctx context.Context, cli *lambda.Client, opts *AWSOpts, roleARN string, arch lamtypes.Architecture, pkg []byte) (string, error) {
+	env := &lamtypes.Environment{Variables: map[string]string{envArgsParam: opts.DeployArgsParam}}
+	got, err := cli.GetFunction(ctx, &lambda.GetFunctionInput{FunctionName: aws.String(opts.DeployName)})
+	var notFound *lamtypes.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		in := &lambda.CreateFunctionInput{
+			FunctionName:  aws.String(opts.DeployName),
+			Runtime:       lamtypes.RuntimeProvidedal2023,
+			Handler:       aws.String("bootstrap"),
+			Architectures: []lamtypes.Architecture{arch},
+			Role:          aws.String(roleARN),
+			Code:          &lamtypes.FunctionCode{ZipFile: pkg},
+			Timeout:       aws.Int32(deployTimeout),
+			MemorySize:    aws.Int32(deployMemory),
+			Environment:   env,
+			Description:   aws.String("Scheduled update-lambda-runtime"),
+		}
+		// A new role takes a few seconds to become assumable by Lambda.
+		for attempt := 0; ; attempt++ {
+			out, err := cli.CreateFunction(ctx, in)
+			var invalid *lamtypes.InvalidParameterValueException
+			if errors.As(err, &invalid) && strings.Contains(invalid.ErrorMessage(), "cannot be assumed") && attempt < 10 {
+				select {
+				case <-ctx.Done():
+					return "", ctx.Err()
+				case <-time.After(3 * time.Second):
+				}
+				continue
+			}
+			if err != nil {
+				return "", err
+			}
+			return aws.ToString(out.FunctionArn), nil
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	waiter := lambda.NewFunctionUpdatedV2Waiter(cli)
+	if _, err := cli.UpdateFunctionCode(ctx, &lambda.UpdateFunctionCodeInput{
+		FunctionName:  aws.String(opts.DeployName),
+		ZipFile:       pkg,
+		Architectures: []lamtypes.Architecture{arch},
+	}); err != nil {
+		return "", err
+	}
+	if err := waiter.Wait(ctx, &lambda.GetFunctionInput{FunctionName: aws.String(opts.DeployName)}, opts.Timeout); err != nil {
+		return "", err
+	}
+	if _, err := cli.UpdateFunctionConfiguration(ctx, &lambda.UpdateFunctionConfigurationInput{
+		FunctionName: aws.String(opts.DeployName),
+		Role:         aws.String(roleARN),
+		Runtime:      lamtypes.RuntimeProvidedal2023,
+		Timeout:      aws.Int32(deployTimeout),
+		MemorySize:   aws.Int32(deployMemory),
+		Environment:  env,
+	}); err != nil {
+		return "", err
+	}
+	return aws.ToString(got.Configuration.FunctionArn), nil
+}
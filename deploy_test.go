@@ -0,0 +1,33 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDeployPolicyDoc(t *testing.T) {
+	doc := deployPolicyDoc("aws-us-gov", "123456789012", "us-gov-west-1", "/ulr/args", []string{"bump", "--all", "--policy", "ssm:///ulr/policy", "--history-table", "hist"})
+	allowed := make(map[string][]any) // action → its resources
+	for _, st := range doc["Statement"].([]map[string]any) {
+		for _, a := range st["Action"].([]string) {
+			allowed[a] = append(allowed[a], st["Resource"])
+		}
+	}
+	for _, a := range []string{
+		"lambda:UpdateFunctionConfiguration", "lambda:GetFunctionConcurrency", "lambda:ListVersionsByFunction", "lambda:ListAliases",
+		"cloudfront:ListDistributions", "cloudfront:GetDistributionConfig", "cloudfront:UpdateDistribution", "lambda:PublishVersion",
+		"ssm:GetParameter", "dynamodb:PutItem",
+	} {
+		if allowed[a] == nil {
+			t.Errorf("%s not allowed", a)
+		}
+	}
+	params, _ := allowed["ssm:GetParameter"][0].([]string)
+	want := []string{"arn:aws-us-gov:ssm:us-gov-west-1:123456789012:parameter/ulr/args", "arn:aws-us-gov:ssm:*:123456789012:parameter/ulr/policy"}
+	if !slices.Equal(params, want) {
+		t.Errorf("parameters %v, want %v", params, want)
+	}
+	if got := allowed["dynamodb:PutItem"][0]; got != "arn:aws-us-gov:dynamodb:us-gov-west-1:123456789012:table/hist" {
+		t.Errorf("history table %v", got)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+
+	"update-lambda-runtime/pkg/inventory"
+)
+
+// untaggedGroup collects functions that do not carry the grouping tag.
+const untaggedGroup = "untagged"
+
+// deprecatedFunction is a function on a runtime in the deprecation calendar.
+type deprecatedFunction struct {
+	fn         functionResult
+	deprecated time.Time
+}
+
+// deprecatedFunctions returns rep's functions on calendar runtimes, the
+// soonest deprecation first.
+func deprecatedFunctions(rep *runReport) []deprecatedFunction {
+	var out []deprecatedFunction
+	for _, r := range rep.Results {
+		if d, ok := inventory.DeprecationDate(r.Runtime); ok {
+			out = append(out, deprecatedFunction{fn: r, deprecated: d})
+		}
+	}
+	slices.SortFunc(out, func(a, b deprecatedFunction) int {
+		return cmp.Or(a.deprecated.Compare(b.deprecated), cmp.Compare(a.fn.Region, b.fn.Region), cmp.Compare(a.fn.Name, b.fn.Name))
+	})
+	return out
+}
+
+// groupByOwner groups fns by the value of tag on each function, keeping
+// their order within a group.
+func groupByOwner(ctx context.Context, clients *clientFactory, tag string, fns []deprecatedFunction) (map[string][]deprecatedFunction, error) {
+	groups := make(map[string][]deprecatedFunction)
+	for _, e := range fns {
+		cli, err := clients.Lambda(ctx, e.fn.Region)
+		if err != nil {
+			return nil, err
+		}
+		out, err := cli.ListTags(ctx, &lambda.ListTagsInput{
+			Resource: aws.String(functionARN(e.fn)),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("tags for %s: %w", e.fn.Name, err)
+		}
+		group := out.Tags[tag]
+		if group == "" {
+			group = untaggedGroup
+		}
+		groups[group] = append(groups[group], e)
+	}
+	return groups, nil
+}
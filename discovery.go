@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+)
+
+// partitionPrefixes are the region prefixes of the partitions other than
+// aws, longest first so that e.g. us-isob- is not taken for us-iso-.
+var partitionPrefixes = []struct{ prefix, partition string }{
+	{"us-isob-", "aws-iso-b"},
+	{"us-isof-", "aws-iso-f"},
+	{"eu-isoe-", "aws-iso-e"},
+	{"us-iso-", "aws-iso"},
+	{"us-gov-", "aws-us-gov"},
+	{"cn-", "aws-cn"},
+}
+
+// partitionOf is the partition of region, e.g. aws-us-gov for
+// us-gov-west-1, for building ARNs that work in GovCloud and China too.
+func partitionOf(region string) string {
+	for _, p := range partitionPrefixes {
+		if strings.HasPrefix(region, p.prefix) {
+			return p.partition
+		}
+	}
+	return "aws"
+}
+
+// functionARN builds the unqualified ARN of r's function.
+func functionARN(r functionResult) string {
+	return arn.ARN{
+		Partition: partitionOf(r.Region),
+		Service:   "lambda",
+		Region:    r.Region,
+		AccountID: r.AccountID,
+		Resource:  "function:" + r.Name,
+	}.String()
+}
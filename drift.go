@@ -0,0 +1,179 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"go.yaml.in/yaml/v3"
+)
+
+// cfnLogicalIDTag is set by CloudFormation on the functions it creates.
+const cfnLogicalIDTag = "aws:cloudformation:logical-id"
+
+// cfnTemplate is the part of a SAM or CloudFormation template (including
+// CDK output) that declares Lambda functions.
+type cfnTemplate struct {
+	Globals struct {
+		Function struct {
+			Runtime yaml.Node `yaml:"Runtime"`
+		} `yaml:"Function"`
+	} `yaml:"Globals"`
+	Resources map[string]struct {
+		Type       string `yaml:"Type"`
+		Properties struct {
+			Runtime      yaml.Node `yaml:"Runtime"`
+			FunctionName yaml.Node `yaml:"FunctionName"`
+		} `yaml:"Properties"`
+	} `yaml:"Resources"`
+}
+
+// literal reports whether n is a plain string rather than an intrinsic
+// function such as !Ref or !Sub.
+func literal(n yaml.Node) bool {
+	return n.Kind == yaml.ScalarNode && n.ShortTag() == "!!str" && n.Value != ""
+}
+
+// scanTemplateFunctions returns one finding per Lambda function in the
+// templates of name, with the runtime it declares or inherits from SAM
+// Globals. Functions without an explicit FunctionName carry their logical
+// ID so they can be matched by the tag CloudFormation puts on them.
+func scanTemplateFunctions(name string) ([]iacFinding, error) {
+	var out []iacFinding
+	err := decodeYAMLDocs(name, func(doc *yaml.Node) error {
+		var t cfnTemplate
+		if err := doc.Decode(&t); err != nil {
+			return err
+		}
+		for _, id := range slices.Sorted(maps.Keys(t.Resources)) {
+			res := t.Resources[id]
+			if res.Type != "AWS::Lambda::Function" && res.Type != "AWS::Serverless::Function" {
+				continue
+			}
+			rt := res.Properties.Runtime
+			if rt.IsZero() && res.Type == "AWS::Serverless::Function" {
+				rt = t.Globals.Function.Runtime
+			}
+			if !literal(rt) { // container image, or a parameter
+				continue
+			}
+			f := iacFinding{File: name, Line: rt.Line, Resource: id, LogicalID: id, Runtime: rt.Value}
+			if fn := res.Properties.FunctionName; literal(fn) {
+				f.Function, f.LogicalID = fn.Value, ""
+			}
+			out = append(out, f)
+		}
+		return nil
+	})
+	return out, err
+}
+
+// runDrift compares the runtimes declared in IaC (templates under paths and
+// opts.TerraformState) with the runtimes of the deployed functions opts
+// selects, and prints the functions that no longer match, e.g. after a
+// manual bump the next deploy would revert.
+func runDrift(ctx context.Context, opts *AWSOpts, paths []string, w io.Writer) error {
+	if len(paths) == 0 && len(opts.TerraformState) == 0 {
+		paths = []string{"."}
+	}
+	if opts.FunctionName == "" {
+		opts.All = true
+	}
+	if err := validateCommon(opts); err != nil {
+		return err
+	}
+	clients := newClientFactory(opts.Profile, opts.APITimeout, opts.MaxRPS)
+	decls, err := collectIAC(ctx, clients, opts, paths, scanTemplateFunctions)
+	if err != nil {
+		return err
+	}
+
+	byName := make(map[string][]functionResult)
+	lambdas := make(map[string]*lambda.Client) // region → client, for tag lookups
+	err = eachFunction(ctx, opts, "drift", func(_ context.Context, cli *lambda.Client, r functionResult) {
+		byName[r.Name] = append(byName[r.Name], r)
+		lambdas[r.Region] = cli
+	})
+	if err != nil {
+		return err
+	}
+	var byLogicalID map[string][]functionResult
+	if slices.ContainsFunc(decls, func(d iacFinding) bool { return d.LogicalID != "" }) {
+		if byLogicalID, err = logicalIDs(ctx, lambdas, byName); err != nil {
+			return err
+		}
+	}
+
+	type driftRow struct {
+		decl     iacFinding
+		fn       functionResult
+		deployed string
+	}
+	var rows []driftRow
+	var inSync, drifted, missing int
+	for _, d := range decls {
+		candidates := byName[d.Function]
+		if d.LogicalID != "" {
+			candidates = byLogicalID[d.LogicalID]
+		}
+		var matched bool
+		for _, r := range candidates {
+			if d.Region != "" && r.Region != d.Region {
+				continue
+			}
+			matched = true
+			if r.Runtime == d.Runtime {
+				inSync++
+				continue
+			}
+			drifted++
+			rows = append(rows, driftRow{decl: d, fn: r, deployed: cmp.Or(r.Runtime, "image")})
+		}
+		if !matched {
+			missing++
+			rows = append(rows, driftRow{decl: d, fn: functionResult{Name: cmp.Or(d.Function, d.LogicalID), Region: cmp.Or(d.Region, "-")}, deployed: "not found"})
+		}
+	}
+	slices.SortFunc(rows, func(a, b driftRow) int {
+		return cmp.Or(cmp.Compare(a.fn.Name, b.fn.Name), cmp.Compare(a.fn.Region, b.fn.Region))
+	})
+
+	nameWidth, regionWidth, resWidth := len("FunctionName"), len("Region"), len("Resource")
+	for _, r := range rows {
+		nameWidth = max(nameWidth, len(r.fn.Name))
+		regionWidth = max(regionWidth, len(r.fn.Region))
+		resWidth = max(resWidth, len(r.decl.Resource))
+	}
+	tbl := newTable(w, nameWidth, regionWidth, max(runtimeNameWidth, len("not found")), runtimeNameWidth, resWidth)
+	tbl.header("FunctionName", "Region", "Deployed", "Declared", "Resource", "Location")
+	for _, r := range rows {
+		tbl.row(r.fn.Name, r.fn.Region, r.deployed, r.decl.Runtime, r.decl.Resource, r.decl.location())
+	}
+	fmt.Fprintf(w, "\nSummary: %d declarations, %d in sync, %d drifted, %d not found\n", len(decls), inSync, drifted, missing)
+	return nil
+}
+
+// logicalIDs groups the deployed functions by the CloudFormation logical ID
+// they are tagged with, one ListTags call per function.
+func logicalIDs(ctx context.Context, lambdas map[string]*lambda.Client, byName map[string][]functionResult) (map[string][]functionResult, error) {
+	out := make(map[string][]functionResult)
+	for _, fns := range byName {
+		for _, r := range fns {
+			tags, err := lambdas[r.Region].ListTags(ctx, &lambda.ListTagsInput{
+				Resource: aws.String(functionARN(r)),
+			})
+			if err != nil {
+				return nil, fmt.Errorf("tags for %s: %w", r.Name, err)
+			}
+			if id := tags.Tags[cfnLogicalIDTag]; id != "" {
+				out[id] = append(out[id], r)
+			}
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/cloudfront"
+	cftypes "github.com/aws/aws-sdk-go-v2/service/cloudfront/types"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+
+	"update-lambda-runtime/pkg/bump"
+)
+
+// edgeRegion is where Lambda@Edge functions live and are updated; CloudFront
+// replicates the associated versions to every edge location.
+const edgeRegion = "us-east-1"
+
+// Roles a function plays in Lambda@Edge, as recorded in functionResult.Edge.
+const (
+	edgeOrigin  = "origin"  // associated with a CloudFront distribution
+	edgeReplica = "replica" // a read-only copy CloudFront made in another region
+)
+
+// edgeIndex maps unqualified function ARNs to the IDs of the CloudFront
+// distributions that run a version of them.
+type edgeIndex map[string][]string
+
+// loadEdgeFunctions returns a CloudFront client and the Lambda@Edge
+// associations when regions include us-east-1, the only region edge
+// functions are updated in. Without CloudFront access edge functions are
+// bumped like any other, but not republished to the edge.
+func loadEdgeFunctions(ctx context.Context, clients *clientFactory, regions []string) (*cloudfront.Client, edgeIndex) {
+	if !slices.Contains(regions, edgeRegion) {
+		return nil, nil
+	}
+	cfg, err := clients.Config(ctx)
+	if err == nil {
+		cf := cloudfront.NewFromConfig(cfg, func(o *cloudfront.Options) {
+			o.Region = edgeRegion
+		})
+		var idx edgeIndex
+		if idx, err = loadEdgeIndex(ctx, cf); err == nil {
+			return cf, idx
+		}
+	}
+	fmt.Fprintln(os.Stderr, "warning: Lambda@Edge detection skipped:", err)
+	return nil, nil
+}
+
+// loadEdgeIndex lists every CloudFront distribution's Lambda@Edge
+// associations.
+func loadEdgeIndex(ctx context.Context, cf *cloudfront.Client) (edgeIndex, error) {
+	idx := make(edgeIndex)
+	add := func(id string, assocs *cftypes.LambdaFunctionAssociations) {
+		if assocs == nil {
+			return
+		}
+		for _, a := range assocs.Items {
+			fn := unqualifiedFunction(aws.ToString(a.LambdaFunctionARN))
+			if ids := idx[fn]; len(ids) == 0 || ids[len(ids)-1] != id {
+				idx[fn] = append(ids, id)
+			}
+		}
+	}
+	pages := cloudfront.NewListDistributionsPaginator(cf, &cloudfront.ListDistributionsInput{})
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if page.DistributionList == nil {
+			continue
+		}
+		for _, d := range page.DistributionList.Items {
+			id := aws.ToString(d.Id)
+			if d.DefaultCacheBehavior != nil {
+				add(id, d.DefaultCacheBehavior.LambdaFunctionAssociations)
+			}
+			if d.CacheBehaviors != nil {
+				for _, b := range d.CacheBehaviors.Items {
+					add(id, b.LambdaFunctionAssociations)
+				}
+			}
+		}
+	}
+	return idx, nil
+}
+
+// unqualifiedFunction strips the version from a function ARN.
+func unqualifiedFunction(s string) string {
+	a, err := arn.Parse(s)
+	if err != nil {
+		return s
+	}
+	if parts := strings.Split(a.Resource, ":"); len(parts) > 2 {
+		a.Resource = strings.Join(parts[:2], ":")
+	}
+	return a.String()
+}
+
+// isEdgeReplica reports whether the function named name in region is a
+// replica CloudFront created, which can only be changed through its origin
+// in us-east-1.
+func isEdgeReplica(region, name string) bool {
+	return region != edgeRegion && strings.HasPrefix(name, edgeRegion+".")
+}
+
+// deployEdge makes CloudFront run j's updated function: it publishes a new
+// version, points every association of the function in j.distributions at
+// it and waits until the distributions have deployed it to the edge. The
+// versions they used before stay in place; Lambda refuses to delete a
+// version while it is still replicated. When a distribution cannot be
+// associated, the detail names those that already were, since they serve
+// the new version while the rest do not.
+func deployEdge(ctx context.Context, log *resultCollector, cf *cloudfront.Client, j bumpJob, pollEvery, timeout time.Duration) (bump.Outcome, string) {
+	fn := j.result.Name
+	v, err := j.cli.PublishVersion(ctx, &lambda.PublishVersionInput{
+		FunctionName: aws.String(fn),
+		Description:  aws.String("runtime " + j.result.TargetRuntime),
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return bump.Interrupted, ""
+		}
+		log.progressf("  publish error for %s: %v\n", fn, err)
+		return bump.Failed, ""
+	}
+	version := aws.ToString(v.FunctionArn)
+	log.progressf("Associating %s with CloudFront distributions %s...\n", version, strings.Join(j.distributions, ", "))
+	for i, id := range j.distributions {
+		if err := associateVersion(ctx, cf, id, unqualifiedFunction(version), version); err != nil {
+			detail := ""
+			if i > 0 {
+				detail = fmt.Sprintf("distributions %s serve %s, %s and the rest do not", strings.Join(j.distributions[:i], ", "), version, id)
+				log.progressf("  %s: %s\n", fn, detail)
+			}
+			if ctx.Err() != nil {
+				return bump.Interrupted, detail
+			}
+			log.progressf("  distribution %s error for %s: %v\n", id, fn, err)
+			return bump.Failed, detail
+		}
+	}
+
+	deadline := time.Now().Add(timeout)
+	waiter := cloudfront.NewDistributionDeployedWaiter(cf, func(o *cloudfront.DistributionDeployedWaiterOptions) {
+		o.MinDelay = pollEvery
+		o.MaxDelay = max(o.MaxDelay, pollEvery)
+	})
+	for _, id := range j.distributions {
+		err := waiter.Wait(ctx, &cloudfront.GetDistributionInput{Id: aws.String(id)}, time.Until(deadline))
+		switch {
+		case err == nil:
+		case ctx.Err() != nil:
+			log.progressf("Stopped waiting for %s to replicate; the deployment continues in AWS\n", fn)
+			return bump.Interrupted, ""
+		case time.Now().After(deadline):
+			log.progressf("Timed out waiting for distribution %s to deploy %s\n", id, version)
+			return bump.TimedOut, ""
+		default:
+			log.progressf("  replication wait error for %s: %v\n", fn, err)
+			return bump.Failed, ""
+		}
+	}
+	log.progressf("%s replicated to the edge\n", fn)
+	return bump.Updated, ""
+}
+
+// distributionAPI is the part of the CloudFront API associateVersion
+// calls. *cloudfront.Client implements it; tests substitute a fake.
+type distributionAPI interface {
+	GetDistributionConfig(ctx context.Context, in *cloudfront.GetDistributionConfigInput, optFns ...func(*cloudfront.Options)) (*cloudfront.GetDistributionConfigOutput, error)
+	UpdateDistribution(ctx context.Context, in *cloudfront.UpdateDistributionInput, optFns ...func(*cloudfront.Options)) (*cloudfront.UpdateDistributionOutput, error)
+}
+
+// distributionLocks holds a lock per distribution ID, so that functions
+// of one distribution updated at the same time take turns to rewrite its
+// config rather than fail each other's writes.
+var distributionLocks = struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}{locks: make(map[string]*sync.Mutex)}
+
+func lockDistribution(id string) (unlock func()) {
+	distributionLocks.mu.Lock()
+	l, ok := distributionLocks.locks[id]
+	if !ok {
+		l = &sync.Mutex{}
+		distributionLocks.locks[id] = l
+	}
+	distributionLocks.mu.Unlock()
+	l.Lock()
+	return l.Unlock
+}
+
+// maxAssociateAttempts is how many times associateVersion reads and writes
+// a distribution's config when something else changed it in between.
+const maxAssociateAttempts = 5
+
+// associateVersion points the associations of function fn (any version)
+// in distribution id at version. The config is written back only if it
+// is unchanged since it was read; otherwise it is read again and retried.
+func associateVersion(ctx context.Context, cf distributionAPI, id, fn, version string) error {
+	defer lockDistribution(id)()
+	for attempt := 1; ; attempt++ {
+		err := retargetDistribution(ctx, cf, id, fn, version)
+		var changed *cftypes.PreconditionFailed
+		if err == nil || !errors.As(err, &changed) || attempt >= maxAssociateAttempts || ctx.Err() != nil {
+			return err
+		}
+	}
+}
+
+func retargetDistribution(ctx context.Context, cf distributionAPI, id, fn, version string) error {
+	out, err := cf.GetDistributionConfig(ctx, &cloudfront.GetDistributionConfigInput{Id: aws.String(id)})
+	if err != nil {
+		return err
+	}
+	cfg := out.DistributionConfig
+	retarget := func(assocs *cftypes.LambdaFunctionAssociations) {
+		if assocs == nil {
+			return
+		}
+		for i, a := range assocs.Items {
+			if unqualifiedFunction(aws.ToString(a.LambdaFunctionARN)) == fn {
+				assocs.Items[i].LambdaFunctionARN = aws.String(version)
+			}
+		}
+	}
+	if cfg.DefaultCacheBehavior != nil {
+		retarget(cfg.DefaultCacheBehavior.LambdaFunctionAssociations)
+	}
+	if cfg.CacheBehaviors != nil {
+		for _, b := range cfg.CacheBehaviors.Items {
+			retarget(b.LambdaFunctionAssociations)
+		}
+	}
+	_, err = cf.UpdateDistribution(ctx, &cloudfront.UpdateDistributionInput{
+		Id:                 aws.String(id),
+		IfMatch:            out.ETag,
+		DistributionConfig: cfg,
+	})
+	if err != nil {
+		return fmt.Errorf("update: %w", err)
+	}
+	return nil
+}
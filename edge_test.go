@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudfront"
+	cftypes "github.com/aws/aws-sdk-go-v2/service/cloudfront/types"
+)
+
+// fakeDistribution is a distribution whose config is written only with
+// the ETag it was read at, as CloudFront does. interfere, when set, changes
+// the config behind the caller's back after each of the first reads.
+type fakeDistribution struct {
+	mu        sync.Mutex
+	etag      int
+	cfg       *cftypes.DistributionConfig
+	interfere int
+	conflicts int
+}
+
+func (f *fakeDistribution) GetDistributionConfig(_ context.Context, _ *cloudfront.GetDistributionConfigInput, _ ...func(*cloudfront.Options)) (*cloudfront.GetDistributionConfigOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := &cloudfront.GetDistributionConfigOutput{ETag: aws.String(fmt.Sprint(f.etag)), DistributionConfig: cloneAssociations(f.cfg)}
+	if f.interfere > 0 {
+		f.interfere--
+		f.etag++
+	}
+	return out, nil
+}
+
+func (f *fakeDistribution) UpdateDistribution(_ context.Context, in *cloudfront.UpdateDistributionInput, _ ...func(*cloudfront.Options)) (*cloudfront.UpdateDistributionOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if aws.ToString(in.IfMatch) != fmt.Sprint(f.etag) {
+		f.conflicts++
+		return nil, &cftypes.PreconditionFailed{Message: aws.String("The request failed because it didn't meet the preconditions")}
+	}
+	f.etag++
+	f.cfg = in.DistributionConfig
+	return &cloudfront.UpdateDistributionOutput{}, nil
+}
+
+// cloneAssociations copies cfg deep enough for its associations to be
+// changed without touching cfg.
+func cloneAssociations(cfg *cftypes.DistributionConfig) *cftypes.DistributionConfig {
+	out := *cfg
+	def := *cfg.DefaultCacheBehavior
+	assocs := *def.LambdaFunctionAssociations
+	assocs.Items = append([]cftypes.LambdaFunctionAssociation(nil), assocs.Items...)
+	def.LambdaFunctionAssociations = &assocs
+	out.DefaultCacheBehavior = &def
+	return &out
+}
+
+func TestAssociateVersion(t *testing.T) {
+	const viewer, origin = "arn:aws:lambda:us-east-1:123456789012:function:viewer", "arn:aws:lambda:us-east-1:123456789012:function:origin"
+	dist := &fakeDistribution{cfg: &cftypes.DistributionConfig{DefaultCacheBehavior: &cftypes.DefaultCacheBehavior{
+		LambdaFunctionAssociations: &cftypes.LambdaFunctionAssociations{Items: []cftypes.LambdaFunctionAssociation{
+			{EventType: cftypes.EventTypeViewerRequest, LambdaFunctionARN: aws.String(viewer + ":3")},
+			{EventType: cftypes.EventTypeOriginRequest, LambdaFunctionARN: aws.String(origin + ":7")},
+		}},
+	}}}
+	// Both functions of the distribution finish their update together,
+	// while someone else changes the distribution twice.
+	dist.interfere = 2
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i, fn := range []string{viewer, origin} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = associateVersion(context.Background(), dist, "E1", fn, fn+":8")
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	items := dist.cfg.DefaultCacheBehavior.LambdaFunctionAssociations.Items
+	if got := []string{aws.ToString(items[0].LambdaFunctionARN), aws.ToString(items[1].LambdaFunctionARN)}; got[0] != viewer+":8" || got[1] != origin+":8" {
+		t.Errorf("associations %v, want both at version 8", got)
+	}
+	if dist.conflicts != 2 {
+		t.Errorf("%d conflicting writes, want only the 2 someone else caused", dist.conflicts)
+	}
+
+	dist.interfere = maxAssociateAttempts
+	if err := associateVersion(context.Background(), dist, "E1", viewer, viewer+":9"); err == nil {
+		t.Error("a distribution that never stops changing was associated")
+	}
+}
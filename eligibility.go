@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/google/cel-go/cel"
+
+	"update-lambda-runtime/pkg/inventory"
+)
+
+// eligibilityPolicy is an --eligibility file: rules written in CEL that a
+// function the runtime policy matched must pass to be bumped, e.g.
+//
+//	rules:
+//	  - name: platform-only
+//	    allow: tags.?team.orValue("") == "platform"
+//	  - name: not-in-prod
+//	    deny: account == "222222222222"
+//	  - name: never-legacy
+//	    deny: name.matches("-legacy$")
+//
+// Every allow rule must hold and no deny rule may. Expressions see name,
+// runtime, target, region, account, profile, architecture, layers, edge
+// (true for Lambda@Edge functions) and tags; tags are only looked up when
+// a rule reads them.
+type eligibilityPolicy struct {
+	Rules []*eligibilityRule `yaml:"rules"`
+}
+
+type eligibilityRule struct {
+	Name  string `yaml:"name"`
+	Allow string `yaml:"allow"`
+	Deny  string `yaml:"deny"`
+
+	prg cel.Program
+}
+
+// loadEligibility reads, checks against the eligibility schema and compiles
+// an --eligibility file, YAML or JSON.
+func loadEligibility(path string) (*eligibilityPolicy, error) {
+	doc, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("--eligibility: %w", err)
+	}
+	var p eligibilityPolicy
+	root, err := decodeDocument("eligibility", doc, &p)
+	if err != nil {
+		return nil, fmt.Errorf("--eligibility %s: %w", path, err)
+	}
+	env, err := cel.NewEnv(
+		cel.OptionalTypes(),
+		cel.Variable("name", cel.StringType),
+		cel.Variable("runtime", cel.StringType),
+		cel.Variable("target", cel.StringType),
+		cel.Variable("region", cel.StringType),
+		cel.Variable("account", cel.StringType),
+		cel.Variable("profile", cel.StringType),
+		cel.Variable("architecture", cel.StringType),
+		cel.Variable("layers", cel.ListType(cel.StringType)),
+		cel.Variable("edge", cel.BoolType),
+		cel.Variable("tags", cel.MapType(cel.StringType, cel.StringType)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	for i, r := range p.Rules {
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("rule %d", i+1)
+		}
+		at := fmt.Sprintf("--eligibility %s: line %d: %s", path, nodeAt(root, []string{"rules", strconv.Itoa(i)}).Line, r.Name)
+		expr := r.Allow
+		if (r.Allow == "") == (r.Deny == "") {
+			return nil, fmt.Errorf("%s: want exactly one of allow and deny", at)
+		}
+		if expr == "" {
+			expr = r.Deny
+		}
+		ast, iss := env.Compile(expr)
+		if iss.Err() != nil {
+			return nil, fmt.Errorf("%s: %w", at, iss.Err())
+		}
+		if ast.OutputType() != cel.BoolType {
+			return nil, fmt.Errorf("%s: evaluates to %s, want bool", at, ast.OutputType())
+		}
+		if r.prg, err = env.Program(ast); err != nil {
+			return nil, fmt.Errorf("%s: %w", at, err)
+		}
+	}
+	return &p, nil
+}
+
+// tagsAPI lists a function's tags; *lambda.Client implements it.
+type tagsAPI interface {
+	ListTags(ctx context.Context, in *lambda.ListTagsInput, optFns ...func(*lambda.Options)) (*lambda.ListTagsOutput, error)
+}
+
+// check runs every rule against f, discovered as r, and returns the name of
+// the first rule that rules the function out, or "" when it is eligible.
+// cli looks up the function's tags if a rule reads them.
+func (p *eligibilityPolicy) check(ctx context.Context, cli tagsAPI, r functionResult, f inventory.Function) (string, error) {
+	if p == nil {
+		return "", nil
+	}
+	var (
+		tags   map[string]string
+		tagErr error
+	)
+	vars := map[string]any{
+		"name":         r.Name,
+		"runtime":      r.Runtime,
+		"target":       r.TargetRuntime,
+		"region":       r.Region,
+		"account":      r.AccountID,
+		"profile":      r.Profile,
+		"architecture": f.Architecture,
+		"layers":       append([]string{}, f.Layers...),
+		"edge":         r.Edge != "",
+		"tags": func() any {
+			if tags == nil && tagErr == nil {
+				out, err := cli.ListTags(ctx, &lambda.ListTagsInput{Resource: aws.String(functionARN(r))})
+				if tagErr = err; err == nil {
+					tags = out.Tags
+					if tags == nil {
+						tags = make(map[string]string)
+					}
+				}
+			}
+			if tags == nil {
+				return map[string]string{}
+			}
+			return tags
+		},
+	}
+	for _, rule := range p.Rules {
+		val, _, err := rule.prg.Eval(vars)
+		if tagErr != nil {
+			return "", fmt.Errorf("tags: %w", tagErr)
+		}
+		if err != nil {
+			return "", fmt.Errorf("eligibility rule %s: %w", rule.Name, err)
+		}
+		if holds, _ := val.Value().(bool); holds != (rule.Allow != "") {
+			return rule.Name, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+
+	"update-lambda-runtime/pkg/inventory"
+)
+
+type fakeTags struct {
+	tags  map[string]string
+	err   error
+	calls int
+}
+
+func (f *fakeTags) ListTags(context.Context, *lambda.ListTagsInput, ...func(*lambda.Options)) (*lambda.ListTagsOutput, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &lambda.ListTagsOutput{Tags: f.tags}, nil
+}
+
+func writeEligibility(t *testing.T, doc string) *eligibilityPolicy {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "eligibility.yaml")
+	if err := os.WriteFile(path, []byte(doc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := loadEligibility(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestEligibility(t *testing.T) {
+	p := writeEligibility(t, `
+rules:
+  - name: platform-only
+    allow: tags.?team.orValue("") == "platform"
+  - name: non-prod
+    deny: account == "222222222222"
+  - name: never-legacy
+    deny: name.endsWith("-legacy")
+`)
+	tests := []struct {
+		name    string
+		account string
+		tags    map[string]string
+		want    string
+	}{
+		{"api", "111111111111", map[string]string{"team": "platform"}, ""},
+		{"api", "111111111111", map[string]string{"team": "data"}, "platform-only"},
+		{"api", "111111111111", nil, "platform-only"},
+		{"api", "222222222222", map[string]string{"team": "platform"}, "non-prod"},
+		{"billing-legacy", "111111111111", map[string]string{"team": "platform"}, "never-legacy"},
+	}
+	for _, tt := range tests {
+		cli := &fakeTags{tags: tt.tags}
+		r := functionResult{AccountID: tt.account, Region: "us-east-1", Name: tt.name, Runtime: "python3.9", TargetRuntime: "python3.12"}
+		got, err := p.check(context.Background(), cli, r, inventory.Function{Name: tt.name})
+		if err != nil || got != tt.want {
+			t.Errorf("%s in %s tagged %v: check = %q, %v, want %q", tt.name, tt.account, tt.tags, got, err, tt.want)
+		}
+		if cli.calls != 1 {
+			t.Errorf("%d ListTags calls, want 1", cli.calls)
+		}
+	}
+}
+
+func TestEligibilityTagsOnlyWhenRead(t *testing.T) {
+	p := writeEligibility(t, "rules:\n  - deny: runtime.startsWith(\"nodejs\")\n")
+	cli := &fakeTags{err: errors.New("AccessDenied")}
+	got, err := p.check(context.Background(), cli, functionResult{Name: "f", Runtime: "nodejs16.x"}, inventory.Function{})
+	if err != nil || got != "rule 1" || cli.calls != 0 {
+		t.Errorf("check = %q, %v after %d ListTags calls; want rule 1 without looking up tags", got, err, cli.calls)
+	}
+
+	p = writeEligibility(t, "rules:\n  - allow: '\"team\" in tags'\n")
+	if _, err := p.check(context.Background(), cli, functionResult{Name: "f"}, inventory.Function{}); err == nil || !strings.Contains(err.Error(), "AccessDenied") {
+		t.Errorf("err = %v, want the ListTags error", err)
+	}
+}
+
+func TestEligibilityInvalid(t *testing.T) {
+	for _, doc := range []string{
+		"rules: []",
+		"rules:\n  - name: both\n    allow: true\n    deny: false\n",
+		"rules:\n  - allow: name\n",
+		"rules:\n  - deny: unknown == 1\n",
+	} {
+		path := filepath.Join(t.TempDir(), "eligibility.yaml")
+		os.WriteFile(path, []byte(doc), 0o644)
+		if _, err := loadEligibility(path); err == nil {
+			t.Errorf("loadEligibility(%q): want an error", doc)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"update-lambda-runtime/pkg/bump"
+)
+
+// Lifecycle event types, emitted as a function moves through a bump.
+const (
+	eventStarted = "lambda-runtime-bump.started"
+	eventUpdated = "lambda-runtime-bump.updated"
+	eventFailed  = "lambda-runtime-bump.failed"
+)
+
+// lifecycleEvent is one state transition of a single function's bump.
+type lifecycleEvent struct {
+	Type          string       `json:"type"`
+	Time          time.Time    `json:"time"`
+	AccountID     string       `json:"accountId"`
+	Region        string       `json:"region"`
+	FunctionName  string       `json:"functionName"`
+	SourceRuntime string       `json:"sourceRuntime"`
+	TargetRuntime string       `json:"targetRuntime"`
+	Outcome       bump.Outcome `json:"outcome,omitempty"`
+}
+
+// eventSink receives lifecycle events as they happen, unlike a notifier,
+// which only sees the finished run.
+type eventSink interface {
+	emit(ctx context.Context, ev lifecycleEvent) error
+}
+
+// lifecycle fans a bump's transitions out to the configured sinks.
+type lifecycle struct {
+	sinks []eventSink
+}
+
+func (l *lifecycle) started(ctx context.Context, r functionResult) {
+	l.send(ctx, eventStarted, r)
+}
+
+// finished emits updated or failed for r's outcome. Interrupted and
+// not-attempted functions produce no event: nothing changed state.
+func (l *lifecycle) finished(ctx context.Context, r functionResult) {
+	switch r.Outcome {
+	case bump.Updated:
+		l.send(ctx, eventUpdated, r)
+	case bump.Failed, bump.TimedOut:
+		l.send(ctx, eventFailed, r)
+	}
+}
+
+func (l *lifecycle) send(ctx context.Context, typ string, r functionResult) {
+	if len(l.sinks) == 0 {
+		return
+	}
+	ev := lifecycleEvent{
+		Type:          typ,
+		Time:          time.Now().UTC(),
+		AccountID:     r.AccountID,
+		Region:        r.Region,
+		FunctionName:  r.Name,
+		SourceRuntime: r.Runtime,
+		TargetRuntime: r.TargetRuntime,
+		Outcome:       r.Outcome,
+	}
+	// Deliver even if the run is being interrupted: the transition happened.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), notifyTimeout)
+	defer cancel()
+	for _, s := range l.sinks {
+		if err := s.emit(ctx, ev); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %s event for %s: %v\n", typ, r.Name, err)
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// IaC flavours generate writes.
+const (
+	generateTerraform      = "terraform"
+	generateCloudFormation = "cloudformation"
+)
+
+// generateArch is the architecture of generated runners unless
+// --architecture says otherwise.
+const generateArch = "arm64"
+
+// runGenerate writes infrastructure as code that sets up what
+// deploy-schedule creates through the API: the SSM parameter holding args,
+// the execution role and its policy, the provided.al2023 runner function
+// and the EventBridge schedule invoking it. The deployment package is an
+// input: a Terraform variable naming a local zip, or CloudFormation
+// parameters naming one in S3.
+func runGenerate(opts *AWSOpts, flavour string, args []string, w io.Writer) error {
+	if err := checkScheduledArgs(args); err != nil {
+		return err
+	}
+	args = withHistoryTable(opts, args)
+	arch := cmp.Or(opts.DeployArch, generateArch)
+	if arch != "arm64" && arch != "x86_64" {
+		return fmt.Errorf("--architecture must be arm64 or x86_64")
+	}
+	switch flavour {
+	case generateTerraform:
+		if len(opts.Regions) == 0 {
+			return fmt.Errorf("--regions is required: the first one is where the runner is deployed")
+		}
+		return terraformTemplate.Execute(w, map[string]any{
+			"Opts":       opts,
+			"Region":     opts.Regions[0],
+			"Arch":       arch,
+			"Command":    strings.Join(args, " "),
+			"Args":       args,
+			"Policy":     policyJSON(deployPolicyDoc(terraformPartition, "${data.aws_caller_identity.current.account_id}", "${data.aws_region.current.name}", opts.DeployArgsParam, args)),
+			"Trust":      lambdaTrustPolicy,
+			"Basic":      basicExecPolicy(terraformPartition),
+			"PolicyName": deployPolicyName,
+			"EnvVar":     envArgsParam,
+			"Timeout":    deployTimeout,
+			"Memory":     deployMemory,
+		})
+	case generateCloudFormation:
+		return writeCloudFormation(w, opts, arch, args)
+	}
+	return fmt.Errorf("generate %q: want %s or %s", flavour, generateTerraform, generateCloudFormation)
+}
+
+// terraformPartition is the provider's partition, for the ARNs of the
+// generated Terraform.
+const terraformPartition = "${data.aws_partition.current.partition}"
+
+// policyJSON indents doc for embedding in a template.
+func policyJSON(doc map[string]any) string {
+	b, _ := json.MarshalIndent(doc, "", "  ")
+	return string(b)
+}
+
+// hclString quotes s as an HCL string literal, escaping template sequences.
+func hclString(s string) string {
+	q := strconv.Quote(s)
+	q = strings.ReplaceAll(q, "${", "$${")
+	return strings.ReplaceAll(q, "%{", "%%{")
+}
+
+var terraformTemplate = template.Must(template.New("terraform").Funcs(template.FuncMap{
+	"q": hclString,
+	"list": func(items []string) string {
+		quoted := make([]string, len(items))
+		for i, s := range items {
+			quoted[i] = hclString(s)
+		}
+		return "[" + strings.Join(quoted, ", ") + "]"
+	},
+}).Parse(`# Generated by update-lambda-runtime generate terraform.
+# Runs "{{.Command}}" {{.Opts.DeploySchedule}}.
+
+terraform {
+  required_providers {
+    aws = {
+      source = "hashicorp/aws"
+    }
+  }
+}
+
+provider "aws" {
+  region = {{q .Region}}
+}
+
+variable "package_file" {
+  description = "Zip holding a Linux {{.Arch}} build of update-lambda-runtime named bootstrap"
+  type        = string
+  default     = "update-lambda-runtime.zip"
+}
+
+data "aws_caller_identity" "current" {}
+
+data "aws_partition" "current" {}
+
+data "aws_region" "current" {}
+
+resource "aws_ssm_parameter" "args" {
+  name  = {{q .Opts.DeployArgsParam}}
+  type  = "String"
+  value = jsonencode({ args = {{list .Args}} })
+}
+
+resource "aws_iam_role" "runner" {
+  name               = {{q (print .Opts.DeployName "-role")}}
+  description        = "Execution role of the scheduled update-lambda-runtime function"
+  assume_role_policy = {{q .Trust}}
+}
+
+resource "aws_iam_role_policy_attachment" "logs" {
+  role       = aws_iam_role.runner.name
+  policy_arn = "{{.Basic}}"
+}
+
+resource "aws_iam_role_policy" "runner" {
+  name   = {{q .PolicyName}}
+  role   = aws_iam_role.runner.id
+  policy = <<-EOT
+{{.Policy}}
+  EOT
+}
+
+resource "aws_lambda_function" "runner" {
+  function_name    = {{q .Opts.DeployName}}
+  description      = "Scheduled update-lambda-runtime"
+  role             = aws_iam_role.runner.arn
+  runtime          = "provided.al2023"
+  handler          = "bootstrap"
+  architectures    = [{{q .Arch}}]
+  filename         = var.package_file
+  source_code_hash = filebase64sha256(var.package_file)
+  timeout          = {{.Timeout}}
+  memory_size      = {{.Memory}}
+
+  environment {
+    variables = {
+      {{.EnvVar}} = aws_ssm_parameter.args.name
+    }
+  }
+}
+
+resource "aws_cloudwatch_event_rule" "schedule" {
+  name                = {{q (print .Opts.DeployName "-schedule")}}
+  description         = {{q (print "Runs " .Command)}}
+  schedule_expression = {{q .Opts.DeploySchedule}}
+}
+
+resource "aws_cloudwatch_event_target" "runner" {
+  rule      = aws_cloudwatch_event_rule.schedule.name
+  target_id = {{q .Opts.DeployName}}
+  arn       = aws_lambda_function.runner.arn
+}
+
+resource "aws_lambda_permission" "schedule" {
+  statement_id  = {{q (print .Opts.DeployName "-schedule")}}
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.runner.function_name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.schedule.arn
+}
+`))
+
+// writeCloudFormation writes the stack as a CloudFormation YAML template.
+// The runner is deployed in the stack's region; partition, account and
+// region in the ARNs are resolved with Fn::Sub.
+func writeCloudFormation(w io.Writer, opts *AWSOpts, arch string, args []string) error {
+	value, err := json.Marshal(scheduledArgs{Args: args})
+	if err != nil {
+		return err
+	}
+	var trust map[string]any
+	if err := json.Unmarshal([]byte(lambdaTrustPolicy), &trust); err != nil {
+		return err
+	}
+	ref := func(name string) map[string]any { return map[string]any{"Ref": name} }
+	getAtt := func(name string) map[string]any { return map[string]any{"Fn::GetAtt": []string{name, "Arn"}} }
+	tmpl := map[string]any{
+		"AWSTemplateFormatVersion": "2010-09-09",
+		"Description":              fmt.Sprintf("Runs update-lambda-runtime %q %s", strings.Join(args, " "), opts.DeploySchedule),
+		"Parameters": map[string]any{
+			"CodeS3Bucket": map[string]any{"Type": "String", "Description": "Bucket of the zip holding a Linux " + arch + " build of update-lambda-runtime named bootstrap"},
+			"CodeS3Key":    map[string]any{"Type": "String", "Default": "update-lambda-runtime.zip"},
+		},
+		"Resources": map[string]any{
+			"ArgsParameter": map[string]any{
+				"Type":       "AWS::SSM::Parameter",
+				"Properties": map[string]any{"Name": opts.DeployArgsParam, "Type": "String", "Value": string(value)},
+			},
+			"RunnerRole": map[string]any{
+				"Type": "AWS::IAM::Role",
+				"Properties": map[string]any{
+					"RoleName":                 opts.DeployName + "-role",
+					"Description":              "Execution role of the scheduled update-lambda-runtime function",
+					"AssumeRolePolicyDocument": trust,
+					"ManagedPolicyArns":        cfnSub([]string{basicExecPolicy("${AWS::Partition}")}),
+					"Policies": []map[string]any{{
+						"PolicyName":     deployPolicyName,
+						"PolicyDocument": cfnSub(deployPolicyDoc("${AWS::Partition}", "${AWS::AccountId}", "${AWS::Region}", opts.DeployArgsParam, args)),
+					}},
+				},
+			},
+			"Runner": map[string]any{
+				"Type": "AWS::Lambda::Function",
+				"Properties": map[string]any{
+					"FunctionName":  opts.DeployName,
+					"Description":   "Scheduled update-lambda-runtime",
+					"Role":          getAtt("RunnerRole"),
+					"Runtime":       "provided.al2023",
+					"Handler":       "bootstrap",
+					"Architectures": []string{arch},
+					"Code":          map[string]any{"S3Bucket": ref("CodeS3Bucket"), "S3Key": ref("CodeS3Key")},
+					"Timeout":       deployTimeout,
+					"MemorySize":    deployMemory,
+					"Environment":   map[string]any{"Variables": map[string]any{envArgsParam: ref("ArgsParameter")}},
+				},
+			},
+			"Schedule": map[string]any{
+				"Type": "AWS::Events::Rule",
+				"Properties": map[string]any{
+					"Name":               opts.DeployName + "-schedule",
+					"Description":        "Runs " + strings.Join(args, " "),
+					"ScheduleExpression": opts.DeploySchedule,
+					"State":              "ENABLED",
+					"Targets":            []map[string]any{{"Id": opts.DeployName, "Arn": getAtt("Runner")}},
+				},
+			},
+			"SchedulePermission": map[string]any{
+				"Type": "AWS::Lambda::Permission",
+				"Properties": map[string]any{
+					"FunctionName": ref("Runner"),
+					"Action":       "lambda:InvokeFunction",
+					"Principal":    "events.amazonaws.com",
+					"SourceArn":    getAtt("Schedule"),
+				},
+			},
+		},
+	}
+	fmt.Fprintln(w, "# Generated by update-lambda-runtime generate cloudformation.")
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(tmpl); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// cfnSub wraps the strings in v that reference pseudo parameters in
+// Fn::Sub.
+func cfnSub(v any) any {
+	switch v := v.(type) {
+	case string:
+		if strings.Contains(v, "${") {
+			return map[string]any{"Fn::Sub": v}
+		}
+		return v
+	case []string:
+		out := make([]any, len(v))
+		for i, s := range v {
+			out[i] = cfnSub(s)
+		}
+		return out
+	case []map[string]any:
+		out := make([]any, len(v))
+		for i, m := range v {
+			out[i] = cfnSub(m)
+		}
+		return out
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, e := range v {
+			out[k] = cfnSub(e)
+		}
+		return out
+	}
+	return v
+}
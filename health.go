@@ -0,0 +1,215 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+
+	"update-lambda-runtime/pkg/bump"
+)
+
+// How a function fared after the run, as shown in the Result column.
+const (
+	healthOK        = "healthy"
+	healthRegressed = "regressed"
+	healthNoTraffic = "no traffic"
+	healthError     = "error"
+)
+
+// minErrorRateRise is how many percentage points the error rate must rise
+// by, on top of --tolerance, to count: 0 errors in 50 calls becoming 1 in
+// 40 is noise.
+const minErrorRateRise = 0.01
+
+// healthMetrics is a function's CloudWatch metrics over one window. Init
+// durations come from Lambda Insights, and are missing without it.
+type healthMetrics struct {
+	invocations, errors        float64
+	durationSum, durationCount float64
+	initSum, initCount         float64
+}
+
+func (m healthMetrics) errorRate() float64 {
+	if m.invocations == 0 {
+		return 0
+	}
+	return m.errors / m.invocations
+}
+
+// healthRegressions compares a function's metrics after the run with those
+// before it, and lists what got worse by more than tolerance (0.25 for
+// 25%).
+func healthRegressions(before, after healthMetrics, tolerance float64) []string {
+	var out []string
+	if b, a := before.errorRate(), after.errorRate(); a > b*(1+tolerance) && a-b >= minErrorRateRise {
+		out = append(out, fmt.Sprintf("error rate %.1f%% → %.1f%%", b*100, a*100))
+	}
+	worse := func(name string, bSum, bCount, aSum, aCount float64) {
+		if bCount == 0 || aCount == 0 {
+			return
+		}
+		if b, a := bSum/bCount, aSum/aCount; a > b*(1+tolerance) {
+			out = append(out, fmt.Sprintf("%s %.0fms → %.0fms", name, b, a))
+		}
+	}
+	worse("duration", before.durationSum, before.durationCount, after.durationSum, after.durationCount)
+	worse("init", before.initSum, before.initCount, after.initSum, after.initCount)
+	return out
+}
+
+// healthWindows are the windows runHealth compares: since ago up to now,
+// starting no earlier than the run finished, and as long again up to when
+// the run started.
+func healthWindows(rec *runReport, since time.Duration, now time.Time) (beforeFrom, beforeTo, afterFrom, afterTo time.Time) {
+	afterFrom = rec.FinishedAt
+	if since > 0 && now.Add(-since).After(afterFrom) {
+		afterFrom = now.Add(-since)
+	}
+	length := now.Sub(afterFrom)
+	return rec.StartedAt.Add(-length), rec.StartedAt, afterFrom, now
+}
+
+// runHealth compares the CloudWatch metrics of every function bump run id
+// updated, after the run against before it: error rate, average duration
+// and, with Lambda Insights, average init duration. Any that got worse by
+// more than --tolerance is a regression and fails the command, so a check
+// days after the change can gate closing it.
+func runHealth(ctx context.Context, opts *AWSOpts, id string, w io.Writer) error {
+	rec, err := loadRunRecord(id)
+	if err != nil {
+		return err
+	}
+	profile := cmp.Or(opts.Profile, rec.Profile)
+	clients := newClientFactory(profile, opts.APITimeout, opts.MaxRPS).assuming(rec.Role)
+	acctID, err := resolveAccountID(ctx, clients)
+	if err != nil {
+		return fmt.Errorf("resolve account id: %w", err)
+	}
+	if acctID != rec.AccountID {
+		return fmt.Errorf("run %s was in account %s, but profile %s is account %s", id, rec.AccountID, profile, acctID)
+	}
+	now := time.Now()
+	beforeFrom, beforeTo, afterFrom, afterTo := healthWindows(rec, opts.HealthSince, now)
+	if afterTo.Sub(afterFrom) < time.Hour {
+		fmt.Fprintf(w, "warning: only %s since the run finished; metrics are sparse this soon\n", afterTo.Sub(afterFrom).Round(time.Minute))
+	}
+	fmt.Fprintf(w, "Comparing %s to %s with %s to %s, before run %s\n\n",
+		inZone(afterFrom, time.Local).Format(time.DateTime), inZone(afterTo, time.Local).Format(time.DateTime),
+		inZone(beforeFrom, time.Local).Format(time.DateTime), inZone(beforeTo, time.Local).Format(time.DateTime), id)
+
+	var bumped []functionResult
+	for _, r := range rec.Results {
+		if r.Outcome == bump.Updated || r.Outcome == bump.Started {
+			bumped = append(bumped, r)
+		}
+	}
+	type checked struct {
+		result, detail string
+	}
+	found := make([]checked, len(bumped))
+	usage := newUsageLookup(clients)
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, listLookups)
+	for i, r := range bumped {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			before, err := usage.health(ctx, r.Region, r.Name, beforeFrom, beforeTo)
+			var after healthMetrics
+			if err == nil {
+				after, err = usage.health(ctx, r.Region, r.Name, afterFrom, afterTo)
+			}
+			switch regressions := healthRegressions(before, after, opts.HealthTolerance/100); {
+			case err != nil:
+				found[i] = checked{healthError, err.Error()}
+			case after.invocations == 0:
+				found[i] = checked{healthNoTraffic, ""}
+			case len(regressions) > 0:
+				found[i] = checked{healthRegressed, strings.Join(regressions, "; ")}
+			default:
+				found[i] = checked{healthOK, ""}
+			}
+		}()
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return stopped(ctx)
+	}
+
+	tbl := newFunctionTable(w, opts, runtimeNameWidth)
+	printHeader(tbl, opts.ShowProfile, "Result")
+	counts := make(map[string]int)
+	for i, r := range bumped {
+		c := found[i]
+		counts[c.result]++
+		result := c.result
+		if c.detail != "" {
+			result += ": " + c.detail
+		}
+		printRow(tbl, r.AccountID, r.Profile, r.Region, r.Name, r.TargetRuntime, opts.ShowProfile, result)
+	}
+	fmt.Fprintf(w, "\nSummary: %d healthy, %d regressed, %d no traffic, %d failed\n", counts[healthOK], counts[healthRegressed], counts[healthNoTraffic], counts[healthError])
+	if counts[healthRegressed]+counts[healthError] > 0 {
+		return errors.New("health check failed")
+	}
+	return nil
+}
+
+// health reads the function's metrics from from to to.
+func (l *usageLookup) health(ctx context.Context, region, name string, from, to time.Time) (healthMetrics, error) {
+	cli, err := l.client(ctx, region)
+	if err != nil {
+		return healthMetrics{}, err
+	}
+	var m healthMetrics
+	for _, q := range []struct {
+		namespace, dimension, metric string
+		sum, count                   *float64
+	}{
+		{"AWS/Lambda", "FunctionName", "Invocations", &m.invocations, nil},
+		{"AWS/Lambda", "FunctionName", "Errors", &m.errors, nil},
+		{"AWS/Lambda", "FunctionName", "Duration", &m.durationSum, &m.durationCount},
+		{"LambdaInsights", "function_name", "init_duration", &m.initSum, &m.initCount},
+	} {
+		out, err := cli.GetMetricStatistics(ctx, &cloudwatch.GetMetricStatisticsInput{
+			Namespace:  aws.String(q.namespace),
+			MetricName: aws.String(q.metric),
+			Dimensions: []cwtypes.Dimension{{Name: aws.String(q.dimension), Value: aws.String(name)}},
+			StartTime:  aws.Time(from),
+			EndTime:    aws.Time(to),
+			Period:     aws.Int32(metricPeriod(to.Sub(from))),
+			Statistics: []cwtypes.Statistic{cwtypes.StatisticSum, cwtypes.StatisticSampleCount},
+		})
+		if err != nil {
+			return healthMetrics{}, fmt.Errorf("%s: %w", q.metric, err)
+		}
+		for _, p := range out.Datapoints {
+			*q.sum += aws.ToFloat64(p.Sum)
+			if q.count != nil {
+				*q.count += aws.ToFloat64(p.SampleCount)
+			}
+		}
+	}
+	return m, nil
+}
+
+// metricPeriod is a period covering a window of length d in one or two
+// datapoints: whole minutes, or whole hours past an hour, since CloudWatch
+// keeps older data only at coarser resolutions.
+func metricPeriod(d time.Duration) int32 {
+	unit := time.Minute
+	if d > time.Hour {
+		unit = time.Hour
+	}
+	return int32(max(d.Round(unit)/time.Second, unit/time.Second))
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestHealthRegressions(t *testing.T) {
+	before := healthMetrics{invocations: 1000, errors: 5, durationSum: 100000, durationCount: 1000}
+	tests := []struct {
+		name  string
+		after healthMetrics
+		want  []string
+	}{
+		{"same", healthMetrics{invocations: 800, errors: 4, durationSum: 88000, durationCount: 800}, nil},
+		{"errors", healthMetrics{invocations: 1000, errors: 40, durationSum: 100000, durationCount: 1000}, []string{"error rate 0.5% → 4.0%"}},
+		{"slower", healthMetrics{invocations: 1000, errors: 5, durationSum: 150000, durationCount: 1000}, []string{"duration 100ms → 150ms"}},
+		// Above the tolerance, but under a point: noise.
+		{"one more error", healthMetrics{invocations: 1000, errors: 9, durationSum: 100000, durationCount: 1000}, nil},
+		{"no init metrics before", healthMetrics{invocations: 1000, errors: 5, durationSum: 100000, durationCount: 1000, initSum: 9000, initCount: 10}, nil},
+	}
+	for _, tt := range tests {
+		if got := healthRegressions(before, tt.after, 0.25); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: regressions = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHealthWindows(t *testing.T) {
+	started := time.Date(2025, 1, 10, 9, 0, 0, 0, time.UTC)
+	rec := &runReport{StartedAt: started, FinishedAt: started.Add(10 * time.Minute)}
+	now := started.Add(72 * time.Hour)
+
+	bf, bt, af, at := healthWindows(rec, 24*time.Hour, now)
+	if !af.Equal(now.Add(-24*time.Hour)) || !at.Equal(now) || !bt.Equal(started) || !bf.Equal(started.Add(-24*time.Hour)) {
+		t.Errorf("--since 24h: before %s–%s, after %s–%s", bf, bt, af, at)
+	}
+	// Never earlier than the run finished.
+	bf, _, af, _ = healthWindows(rec, 0, started.Add(2*time.Hour))
+	if !af.Equal(rec.FinishedAt) || !bf.Equal(started.Add(-110*time.Minute)) {
+		t.Errorf("--since 0: before from %s, after from %s", bf, af)
+	}
+}
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"update-lambda-runtime/pkg/inventory"
+)
+
+// historyEntry is the runtime distribution of one region of an account, as
+// a full list or bump run saw it. stats --trend reads them back.
+type historyEntry struct {
+	Time      time.Time      `json:"time"`
+	AccountID string         `json:"accountId"`
+	Profile   string         `json:"profile"`
+	Region    string         `json:"region"`
+	Runtimes  map[string]int `json:"runtimes"`
+}
+
+// historyPath is the JSON lines file every run appends its entries to,
+// next to the run records.
+func historyPath() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("locate cache dir: %w", err)
+	}
+	return filepath.Join(base, "update-lambda-runtime", "history.jsonl"), nil
+}
+
+// recordHistory appends a region's distribution to the history: the
+// --history-table shared by every machine and scheduled run when set,
+// else the local file. Only runs that listed every function count: one
+// --function would look like a fleet of one. Failing to write is a
+// warning, never a failed run.
+func recordHistory(ctx context.Context, clients *clientFactory, opts *AWSOpts, accountID, region string, byRuntime map[string]int) {
+	if opts.FunctionName != "" || opts.Offline {
+		return
+	}
+	e := historyEntry{Time: time.Now().UTC(), AccountID: accountID, Profile: opts.Profile, Region: region, Runtimes: byRuntime}
+	var err error
+	if opts.HistoryTable != "" {
+		var h *historyTable
+		if h, err = newHistoryTable(ctx, clients, opts); err == nil {
+			err = h.append(ctx, e)
+		}
+	} else {
+		err = appendHistory(e)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "warning: runtime history not recorded:", err)
+	}
+}
+
+func appendHistory(e historyEntry) error {
+	path, err := historyPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	// One write per line, so concurrent runs append whole lines.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// loadHistory reads every entry recorded since since, oldest first. Lines
+// that do not parse, such as one cut short by a crash, are skipped.
+func loadHistory(since time.Time) ([]historyEntry, error) {
+	path, err := historyPath()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var entries []historyEntry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		var e historyEntry
+		if json.Unmarshal(sc.Bytes(), &e) == nil && !e.Time.Before(since) {
+			entries = append(entries, e)
+		}
+	}
+	slices.SortStableFunc(entries, func(a, b historyEntry) int { return a.Time.Compare(b.Time) })
+	return entries, sc.Err()
+}
+
+// historyTable is the history kept in a DynamoDB table, for runs whose
+// cache directory does not outlive them, such as scheduled ones in Lambda
+// or CI jobs. Items are keyed by the string partition key accountId and
+// the string sort key scanned, the entry's time and region.
+type historyTable struct {
+	cli   dynamoHistoryAPI
+	table string
+}
+
+// dynamoHistoryAPI is the part of the DynamoDB API historyTable calls.
+// *dynamodb.Client implements it; tests substitute a fake.
+type dynamoHistoryAPI interface {
+	PutItem(ctx context.Context, in *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	Scan(ctx context.Context, in *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+}
+
+func newHistoryTable(ctx context.Context, clients *clientFactory, opts *AWSOpts) (*historyTable, error) {
+	var fallback string
+	if len(opts.Regions) > 0 {
+		fallback = opts.Regions[0]
+	}
+	cli, err := dynamoClient(ctx, clients, opts.HistoryTable, fallback)
+	if err != nil {
+		return nil, fmt.Errorf("--history-table: %w", err)
+	}
+	return &historyTable{cli: cli, table: opts.HistoryTable}, nil
+}
+
+func (h *historyTable) append(ctx context.Context, e historyEntry) error {
+	runtimes := make(map[string]ddbtypes.AttributeValue, len(e.Runtimes))
+	for rt, n := range e.Runtimes {
+		runtimes[rt] = &ddbtypes.AttributeValueMemberN{Value: strconv.Itoa(n)}
+	}
+	_, err := h.cli.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(h.table),
+		Item: map[string]ddbtypes.AttributeValue{
+			"accountId": &ddbtypes.AttributeValueMemberS{Value: e.AccountID},
+			"scanned":   &ddbtypes.AttributeValueMemberS{Value: e.Time.Format(time.RFC3339Nano) + "#" + e.Region},
+			"time":      &ddbtypes.AttributeValueMemberS{Value: e.Time.Format(time.RFC3339Nano)},
+			"profile":   &ddbtypes.AttributeValueMemberS{Value: e.Profile},
+			"region":    &ddbtypes.AttributeValueMemberS{Value: e.Region},
+			"runtimes":  &ddbtypes.AttributeValueMemberM{Value: runtimes},
+		},
+	})
+	return err
+}
+
+// load reads every entry recorded since since, oldest first. Items that
+// do not parse are skipped, as loadHistory skips lines.
+func (h *historyTable) load(ctx context.Context, since time.Time) ([]historyEntry, error) {
+	in := &dynamodb.ScanInput{TableName: aws.String(h.table)}
+	if !since.IsZero() {
+		in.FilterExpression = aws.String("#t >= :since")
+		in.ExpressionAttributeNames = map[string]string{"#t": "time"}
+		in.ExpressionAttributeValues = map[string]ddbtypes.AttributeValue{":since": &ddbtypes.AttributeValueMemberS{Value: since.UTC().Format(time.RFC3339Nano)}}
+	}
+	var entries []historyEntry
+	for {
+		out, err := h.cli.Scan(ctx, in)
+		if err != nil {
+			return nil, fmt.Errorf("--history-table: %w", err)
+		}
+		for _, item := range out.Items {
+			if e, ok := historyItem(item); ok && !e.Time.Before(since) {
+				entries = append(entries, e)
+			}
+		}
+		if len(out.LastEvaluatedKey) == 0 {
+			break
+		}
+		in.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+	slices.SortStableFunc(entries, func(a, b historyEntry) int { return a.Time.Compare(b.Time) })
+	return entries, nil
+}
+
+// historyItem is the entry a historyTable item holds.
+func historyItem(item map[string]ddbtypes.AttributeValue) (historyEntry, bool) {
+	str := func(k string) string {
+		v, _ := item[k].(*ddbtypes.AttributeValueMemberS)
+		if v == nil {
+			return ""
+		}
+		return v.Value
+	}
+	t, err := time.Parse(time.RFC3339Nano, str("time"))
+	runtimes, ok := item["runtimes"].(*ddbtypes.AttributeValueMemberM)
+	if err != nil || !ok {
+		return historyEntry{}, false
+	}
+	e := historyEntry{Time: t, AccountID: str("accountId"), Profile: str("profile"), Region: str("region"), Runtimes: make(map[string]int)}
+	for rt, v := range runtimes.Value {
+		if n, ok := v.(*ddbtypes.AttributeValueMemberN); ok {
+			e.Runtimes[rt], _ = strconv.Atoi(n.Value)
+		}
+	}
+	return e, true
+}
+
+// trendPoint is an account's functions by deprecation status at the end of
+// a day on which it was scanned.
+type trendPoint struct {
+	AccountID string
+	Day       string // YYYY-MM-DD, UTC
+	Status    map[string]int
+}
+
+// runtimeTrend folds entries, oldest first, into one point per account and
+// day. A region not scanned that day counts as it was last seen, so
+// scanning regions on different days does not make the fleet jump.
+// Statuses are as of each day, so a runtime passing its deprecation date
+// moves its functions over.
+func runtimeTrend(entries []historyEntry) []trendPoint {
+	type accountState struct {
+		regions map[string]map[string]int
+		last    time.Time
+	}
+	states := make(map[string]*accountState)
+	var points []trendPoint
+	flush := func(acct string) {
+		st := states[acct]
+		status := make(map[string]int)
+		for _, byRuntime := range st.regions {
+			for rt, n := range byRuntime {
+				if rt != "" {
+					status[inventory.DeprecationStatus(rt, st.last)] += n
+				}
+			}
+		}
+		points = append(points, trendPoint{AccountID: acct, Day: st.last.Format(time.DateOnly), Status: status})
+	}
+	for _, e := range entries {
+		st, ok := states[e.AccountID]
+		if !ok {
+			st = &accountState{regions: make(map[string]map[string]int)}
+			states[e.AccountID] = st
+		} else if e.Time.Format(time.DateOnly) != st.last.Format(time.DateOnly) {
+			flush(e.AccountID)
+		}
+		st.regions[e.Region] = e.Runtimes
+		st.last = e.Time
+	}
+	for _, acct := range slices.Sorted(maps.Keys(states)) {
+		flush(acct)
+	}
+	slices.SortStableFunc(points, func(a, b trendPoint) int {
+		return cmp.Or(cmp.Compare(a.AccountID, b.AccountID), cmp.Compare(a.Day, b.Day))
+	})
+	return points
+}
+
+// runStats prints the runtime history, from --history-table when set:
+// with --trend, each account's functions by deprecation status per day
+// scanned, for a burn-down; otherwise each account's latest runtimes.
+func runStats(ctx context.Context, w io.Writer, opts *AWSOpts) error {
+	trend := opts.StatsTrend
+	var from time.Time
+	if opts.StatsSince > 0 {
+		from = time.Now().Add(-opts.StatsSince)
+	}
+	var entries []historyEntry
+	var err error
+	if opts.HistoryTable != "" {
+		if opts.Profile == "" {
+			return fmt.Errorf("--profile is required with --history-table")
+		}
+		var h *historyTable
+		if h, err = newHistoryTable(ctx, newClientFactory(opts.Profile, opts.APITimeout, opts.MaxRPS), opts); err == nil {
+			entries, err = h.load(ctx, from)
+		}
+	} else {
+		entries, err = loadHistory(from)
+	}
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(w, "No runtime history yet: it is recorded by every list and bump run over --all.")
+		return nil
+	}
+	statuses := []string{inventory.Deprecated, inventory.Deprecating, inventory.Supported}
+	if trend {
+		tbl := newTable(w, accountIDWidth, len("2006-01-02"), len("Deprecated"), len("Deprecating"), len("Supported"))
+		tbl.header("AccountID", "Date", "Deprecated", "Deprecating", "Supported", "Total")
+		for _, p := range runtimeTrend(entries) {
+			row := []string{p.AccountID, p.Day}
+			var total int
+			for _, s := range statuses {
+				row = append(row, strconv.Itoa(p.Status[s]))
+				total += p.Status[s]
+			}
+			tbl.row(append(row, strconv.Itoa(total))...)
+		}
+		return nil
+	}
+	latest := make(map[string]map[string]historyEntry) // account → region → entry
+	for _, e := range entries {
+		if latest[e.AccountID] == nil {
+			latest[e.AccountID] = make(map[string]historyEntry)
+		}
+		latest[e.AccountID][e.Region] = e
+	}
+	now := time.Now()
+	tbl := newTable(w, accountIDWidth, runtimeNameWidth, len("Functions"), len("deprecating"))
+	tbl.header("AccountID", "Runtime", "Functions", "Status", "LastScanned")
+	for _, acct := range slices.Sorted(maps.Keys(latest)) {
+		byRuntime := make(map[string]int)
+		var scanned time.Time
+		for _, e := range latest[acct] {
+			for rt, n := range e.Runtimes {
+				byRuntime[rt] += n
+			}
+			if e.Time.After(scanned) {
+				scanned = e.Time
+			}
+		}
+		for _, rt := range slices.Sorted(maps.Keys(byRuntime)) {
+			name, status := rt, inventory.DeprecationStatus(rt, now)
+			if rt == "" {
+				name, status = "N/A", "-"
+			}
+			tbl.row(acct, name, strconv.Itoa(byRuntime[rt]), status, inZone(scanned, time.Local).Format(time.DateTime))
+		}
+	}
+	return nil
+}
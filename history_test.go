@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"maps"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestRuntimeTrend(t *testing.T) {
+	day1 := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+	entries := []historyEntry{
+		{Time: day1, AccountID: "111111111111", Region: "us-east-1", Runtimes: map[string]int{"python3.8": 3}},
+		{Time: day1.Add(time.Hour), AccountID: "111111111111", Region: "eu-west-1", Runtimes: map[string]int{"python3.12": 2, "": 1}},
+		{Time: day1.Add(2 * time.Hour), AccountID: "222222222222", Region: "us-east-1", Runtimes: map[string]int{"nodejs16.x": 4}},
+		// Rescanned twice on day 2; only the last scan counts, and
+		// eu-west-1 is carried over.
+		{Time: day2, AccountID: "111111111111", Region: "us-east-1", Runtimes: map[string]int{"python3.8": 2, "python3.12": 1}},
+		{Time: day2.Add(time.Hour), AccountID: "111111111111", Region: "us-east-1", Runtimes: map[string]int{"python3.8": 1, "python3.12": 2}},
+	}
+	want := []trendPoint{
+		{"111111111111", "2026-03-01", map[string]int{"deprecated": 3, "supported": 2}},
+		{"111111111111", "2026-03-02", map[string]int{"deprecated": 1, "supported": 4}},
+		{"222222222222", "2026-03-01", map[string]int{"deprecated": 4}},
+	}
+	got := runtimeTrend(entries)
+	if len(got) != len(want) {
+		t.Fatalf("got %d points, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i].AccountID != want[i].AccountID || got[i].Day != want[i].Day || !maps.Equal(got[i].Status, want[i].Status) {
+			t.Errorf("point %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+// fakeHistoryTable stores items as PutItem writes them and scans them back
+// one per page, ignoring the filter: load must check since itself too.
+type fakeHistoryTable struct {
+	items []map[string]ddbtypes.AttributeValue
+}
+
+func (f *fakeHistoryTable) PutItem(_ context.Context, in *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	f.items = append(f.items, in.Item)
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (f *fakeHistoryTable) Scan(_ context.Context, in *dynamodb.ScanInput, _ ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	i := 0
+	if in.ExclusiveStartKey != nil {
+		i, _ = strconv.Atoi(in.ExclusiveStartKey["i"].(*ddbtypes.AttributeValueMemberN).Value)
+	}
+	out := &dynamodb.ScanOutput{}
+	if i < len(f.items) {
+		out.Items = f.items[i : i+1]
+	}
+	if i+1 < len(f.items) {
+		out.LastEvaluatedKey = map[string]ddbtypes.AttributeValue{"i": &ddbtypes.AttributeValueMemberN{Value: strconv.Itoa(i + 1)}}
+	}
+	return out, nil
+}
+
+func TestHistoryTable(t *testing.T) {
+	fake := &fakeHistoryTable{}
+	h := &historyTable{cli: fake, table: "runtime-history"}
+	day1 := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	entries := []historyEntry{
+		{Time: day1.Add(48 * time.Hour), AccountID: "111111111111", Profile: "ci", Region: "eu-west-1", Runtimes: map[string]int{"python3.12": 2, "": 1}},
+		{Time: day1, AccountID: "111111111111", Profile: "ci", Region: "us-east-1", Runtimes: map[string]int{"python3.8": 3}},
+		{Time: day1.Add(24 * time.Hour), AccountID: "222222222222", Profile: "scheduled", Region: "us-east-1", Runtimes: map[string]int{"nodejs16.x": 4}},
+	}
+	for _, e := range entries {
+		if err := h.append(context.Background(), e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if key := fake.items[0]["scanned"].(*ddbtypes.AttributeValueMemberS).Value; key != "2026-03-03T09:00:00Z#eu-west-1" {
+		t.Errorf("sort key %q, want time and region", key)
+	}
+
+	got, err := h.load(context.Background(), day1.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || !reflect.DeepEqual(got[0], entries[2]) || !reflect.DeepEqual(got[1], entries[0]) {
+		t.Errorf("loaded %+v, want the two entries since, oldest first", got)
+	}
+}
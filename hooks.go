@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// hookTimeout bounds a single --pre-hook, --ruby-pre-hook or --post-hook
+// run.
+const hookTimeout = 5 * time.Minute
+
+// runHook runs command with sh -c around r's update, passing the function
+// in FUNCTION_NAME, REGION, ACCOUNT_ID, OLD_RUNTIME and NEW_RUNTIME, and in
+// STATUS "pending" before the update or its outcome after it. Output is
+// relayed through log, indented under the function's progress lines. env
+// adds to the environment.
+func runHook(ctx context.Context, log *resultCollector, flag, command string, r functionResult, status string, env ...string) error {
+	ctx, cancel := context.WithTimeout(ctx, hookTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Env = append(os.Environ(),
+		"FUNCTION_NAME="+r.Name,
+		"REGION="+r.Region,
+		"ACCOUNT_ID="+r.AccountID,
+		"OLD_RUNTIME="+r.Runtime,
+		"NEW_RUNTIME="+r.TargetRuntime,
+		"STATUS="+status,
+		"LAST_MODIFIED_BY="+r.LastModifiedBy,
+	)
+	cmd.Env = append(cmd.Env, env...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := cmd.Run()
+	for line := range strings.Lines(out.String()) {
+		log.progressf("  %s %s: %s\n", flag, r.Name, strings.TrimSuffix(line, "\n"))
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", flag, err)
+	}
+	return nil
+}
+
+// runRubyHook runs command before the update of Ruby function j like
+// runHook, with its deployment package unpacked in PACKAGE_DIR, so a check
+// can load or bundle the code under the target Ruby. The package the bump
+// already downloaded to scan is reused.
+func runRubyHook(ctx context.Context, log *resultCollector, command string, j bumpJob) error {
+	zr := j.code
+	if zr == nil {
+		var err error
+		if zr, _, err = functionCode(ctx, j.cli, j.result.Name); err != nil {
+			return fmt.Errorf("ruby-pre-hook: %w", err)
+		}
+	}
+	dir, err := os.MkdirTemp("", "ruby-pre-hook-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	if err := extractPackage(zr, dir); err != nil {
+		return fmt.Errorf("ruby-pre-hook: unpack code: %w", err)
+	}
+	return runHook(ctx, log, "ruby-pre-hook", command, j.result, "pending", "PACKAGE_DIR="+dir)
+}
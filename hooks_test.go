@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestRunHook(t *testing.T) {
+	var out bytes.Buffer
+	log := newResultCollector(&out)
+	r := functionResult{AccountID: "123456789012", Region: "us-east-1", Name: "api", Runtime: "python3.9", TargetRuntime: "python3.12"}
+	err := runHook(context.Background(), log, "post-hook", `echo "$FUNCTION_NAME $REGION $ACCOUNT_ID $OLD_RUNTIME $NEW_RUNTIME $STATUS"`, r, "updated")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "  post-hook api: api us-east-1 123456789012 python3.9 python3.12 updated\n"; out.String() != want {
+		t.Errorf("output %q, want %q", out.String(), want)
+	}
+	if err := runHook(context.Background(), log, "pre-hook", "exit 2", r, "pending"); err == nil {
+		t.Error("want the hook's exit status as an error")
+	}
+}
+
+func TestRunRubyHookReusesPackage(t *testing.T) {
+	var out bytes.Buffer
+	log := newResultCollector(&out)
+	// No client: the package the scan downloaded is the one unpacked.
+	j := bumpJob{
+		result: functionResult{Region: "us-east-1", Name: "api", Runtime: "ruby3.2", TargetRuntime: "ruby3.4"},
+		code:   goPackage(t, "app.rb", []byte("puts 1\n"), 0o644),
+	}
+	if err := runRubyHook(context.Background(), log, `cat "$PACKAGE_DIR/app.rb"`, j); err != nil {
+		t.Fatal(err)
+	}
+	if want := "  ruby-pre-hook api: puts 1\n"; out.String() != want {
+		t.Errorf("output %q, want %q", out.String(), want)
+	}
+}
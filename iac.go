@@ -0,0 +1,253 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+
+	"update-lambda-runtime/pkg/inventory"
+)
+
+// iacExtensions are the files iac-scan reads: SAM and CloudFormation
+// templates, serverless.yml and CDK-synthesized cdk.out/*.template.json.
+var iacExtensions = []string{".yaml", ".yml", ".json", ".template"}
+
+// tfStateExt marks Terraform state files, which iac-scan also reads when
+// it finds them (e.g. a local backend's terraform.tfstate).
+const tfStateExt = ".tfstate"
+
+// iacSkipDirs are never descended into.
+var iacSkipDirs = []string{".git", "node_modules", ".terraform", ".aws-sam", "vendor"}
+
+// iacMaxFile bounds the files iac-scan parses; templates are far smaller.
+const iacMaxFile = 10 << 20
+
+// iacFinding is a runtime declared in an IaC file.
+type iacFinding struct {
+	File      string
+	Line      int
+	Resource  string // dotted path of the mapping holding the declaration, or a Terraform address
+	Function  string // deployed function name, when the IaC names it
+	LogicalID string // CloudFormation logical ID of a function without a FunctionName
+	Region    string // deployed function region, known for Terraform state
+	Runtime   string
+	Status    string
+}
+
+// runIACScan scans paths (files or directories) for Runtime declarations,
+// and the Terraform states of opts.TerraformState for Lambda functions, and
+// prints the ones on runtimes that are deprecated or deprecating. It fails
+// when any declared runtime is already deprecated, so it can gate CI.
+func runIACScan(ctx context.Context, opts *AWSOpts, paths []string, w io.Writer) error {
+	if len(paths) == 0 && len(opts.TerraformState) == 0 {
+		paths = []string{"."}
+	}
+	clients := newClientFactory(opts.Profile, opts.APITimeout, opts.MaxRPS)
+	findings, err := collectIAC(ctx, clients, opts, paths, scanIACFile)
+	if err != nil {
+		return err
+	}
+
+	total, deprecated := len(findings), 0
+	reported := slices.DeleteFunc(findings, func(f iacFinding) bool { return f.Status == inventory.Supported })
+	slices.SortFunc(reported, func(a, b iacFinding) int {
+		return cmp.Or(cmp.Compare(a.File, b.File), cmp.Compare(a.Line, b.Line))
+	})
+	locWidth, resWidth, withFunctions := len("Location"), len("Resource"), false
+	for _, f := range reported {
+		locWidth = max(locWidth, len(f.location()))
+		resWidth = max(resWidth, len(f.Resource))
+		withFunctions = withFunctions || f.Function != ""
+	}
+	tbl := newTable(w, locWidth, resWidth, runtimeNameWidth, len(runtimeUpdateBlocked), len("Deprecation"))
+	cols := []string{"Location", "Resource", "Runtime", "Status", "Deprecation"}
+	if withFunctions {
+		cols = append(cols, "Function")
+	}
+	tbl.header(cols...)
+	for _, f := range reported {
+		date := "-"
+		if d, ok := inventory.DeprecationDate(f.Runtime); ok {
+			date = d.Format(time.DateOnly)
+		}
+		row := []string{f.location(), f.Resource, f.Runtime, f.Status, date}
+		if withFunctions {
+			row = append(row, cmp.Or(f.Function, "-"))
+		}
+		tbl.row(row...)
+		if f.Status != inventory.Deprecating {
+			deprecated++
+		}
+	}
+	fmt.Fprintf(w, "\nSummary: %d runtime declarations, %d deprecated, %d deprecating\n", total, deprecated, len(reported)-deprecated)
+	if deprecated > 0 {
+		return errors.New("deprecated runtimes declared in code")
+	}
+	return nil
+}
+
+// collectIAC scans the Terraform states of opts.TerraformState and the files
+// under paths: *.tfstate files as Terraform state, IaC templates with
+// scanTemplate. Files named in paths must scan cleanly; files found in
+// directories that are not templates (Helm charts, fixtures) are skipped
+// quietly, and unreadable state files with a warning.
+func collectIAC(ctx context.Context, clients *clientFactory, opts *AWSOpts, paths []string, scanTemplate func(name string) ([]iacFinding, error)) ([]iacFinding, error) {
+	var findings []iacFinding
+	scan := func(name string) ([]iacFinding, error) {
+		if filepath.Ext(name) == tfStateExt {
+			return scanTerraformState(ctx, clients, opts.Regions, name)
+		}
+		return scanTemplate(name)
+	}
+	for _, src := range opts.TerraformState {
+		found, err := scanTerraformState(ctx, clients, opts.Regions, src)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", src, err)
+		}
+		findings = append(findings, found...)
+	}
+	for _, root := range paths {
+		info, err := os.Stat(root)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			found, err := scan(root)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", root, err)
+			}
+			findings = append(findings, found...)
+			continue
+		}
+		err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if path != root && slices.Contains(iacSkipDirs, d.Name()) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			ext := filepath.Ext(path)
+			if ext != tfStateExt && !slices.Contains(iacExtensions, ext) {
+				return nil
+			}
+			found, err := scan(path)
+			if err != nil && ext == tfStateExt {
+				fmt.Fprintf(os.Stderr, "warning: %s: %v\n", path, err)
+			}
+			findings = append(findings, found...)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return findings, nil
+}
+
+// location is where f was declared: file and line, or the state file.
+func (f iacFinding) location() string {
+	if f.Line == 0 {
+		return f.File
+	}
+	return f.File + ":" + strconv.Itoa(f.Line)
+}
+
+// scanIACFile returns every runtime declaration in the YAML or JSON
+// documents of name. CloudFormation short-form tags (!Ref, !Sub, ...) are
+// kept as tagged nodes, so templates using them parse too.
+func scanIACFile(name string) ([]iacFinding, error) {
+	var out []iacFinding
+	now := time.Now()
+	err := decodeYAMLDocs(name, func(doc *yaml.Node) error {
+		walkRuntimes(doc, nil, func(path []string, v *yaml.Node) {
+			entry, _ := inventory.Entry(v.Value)
+			entry.Runtime = v.Value
+			out = append(out, iacFinding{
+				File:     name,
+				Line:     v.Line,
+				Resource: strings.Join(path, "."),
+				Runtime:  v.Value,
+				Status:   runtimePhase(entry, now),
+			})
+		})
+		return nil
+	})
+	return out, err
+}
+
+// decodeYAMLDocs calls visit with each YAML (or JSON) document in name.
+func decodeYAMLDocs(name string, visit func(doc *yaml.Node) error) error {
+	info, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+	if info.Size() > iacMaxFile {
+		return fmt.Errorf("larger than %d MiB", iacMaxFile>>20)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	dec := yaml.NewDecoder(f)
+	for {
+		var doc yaml.Node
+		if err := dec.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if err := visit(&doc); err != nil {
+			return err
+		}
+	}
+}
+
+// walkRuntimes calls found for every "Runtime" (CloudFormation, SAM) or
+// "runtime" (serverless.yml) key under n whose value names a runtime
+// family the calendar knows, with the keys leading to its mapping.
+func walkRuntimes(n *yaml.Node, path []string, found func(path []string, v *yaml.Node)) {
+	switch n.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, c := range n.Content {
+			walkRuntimes(c, path, found)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, v := n.Content[i], n.Content[i+1]
+			if (k.Value == "Runtime" || k.Value == "runtime") && v.Kind == yaml.ScalarNode && knownFamily(v.Value) {
+				found(slices.Clone(path), v)
+				continue
+			}
+			walkRuntimes(v, append(path, k.Value), found)
+		}
+	}
+}
+
+// knownFamily reports whether rt looks like a Lambda runtime: it is in
+// the calendar, or is a versioned runtime of a family the calendar knows.
+func knownFamily(rt string) bool {
+	if _, ok := inventory.Entry(rt); ok {
+		return true
+	}
+	family := inventory.Family(rt)
+	return family != rt && slices.ContainsFunc(inventory.Calendar(), func(p inventory.Phases) bool {
+		return inventory.Family(p.Runtime) == family
+	})
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"update-lambda-runtime/pkg/inventory"
+	"update-lambda-runtime/pkg/report"
+)
+
+// importFormat is the format of an inventory file: --format, else its
+// extension, else JSON (which also reads JSON lines).
+func importFormat(path, format string) string {
+	if format != "" {
+		return format
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return report.CSV
+	case ".jsonl", ".ndjson":
+		return report.JSONL
+	}
+	return report.JSON
+}
+
+// runImport loads an inventory exported by report, or made by another tool
+// in the same layout, into the inventory cache, one profile per account,
+// and into the runtime history. list --offline and stats then work from it
+// without credentials; bump still lists the functions live.
+func runImport(opts *AWSOpts, path string, w io.Writer) error {
+	format := importFormat(path, opts.ImportFormat)
+	if format != report.JSON && format != report.JSONL && format != report.CSV {
+		return fmt.Errorf("--format must be %s, %s or %s", report.JSON, report.JSONL, report.CSV)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	inv, err := report.Read(f, format, time.Now())
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	savedAt := inv.GeneratedAt
+	if savedAt.IsZero() {
+		// Nothing in the file says when it was taken.
+		st, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		savedAt = st.ModTime().UTC()
+	}
+
+	var accounts []report.Account
+	for _, a := range inv.Accounts {
+		if len(a.Regions) == 0 {
+			fmt.Fprintf(os.Stderr, "warning: skipping %s: %s\n", cmp.Or(a.AccountID, a.Profile), cmp.Or(a.Error, "no regions"))
+			continue
+		}
+		if opts.Profile == "" && a.Profile == "" {
+			return fmt.Errorf("%s has functions with no profile; give --profile to import them under", path)
+		}
+		accounts = append(accounts, a)
+	}
+	if opts.Profile != "" && len(accounts) > 1 {
+		return fmt.Errorf("%s holds %d accounts; import it without --profile to keep each under its own", path, len(accounts))
+	}
+
+	for _, a := range accounts {
+		profile := cmp.Or(opts.Profile, a.Profile)
+		cache, err := newInventoryCache(profile)
+		if err != nil {
+			return err
+		}
+		var regions []string
+		var total int
+		for _, r := range a.Regions {
+			if r.Error != "" {
+				// Caching part of a region would pass for all of it.
+				fmt.Fprintf(os.Stderr, "warning: skipping %s in %s: it was only partly listed: %s\n", r.Region, profile, r.Error)
+				continue
+			}
+			cw, err := cache.createAt(r.Region, cacheHeader{SavedAt: savedAt, AccountID: a.AccountID})
+			if err != nil {
+				return err
+			}
+			byRuntime := make(map[string]int)
+			for _, rt := range r.Runtimes {
+				for _, name := range rt.Functions {
+					cw.add(inventory.Function{Name: name, Runtime: rt.Runtime})
+				}
+				byRuntime[rt.Runtime] += len(rt.Functions)
+				total += len(rt.Functions)
+			}
+			if err := cw.commit(); err != nil {
+				return fmt.Errorf("write cache for %s in %s: %w", profile, r.Region, err)
+			}
+			if a.AccountID != "" {
+				err := appendHistory(historyEntry{Time: savedAt, AccountID: a.AccountID, Profile: profile, Region: r.Region, Runtimes: byRuntime})
+				if err != nil {
+					fmt.Fprintln(os.Stderr, "warning: runtime history not recorded:", err)
+				}
+			}
+			regions = append(regions, r.Region)
+		}
+		if len(regions) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "Imported %d functions of %s in %s as profile %s, taken %s\n",
+			total, cmp.Or(a.AccountID, "an unknown account"), strings.Join(regions, ", "), profile, inZone(savedAt, time.Local).Format(time.DateTime))
+		fmt.Fprintf(w, "  update-lambda-runtime list --offline --all --profile %s --regions %s\n", profile, strings.Join(regions, ","))
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lamtypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/aws/aws-sdk-go-v2/service/resourceexplorer2"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"update-lambda-runtime/pkg/inventory"
+)
+
+// Inventory sources for --source.
+const (
+	sourceLambda           = "lambda"
+	sourceResourceExplorer = "resource-explorer"
+)
+
+const (
+	// listBatch is how many listed functions list holds back while it
+	// looks up what ListFunctions does not return, for --show-tags and
+	// --show-state.
+	listBatch = 50
+	// listLookups is how many of those lookups a batch makes at once.
+	listLookups = 8
+)
+
+// explorerResultCap is the most resources a single Resource Explorer search
+// returns, however many pages it is read in.
+const explorerResultCap = 1000
+
+// discoverer decides how each region's functions are discovered: by listing
+// them with the Lambda API, or from the names a Resource Explorer search
+// matched.
+type discoverer struct {
+	opts  *AWSOpts
+	found map[string][]string // region → function names; nil for --source lambda
+}
+
+// newDiscoverer prepares discovery for opts. With --source resource-explorer
+// it runs the search up front and, unless --regions narrows it, sets
+// opts.Regions to every region the search found functions in.
+func newDiscoverer(ctx context.Context, clients *clientFactory, opts *AWSOpts) (*discoverer, error) {
+	inv := &discoverer{opts: opts}
+	if opts.Source != sourceResourceExplorer {
+		return inv, nil
+	}
+	region := opts.ExplorerRegion
+	if region == "" {
+		cfg, err := clients.Config(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if region = cfg.Region; region == "" {
+			return nil, fmt.Errorf("--source %s needs --explorer-region or a profile region", sourceResourceExplorer)
+		}
+	}
+	found, err := searchFunctions(ctx, clients, region)
+	if err != nil {
+		return nil, fmt.Errorf("resource explorer: %w", err)
+	}
+	inv.found = found
+	if len(opts.Regions) == 0 {
+		opts.Regions = slices.Sorted(maps.Keys(found))
+		if len(opts.Regions) == 0 {
+			opts.Regions = []string{region}
+		}
+	}
+	return inv, nil
+}
+
+// stream calls visit for every function in region, like inventory.Stream.
+func (inv *discoverer) stream(ctx context.Context, cli *lambda.Client, region string, visit func(inventory.Function)) error {
+	if inv.found == nil {
+		name := inv.opts.FunctionName
+		if q := inv.opts.Qualifier; q != "" {
+			name += ":" + q
+		}
+		return inventory.Stream(ctx, cli, name, visit)
+	}
+	return streamNamed(ctx, cli, region, inv.found[region], visit)
+}
+
+// streamNamed looks up the runtime of each named function in region.
+// Functions deleted since the Resource Explorer index last saw them are
+// skipped.
+func streamNamed(ctx context.Context, cli inventory.LambdaAPI, region string, names []string, visit func(inventory.Function)) (err error) {
+	ctx, span := tracer.Start(ctx, "discover", trace.WithAttributes(semconv.CloudRegion(region)))
+	defer func() {
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	for _, name := range names {
+		fn, err := inventory.Describe(ctx, cli, name)
+		switch {
+		case isNotFound(err):
+			continue
+		case err != nil:
+			return err
+		}
+		visit(fn)
+	}
+	return nil
+}
+
+// isNotFound reports whether err is Lambda saying the function does not
+// exist.
+func isNotFound(err error) bool {
+	var notFound *lamtypes.ResourceNotFoundException
+	return errors.As(err, &notFound)
+}
+
+// searchFunctions finds every Lambda function visible to the default
+// Resource Explorer view in region, grouped by the region it lives in.
+func searchFunctions(ctx context.Context, clients *clientFactory, region string) (map[string][]string, error) {
+	cfg, err := clients.Config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cli := resourceexplorer2.NewFromConfig(cfg, func(o *resourceexplorer2.Options) {
+		o.Region = region
+	})
+
+	found := make(map[string][]string)
+	n := 0
+	p := resourceexplorer2.NewSearchPaginator(cli, &resourceexplorer2.SearchInput{
+		QueryString: aws.String("resourcetype:lambda:function"),
+	})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range page.Resources {
+			a, err := arn.Parse(aws.ToString(r.Arn))
+			if err != nil {
+				continue
+			}
+			// Versions and aliases are indexed as "function:<name>:<qualifier>"
+			// next to the function itself.
+			name, ok := strings.CutPrefix(a.Resource, "function:")
+			if !ok || strings.Contains(name, ":") {
+				continue
+			}
+			found[a.Region] = append(found[a.Region], name)
+			n++
+		}
+	}
+	if n >= explorerResultCap {
+		fmt.Fprintf(os.Stderr, "warning: Resource Explorer returned its maximum of %d functions; some may be missing, use --source lambda\n", explorerResultCap)
+	}
+	return found, nil
+}
+
+// fillStates sets the State and LastUpdateStatus of every result in rs, all
+// from region, that lacks them, looking them up listLookups at a time. One
+// that cannot be looked up is left blank with a warning.
+func fillStates(ctx context.Context, clients *clientFactory, region string, rs []functionResult) error {
+	cli, err := clients.Lambda(ctx, region)
+	if err != nil {
+		return err
+	}
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, listLookups)
+	for i := range rs {
+		if rs[i].State != "" {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			fn, err := inventory.Describe(ctx, cli, rs[i].Name)
+			if err != nil {
+				if ctx.Err() == nil {
+					fmt.Fprintf(os.Stderr, "warning: state of %s: %v\n", rs[i].Name, err)
+				}
+				return
+			}
+			rs[i].State, rs[i].LastUpdateStatus = fn.State, fn.LastUpdateStatus
+		}()
+	}
+	wg.Wait()
+	return nil
+}
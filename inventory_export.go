@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"update-lambda-runtime/pkg/report"
+)
+
+// exportInventory uploads rep as JSON lines under dest (s3://bucket/prefix),
+// partitioned by day in the Hive layout Glue and Athena expect:
+// <prefix>/dt=2024-05-01/inventory-20240501T120000Z.json. Each run adds a
+// file, so the prefix keeps the inventory's history.
+func exportInventory(ctx context.Context, opts *AWSOpts, rep *report.Inventory) error {
+	loc, ok := strings.CutPrefix(opts.ReportExport, "s3://")
+	bucket, prefix, _ := strings.Cut(loc, "/")
+	if !ok || bucket == "" {
+		return errors.New("--s3-export: want s3://bucket/prefix")
+	}
+	var body bytes.Buffer
+	if err := report.WriteJSONL(&body, rep); err != nil {
+		return err
+	}
+	clients := newClientFactory(opts.Profile, opts.APITimeout, opts.MaxRPS)
+	cli, err := newS3Client(ctx, clients, opts.Regions)
+	if err != nil {
+		return err
+	}
+	key := path.Join(prefix, "dt="+rep.GeneratedAt.Format("2006-01-02"), "inventory-"+rep.GeneratedAt.Format("20060102T150405Z")+".json")
+	_, err = cli.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(body.Bytes()),
+		ContentType: aws.String("application/x-ndjson"),
+	})
+	if err != nil {
+		return fmt.Errorf("--s3-export: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Exported inventory to s3://%s/%s\n", bucket, key)
+	return nil
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"update-lambda-runtime/pkg/report"
+)
+
+// runInventoryReport sweeps every profile across opts.Regions and writes
+// the inventory document.
+func runInventoryReport(ctx context.Context, opts *AWSOpts, w io.Writer) error {
+	if len(opts.Regions) == 0 {
+		return fmt.Errorf("--regions is required")
+	}
+	if !slices.Contains(report.Formats, opts.ReportFormat) {
+		return fmt.Errorf("--format must be %s, %s, %s, %s or %s", report.JSON, report.JSONL, report.CSV, report.HTML, report.CycloneDX)
+	}
+	if opts.ReportExport != "" && !strings.HasPrefix(opts.ReportExport, "s3://") {
+		return fmt.Errorf("--s3-export: want s3://bucket/prefix")
+	}
+	if err := validateRedact(opts.Redact); err != nil {
+		return err
+	}
+	ctx, span := tracer.Start(ctx, "report", runAttrs(opts))
+	defer span.End()
+	rep, err := sweepInventory(ctx, opts)
+	if err != nil {
+		return err
+	}
+	newRedactor(opts.Redact).inventory(rep)
+
+	if opts.ReportExport != "" {
+		if err := exportInventory(ctx, opts, rep); err != nil {
+			return err
+		}
+	}
+	if opts.ReportFormat == report.CSV || opts.ReportFormat == report.HTML {
+		rep.GeneratedAt = inZone(rep.GeneratedAt, time.UTC)
+	}
+	return report.Write(w, rep, opts.ReportFormat)
+}
+
+// sweepInventory scans opts.Regions of every profile sweptProfiles picks.
+// A profile or region that cannot be read is recorded in the inventory and
+// the sweep goes on; profiles resolving to an account already swept are
+// skipped.
+func sweepInventory(ctx context.Context, opts *AWSOpts) (*report.Inventory, error) {
+	profiles, err := sweptProfiles(opts)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	rep := report.New(opts.Regions, now)
+	seen := make(map[string]string) // account ID → first profile
+	for _, profile := range profiles {
+		fmt.Fprintf(os.Stderr, "Sweeping %s...\n", profile)
+		clients := newClientFactory(profile, opts.APITimeout, opts.MaxRPS)
+		acct := report.Account{Profile: profile}
+		id, err := resolveAccountID(ctx, clients)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %s: %v\n", profile, err)
+			acct.Error = err.Error()
+			rep.Add(acct)
+			continue
+		}
+		if first, ok := seen[id]; ok {
+			fmt.Fprintf(os.Stderr, "Skipping %s: account %s already swept with %s\n", profile, id, first)
+			continue
+		}
+		seen[id] = profile
+		acct.AccountID = id
+
+		for _, region := range opts.Regions {
+			ri := report.Region{Region: region}
+			cli, err := clients.Lambda(ctx, region)
+			if err == nil {
+				ri, err = report.ScanRegion(ctx, cli, region, now)
+			}
+			if ctx.Err() != nil {
+				return nil, stopped(ctx)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "warning: %s %s: %v\n", profile, region, err)
+				ri.Error = err.Error()
+			}
+			acct.Regions = append(acct.Regions, ri)
+		}
+		rep.Add(acct)
+	}
+	rep.Sort()
+	return rep, nil
+}
+
+// sweptProfiles is --profiles, else --profile, else every profile in the
+// shared config.
+func sweptProfiles(opts *AWSOpts) ([]string, error) {
+	if len(opts.ReportProfiles) > 0 {
+		return opts.ReportProfiles, nil
+	}
+	if opts.Profile != "" {
+		return []string{opts.Profile}, nil
+	}
+	profiles, err := sharedProfiles()
+	if err != nil {
+		return nil, err
+	}
+	if len(profiles) == 0 {
+		return nil, fmt.Errorf("no profiles configured; pass --profiles")
+	}
+	return profiles, nil
+}
+
+// sharedConfigFiles returns the paths of the shared config and
+// credentials files, honouring AWS_CONFIG_FILE and
+// AWS_SHARED_CREDENTIALS_FILE like the SDK does.
+func sharedConfigFiles() (configFile, credentialsFile string, err error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", "", err
+	}
+	return cmp.Or(os.Getenv("AWS_CONFIG_FILE"), filepath.Join(home, ".aws", "config")),
+		cmp.Or(os.Getenv("AWS_SHARED_CREDENTIALS_FILE"), filepath.Join(home, ".aws", "credentials")), nil
+}
+
+// sharedProfiles lists the profiles defined in the shared config and
+// credentials files.
+func sharedProfiles() ([]string, error) {
+	configFile, credentialsFile, err := sharedConfigFiles()
+	if err != nil {
+		return nil, err
+	}
+	files := []struct {
+		path   string
+		prefix string // config sections other than default are "[profile name]"
+	}{
+		{configFile, "profile "},
+		{credentialsFile, ""},
+	}
+	found := make(map[string]bool)
+	for _, f := range files {
+		fh, err := os.Open(f.path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		sc := bufio.NewScanner(fh)
+		for sc.Scan() {
+			line := strings.TrimSpace(sc.Text())
+			section, ok := strings.CutPrefix(line, "[")
+			if !ok {
+				continue
+			}
+			section, ok = strings.CutSuffix(section, "]")
+			if !ok {
+				continue
+			}
+			section = strings.TrimSpace(section)
+			if f.prefix != "" && section != "default" {
+				if section, ok = strings.CutPrefix(section, f.prefix); !ok {
+					continue // sso-session and services sections
+				}
+			}
+			found[strings.TrimSpace(section)] = true
+		}
+		fh.Close()
+		if err := sc.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return slices.Sorted(maps.Keys(found)), nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lamtypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+
+	"update-lambda-runtime/pkg/inventory"
+)
+
+// fakeLambda answers GetFunctionConfiguration from runtimes, with errs
+// taking precedence.
+type fakeLambda struct {
+	runtimes map[string]lamtypes.Runtime
+	errs     map[string]error
+}
+
+func (f fakeLambda) ListFunctions(context.Context, *lambda.ListFunctionsInput, ...func(*lambda.Options)) (*lambda.ListFunctionsOutput, error) {
+	return nil, errors.New("unexpected ListFunctions")
+}
+
+func (f fakeLambda) GetFunctionConfiguration(ctx context.Context, in *lambda.GetFunctionConfigurationInput, _ ...func(*lambda.Options)) (*lambda.GetFunctionConfigurationOutput, error) {
+	name := aws.ToString(in.FunctionName)
+	if err := f.errs[name]; err != nil {
+		return nil, err
+	}
+	rt, ok := f.runtimes[name]
+	if !ok {
+		return nil, &lamtypes.ResourceNotFoundException{Message: aws.String("Function not found: " + name)}
+	}
+	return &lambda.GetFunctionConfigurationOutput{FunctionName: in.FunctionName, Runtime: rt}, nil
+}
+
+func TestStreamNamedSkipsDeleted(t *testing.T) {
+	cli := fakeLambda{runtimes: map[string]lamtypes.Runtime{"a": lamtypes.RuntimePython39, "c": lamtypes.RuntimeNodejs20x}}
+	var got []string
+	err := streamNamed(context.Background(), cli, "us-east-1", []string{"a", "deleted", "c"}, func(f inventory.Function) {
+		got = append(got, f.Name+"="+f.Runtime)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a=python3.9", "c=nodejs20.x"}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestStreamNamedStopsOnError(t *testing.T) {
+	cli := fakeLambda{
+		runtimes: map[string]lamtypes.Runtime{"a": lamtypes.RuntimePython39, "c": lamtypes.RuntimeNodejs20x},
+		errs:     map[string]error{"b": errors.New("AccessDenied")},
+	}
+	var got []string
+	err := streamNamed(context.Background(), cli, "us-east-1", []string{"a", "b", "c"}, func(f inventory.Function) {
+		got = append(got, f.Name)
+	})
+	if err == nil || !slices.Equal(got, []string{"a"}) {
+		t.Errorf("visited %v, err %v; want a then the AccessDenied error", got, err)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errKillSwitch is returned by a bump the --kill-switch parameter stopped,
+// or kept from starting.
+var errKillSwitch = fmt.Errorf("%w: --kill-switch is off", errInterrupted)
+
+// killSwitch is an SSM parameter platform owners set to false to stop
+// every scheduled or automated bump reading it: no run starts while it is
+// off, and a run in progress issues no new updates once it is turned off.
+type killSwitch struct {
+	name  string // the parameter's name
+	every time.Duration
+	read  func(ctx context.Context) (string, error)
+}
+
+func validateKillSwitch(opts *AWSOpts) error {
+	if opts.KillSwitch == "" {
+		return nil
+	}
+	if name, ok := strings.CutPrefix(opts.KillSwitch, ssmScheme); !ok || name == "" {
+		return fmt.Errorf("--kill-switch must be %s<parameter name>, e.g. %s/lambda-bump/enabled", ssmScheme, ssmScheme)
+	}
+	if opts.KillSwitchInterval <= 0 {
+		return errors.New("--kill-switch-interval must be positive")
+	}
+	return nil
+}
+
+// newKillSwitch reads the parameter with the profile's own credentials,
+// so one parameter governs runs into every account of --accounts-file.
+func newKillSwitch(opts *AWSOpts) *killSwitch {
+	if opts.KillSwitch == "" {
+		return nil
+	}
+	name := strings.TrimPrefix(opts.KillSwitch, ssmScheme)
+	clients := newClientFactory(opts.Profile, opts.APITimeout, opts.MaxRPS)
+	return &killSwitch{
+		name:  name,
+		every: opts.KillSwitchInterval,
+		read: func(ctx context.Context) (string, error) {
+			return readParameter(ctx, clients, name, opts.Regions)
+		},
+	}
+}
+
+// on reports whether the switch allows updates. Any value other than a
+// true one (true, 1, t in any case) turns it off, so a mistyped value
+// stops runs rather than being ignored.
+func (k *killSwitch) on(ctx context.Context) (bool, string, error) {
+	v, err := k.read(ctx)
+	if err != nil {
+		return false, "", fmt.Errorf("--kill-switch %s: %w", k.name, err)
+	}
+	on, err := strconv.ParseBool(strings.TrimSpace(v))
+	return on && err == nil, v, nil
+}
+
+// arm checks the switch before a run, then watches it for as long as the
+// returned context lives, cancelling it with errKillSwitch once the switch
+// is turned off. Updates already issued are still waited on, through
+// waitContext. A switch that cannot be read before the run fails it; one
+// that cannot be read during it is warned about and checked again later.
+func (k *killSwitch) arm(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	if k == nil {
+		return ctx, func() {}, nil
+	}
+	switch on, v, err := k.on(ctx); {
+	case err != nil:
+		return nil, nil, err
+	case !on:
+		return nil, nil, fmt.Errorf("%w: %s is %q", errKillSwitch, k.name, v)
+	}
+	run, cancel := context.WithCancelCause(ctx)
+	if _, ok := ctx.Value(waitContextKey{}).(context.Context); !ok {
+		run = context.WithValue(run, waitContextKey{}, ctx)
+	}
+	go func() {
+		t := time.NewTicker(k.every)
+		defer t.Stop()
+		for {
+			select {
+			case <-run.Done():
+				return
+			case <-t.C:
+			}
+			switch on, v, err := k.on(run); {
+			case err != nil && run.Err() == nil:
+				fmt.Fprintf(os.Stderr, "warning: %v; checking again in %s\n", err, k.every)
+			case err == nil && !on:
+				cancel(fmt.Errorf("%w: %s is %q", errKillSwitch, k.name, v))
+				return
+			}
+		}
+	}()
+	return run, func() { cancel(context.Canceled) }, nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestKillSwitch(t *testing.T) {
+	var mu sync.Mutex
+	values := []string{"true", "TRUE"} // then whatever value is last
+	var readErr error
+	k := &killSwitch{name: "/lambda-bump/enabled", every: time.Millisecond, read: func(context.Context) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		v := values[0]
+		if len(values) > 1 {
+			values = values[1:]
+		}
+		return v, readErr
+	}}
+
+	ctx, disarm, err := k.arm(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer disarm()
+	waits, stopWaits := waitContext(ctx)
+	defer stopWaits()
+	mu.Lock()
+	values = []string{"false"}
+	mu.Unlock()
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("run not stopped after the switch turned off")
+	}
+	if err := stopped(ctx); !errors.Is(err, errKillSwitch) || !errors.Is(err, errInterrupted) {
+		t.Errorf("stopped: %v, want errKillSwitch", err)
+	}
+	if waits.Err() != nil {
+		t.Error("waits for updates in flight cancelled with the run")
+	}
+
+	for _, v := range []string{"false", "0", "yes"} {
+		values = []string{v}
+		if _, _, err := k.arm(context.Background()); !errors.Is(err, errKillSwitch) {
+			t.Errorf("switch %q: %v, want the run refused", v, err)
+		}
+	}
+	values, readErr = []string{""}, errors.New("ParameterNotFound")
+	if _, _, err := k.arm(context.Background()); err == nil || errors.Is(err, errKillSwitch) {
+		t.Errorf("unreadable switch: %v, want the read error", err)
+	}
+}
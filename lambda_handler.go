@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	lambdart "github.com/aws/aws-lambda-go/lambda"
+)
+
+// envArgsParam names the SSM parameter holding the command a deployed
+// function runs on each invocation; deploy-schedule sets it.
+const envArgsParam = "UPDATE_LAMBDA_RUNTIME_ARGS_PARAM"
+
+// scheduledArgs is the value of the envArgsParam parameter.
+type scheduledArgs struct {
+	Args []string `json:"args"`
+}
+
+// startLambda serves Lambda invocations when the binary runs as a function.
+// Each invocation re-reads its arguments from SSM, so the schedule's
+// command can be changed without redeploying, and runs them on a fresh
+// command tree: flag values must not leak between warm invocations.
+// Invoked by an SQS trigger instead, it works the batch as a bump worker,
+// configured by ULR_* environment variables.
+func startLambda(ctx context.Context) {
+	lambdart.StartWithOptions(func(ctx context.Context, payload json.RawMessage) (any, error) {
+		var batch events.SQSEvent
+		if json.Unmarshal(payload, &batch) == nil && len(batch.Records) > 0 && batch.Records[0].EventSource == "aws:sqs" {
+			return runWorkerBatch(ctx, batch.Records)
+		}
+		args, err := loadScheduledArgs(ctx)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(os.Stderr, "Running: %s\n", strings.Join(args, " "))
+		cmd := newRootCmd()
+		cmd.SetArgs(args)
+		cmd.SilenceUsage = true
+		if err := execute(ctx, cmd); err != nil {
+			return "", err
+		}
+		return "ok", nil
+	}, lambdart.WithContext(ctx))
+}
+
+// runWorkerBatch runs the worker command on one SQS batch, reporting the
+// messages it could not finish so only those are retried (the trigger
+// needs ReportBatchItemFailures).
+func runWorkerBatch(ctx context.Context, records []events.SQSMessage) (events.SQSEventResponse, error) {
+	b := &lambdaBatch{records: records}
+	cmd := newRootCmd()
+	cmd.SetArgs([]string{"worker", "--profile=" + lambdaProfile})
+	cmd.SilenceUsage = true
+	if err := execute(context.WithValue(ctx, lambdaBatchKey{}, b), cmd); err != nil {
+		return events.SQSEventResponse{}, err
+	}
+	return events.SQSEventResponse{BatchItemFailures: b.failures}, nil
+}
+
+func loadScheduledArgs(ctx context.Context) ([]string, error) {
+	name := os.Getenv(envArgsParam)
+	if name == "" {
+		return nil, fmt.Errorf("%s is not set", envArgsParam)
+	}
+	// Credentials come from the execution role; see clientFactory.config.
+	clients := newClientFactory(lambdaProfile, 0, 0)
+	value, err := readParameter(ctx, clients, name, nil)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", name, err)
+	}
+	var sa scheduledArgs
+	if err := json.Unmarshal([]byte(value), &sa); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", name, err)
+	}
+	if len(sa.Args) == 0 {
+		return nil, fmt.Errorf("%s has no args", name)
+	}
+	args := slices.Clone(sa.Args)
+	if !slices.ContainsFunc(args, func(a string) bool { return a == "--profile" || strings.HasPrefix(a, "--profile=") }) {
+		args = append(args, "--profile="+lambdaProfile)
+	}
+	return args, nil
+}
+
+// lambdaProfile is the profile name runs inside Lambda report under.
+const lambdaProfile = "default"
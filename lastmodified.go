@@ -0,0 +1,117 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudtrail"
+	cttypes "github.com/aws/aws-sdk-go-v2/service/cloudtrail/types"
+)
+
+// lastModifiedPages bounds the CloudTrail lookup per function. Reads such
+// as GetFunction are recorded against the function too, and a busy one can
+// have thousands; LookupEvents allows only two calls a second.
+const lastModifiedPages = 4
+
+// lastModification is the most recent change to a function's code or
+// configuration that CloudTrail recorded.
+type lastModification struct {
+	Principal string
+	Event     string
+	Time      time.Time
+}
+
+// lastModifiedLookup finds who last changed a function, for --last-modified-by.
+// CloudTrail clients are made per region on first use.
+type lastModifiedLookup struct {
+	clients *clientFactory
+
+	mu   sync.Mutex
+	clis map[string]*cloudtrail.Client
+}
+
+func newLastModifiedLookup(clients *clientFactory) *lastModifiedLookup {
+	return &lastModifiedLookup{clients: clients, clis: make(map[string]*cloudtrail.Client)}
+}
+
+func (l *lastModifiedLookup) client(ctx context.Context, region string) (*cloudtrail.Client, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if cli, ok := l.clis[region]; ok {
+		return cli, nil
+	}
+	cfg, err := l.clients.Config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cli := cloudtrail.NewFromConfig(cfg, func(o *cloudtrail.Options) {
+		o.Region = region
+	})
+	l.clis[region] = cli
+	return cli, nil
+}
+
+// lookup returns the last UpdateFunctionCode or UpdateFunctionConfiguration
+// of the function name in region within CloudTrail's 90 days of event
+// history, or nil when there is none.
+func (l *lastModifiedLookup) lookup(ctx context.Context, region, name string) (*lastModification, error) {
+	cli, err := l.client(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+	p := cloudtrail.NewLookupEventsPaginator(cli, &cloudtrail.LookupEventsInput{
+		LookupAttributes: []cttypes.LookupAttribute{{
+			AttributeKey:   cttypes.LookupAttributeKeyResourceName,
+			AttributeValue: aws.String(name),
+		}},
+		MaxResults: aws.Int32(50),
+	})
+	// Events come newest first.
+	for range lastModifiedPages {
+		if !p.HasMorePages() {
+			break
+		}
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range page.Events {
+			if isFunctionUpdate(aws.ToString(e.EventName)) {
+				return &lastModification{Principal: eventPrincipal(e), Event: aws.ToString(e.EventName), Time: aws.ToTime(e.EventTime)}, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// isFunctionUpdate reports whether a CloudTrail event name is a code or
+// configuration update. Lambda records them with the API version appended,
+// as in UpdateFunctionCode20150331v2.
+func isFunctionUpdate(event string) bool {
+	return strings.HasPrefix(event, "UpdateFunctionCode") || strings.HasPrefix(event, "UpdateFunctionConfiguration")
+}
+
+// eventPrincipal names who made e: the ARN of its user identity, which for
+// an assumed role includes the session name, or the event's user name when
+// the record has none.
+func eventPrincipal(e cttypes.Event) string {
+	var rec struct {
+		UserIdentity struct {
+			ARN string `json:"arn"`
+		} `json:"userIdentity"`
+	}
+	if err := json.Unmarshal([]byte(aws.ToString(e.CloudTrailEvent)), &rec); err == nil && rec.UserIdentity.ARN != "" {
+		return rec.UserIdentity.ARN
+	}
+	return cmp.Or(aws.ToString(e.Username), "unknown")
+}
+
+func (m *lastModification) String() string {
+	return fmt.Sprintf("%s at %s (%s)", m.Principal, inZone(m.Time, time.UTC).Format(time.RFC3339), m.Event)
+}
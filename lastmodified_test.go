@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cttypes "github.com/aws/aws-sdk-go-v2/service/cloudtrail/types"
+)
+
+func TestIsFunctionUpdate(t *testing.T) {
+	for event, want := range map[string]bool{
+		"UpdateFunctionCode20150331v2":          true,
+		"UpdateFunctionConfiguration20150331v2": true,
+		"UpdateFunctionUrlConfig":               false,
+		"GetFunction":                           false,
+	} {
+		if got := isFunctionUpdate(event); got != want {
+			t.Errorf("isFunctionUpdate(%s) = %v, want %v", event, got, want)
+		}
+	}
+}
+
+func TestEventPrincipal(t *testing.T) {
+	tests := []struct {
+		record, username, want string
+	}{
+		{`{"userIdentity":{"type":"AssumedRole","arn":"arn:aws:sts::123456789012:assumed-role/deployer/alice"}}`, "alice", "arn:aws:sts::123456789012:assumed-role/deployer/alice"},
+		{`{"userIdentity":{"type":"AWSService"}}`, "cloudformation.amazonaws.com", "cloudformation.amazonaws.com"},
+		{"", "", "unknown"},
+	}
+	for _, tt := range tests {
+		e := cttypes.Event{CloudTrailEvent: aws.String(tt.record), Username: aws.String(tt.username)}
+		if got := eventPrincipal(e); got != tt.want {
+			t.Errorf("eventPrincipal(%s) = %q, want %q", tt.record, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lamtypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+
+	"update-lambda-runtime/pkg/inventory"
+)
+
+// layerProbeMax caps the GetLayerVersion calls spent finding a layer's
+// latest version when its publisher does not allow listing them.
+const layerProbeMax = 40
+
+// knownLayer is a family of layers a vendor publishes from one account.
+// Some are one layer for every runtime, others one per runtime version,
+// such as Datadog-Python39 and Datadog-Python312; rename gives the layer
+// name for target from the pattern's match, or false when the family has
+// no layer for it.
+type knownLayer struct {
+	vendor  string
+	account string
+	pattern *regexp.Regexp
+	rename  func(m []string, target string) (string, bool)
+}
+
+// sameLayer is the rename of a family with one layer for every runtime.
+func sameLayer(m []string, _ string) (string, bool) { return m[0], true }
+
+// runtimeDigits is the version of a runtime as vendors write it in layer
+// names: 312 for python3.12, 20 for nodejs20.x.
+func runtimeDigits(rt string) string {
+	var b strings.Builder
+	for _, n := range inventory.Version(rt) {
+		b.WriteString(strconv.Itoa(n))
+	}
+	return b.String()
+}
+
+// knownLayers are the layers --resolve-layers recognizes.
+var knownLayers = []knownLayer{
+	{"Powertools for AWS Lambda (Python)", "017000801446", regexp.MustCompile(`^AWSLambdaPowertoolsPythonV2(-Arm64)?$`), sameLayer},
+	{"Powertools for AWS Lambda (Python)", "017000801446", regexp.MustCompile(`^AWSLambdaPowertoolsPythonV3-python\d+-(x86_64|arm64)$`), func(m []string, target string) (string, bool) {
+		return fmt.Sprintf("AWSLambdaPowertoolsPythonV3-python%s-%s", runtimeDigits(target), m[1]), inventory.Family(target) == "python"
+	}},
+	{"Powertools for AWS Lambda (TypeScript)", "094274105915", regexp.MustCompile(`^AWSLambdaPowertoolsTypeScript(V2)?$`), sameLayer},
+	{"Lambda Insights", "580247275435", regexp.MustCompile(`^LambdaInsightsExtension(-Arm64)?$`), sameLayer},
+	{"Datadog", "464622532012", regexp.MustCompile(`^Datadog-Extension(-ARM)?$`), sameLayer},
+	{"Datadog", "464622532012", regexp.MustCompile(`^Datadog-Python\d+(-ARM)?$`), func(m []string, target string) (string, bool) {
+		return "Datadog-Python" + runtimeDigits(target) + m[1], inventory.Family(target) == "python"
+	}},
+	{"Datadog", "464622532012", regexp.MustCompile(`^Datadog-Node\d+-x$`), func(m []string, target string) (string, bool) {
+		return "Datadog-Node" + runtimeDigits(target) + "-x", inventory.Family(target) == "nodejs"
+	}},
+	{"Sentry", "943013980633", regexp.MustCompile(`^Sentry(Python|Node)ServerlessSDK\w*$`), sameLayer},
+}
+
+// layerAPI is the part of the Lambda API layer resolution uses.
+type layerAPI interface {
+	ListLayerVersions(ctx context.Context, in *lambda.ListLayerVersionsInput, optFns ...func(*lambda.Options)) (*lambda.ListLayerVersionsOutput, error)
+	GetLayerVersionByArn(ctx context.Context, in *lambda.GetLayerVersionByArnInput, optFns ...func(*lambda.Options)) (*lambda.GetLayerVersionByArnOutput, error)
+}
+
+// layerResolver swaps known layers for their latest version built for a
+// function's target runtime. Resolutions are shared by every function of
+// the run.
+type layerResolver struct {
+	mu       sync.Mutex
+	resolved map[string]layerResolution // by layer name ARN, target runtime and architecture
+}
+
+type layerResolution struct {
+	arn string // layer version ARN, "" when there is none to swap to
+	err error
+}
+
+func newLayerResolver() *layerResolver {
+	return &layerResolver{resolved: make(map[string]layerResolution)}
+}
+
+// layerSwap is a layer resolve replaced.
+type layerSwap struct {
+	vendor, from, to string
+}
+
+// resolve returns a function's layers with every known layer replaced by
+// the latest version built for target and arch, the swaps made, and the
+// problems resolving any of them, which leave those layers as they are.
+// mapped is what the layer map made of layers, or nil; the layers it
+// swapped are left to it.
+func (lr *layerResolver) resolve(ctx context.Context, cli layerAPI, layers, mapped []string, target, arch string) ([]string, []layerSwap, error) {
+	out := slices.Clone(layers)
+	if mapped != nil {
+		out = slices.Clone(mapped)
+	}
+	var swaps []layerSwap
+	var errs []error
+	for i, l := range layers {
+		if out[i] != l {
+			continue
+		}
+		a, err := arn.Parse(l)
+		parts := strings.Split(a.Resource, ":")
+		if err != nil || len(parts) != 3 || parts[0] != "layer" {
+			continue
+		}
+		for _, k := range knownLayers {
+			m := k.pattern.FindStringSubmatch(parts[1])
+			if a.AccountID != k.account || m == nil {
+				continue
+			}
+			name, ok := k.rename(m, target)
+			if !ok {
+				break
+			}
+			a.Resource = "layer:" + name
+			current, _ := strconv.ParseInt(parts[2], 10, 64)
+			if name != parts[1] {
+				current = 0 // another layer, whose versions are numbered afresh
+			}
+			res := lr.latest(ctx, cli, a.String(), current, target, arch)
+			switch {
+			case res.err != nil:
+				errs = append(errs, fmt.Errorf("%s layer %s: %w", k.vendor, l, res.err))
+			case res.arn != "" && res.arn != l:
+				out[i] = res.arn
+				swaps = append(swaps, layerSwap{k.vendor, l, res.arn})
+			}
+			break
+		}
+	}
+	return out, swaps, errors.Join(errs...)
+}
+
+// latest finds the newest version of the layer named by nameARN that
+// declares target and arch, or declares none; from is a version known to
+// exist, or 0.
+func (lr *layerResolver) latest(ctx context.Context, cli layerAPI, nameARN string, from int64, target, arch string) layerResolution {
+	key := nameARN + "|" + target + "|" + arch
+	lr.mu.Lock()
+	res, ok := lr.resolved[key]
+	lr.mu.Unlock()
+	if ok {
+		return res
+	}
+	res = findLatestLayer(ctx, cli, nameARN, from, target, arch)
+	if ctx.Err() == nil {
+		lr.mu.Lock()
+		lr.resolved[key] = res
+		lr.mu.Unlock()
+	}
+	return res
+}
+
+func findLatestLayer(ctx context.Context, cli layerAPI, nameARN string, from int64, target, arch string) layerResolution {
+	in := &lambda.ListLayerVersionsInput{LayerName: aws.String(nameARN), CompatibleRuntime: lamtypes.Runtime(target)}
+	if arch != "" {
+		in.CompatibleArchitecture = lamtypes.Architecture(arch)
+	}
+	listed, err := cli.ListLayerVersions(ctx, in)
+	if err == nil {
+		if len(listed.LayerVersions) == 0 {
+			return layerResolution{err: fmt.Errorf("no version of %s supports %s", nameARN, target)}
+		}
+		// Versions are listed newest first.
+		return layerResolution{arn: aws.ToString(listed.LayerVersions[0].LayerVersionArn)}
+	}
+	// Vendors let anyone get their layer versions, but seldom list them:
+	// find the newest by trying version numbers past from.
+	var probes int
+	get := func(v int64) (*lambda.GetLayerVersionByArnOutput, error) {
+		probes++
+		out, err := cli.GetLayerVersionByArn(ctx, &lambda.GetLayerVersionByArnInput{Arn: aws.String(fmt.Sprintf("%s:%d", nameARN, v))})
+		var missing *lamtypes.ResourceNotFoundException
+		if errors.As(err, &missing) {
+			return nil, nil
+		}
+		return out, err
+	}
+	var newest *lambda.GetLayerVersionByArnOutput
+	lo, step := max(from-1, 0), int64(1)
+	hi := lo + step
+	for probes < layerProbeMax {
+		out, err := get(hi)
+		if err != nil {
+			return layerResolution{err: err}
+		}
+		if out == nil {
+			break
+		}
+		newest, lo = out, hi
+		step *= 2
+		hi = lo + step
+	}
+	for hi-lo > 1 && probes < layerProbeMax {
+		mid := lo + (hi-lo)/2
+		out, err := get(mid)
+		if err != nil {
+			return layerResolution{err: err}
+		}
+		if out == nil {
+			hi = mid
+		} else {
+			newest, lo = out, mid
+		}
+	}
+	switch {
+	case newest == nil:
+		return layerResolution{err: fmt.Errorf("no version of %s found", nameARN)}
+	case len(newest.CompatibleRuntimes) > 0 && !slices.Contains(newest.CompatibleRuntimes, lamtypes.Runtime(target)):
+		return layerResolution{err: fmt.Errorf("%s declares %s, not %s", aws.ToString(newest.LayerVersionArn), joinRuntimes(newest.CompatibleRuntimes), target)}
+	case arch != "" && len(newest.CompatibleArchitectures) > 0 && !slices.Contains(newest.CompatibleArchitectures, lamtypes.Architecture(arch)):
+		return layerResolution{err: fmt.Errorf("%s does not support %s", aws.ToString(newest.LayerVersionArn), arch)}
+	}
+	return layerResolution{arn: aws.ToString(newest.LayerVersionArn)}
+}
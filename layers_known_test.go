@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lamtypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+// fakeLayers publishes layers as vendors do: listable only when listed
+// says so, each version gettable by anyone.
+type fakeLayers struct {
+	newest   map[string]int64 // layer name ARN → newest version
+	runtimes map[string][]lamtypes.Runtime
+	listed   map[string]bool
+	calls    int
+}
+
+func (f *fakeLayers) ListLayerVersions(_ context.Context, in *lambda.ListLayerVersionsInput, _ ...func(*lambda.Options)) (*lambda.ListLayerVersionsOutput, error) {
+	f.calls++
+	name := aws.ToString(in.LayerName)
+	if !f.listed[name] {
+		return nil, errors.New("AccessDeniedException: not authorized to perform lambda:ListLayerVersions")
+	}
+	out := &lambda.ListLayerVersionsOutput{}
+	if slices.Contains(f.runtimes[name], in.CompatibleRuntime) {
+		for v := f.newest[name]; v > 0; v-- {
+			out.LayerVersions = append(out.LayerVersions, lamtypes.LayerVersionsListItem{LayerVersionArn: aws.String(fmt.Sprintf("%s:%d", name, v))})
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeLayers) GetLayerVersionByArn(_ context.Context, in *lambda.GetLayerVersionByArnInput, _ ...func(*lambda.Options)) (*lambda.GetLayerVersionByArnOutput, error) {
+	f.calls++
+	a := aws.ToString(in.Arn)
+	i := strings.LastIndex(a, ":")
+	v, _ := strconv.ParseInt(a[i+1:], 10, 64)
+	if v < 1 || v > f.newest[a[:i]] {
+		return nil, &lamtypes.ResourceNotFoundException{Message: aws.String("not found")}
+	}
+	return &lambda.GetLayerVersionByArnOutput{LayerVersionArn: aws.String(a), CompatibleRuntimes: f.runtimes[a[:i]]}, nil
+}
+
+func TestLayerResolver(t *testing.T) {
+	const (
+		datadog    = "arn:aws:lambda:us-east-1:464622532012:layer:Datadog-Python"
+		powertools = "arn:aws:lambda:us-east-1:017000801446:layer:AWSLambdaPowertoolsPythonV2"
+		insights   = "arn:aws:lambda:us-east-1:580247275435:layer:LambdaInsightsExtension"
+		own        = "arn:aws:lambda:us-east-1:123456789012:layer:deps"
+	)
+	api := &fakeLayers{
+		newest: map[string]int64{datadog + "39": 80, datadog + "312": 57, powertools: 79, insights: 53},
+		runtimes: map[string][]lamtypes.Runtime{
+			datadog + "39":  {lamtypes.RuntimePython39},
+			datadog + "312": {lamtypes.RuntimePython312},
+			powertools:      {lamtypes.RuntimePython39, lamtypes.RuntimePython312},
+		},
+		listed: map[string]bool{powertools: true},
+	}
+	lr := newLayerResolver()
+	layers := []string{datadog + "39:80", powertools + ":40", insights + ":38", own + ":3"}
+
+	got, swaps, err := lr.resolve(context.Background(), api, layers, nil, "python3.12", "x86_64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{datadog + "312:57", powertools + ":79", insights + ":53", own + ":3"}
+	if !slices.Equal(got, want) {
+		t.Errorf("resolved %v, want %v", got, want)
+	}
+	if len(swaps) != 3 || swaps[0].vendor != "Datadog" {
+		t.Errorf("swaps %+v, want the three vendor layers", swaps)
+	}
+
+	// Resolutions are reused, and layers the layer map swapped are left to it.
+	calls := api.calls
+	mapped := slices.Clone(layers)
+	mapped[1] = powertools + ":60"
+	got, swaps, err = lr.resolve(context.Background(), api, layers, mapped, "python3.12", "x86_64")
+	if err != nil || api.calls != calls {
+		t.Errorf("err %v after %d more calls, want cached resolutions", err, api.calls-calls)
+	}
+	if got[1] != powertools+":60" || len(swaps) != 2 {
+		t.Errorf("resolved %v with swaps %+v, want the mapped Powertools layer kept", got, swaps)
+	}
+
+	// A Python layer has no build for Node.js; a layer without a build for
+	// the target is left as it is, with the reason.
+	got, _, err = lr.resolve(context.Background(), api, []string{datadog + "39:80"}, nil, "nodejs20.x", "x86_64")
+	if err != nil || got[0] != datadog+"39:80" {
+		t.Errorf("nodejs20.x: %v, %v; want the layer kept", got, err)
+	}
+	if _, _, err := lr.resolve(context.Background(), api, []string{datadog + "39:80"}, nil, "python3.13", "x86_64"); err == nil {
+		t.Error("python3.13: want an error for a layer without a python3.13 build")
+	}
+}
+
+func TestRuntimeDigits(t *testing.T) {
+	for rt, want := range map[string]string{"python3.12": "312", "nodejs20.x": "20", "python3.9": "39"} {
+		if got := runtimeDigits(rt); got != want {
+			t.Errorf("runtimeDigits(%s) = %s, want %s", rt, got, want)
+		}
+	}
+}
@@ -1,278 +1,1874 @@
 package main
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
-	"text/tabwriter"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
+	// --timezone works where the system has no zone database, such as
+	// the Lambda worker.
+	_ "time/tzdata"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/service/lambda"
-	lamtypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
-	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"update-lambda-runtime/pkg/bump"
+	"update-lambda-runtime/pkg/inventory"
+	"update-lambda-runtime/pkg/report"
 )
 
 type AWSOpts struct {
-	Profile       string
-	Regions       []string
-	FunctionName  string
-	All           bool
-	SourceRuntime string
-	TargetRuntime string
-	Timeout       time.Duration
-	PollEvery     time.Duration
-	ShowProfile   bool // default false; output focuses on AccountID
+	Profile              string
+	Regions              []string
+	FunctionName         string
+	Qualifier            string
+	IncludeVersions      bool
+	ShowTags             []string
+	ShowState            bool
+	ShowTriggers         bool
+	ShowRuntimeMgmt      bool
+	Group                bool
+	LastModifiedBy       bool
+	IncludeDisabled      bool
+	AllowProd            bool
+	SkipValidation       bool
+	PreflightChecks      []string
+	All                  bool
+	Source               string
+	ExplorerRegion       string
+	SourceRuntime        string
+	TargetRuntime        string
+	PolicyFile           string
+	ConfigFile           string
+	Environment          string
+	AccountsFile         string
+	AssumeRole           string // set per account by --accounts-file
+	Align                string
+	Timezone             string
+	MapFile              string
+	ToMinimumSupported   bool
+	Fixtures             string
+	PythonPath           string
+	RecordGolden         bool
+	HealthSince          time.Duration
+	HealthTolerance      float64
+	IgnoreFields         []string
+	TerraformState       []string
+	Policy               *bump.Policy // loaded from Config, or the two flags above
+	LayerMap             map[string]string
+	ResolveLayers        bool
+	SetEnv               []string
+	UnsetEnv             []string
+	Timeout              time.Duration
+	EdgeTimeout          time.Duration
+	ScaleWaitTimeout     bool
+	PollEvery            time.Duration
+	WaitStrategy         string
+	MaxAttempts          int
+	RetryDelay           time.Duration
+	APITimeout           time.Duration
+	RunDeadline          time.Duration
+	RunDeadlineGrace     time.Duration
+	KillSwitch           string
+	KillSwitchInterval   time.Duration
+	MaxRPS               float64
+	APIUsage             bool
+	UpdatesPerMinute     float64
+	CacheTTL             time.Duration
+	Offline              bool
+	Async                bool
+	NoWait               bool
+	DescriptionNote      bool
+	AllResults           bool
+	Force                bool
+	QueueURL             string
+	ResultsQueueURL      string
+	ExitWhenEmpty        bool
+	Pick                 bool
+	Concurrency          int
+	SlackWebhook         string
+	SlackFailures        bool
+	SlackApproval        string
+	SlackApprovalListen  string
+	SlackApprovers       []string
+	SlackApprovalTimeout time.Duration
+	SNSTopicARN          string
+	EmailTo              []string
+	EmailFrom            string
+	EventBus             string
+	SecurityHub          bool
+	PagerDutyKey         string
+	OpsgenieKey          string
+	OpsgenieTeam         string
+	GitHubIssues         string
+	GitHubRepoTag        string
+	GitHubOwnerTag       string
+	JiraURL              string
+	JiraProject          string
+	JiraGroupTag         string
+	OpsItems             string
+	OpsItemsTag          string
+	Datadog              bool
+	InventoryTable       string
+	HistoryTable         string
+	WebhookURL           string
+	WebhookSecret        string
+	WebhookEvents        []string
+	Eligibility          string
+	Overrides            string
+	PreHook              string
+	RubyPreHook          string
+	PostHook             string
+	Plugins              []string
+	VerifyLogs           string
+	VerifyLogsWindow     time.Duration
+	VerifyLogsPayload    string
+	MetricsAddr          string
+	Output               string
+	ReportProfiles       []string
+	ReportFormat         string
+	ReportExport         string
+	Redact               string
+	BaselineOut          string
+	BaselineKey          string
+	ImportFormat         string
+	ExportDir            string
+	ExportEnvValues      bool
+	LaggingOnly          bool
+	StatsTrend           bool
+	StatsSince           time.Duration
+	WatchInterval        time.Duration
+	AutoBump             bool
+	AlertNew             bool
+	ServeAddr            string
+	GRPCAddr             string
+	APIToken             string
+	DeployName           string
+	DeploySchedule       string
+	DeployArgsParam      string
+	DeployBinary         string
+	DeployArch           string
+	ArchTarget           string
+	RefreshCalendar      bool
+	CalendarURL          string
+	RuntimeMode          string
+	RuntimeVersionARN    string
+	RuntimeFilter        string
+	ShowProfile          bool // default false; output focuses on AccountID
 
 }
 
 func main() {
+	// Ctrl-C/SIGTERM cancel the context so no new updates start and the
+	// summary still prints. Once it fires, restore default handling so a
+	// second signal kills the process immediately.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	stopTracing, err := startTracing(ctx)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	if os.Getenv("AWS_LAMBDA_RUNTIME_API") != "" {
+		startLambda(ctx) // never returns
+	}
+	err = execute(ctx, newRootCmd())
+	stopTracing()
+	printAPIUsage(os.Stderr)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+}
+
+// execute runs cmd, built by newRootCmd, under a context cancelled once it
+// returns. That releases what the run derived from it, such as the
+// --run-deadline timers, also when the command fails, which cobra's
+// PersistentPostRun would not.
+func execute(ctx context.Context, cmd *cobra.Command) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	return cmd.ExecuteContext(ctx)
+}
+
+// newRootCmd builds the command tree around a fresh set of options.
+func newRootCmd() *cobra.Command {
 	opts := &AWSOpts{
-		SourceRuntime: "python3.9",
-		TargetRuntime: "python3.12",
-		Timeout:       5 * time.Minute,
-		PollEvery:     5 * time.Second,
-		ShowProfile:   false,
+		SourceRuntime:      "python3.9",
+		TargetRuntime:      "python3.12",
+		Timeout:            5 * time.Minute,
+		EdgeTimeout:        30 * time.Minute,
+		PollEvery:          5 * time.Second,
+		VerifyLogsWindow:   10 * time.Minute,
+		WaitStrategy:       waitWaiter,
+		MaxAttempts:        3,
+		RetryDelay:         10 * time.Second,
+		APITimeout:         30 * time.Second,
+		RunDeadlineGrace:   5 * time.Minute,
+		KillSwitchInterval: 30 * time.Second,
+		MaxRPS:             10,
+		Concurrency:        1,
+		Source:             sourceLambda,
+		JiraGroupTag:       "team",
+		OpsItemsTag:        "team",
+		Output:             outputTable,
+		Align:              alignAuto,
+		ReportFormat:       report.JSON,
+		WatchInterval:      6 * time.Hour,
+		ServeAddr:          "127.0.0.1:8080",
+		DeployName:         "update-lambda-runtime",
+		DeploySchedule:     "rate(1 day)",
+		DeployArgsParam:    "/update-lambda-runtime/schedule-args",
+		ArchTarget:         "arm64",
+		ShowProfile:        false,
 	}
 
 	rootCmd := &cobra.Command{
 		Use:   "update-lambda-runtime",
 		Short: "Manage AWS Lambda runtimes across accounts/regions",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			useCachedCalendar()
+			if err := applySettings(cmd, cmp.Or(opts.ConfigFile, os.Getenv(settingsEnvPrefix+"_CONFIG_FILE"))); err != nil {
+				return err
+			}
+			if !slices.Contains([]string{alignAuto, alignAlways, alignNever}, opts.Align) {
+				return fmt.Errorf("--align must be %s, %s or %s", alignAuto, alignAlways, alignNever)
+			}
+			tableAlign = opts.Align
+			showAPIUsage = opts.APIUsage
+			if opts.Timezone != "" {
+				loc, err := time.LoadLocation(opts.Timezone)
+				if err != nil {
+					return fmt.Errorf("--timezone: %w", err)
+				}
+				displayZone = loc
+			}
+			if opts.WaitStrategy != waitWaiter && opts.WaitStrategy != waitPoll {
+				return fmt.Errorf("--wait-strategy must be %s or %s", waitWaiter, waitPoll)
+			}
+			opts.RuntimeFilter = inventory.Normalize(opts.RuntimeFilter)
+			if opts.MaxAttempts < 1 {
+				return errors.New("--max-attempts must be at least 1")
+			}
+			if len(opts.Regions) == 0 && opts.Source == sourceLambda && opts.Profile != "" {
+				if region := defaultRegion(cmd.Context(), opts.Profile); region != "" {
+					fmt.Fprintf(os.Stderr, "No --regions given; using %s, the region of profile %s\n", region, opts.Profile)
+					opts.Regions = []string{region}
+				}
+			}
+			if opts.RunDeadlineGrace < 0 {
+				return errors.New("--run-deadline-grace cannot be negative")
+			}
+			if opts.RunDeadline > 0 {
+				// Every call and wait of the run derives from this context,
+				// so the deadline stops the run the way a signal does.
+				// Waits for updates already issued go on for the grace
+				// period, through waitContext. Their timers are released
+				// once execute returns, however the command ended.
+				parent := cmd.Context()
+				ctx, cancel := context.WithTimeoutCause(parent, opts.RunDeadline, errRunDeadline)
+				grace, cancelGrace := context.WithTimeoutCause(parent, opts.RunDeadline+opts.RunDeadlineGrace, errRunDeadline)
+				context.AfterFunc(parent, func() {
+					cancel()
+					cancelGrace()
+				})
+				cmd.SetContext(context.WithValue(ctx, waitContextKey{}, grace))
+			}
+			return nil
+		},
 	}
 
+	rootCmd.PersistentFlags().StringVar(&opts.Environment, "env", "", "Named environment preset from the settings file's environments (e.g. prod), supplying flags such as --profile and --regions")
+	rootCmd.PersistentFlags().StringVar(&opts.ConfigFile, "config-file", "", "Settings file of flag: value pairs (default ~/.config/update-lambda-runtime/config.yaml); flags and ULR_* environment variables win over it. Not the runtime policy, which is --policy")
 	rootCmd.PersistentFlags().StringVar(&opts.Profile, "profile", "", "AWS CLI profile (required)")
-	rootCmd.PersistentFlags().StringSliceVar(&opts.Regions, "regions", nil, "Comma or multiple --regions (required)")
+	rootCmd.PersistentFlags().StringSliceVar(&opts.Regions, "regions", nil, "Comma or multiple --regions (default: the profile's region, or AWS_REGION)")
 	rootCmd.PersistentFlags().StringVar(&opts.FunctionName, "function", "", "Lambda function name (if not using --all)")
 	rootCmd.PersistentFlags().BoolVar(&opts.All, "all", false, "Process all functions in region(s)")
-	rootCmd.PersistentFlags().StringVar(&opts.SourceRuntime, "source-runtime", opts.SourceRuntime, "Only update from this runtime")
-	rootCmd.PersistentFlags().StringVar(&opts.TargetRuntime, "target-runtime", opts.TargetRuntime, "Update to this runtime")
-	rootCmd.PersistentFlags().DurationVar(&opts.Timeout, "wait-timeout", opts.Timeout, "Max time to wait for update")
-	rootCmd.PersistentFlags().DurationVar(&opts.PollEvery, "wait-interval", opts.PollEvery, "Polling interval during update")
+	rootCmd.PersistentFlags().StringVar(&opts.Source, "source", opts.Source, "Where functions are discovered: lambda or resource-explorer")
+	rootCmd.PersistentFlags().StringVar(&opts.ExplorerRegion, "explorer-region", "", "Region of the Resource Explorer index to search (default: the profile's region)")
+	rootCmd.PersistentFlags().DurationVar(&opts.APITimeout, "api-timeout", opts.APITimeout, "Max time for a single AWS API call, retries included (0 disables)")
+	rootCmd.PersistentFlags().DurationVar(&opts.RunDeadline, "run-deadline", 0, "Stop the whole run after this long, as Ctrl-C would (e.g. 45m; 0 = no deadline)")
+	rootCmd.PersistentFlags().DurationVar(&opts.RunDeadlineGrace, "run-deadline-grace", opts.RunDeadlineGrace, "How long past --run-deadline updates already issued are still waited on; no new ones start")
+	rootCmd.PersistentFlags().Float64Var(&opts.MaxRPS, "max-rps", opts.MaxRPS, "Max AWS API requests per second across the run (0 = unlimited)")
+	rootCmd.PersistentFlags().BoolVar(&opts.APIUsage, "api-usage", false, "Print the AWS API calls made, with retries and throttled attempts, per service and operation when the run ends")
+	rootCmd.PersistentFlags().StringVar(&opts.MetricsAddr, "metrics-addr", "", "Serve Prometheus metrics on this address (e.g. :9090) while running")
+	rootCmd.PersistentFlags().BoolVar(&opts.Datadog, "datadog", false, "Send the runtime distribution (and bump events) to Datadog using DD_API_KEY")
+	rootCmd.PersistentFlags().StringVar(&opts.InventoryTable, "inventory-table", "", "DynamoDB table (name or ARN) to upsert one inventory item per function into")
+	rootCmd.PersistentFlags().StringVar(&opts.HistoryTable, "history-table", "", "DynamoDB table (name or ARN) list and bump record runtime history in and stats reads it from, instead of the local cache; shared by every machine and scheduled run")
 	rootCmd.PersistentFlags().BoolVar(&opts.ShowProfile, "show-profile", opts.ShowProfile, "Also print profile column")
+	rootCmd.PersistentFlags().StringVar(&opts.Timezone, "timezone", "", "IANA time zone timestamps are shown in, e.g. Asia/Bangkok or UTC (default: as each output always has; JSON stays as recorded)")
+	rootCmd.PersistentFlags().StringVar(&opts.Align, "align", opts.Align, "Table layout: auto pads columns on a terminal and separates them with tabs when piped; always or never force one")
 
 	listCmd := &cobra.Command{
 		Use:   "list",
 		Short: "List Lambda functions and runtimes",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runList(opts)
+			err := runList(cmd.Context(), opts)
+			// Discovery errors were shown per row and a stopped run was
+			// reported; usage would bury them.
+			cmd.SilenceUsage = errors.Is(err, errDiscovery) || errors.Is(err, errInterrupted) || errors.Is(err, errAccounts)
+			return err
 		},
 	}
+	addPolicyFlags(listCmd.Flags(), opts)
+	listCmd.Flags().DurationVar(&opts.CacheTTL, "cache", 0, "Reuse the on-disk inventory if younger than this, refreshing it otherwise (e.g. 1h)")
+	listCmd.Flags().StringVar(&opts.Qualifier, "qualifier", "", "With --function, describe this version or alias instead of $LATEST")
+	addAccountsFlag(listCmd.Flags(), opts)
+	listCmd.Flags().BoolVar(&opts.Group, "group", false, "Group functions under account and region headings, with subtotals by deprecation status")
+	listCmd.Flags().BoolVar(&opts.ShowState, "show-state", false, "Add State and LastUpdateStatus columns, to spot stuck or failed functions (one extra call per function)")
+	listCmd.Flags().BoolVar(&opts.ShowRuntimeMgmt, "show-runtime-management", false, "Add an UpdateRuntimeOn column (Auto, FunctionUpdate or Manual with the pinned version), to find functions opted out of automatic runtime patching (one extra call per function)")
+	listCmd.Flags().BoolVar(&opts.ShowTriggers, "show-triggers", false, "Add a Triggers column of each function's event source mappings (SQS, Kinesis, DynamoDB streams, ...) and the services its policy lets invoke it (EventBridge, S3, SNS, ...)")
+	listCmd.Flags().StringSliceVar(&opts.ShowTags, "show-tags", nil, "Add a column for each of these function tags (e.g. team,owner,env); cached with --cache")
+	listCmd.Flags().BoolVar(&opts.IncludeVersions, "include-versions", false, "Also list every published version of each function with the runtime it still runs")
+	listCmd.Flags().BoolVar(&opts.Offline, "offline", false, "Read the cached inventory only, never calling AWS")
+	addRedactFlag(listCmd.Flags(), opts)
+	listCmd.Flags().BoolVar(&opts.SecurityHub, "securityhub", false, "Import a Security Hub finding for every function on the source runtime")
+	listCmd.Flags().StringVar(&opts.JiraProject, "jira-project", "", "Open or update one Jira issue per team listing functions on deprecated runtimes")
+	listCmd.Flags().StringVar(&opts.JiraURL, "jira-url", "", "Jira base URL for --jira-project (e.g. https://example.atlassian.net)")
+	listCmd.Flags().StringVar(&opts.JiraGroupTag, "jira-group-tag", opts.JiraGroupTag, "Function tag that names the owning team for --jira-project")
+	listCmd.Flags().StringVar(&opts.OpsItems, "opsitems", "", "Create or update OpsCenter OpsItems for functions on deprecated runtimes: one per function or per team")
+	listCmd.Flags().StringVar(&opts.OpsItemsTag, "opsitems-group-tag", opts.OpsItemsTag, "Function tag that names the owning team for --opsitems team")
 
 	bumpCmd := &cobra.Command{
 		Use:   "bump",
-		Short: fmt.Sprintf("Update Lambda runtime from %s to %s", opts.SourceRuntime, opts.TargetRuntime),
+		Short: "Update Lambda functions to the runtime their runtime policy maps them to",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runBump(opts)
+			err := runBump(cmd.Context(), opts)
+			// Discovery errors were shown per row and a stopped run was
+			// reported; usage would bury them.
+			cmd.SilenceUsage = errors.Is(err, errDiscovery) || errors.Is(err, errInterrupted) || errors.Is(err, errValidation) || errors.Is(err, errNotApproved) || errors.Is(err, errAccounts)
+			return err
 		},
 	}
+	addPolicyFlags(bumpCmd.Flags(), opts)
+	bumpCmd.Flags().BoolVar(&opts.ToMinimumSupported, "to-minimum-supported", false, "Move each deprecated runtime only to the oldest still-supported runtime of its family (e.g. python3.8 → python3.9), instead of the mappings (replaces --source-runtime/--target-runtime and --policy mappings)")
+	addChangeFlags(bumpCmd.Flags(), opts)
+	addWaitFlags(bumpCmd.Flags(), opts)
+	addRetryFlags(bumpCmd.Flags(), opts)
+	addPaceFlag(bumpCmd.Flags(), opts)
+	bumpCmd.Flags().StringVar(&opts.Qualifier, "qualifier", "", "With --function, bump through this alias; refused when it resolves to a published version, which cannot change")
+	bumpCmd.Flags().IntVar(&opts.Concurrency, "concurrency", opts.Concurrency, "Number of functions updated in parallel")
+	bumpCmd.Flags().StringVar(&opts.SlackWebhook, "notify-slack", "", "Slack incoming webhook URL to post the run summary to")
+	bumpCmd.Flags().BoolVar(&opts.SlackFailures, "notify-slack-failures", false, "Also post one Slack message per failed function")
+	bumpCmd.Flags().StringVar(&opts.SlackApproval, "slack-approval", "", "Slack incoming webhook URL to post the plan to with Approve/Reject buttons; nothing is updated until an approver approves")
+	bumpCmd.Flags().StringVar(&opts.SlackApprovalListen, "slack-approval-listen", ":3000", "Address the Slack app's interactivity Request URL reaches, for --slack-approval button presses")
+	bumpCmd.Flags().StringSliceVar(&opts.SlackApprovers, "slack-approvers", nil, "Slack user IDs allowed to approve or reject a --slack-approval plan")
+	bumpCmd.Flags().DurationVar(&opts.SlackApprovalTimeout, "slack-approval-timeout", time.Hour, "How long to wait for an answer to a --slack-approval plan before giving up")
+	bumpCmd.Flags().StringVar(&opts.SNSTopicARN, "notify-sns", "", "SNS topic ARN to publish the JSON run summary to")
+	bumpCmd.Flags().StringSliceVar(&opts.EmailTo, "email-report", nil, "Email the HTML/CSV report to these addresses via SES")
+	bumpCmd.Flags().StringVar(&opts.EmailFrom, "email-from", "", "Verified SES sender address for --email-report")
+	bumpCmd.Flags().StringVar(&opts.EventBus, "event-bus", "", "EventBridge bus (name or ARN) to receive per-function lifecycle events")
+	bumpCmd.Flags().StringVar(&opts.PagerDutyKey, "notify-pagerduty", "", "PagerDuty Events v2 routing key; triggers an incident when any update fails")
+	bumpCmd.Flags().StringVar(&opts.OpsgenieKey, "notify-opsgenie", "", "Opsgenie API key; opens an alert when any update fails")
+	bumpCmd.Flags().StringVar(&opts.OpsgenieTeam, "opsgenie-team", "", "Opsgenie team the --notify-opsgenie alert is routed to")
+	bumpCmd.Flags().BoolVar(&opts.SecurityHub, "securityhub", false, "Import Security Hub findings for functions on the source runtime, resolving them once bumped")
+	bumpCmd.Flags().StringVar(&opts.GitHubIssues, "github-issues", "", "GitHub repository (owner/name) to open an issue in for each function that fails verification or is blocked by a code or layer incompatibility, using GITHUB_TOKEN")
+	bumpCmd.Flags().StringVar(&opts.GitHubRepoTag, "github-repo-tag", "github-repo", "Function tag naming the owner/name repository its --github-issues issue goes to instead")
+	bumpCmd.Flags().StringVar(&opts.GitHubOwnerTag, "github-owner-tag", "team", "Function tag naming its owner: a GitHub user is assigned the issue, an org/team is mentioned")
+	bumpCmd.Flags().StringVar(&opts.WebhookURL, "webhook", "", "POST JSON callbacks for run and function events to this URL")
+	bumpCmd.Flags().StringVar(&opts.WebhookSecret, "webhook-secret", "", "Sign --webhook bodies with HMAC-SHA256 using this secret")
+	bumpCmd.Flags().StringSliceVar(&opts.WebhookEvents, "webhook-events", webhookEvents, "Events sent to --webhook")
+	bumpCmd.Flags().StringVar(&opts.Overrides, "overrides", "", "CSV of function,region,target_runtime[,handler[,wait_timeout]] rows giving those functions their own target or wait (a blank region matches every region)")
+	bumpCmd.Flags().StringVar(&opts.Eligibility, "eligibility", "", "YAML or JSON file of CEL rules a function must pass to be bumped (allow/deny on name, tags, account, region, ...)")
+	bumpCmd.Flags().BoolVar(&opts.SkipValidation, "skip-validation", false, "Start updating as functions are discovered, without first checking the whole selection and stopping on any finding")
+	bumpCmd.Flags().StringSliceVar(&opts.PreflightChecks, "preflight-checks", preflightChecks, "Checks run on every selected function before anything changes: runtime, layers, package, state, iac")
+	bumpCmd.Flags().BoolVar(&opts.AllowProd, "allow-prod", false, "Also bump production functions, as the policy's production rules (default: tag env=prod or a -prod name suffix) define them")
+	bumpCmd.Flags().BoolVar(&opts.IncludeDisabled, "include-disabled", false, "Also bump functions disabled with a reserved concurrency of 0, which are otherwise reported as disabled")
+	bumpCmd.Flags().BoolVar(&opts.LastModifiedBy, "last-modified-by", false, "Look up in CloudTrail who last updated each function's code or configuration, for hooks, plugins and the run record")
+	bumpCmd.Flags().StringVar(&opts.PreHook, "pre-hook", "", "Shell command run before each function's update; a failure leaves the function alone")
+	bumpCmd.Flags().StringVar(&opts.RubyPreHook, "ruby-pre-hook", "", "Shell command run before each Ruby function's update with its package unpacked in PACKAGE_DIR; a failure leaves the function alone")
+	bumpCmd.Flags().StringVar(&opts.PostHook, "post-hook", "", "Shell command run after each function's update with its outcome in STATUS")
+	bumpCmd.Flags().StringArrayVar(&opts.Plugins, "plugin", nil, "Executable consulted as a filter, verifier or notifier, speaking JSON over stdin/stdout (repeatable)")
+	addAccountsFlag(bumpCmd.Flags(), opts)
+	bumpCmd.Flags().BoolVar(&opts.Group, "group", false, "Group the result table under account and region headings, with subtotals by outcome")
+	bumpCmd.Flags().StringVar(&opts.Output, "output", opts.Output, "Result format: table, or pr-comment for Markdown to post on a pull request or issue")
+	bumpCmd.Flags().BoolVar(&opts.Pick, "pick", false, "After discovery, choose interactively (fzf if installed) which matching functions to bump")
+	bumpCmd.Flags().BoolVar(&opts.AllResults, "all-results", false, "Keep every function in the results, up-to-date ones too, rather than only counting those; the result table and reports then list them")
+	bumpCmd.Flags().BoolVar(&opts.DescriptionNote, "description-note", false, "Append a note of the runtime change to each function's description in the same update, replacing an earlier note")
+	bumpCmd.Flags().StringVar(&opts.VerifyLogs, "verify-logs", "", "After each update, watch the function's log group for a line matching this regular expression and for runtime errors (ImportModuleError, Runtime.ExitError, ...), failing verification otherwise")
+	bumpCmd.Flags().DurationVar(&opts.VerifyLogsWindow, "verify-logs-window", opts.VerifyLogsWindow, "How long --verify-logs watches each function's logs after its update")
+	bumpCmd.Flags().StringVar(&opts.VerifyLogsPayload, "verify-logs-payload", "", "JSON file each function is invoked with once after its update, before --verify-logs reads its logs")
+	bumpCmd.Flags().BoolVar(&opts.NoWait, "no-wait", false, "Issue every update and print its request ID without waiting for Lambda to apply it; outcomes are \"started\"")
+	bumpCmd.Flags().BoolVar(&opts.Async, "async", false, "Issue every update first, then wait for all of them together")
+	bumpCmd.Flags().BoolVar(&opts.Force, "force", false, "Bump functions whose package checks say they will break on the target runtime (AWS SDK v2, bootstrap, .NET rebuild)")
+	bumpCmd.Flags().StringVar(&opts.QueueURL, "queue-url", "", "SQS queue to send each function's update to, for worker instances to carry out")
+	bumpCmd.Flags().StringVar(&opts.ResultsQueueURL, "results-queue-url", "", "SQS queue workers report --queue-url updates on; the run waits for every report")
+	bumpCmd.Flags().BoolVar(&opts.ScaleWaitTimeout, "scale-wait-timeout", false, "Wait longer for functions that update slowly: another --wait-timeout for VPC attachment, a container image and every 50 MB of package, up to 4 times it")
+	bumpCmd.Flags().DurationVar(&opts.EdgeTimeout, "edge-wait-timeout", opts.EdgeTimeout, "Max time to wait for CloudFront to replicate an updated Lambda@Edge function")
+	addRedactFlag(bumpCmd.Flags(), opts)
+	bumpCmd.Flags().StringVar(&opts.KillSwitch, "kill-switch", "", "SSM parameter (ssm://<name>) that must be true for the run to start; once it turns false no new updates are issued")
+	bumpCmd.Flags().DurationVar(&opts.KillSwitchInterval, "kill-switch-interval", opts.KillSwitchInterval, "How often --kill-switch is checked during the run")
 
-	rootCmd.AddCommand(listCmd, bumpCmd)
+	reportCmd := &cobra.Command{
+		Use:   "report",
+		Short: "Write a consolidated runtime inventory of every profile, grouped by account, region and runtime",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runInventoryReport(cmd.Context(), opts, os.Stdout)
+		},
+	}
+	reportCmd.Flags().StringSliceVar(&opts.ReportProfiles, "profiles", nil, "Profiles to sweep (default: --profile, or every profile in the shared AWS config)")
+	reportCmd.Flags().StringVar(&opts.ReportFormat, "format", opts.ReportFormat, "Document format: json, jsonl, csv, html or cyclonedx (a CycloneDX BOM for SBOM tooling)")
+	reportCmd.Flags().StringVar(&opts.ReportExport, "s3-export", "", "Also upload the inventory as JSON lines to s3://bucket/prefix, partitioned by day (dt=YYYY-MM-DD) for Athena")
+	addRedactFlag(reportCmd.Flags(), opts)
 
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println("Error:", err)
-		os.Exit(1)
+	baselineCmd := &cobra.Command{
+		Use:   "baseline",
+		Short: "Capture a hashed, optionally KMS-signed inventory for auditors, and verify one later",
+	}
+	baselineCreateCmd := &cobra.Command{
+		Use:   "create",
+		Short: "Sweep the inventory as report does and write it as JSON with a seal holding its SHA-256, time and signature",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runBaselineCreate(cmd.Context(), opts, os.Stdout)
+		},
+	}
+	baselineCreateCmd.Flags().StringSliceVar(&opts.ReportProfiles, "profiles", nil, "Profiles to sweep (default: --profile, or every profile in the shared AWS config)")
+	baselineCreateCmd.Flags().StringVar(&opts.BaselineOut, "out", "", "File to write the inventory to; the seal is written beside it with a .sig suffix (required)")
+	baselineCreateCmd.Flags().StringVar(&opts.BaselineKey, "kms-key", "", "KMS asymmetric SIGN_VERIFY key (ARN, ID or alias) to sign the seal with, using --profile")
+	baselineVerifyCmd := &cobra.Command{
+		Use:   "verify <file>",
+		Short: "Check a baseline against its seal: the inventory's digest and, if signed, the KMS signature",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true // failed checks are already reported
+			return runBaselineVerify(cmd.Context(), opts, args[0], os.Stdout)
+		},
+	}
+	baselineVerifyCmd.Flags().StringVar(&opts.BaselineKey, "kms-key", "", "Key ARN or ID the seal must be signed with")
+	baselineCmd.AddCommand(baselineCreateCmd, baselineVerifyCmd)
+
+	importCmd := &cobra.Command{
+		Use:   "import <file>",
+		Short: "Load an inventory exported by report (or another tool) into the cache, for list --offline and stats without AWS access",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runImport(opts, args[0], os.Stdout)
+		},
+	}
+	importCmd.Flags().StringVar(&opts.ImportFormat, "format", "", "File format: json, jsonl or csv (default: from the file extension)")
+
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Write the full configuration of every selected function to a timestamped directory, as a point-in-time archive",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runExport(cmd.Context(), opts, os.Stdout)
+		},
+	}
+	exportCmd.Flags().StringVar(&opts.ExportDir, "dir", "", "Directory to write <time>/<account>/<region>/<function>.json under (required)")
+	exportCmd.Flags().BoolVar(&opts.ExportEnvValues, "include-env-values", false, "Keep environment variable values, which may hold secrets, instead of redacting them")
+
+	watchCmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Keep rescanning the fleet on a schedule, optionally bumping offenders as they appear",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWatch(cmd.Context(), opts)
+		},
+	}
+	watchCmd.Flags().DurationVar(&opts.WatchInterval, "interval", opts.WatchInterval, "Time between scans")
+	watchCmd.Flags().BoolVar(&opts.AutoBump, "auto-bump", false, "Bump functions the runtime policy matches on every scan instead of only listing them")
+	watchCmd.Flags().BoolVar(&opts.AlertNew, "alert-new", false, "Never change anything; alert through --notify-slack, --notify-sns or --email-report when functions newly appear on deprecated runtimes")
+	// Each scan is a list or bump run, so watch takes both sets of flags.
+	watchCmd.Flags().AddFlagSet(listCmd.Flags())
+	watchCmd.Flags().AddFlagSet(bumpCmd.Flags())
+
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve a REST API to list functions, start bump jobs and query their status",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runServe(cmd.Context(), opts)
+		},
+	}
+	serveCmd.Flags().StringVar(&opts.ServeAddr, "listen", opts.ServeAddr, "Address the API listens on; any but a loopback one needs --api-token")
+	serveCmd.Flags().StringVar(&opts.GRPCAddr, "grpc-listen", "", "Also serve the gRPC API on this address (e.g. :9443); any but a loopback one needs --api-token")
+	serveCmd.Flags().StringVar(&opts.APIToken, "api-token", "", "Require this bearer token on every request (default: $SERVE_API_TOKEN)")
+	// Jobs run the bump flow, so serve takes its flags as defaults.
+	serveCmd.Flags().AddFlagSet(bumpCmd.Flags())
+
+	deployCmd := &cobra.Command{
+		Use:   "deploy-schedule [flags] -- <list|bump|report> [args]",
+		Short: "Deploy this tool as a Lambda function that runs a command on an EventBridge schedule",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDeploySchedule(cmd.Context(), opts, args)
+		},
+	}
+	deployCmd.Flags().StringVar(&opts.DeployName, "name", opts.DeployName, "Function name; the role and schedule rule are named after it")
+	deployCmd.Flags().StringVar(&opts.DeploySchedule, "schedule", opts.DeploySchedule, "EventBridge schedule expression (rate(...) or cron(...))")
+	deployCmd.Flags().StringVar(&opts.DeployArgsParam, "args-param", opts.DeployArgsParam, "SSM parameter storing the scheduled command")
+	deployCmd.Flags().StringVar(&opts.DeployBinary, "binary", "", "Linux build of this tool to deploy (default: this executable, on Linux)")
+	deployCmd.Flags().StringVar(&opts.DeployArch, "architecture", "", "Architecture of --binary: x86_64 or arm64 (default: this machine's)")
+
+	generateCmd := &cobra.Command{
+		Use:       "generate <terraform|cloudformation> [flags] -- <list|bump|report> [args]",
+		Short:     "Print Terraform or CloudFormation that runs a command on a schedule, like deploy-schedule",
+		ValidArgs: []string{generateTerraform, generateCloudFormation},
+		Args:      cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGenerate(opts, args[0], args[1:], os.Stdout)
+		},
+	}
+	generateCmd.Flags().StringVar(&opts.DeployName, "name", opts.DeployName, "Function name; the role and schedule rule are named after it")
+	generateCmd.Flags().StringVar(&opts.DeploySchedule, "schedule", opts.DeploySchedule, "EventBridge schedule expression (rate(...) or cron(...))")
+	generateCmd.Flags().StringVar(&opts.DeployArgsParam, "args-param", opts.DeployArgsParam, "SSM parameter storing the scheduled command")
+	generateCmd.Flags().StringVar(&opts.DeployArch, "architecture", "", "Architecture of the packaged build: arm64 or x86_64 (default arm64)")
+
+	archCmd := &cobra.Command{
+		Use:   "arch",
+		Short: "Manage the instruction set architecture of Lambda functions",
+	}
+	archBumpCmd := &cobra.Command{
+		Use:   "bump",
+		Short: "Move functions to another architecture (e.g. Graviton/arm64), skipping those whose runtime or layers cannot follow",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runArchBump(cmd.Context(), opts)
+		},
+	}
+	addPolicyFileFlag(archBumpCmd.Flags(), opts)
+	addWaitFlags(archBumpCmd.Flags(), opts)
+	addPaceFlag(archBumpCmd.Flags(), opts)
+	archBumpCmd.Flags().StringVar(&opts.ArchTarget, "target", opts.ArchTarget, "Architecture to move to: arm64 or x86_64")
+	archBumpCmd.Flags().IntVar(&opts.Concurrency, "concurrency", opts.Concurrency, "Number of functions updated in parallel")
+	archPlanCmd := &cobra.Command{
+		Use:   "plan",
+		Short: "Show what arch bump would move or skip, with each function's estimated monthly cost change from CloudWatch usage",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runArchPlan(cmd.Context(), opts, os.Stdout)
+		},
+	}
+	addPolicyFileFlag(archPlanCmd.Flags(), opts)
+	archPlanCmd.Flags().StringVar(&opts.ArchTarget, "target", opts.ArchTarget, "Architecture to plan a move to: arm64 or x86_64")
+	archCmd.AddCommand(archBumpCmd, archPlanCmd)
+
+	rtmCmd := &cobra.Command{
+		Use:   "runtime-management",
+		Short: "Show or set when functions pick up new runtime versions (Auto, FunctionUpdate or Manual)",
+	}
+	rtmListCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List each function's runtime update mode and pinned runtime version",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRuntimeManagementList(cmd.Context(), opts, os.Stdout)
+		},
+	}
+	rtmSetCmd := &cobra.Command{
+		Use:   "set",
+		Short: "Set the runtime update mode of every selected function",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRuntimeManagementSet(cmd.Context(), opts, os.Stdout)
+		},
+	}
+	addPolicyFileFlag(rtmSetCmd.Flags(), opts)
+	rtmSetCmd.Flags().StringVar(&opts.RuntimeMode, "mode", "", "Auto, FunctionUpdate or Manual (required)")
+	rtmSetCmd.Flags().StringVar(&opts.RuntimeVersionARN, "runtime-version-arn", "", "Runtime version to pin to with --mode Manual")
+	rtmSetCmd.Flags().StringVar(&opts.RuntimeFilter, "runtime", "", "Only change functions on this runtime (required with --mode Manual)")
+	rtmPinCmd := &cobra.Command{
+		Use:   "pin",
+		Short: "Pin every function on --runtime to a runtime version, e.g. to roll back a bad runtime patch",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRuntimeManagementPin(cmd.Context(), opts, os.Stdout)
+		},
+	}
+	addPolicyFileFlag(rtmPinCmd.Flags(), opts)
+	rtmPinCmd.Flags().StringVar(&opts.RuntimeVersionARN, "runtime-version-arn", "", "Runtime version to pin to (required)")
+	rtmPinCmd.Flags().StringVar(&opts.RuntimeFilter, "runtime", "", "Runtime whose functions are pinned (required)")
+	rtmUnpinCmd := &cobra.Command{
+		Use:   "unpin",
+		Short: "Release pinned functions so they pick up runtime versions again",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRuntimeManagementUnpin(cmd.Context(), opts, os.Stdout)
+		},
+	}
+	addPolicyFileFlag(rtmUnpinCmd.Flags(), opts)
+	rtmUnpinCmd.Flags().StringVar(&opts.RuntimeMode, "mode", "", "Mode to return to: Auto or FunctionUpdate (default Auto)")
+	rtmUnpinCmd.Flags().StringVar(&opts.RuntimeFilter, "runtime", "", "Only unpin functions on this runtime")
+	rtmCmd.AddCommand(rtmListCmd, rtmSetCmd, rtmPinCmd, rtmUnpinCmd)
+
+	versionsCmd := &cobra.Command{
+		Use:   "runtime-versions",
+		Short: "Show the runtime version each function runs on and whether each runtime has converged",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRuntimeVersions(cmd.Context(), opts, os.Stdout)
+		},
+	}
+
+	deprecationsCmd := &cobra.Command{
+		Use:   "deprecations",
+		Short: "Show the Lambda runtime deprecation calendar; with --profile, count the fleet's functions on each runtime",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDeprecations(cmd.Context(), opts, os.Stdout)
+		},
+	}
+	deprecationsCmd.Flags().BoolVar(&opts.RefreshCalendar, "refresh", false, "Fetch the latest calendar from AWS and cache it for every command (the embedded copy is used until then)")
+	deprecationsCmd.Flags().StringVar(&opts.CalendarURL, "calendar-url", runtimeCalendarURL, "Where --refresh fetches from: the Lambda runtimes documentation page or a JSON copy of the calendar")
+
+	iacScanCmd := &cobra.Command{
+		Use:   "iac-scan [path...]",
+		Short: "Find deprecated runtimes declared in SAM/CloudFormation templates, serverless.yml and cdk.out (default: the current directory)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Findings fail the command; usage would only bury them.
+			cmd.SilenceUsage = true
+			return runIACScan(cmd.Context(), opts, args, os.Stdout)
+		},
+	}
+	iacScanCmd.Flags().StringSliceVar(&opts.TerraformState, "terraform-state", nil, "Also read aws_lambda_function runtimes from Terraform state: a local file or s3://bucket/key (repeatable; S3 uses --profile)")
+
+	driftCmd := &cobra.Command{
+		Use:   "drift [path...]",
+		Short: "Show deployed functions whose runtime no longer matches their SAM/CloudFormation template or Terraform state",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDrift(cmd.Context(), opts, args, os.Stdout)
+		},
+	}
+	driftCmd.Flags().StringSliceVar(&opts.TerraformState, "terraform-state", nil, "Also compare against Terraform state: a local file or s3://bucket/key (repeatable)")
+
+	verifyCmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Invoke functions with stored payload fixtures and compare the responses with those recorded before a bump",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true // regressions fail the command
+			return runVerify(cmd.Context(), opts, os.Stdout)
+		},
+	}
+	verifyCmd.Flags().StringVar(&opts.Fixtures, "fixtures", "fixtures", "Directory of payloads: <fixtures>/<function>/*.json")
+	verifyCmd.Flags().BoolVar(&opts.RecordGolden, "record", false, "Store the responses as the golden ones instead of comparing (run before the bump)")
+	verifyCmd.Flags().StringSliceVar(&opts.IgnoreFields, "ignore-field", nil, "JSON object keys left out of body comparisons, e.g. requestId,timestamp")
+
+	profilesCmd := &cobra.Command{
+		Use:   "profiles",
+		Short: "Check which AWS profiles resolve credentials, to which account, and which SSO sessions have expired",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true // profiles that do not resolve fail the command
+			return runProfiles(cmd.Context(), opts, os.Stdout)
+		},
+	}
+	profilesCmd.Flags().StringSliceVar(&opts.ReportProfiles, "profiles", nil, "Profiles to check (default: --profile, or every profile in the shared AWS config)")
+
+	healthCmd := &cobra.Command{
+		Use:   "health <run-id>",
+		Short: "Compare the CloudWatch errors, duration and init time of the functions a bump run updated with before it",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true // regressions fail the command
+			return runHealth(cmd.Context(), opts, args[0], os.Stdout)
+		},
+	}
+	healthCmd.Flags().DurationVar(&opts.HealthSince, "since", 24*time.Hour, "Check this long up to now, from no earlier than the run finished, against as long before it started (0 = everything since the run)")
+	healthCmd.Flags().Float64Var(&opts.HealthTolerance, "tolerance", 25, "Percent a metric may worsen by before it counts as a regression")
+
+	codeScanCmd := &cobra.Command{
+		Use:   "code-scan",
+		Short: "Download the packages of functions the runtime policy would bump and rate how likely they are to break on the target runtime",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCodeScan(cmd.Context(), opts, os.Stdout)
+		},
+	}
+	addPolicyFlags(codeScanCmd.Flags(), opts)
+	codeScanCmd.Flags().StringVar(&opts.PythonPath, "python", "", "Interpreter of the target Python version (e.g. python3.12) used to check syntax; skipped when empty")
+
+	compareCmd := &cobra.Command{
+		Use:   "compare",
+		Short: "Show each function's runtime side by side across accounts and regions, flagging the environments that lag",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCompare(cmd.Context(), opts, os.Stdout)
+		},
+	}
+	compareCmd.Flags().StringSliceVar(&opts.ReportProfiles, "profiles", nil, "Profiles (accounts) to compare (default: --profile, or every profile in the shared AWS config)")
+	compareCmd.Flags().BoolVar(&opts.LaggingOnly, "lagging", false, "Only show functions with at least one lagging environment")
+	addRedactFlag(compareCmd.Flags(), opts)
+
+	aliasesCmd := &cobra.Command{
+		Use:   "aliases",
+		Short: "List function aliases with the runtime of the version each points to, flagging those serving deprecated runtimes",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true // deprecated aliases fail the command
+			return runAliases(cmd.Context(), opts, os.Stdout)
+		},
+	}
+
+	undoCmd := &cobra.Command{
+		Use:   "undo <run-id>",
+		Short: "Revert every function a bump run updated to the runtime it had before that run",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runUndo(cmd.Context(), opts, args[0])
+		},
+	}
+	addWaitFlags(undoCmd.Flags(), opts)
+	addRetryFlags(undoCmd.Flags(), opts)
+	addPaceFlag(undoCmd.Flags(), opts)
+	undoCmd.Flags().DurationVar(&opts.EdgeTimeout, "edge-wait-timeout", opts.EdgeTimeout, "Max time to wait for CloudFront to replicate a reverted Lambda@Edge function")
+
+	workerCmd := &cobra.Command{
+		Use:   "worker",
+		Short: "Carry out the updates bump runs with --queue-url send to an SQS work queue",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWorker(cmd.Context(), opts)
+		},
+	}
+	workerCmd.Flags().StringVar(&opts.QueueURL, "queue-url", "", "SQS work queue to consume (required outside Lambda)")
+	workerCmd.Flags().IntVar(&opts.Concurrency, "concurrency", opts.Concurrency, "Number of functions updated in parallel")
+	workerCmd.Flags().BoolVar(&opts.ExitWhenEmpty, "exit-when-empty", false, "Exit once the queue is empty instead of waiting for more work")
+	addWaitFlags(workerCmd.Flags(), opts)
+	addRetryFlags(workerCmd.Flags(), opts)
+	workerCmd.Flags().DurationVar(&opts.EdgeTimeout, "edge-wait-timeout", opts.EdgeTimeout, "Max time to wait for CloudFront to replicate an updated Lambda@Edge function")
+
+	statsCmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show the runtimes recorded by past list and bump runs, or with --trend how deprecated-runtime counts changed",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runStats(cmd.Context(), os.Stdout, opts)
+		},
 	}
+	statsCmd.Flags().BoolVar(&opts.StatsTrend, "trend", false, "Show each account's functions by deprecation status per day scanned, for a burn-down chart")
+	statsCmd.Flags().DurationVar(&opts.StatsSince, "since", 0, "Only use runs from this far back (e.g. 2160h for 90 days; 0 = all)")
+
+	schemaCmd := &cobra.Command{
+		Use:   "schema [name]",
+		Short: "Print the JSON Schema of an input file or machine-readable output, or list them",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var name string
+			if len(args) > 0 {
+				name = args[0]
+			}
+			return runSchema(os.Stdout, name)
+		},
+	}
+
+	rootCmd.AddCommand(listCmd, bumpCmd, reportCmd, importCmd, exportCmd, compareCmd, undoCmd, workerCmd, aliasesCmd, watchCmd, serveCmd, deployCmd, generateCmd, archCmd, rtmCmd, versionsCmd, deprecationsCmd, iacScanCmd, driftCmd, verifyCmd, healthCmd, codeScanCmd, schemaCmd, statsCmd, profilesCmd, baselineCmd)
+	registerCompletions(rootCmd)
+
+	return rootCmd
+}
+
+// addPolicyFileFlag registers --policy, for commands that only honour the
+// runtime policy's exclusions, and --config, its old name. That name is
+// kept working but deprecated, so that it is not mistaken for the settings
+// file (--config-file).
+func addPolicyFileFlag(fs *pflag.FlagSet, opts *AWSOpts) {
+	fs.StringVar(&opts.PolicyFile, "policy", "", "Runtime mappings and exclusions as JSON, from a file or ssm://<parameter> (replaces --source-runtime/--target-runtime); not the settings file, which is --config-file")
+	fs.StringVar(&opts.PolicyFile, "config", "", "Old name of --policy")
+	fs.MarkDeprecated("config", "use --policy (ULR_POLICY) for the runtime policy; the settings file is --config-file (ULR_CONFIG_FILE)")
+}
+
+// addPolicyFlags registers the flags the runtime policy is built from.
+func addPolicyFlags(fs *pflag.FlagSet, opts *AWSOpts) {
+	fs.StringVar(&opts.SourceRuntime, "source-runtime", opts.SourceRuntime, "Only update from this runtime")
+	fs.StringVar(&opts.TargetRuntime, "target-runtime", opts.TargetRuntime, "Update to this runtime, or latest / latest-<family> (e.g. latest-python) for the newest supported one")
+	addPolicyFileFlag(fs, opts)
+	fs.StringVar(&opts.MapFile, "map", "", "YAML or JSON file of source: target runtime pairs applied in one run (replaces --source-runtime/--target-runtime; added to --policy mappings)")
+}
+
+// addChangeFlags registers the configuration changes made in the same
+// update as the runtime.
+func addChangeFlags(fs *pflag.FlagSet, opts *AWSOpts) {
+	fs.StringToStringVar(&opts.LayerMap, "layer-map", nil, "Swap layers during the runtime update: old-layer-arn=new-layer-version-arn (repeatable; an unversioned old ARN matches every version)")
+	fs.BoolVar(&opts.ResolveLayers, "resolve-layers", false, "Swap known vendor layers (Powertools, Lambda Insights, Datadog, Sentry) for their latest version supporting the target runtime during the runtime update")
+	fs.StringArrayVar(&opts.SetEnv, "set-env", nil, "Set an environment variable during the runtime update: KEY=VALUE (repeatable)")
+	fs.StringSliceVar(&opts.UnsetEnv, "unset-env", nil, "Remove environment variables during the runtime update")
+}
+
+// addAccountsFlag registers running in many accounts, one after another.
+func addAccountsFlag(fs *pflag.FlagSet, opts *AWSOpts) {
+	fs.StringVar(&opts.AccountsFile, "accounts-file", "", "CSV of account_id,role_name[,regions] rows: run in each account in turn as that role, assumed with --profile's credentials")
+}
+
+// addRedactFlag registers how account IDs and function names are hidden
+// in what a command shows or writes; a bare --redact hashes them.
+func addRedactFlag(fs *pflag.FlagSet, opts *AWSOpts) {
+	fs.StringVar(&opts.Redact, "redact", "", "Hide account IDs and function names in the output, for sharing it: hash (stable pseudonyms) or mask")
+	fs.Lookup("redact").NoOptDefVal = redactHash
+}
+
+// addPaceFlag registers how fast updates may be issued.
+func addPaceFlag(fs *pflag.FlagSet, opts *AWSOpts) {
+	fs.Float64Var(&opts.UpdatesPerMinute, "updates-per-minute", 0, "Max updates issued per minute, however many run in parallel (0 = unlimited)")
+}
+
+// addWaitFlags registers how updates are waited on.
+func addWaitFlags(fs *pflag.FlagSet, opts *AWSOpts) {
+	fs.DurationVar(&opts.Timeout, "wait-timeout", opts.Timeout, "Max time to wait for update")
+	fs.DurationVar(&opts.PollEvery, "wait-interval", opts.PollEvery, "Polling interval during update; with the waiter strategy the first, backing off from there")
+	fs.StringVar(&opts.WaitStrategy, "wait-strategy", opts.WaitStrategy, "How updates are waited on: waiter (the SDK's FunctionUpdatedV2 waiter, backing off) or poll (every update polled each --wait-interval from one loop)")
+}
+
+// addRetryFlags registers how failed runtime update calls are retried.
+func addRetryFlags(fs *pflag.FlagSet, opts *AWSOpts) {
+	fs.IntVar(&opts.MaxAttempts, "max-attempts", opts.MaxAttempts, "Update calls made per function before it is marked failed, while Lambda throttles, reports another update in progress or fails transiently")
+	fs.DurationVar(&opts.RetryDelay, "retry-delay", opts.RetryDelay, "Wait before retrying a failed update call, doubling after each retry")
 }
 
 // --- core flows ---
-func runList(opts *AWSOpts) error {
-	if err := validateCommon(opts); err != nil {
+func runList(ctx context.Context, opts *AWSOpts) error {
+	if err := validateList(opts); err != nil {
 		return err
 	}
-	tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
-	printHeader(tw, opts.ShowProfile)
+	metrics, stopMetrics, err := startMetrics(ctx, opts.MetricsAddr)
+	if err != nil {
+		return err
+	}
+	defer stopMetrics()
+	var sink listSink = newListTable(os.Stdout, os.Stderr, opts)
+	if opts.Group {
+		tree := newTreeList(os.Stdout, os.Stderr, opts)
+		defer tree.tree.finish()
+		sink = tree
+	}
+	if opts.AccountsFile != "" {
+		return forEachAccount(ctx, opts, func(opts *AWSOpts) error {
+			_, err := listOnce(ctx, opts, metrics, sink)
+			return err
+		})
+	}
+	_, err = listOnce(ctx, opts, metrics, sink)
+	return err
+}
+
+// listOnce scans the fleet a single time, recording into metrics. Each
+// function and discovery error is shown to sink as it is found.
+func listOnce(ctx context.Context, opts *AWSOpts, metrics *runMetrics, sink listSink) (*inventoryResult, error) {
+	ctx, span := tracer.Start(ctx, "list", runAttrs(opts))
+	defer span.End()
 
-	acctID, err := resolveAccountID(opts.Profile)
+	clients := newClientFactory(opts.Profile, opts.APITimeout, opts.MaxRPS).assuming(opts.AssumeRole)
+	if err := setPolicy(ctx, span, clients, opts); err != nil {
+		return nil, err
+	}
+	// The inventory may fill in opts.Regions, which sizes the table.
+	inv, err := newDiscoverer(ctx, clients, opts)
 	if err != nil {
-		return fmt.Errorf("resolve account id: %w", err)
+		return nil, err
+	}
+	if r := newRedactor(opts.Redact); r != nil {
+		sink = redactedSink{sink, r}
 	}
 
-	for _, region := range opts.Regions {
-		cli, err := lambdaClient(region, opts.Profile)
+	var cache *inventoryCache
+	if opts.CacheTTL > 0 || opts.Offline {
+		if cache, err = newInventoryCache(opts.Profile); err != nil {
+			return nil, err
+		}
+	}
+
+	var notifiers []notifier
+	if opts.SecurityHub {
+		notifiers = append(notifiers, &securityHubNotifier{clients: clients})
+	}
+	if opts.JiraProject != "" {
+		notifiers = append(notifiers, &jiraNotifier{clients: clients, baseURL: opts.JiraURL, project: opts.JiraProject, groupTag: opts.JiraGroupTag})
+	}
+	if opts.OpsItems != "" {
+		notifiers = append(notifiers, &opsItemNotifier{clients: clients, perTeam: opts.OpsItems == opsItemsPerTeam, groupTag: opts.OpsItemsTag, fallbackRegion: opts.Regions[0]})
+	}
+	if opts.InventoryTable != "" {
+		notifiers = append(notifiers, &dynamoInventoryWriter{clients: clients, table: opts.InventoryTable, fallbackRegion: opts.Regions[0]})
+	}
+	if opts.Datadog {
+		dd, err := newDatadogNotifier(false)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		notifiers = append(notifiers, dd)
+	}
+	res := &inventoryResult{Profile: opts.Profile, Regions: opts.Regions, StartedAt: time.Now().UTC()}
+	sink.start()
+	// With --show-tags, --show-state, --show-runtime-management or
+	// --show-triggers rows are held back
+	// in batches while what ListFunctions leaves out is looked up; every
+	// batch is from one region.
+	var tags *tagLookup
+	if len(opts.ShowTags) > 0 {
+		tags = newTagLookup(clients, cache, opts)
+	}
+	var triggers *triggerLookup
+	if opts.ShowTriggers {
+		triggers = newTriggerLookup(clients)
+	}
+	batched := tags != nil || opts.ShowState || opts.ShowRuntimeMgmt || triggers != nil
+	var pending []functionResult
+	flush := func() {
+		if len(pending) == 0 {
+			return
 		}
-		if opts.FunctionName != "" {
-			rt, _ := getRuntime(cli, opts.FunctionName)
-			printRow(tw, acctID, opts.Profile, region, opts.FunctionName, rt, opts.ShowProfile)
-		} else {
-			funcs, _ := listAllFunctions(cli)
-			for _, f := range funcs {
-				printRow(tw, acctID, opts.Profile, region, aws.ToString(f.FunctionName), string(f.Runtime), opts.ShowProfile)
+		if tags != nil {
+			if err := tags.fill(ctx, pending[0].Region, pending); err != nil {
+				fmt.Fprintln(os.Stderr, "warning: tags not looked up:", err)
 			}
 		}
+		if opts.ShowState {
+			if err := fillStates(ctx, clients, pending[0].Region, pending); err != nil {
+				fmt.Fprintln(os.Stderr, "warning: states not looked up:", err)
+			}
+		}
+		if opts.ShowRuntimeMgmt {
+			if err := fillRuntimeManagement(ctx, clients, pending[0].Region, pending); err != nil {
+				fmt.Fprintln(os.Stderr, "warning: runtime management not looked up:", err)
+			}
+		}
+		if triggers != nil {
+			if err := triggers.fill(ctx, pending[0].Region, pending); err != nil && ctx.Err() == nil {
+				fmt.Fprintln(os.Stderr, "warning: triggers not looked up:", err)
+			}
+		}
+		for _, r := range pending {
+			res.Functions = append(res.Functions, r)
+			sink.listed(r)
+		}
+		pending = pending[:0]
 	}
-	tw.Flush()
-	return nil
-}
-
-func runBump(opts *AWSOpts) error {
-	if err := validateCommon(opts); err != nil {
-		return err
+	emit := func(r functionResult) {
+		if !batched {
+			res.Functions = append(res.Functions, r)
+			sink.listed(r)
+			return
+		}
+		if pending = append(pending, r); len(pending) >= listBatch {
+			flush()
+		}
 	}
-	tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
-	printHeader(tw, opts.ShowProfile)
-
-	acctID, err := resolveAccountID(opts.Profile)
-	if err != nil {
-		return fmt.Errorf("resolve account id: %w", err)
+	// endRegion lists what is held back and caches the region's tags.
+	endRegion := func(region string) {
+		flush()
+		if tags != nil {
+			if err := tags.save(region); err != nil {
+				fmt.Fprintln(os.Stderr, "warning: write tag cache:", err)
+			}
+		}
+	}
+	found := func(acct, region string, f inventory.Function) {
+		r := functionResult{
+			AccountID: acct,
+			Profile:   opts.Profile,
+			Region:    region,
+			Name:      f.Name,
+			Runtime:   f.Runtime,
+		}
+		if opts.ShowState {
+			r.State, r.LastUpdateStatus = f.State, f.LastUpdateStatus
+		}
+		if opts.Qualifier != "" {
+			r.Name += ":" + opts.Qualifier
+		}
+		emit(r)
+		if !opts.IncludeVersions || f.Version != "" {
+			return
+		}
+		// Each published version keeps the runtime it was published with,
+		// and stays invocable until deleted.
+		cli, err := clients.Lambda(ctx, region)
+		if err == nil {
+			err = inventory.Versions(ctx, cli, f.Name, func(v inventory.Function) {
+				r.Name, r.Runtime = f.Name+":"+v.Version, v.Runtime
+				emit(r)
+			})
+		}
+		if err != nil && ctx.Err() == nil {
+			e := discoveryError{AccountID: acct, Region: region, Function: f.Name, Error: "list versions: " + err.Error()}
+			res.Errors = append(res.Errors, e)
+			sink.failed(e)
+			metrics.recordDiscoveryError(acct, region)
+		}
 	}
 
 	for _, region := range opts.Regions {
-		cli, err := lambdaClient(region, opts.Profile)
+		if cache != nil {
+			hdr, err := cache.header(region)
+			if opts.Offline && err != nil {
+				return nil, fmt.Errorf("no cached inventory for %s in %s (run list --all --cache first): %w", opts.Profile, region, err)
+			}
+			if opts.Offline || (err == nil && time.Since(hdr.SavedAt) < opts.CacheTTL) {
+				byRuntime := make(map[string]int)
+				if _, err := cache.streamCached(region, opts, func(f inventory.Function) {
+					byRuntime[f.Runtime]++
+					found(hdr.AccountID, region, f)
+				}); err != nil {
+					return nil, err
+				}
+				endRegion(region)
+				metrics.observeInventory(hdr.AccountID, region, byRuntime)
+				continue
+			}
+		}
+
+		if res.AccountID == "" {
+			var err error
+			if res.AccountID, err = resolveAccountID(ctx, clients); err != nil {
+				return nil, fmt.Errorf("resolve account id: %w", err)
+			}
+		}
+		acctID := res.AccountID
+		cli, err := clients.Lambda(ctx, region)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		if opts.FunctionName != "" {
-			rt, _ := getRuntime(cli, opts.FunctionName)
-			printRow(tw, acctID, opts.Profile, region, opts.FunctionName, rt, opts.ShowProfile)
-			if rt == opts.SourceRuntime {
-				updateAndWait(cli, opts.FunctionName, opts.TargetRuntime, opts.Timeout, opts.PollEvery)
-			}
-		} else {
-			funcs, _ := listAllFunctions(cli)
-			for _, f := range funcs {
-				fn := aws.ToString(f.FunctionName)
-				rt := string(f.Runtime)
-				printRow(tw, acctID, opts.Profile, region, fn, rt, opts.ShowProfile)
-				if rt == opts.SourceRuntime {
-					updateAndWait(cli, fn, opts.TargetRuntime, opts.Timeout, opts.PollEvery)
-				}
+		// Only full listings are cached; a single --function lookup would
+		// leave a partial inventory behind.
+		var cw *cacheWriter
+		if cache != nil && opts.FunctionName == "" {
+			if cw, err = cache.create(region, acctID); err != nil {
+				fmt.Fprintln(os.Stderr, "warning: inventory cache disabled:", err)
+			}
+		}
+		// Functions reach the sink as each ListFunctions page arrives.
+		byRuntime := make(map[string]int)
+		err = inv.stream(ctx, cli, region, func(f inventory.Function) {
+			byRuntime[f.Runtime]++
+			found(acctID, region, f)
+			if cw != nil {
+				cw.add(f)
+			}
+		})
+		endRegion(region)
+		if err == nil && ctx.Err() == nil {
+			metrics.observeInventory(acctID, region, byRuntime)
+			recordHistory(ctx, clients, opts, acctID, region, byRuntime)
+		}
+		if err != nil && ctx.Err() == nil {
+			if opts.FunctionName != "" && isNotFound(err) {
+				// Shown as N/A, but not part of the inventory.
+				sink.listed(functionResult{AccountID: acctID, Profile: opts.Profile, Region: region, Name: opts.FunctionName})
+			} else {
+				e := discoveryError{AccountID: acctID, Region: region, Function: opts.FunctionName, Error: err.Error()}
+				res.Errors = append(res.Errors, e)
+				sink.failed(e)
+				metrics.recordDiscoveryError(acctID, region)
+			}
+		}
+		if cw != nil {
+			if err != nil || ctx.Err() != nil {
+				cw.abort()
+			} else if err := cw.commit(); err != nil {
+				fmt.Fprintln(os.Stderr, "warning: write inventory cache:", err)
 			}
 		}
+		if ctx.Err() != nil {
+			return res, stopped(ctx)
+		}
 	}
-	tw.Flush()
-	return nil
+	res.FinishedAt = time.Now().UTC()
+	sendNotifications(ctx, notifiers, res.report(opts))
+	if len(res.Errors) > 0 {
+		return res, fmt.Errorf("%w: %d", errDiscovery, len(res.Errors))
+	}
+	return res, nil
 }
 
-func validateCommon(opts *AWSOpts) error {
-	if opts.Profile == "" || len(opts.Regions) == 0 {
-		return fmt.Errorf("--profile and --regions are required")
+func runBump(ctx context.Context, opts *AWSOpts) error {
+	if err := validateBump(opts); err != nil {
+		return err
 	}
-	if opts.FunctionName == "" && !opts.All {
-		return fmt.Errorf("specify --function or --all")
+	metrics, stopMetrics, err := startMetrics(ctx, opts.MetricsAddr)
+	if err != nil {
+		return err
 	}
-	return nil
+	defer stopMetrics()
+	if opts.AccountsFile != "" {
+		return forEachAccount(ctx, opts, func(opts *AWSOpts) error {
+			_, err := bumpAndRender(ctx, os.Stdout, os.Stderr, opts, metrics)
+			return err
+		})
+	}
+	_, err = bumpAndRender(ctx, os.Stdout, os.Stderr, opts, metrics)
+	return err
 }
 
-func lambdaClient(region, profile string) (*lambda.Client, error) {
-	ctx := context.Background()
-	cfg, err := config.LoadDefaultConfig(ctx,
-		config.WithRegion(region),
-		config.WithSharedConfigProfile(profile),
-	)
-	if err != nil {
+// bumpAndRender runs bumpOnce and writes its report in opts.Output to w,
+// as renderBump does.
+func bumpAndRender(ctx context.Context, w, errw io.Writer, opts *AWSOpts, metrics *runMetrics, sinks ...eventSink) (*runReport, error) {
+	rep, err := bumpOnce(ctx, w, errw, opts, metrics, sinks...)
+	if rep == nil {
 		return nil, err
 	}
-	return lambda.NewFromConfig(cfg), nil
+	if rerr := renderBump(w, errw, opts, rep); err == nil {
+		err = rerr
+	}
+	return rep, err
 }
 
-func stsClient(profile string) (*sts.Client, error) {
-	ctx := context.Background()
-	// Region-agnostic; STS is global but SDK requires a region—use us-east-1 safely.
-	cfg, err := config.LoadDefaultConfig(ctx,
-		config.WithRegion("us-east-1"),
-		config.WithSharedConfigProfile(profile),
-	)
-	if err != nil {
-		return nil, err
+// renderBump writes a finished bump run to w: the result table, or with
+// --output pr-comment the Markdown comment, in which case the hint for
+// undoing the run goes to errw instead.
+func renderBump(w, errw io.Writer, opts *AWSOpts, rep *runReport) error {
+	rep = newRedactor(opts.Redact).report(rep)
+	hints := w
+	if opts.Output == outputPRComment {
+		if err := writePRComment(w, rep); err != nil {
+			return err
+		}
+		hints = errw
+	} else {
+		writeBumpTable(w, opts, rep)
+		writeStaleVersions(w, rep.Stale)
+	}
+	if rep.saved && rep.Counts[string(bump.Updated)]+rep.Counts[string(bump.Started)] > 0 {
+		fmt.Fprintf(hints, "Run %s; revert it with: update-lambda-runtime undo %s\n", rep.RunID, rep.RunID)
 	}
-	return sts.NewFromConfig(cfg), nil
+	return nil
 }
 
-func resolveAccountID(profile string) (string, error) {
-	cli, err := stsClient(profile)
+// bumpOnce runs a single bump pass over the fleet, recording into metrics,
+// and returns its report; progress is written to w (errw with pr-comment)
+// as it goes, rendering the report is left to the caller. The report is
+// nil only when the pass could not start. sinks receive lifecycle events besides --event-bus.
+func bumpOnce(ctx context.Context, w, errw io.Writer, opts *AWSOpts, metrics *runMetrics, sinks ...eventSink) (*runReport, error) {
+	ctx, span := tracer.Start(ctx, "bump", runAttrs(opts))
+	defer span.End()
+
+	started := time.Now()
+	runID := newRunID(started)
+	span.SetAttributes(attribute.String("run.id", runID))
+	ctx, disarm, err := newKillSwitch(opts).arm(ctx)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	out, err := cli.GetCallerIdentity(context.Background(), &sts.GetCallerIdentityInput{})
+	defer disarm()
+	clients := newClientFactory(opts.Profile, opts.APITimeout, opts.MaxRPS).assuming(opts.AssumeRole)
+	if err := setPolicy(ctx, span, clients, opts); err != nil {
+		return nil, err
+	}
+	acctID, err := resolveAccountID(ctx, clients)
 	if err != nil {
-		return "", err
+		return nil, fmt.Errorf("resolve account id: %w", err)
 	}
-	return aws.ToString(out.Account), nil
-}
-
-func listAllFunctions(cli *lambda.Client) ([]lamtypes.FunctionConfiguration, error) {
-	ctx := context.Background()
-	var out []lamtypes.FunctionConfiguration
-	p := lambda.NewListFunctionsPaginator(cli, &lambda.ListFunctionsInput{})
-	for p.HasMorePages() {
-		page, err := p.NextPage(ctx)
-		if err != nil {
+	inv, err := newDiscoverer(ctx, clients, opts)
+	if err != nil {
+		return nil, err
+	}
+	notifiers := buildNotifiers(opts, clients)
+	var dd *datadogNotifier
+	if opts.Datadog {
+		if dd, err = newDatadogNotifier(true); err != nil {
 			return nil, err
 		}
-		out = append(out, page.Functions...)
+		notifiers = append(notifiers, dd)
 	}
-	return out, nil
-}
-
-func getRuntime(cli *lambda.Client, fn string) (string, error) {
-	ctx := context.Background()
-	cfg, err := cli.GetFunctionConfiguration(ctx, &lambda.GetFunctionConfigurationInput{
-		FunctionName: aws.String(fn),
-	})
+	hooks, err := newWebhookSender(opts)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	return string(cfg.Runtime), nil
-}
-
-func updateAndWait(cli *lambda.Client, fn, target string, timeout, poll time.Duration) {
-	ctx := context.Background()
-	fmt.Printf("Updating %s to %s...\n", fn, target)
-	_, err := cli.UpdateFunctionConfiguration(ctx, &lambda.UpdateFunctionConfigurationInput{
-		FunctionName: aws.String(fn),
-		Runtime:      lamtypes.Runtime(target),
-	})
+	if hooks != nil {
+		notifiers = append(notifiers, hooks)
+	}
+	var overrides targetOverrides
+	if opts.Overrides != "" {
+		if overrides, err = loadOverrides(opts.Overrides); err != nil {
+			return nil, err
+		}
+	}
+	var eligibility *eligibilityPolicy
+	if opts.Eligibility != "" {
+		if eligibility, err = loadEligibility(opts.Eligibility); err != nil {
+			return nil, err
+		}
+	}
+	plugs, err := loadPlugins(ctx, opts.Plugins)
 	if err != nil {
-		fmt.Println("  update error:", err)
-		return
+		return nil, err
 	}
-	deadline := time.Now().Add(timeout)
-	for {
-		cfg, err := cli.GetFunctionConfiguration(ctx, &lambda.GetFunctionConfigurationInput{
-			FunctionName: aws.String(fn),
-		})
+	notifiers = append(notifiers, plugs.notifiers()...)
+	var lastMod *lastModifiedLookup
+	if opts.LastModifiedBy {
+		lastMod = newLastModifiedLookup(clients)
+	}
+	var logs *logVerifier
+	if opts.VerifyLogs != "" {
+		if logs, err = newLogVerifier(opts, clients); err != nil {
+			return nil, err
+		}
+	}
+	var layerRes *layerResolver
+	if opts.ResolveLayers {
+		layerRes = newLayerResolver()
+	}
+
+	cf, edge := loadEdgeFunctions(ctx, clients, opts.Regions)
+
+	// Waits, the republishing and verification after them and the
+	// reports of their outcomes get the deadline's grace period.
+	waitCtx, stopPolling := waitContext(ctx)
+	defer stopPolling()
+	poller := newUpdateWaiter(opts)
+	go poller.run(waitCtx)
+	var queue *workQueue
+	if opts.QueueURL != "" {
+		if queue, err = newWorkQueue(ctx, clients, opts, runID); err != nil {
+			return nil, err
+		}
+	}
+
+	events := &lifecycle{sinks: sinks}
+	if opts.EventBus != "" {
+		sink, err := newEventBridgeSink(ctx, clients, opts.EventBus, opts.Regions[0])
 		if err != nil {
-			fmt.Println("  wait error:", err)
+			return nil, err
+		}
+		events.sinks = append(events.sinks, sink)
+	}
+	// The run is announced only once it is set up, so a run that cannot
+	// start sends no started event without a report to follow it.
+	if dd != nil {
+		if err := dd.started(ctx, opts, acctID); err != nil {
+			fmt.Fprintln(os.Stderr, "warning: notification failed:", err)
+		}
+	}
+	if hooks != nil {
+		hooks.started(ctx, opts, acctID)
+	}
+
+	// With pr-comment, w carries only the Markdown; progress goes to errw.
+	progress := w
+	if opts.Output == outputPRComment {
+		progress = errw
+	}
+	results := newResultCollector(progress)
+	// --inventory-table and --datadog report every function.
+	if !opts.AllResults && opts.InventoryTable == "" && !opts.Datadog {
+		results.countUnchanged(opts.Policy.Mappings)
+	}
+	defer context.AfterFunc(ctx, func() {
+		switch cause := context.Cause(ctx); {
+		case errors.Is(cause, errRunDeadline) && opts.RunDeadlineGrace > 0:
+			results.progressf("Run deadline reached: no new updates start; waiting up to %s for those in flight\n", opts.RunDeadlineGrace)
+		case errors.Is(cause, errKillSwitch):
+			results.progressf("Kill switch turned off (%v): no new updates start; waiting for those in flight\n", cause)
+		}
+	})()
+	finish := func(span trace.Span, r functionResult, o bump.Outcome) {
+		endSpan(span, o)
+		r.Outcome = o
+		results.add(r)
+		metrics.recordUpdate(r)
+		events.finished(waitCtx, r)
+		hooks.result(waitCtx, r)
+	}
+	// settle waits for j's update; Lambda@Edge functions are then
+	// republished to their distributions, verify plugins consulted and
+	// the function's logs checked.
+	settle := func(ctx context.Context, j bumpJob, p *pendingUpdate) (o bump.Outcome, failure bump.FailureClass, detail string) {
+		o = <-poller.track(p)
+		if o != bump.Updated {
+			return o, p.Failure(o), ""
+		}
+		if len(j.distributions) > 0 {
+			if o, detail = deployEdge(ctx, results, cf, j, opts.PollEvery, opts.EdgeTimeout); o != bump.Updated {
+				return o, "", detail
+			}
+		}
+		switch reason, err := plugs.verify(ctx, j.result); {
+		case err != nil:
+			results.progressf("  verify error for %s: %v\n", j.result.Name, err)
+			return bump.Failed, bump.VerificationFailed, "verify error: " + err.Error()
+		case reason != "":
+			results.progressf("%s failed verification by %s\n", j.result.Name, reason)
+			return bump.Failed, bump.VerificationFailed, "failed verification by " + reason
+		}
+		if logs != nil {
+			switch reason, err := logs.verify(ctx, j.cli, j); {
+			case err != nil:
+				results.progressf("  log verification error for %s: %v\n", j.result.Name, err)
+				return bump.Failed, bump.VerificationFailed, "log verification error: " + err.Error()
+			case reason != "":
+				results.progressf("%s failed verification by %s\n", j.result.Name, reason)
+				return bump.Failed, bump.VerificationFailed, "failed verification by " + reason
+			}
+		}
+		return o, "", ""
+	}
+	pace := newUpdatePace(opts.UpdatesPerMinute)
+	// paced holds r back until --updates-per-minute allows another update,
+	// reporting whether it may go ahead.
+	paced := func(span trace.Span, r functionResult) bool {
+		if err := awaitPace(ctx, pace); err != nil {
+			finish(span, r, bump.NotAttempted)
+			return false
+		}
+		return true
+	}
+	start := func(j bumpJob, waits *sync.WaitGroup) {
+		r := j.result
+		ctx, span := tracer.Start(ctx, "update", functionAttrs(r))
+		if ctx.Err() != nil {
+			finish(span, r, bump.NotAttempted)
 			return
 		}
-		switch cfg.LastUpdateStatus {
-		case lamtypes.LastUpdateStatusSuccessful:
-			fmt.Printf("%s updated successfully\n", fn)
+		if queue != nil {
+			// The worker checks and updates it, and reports back.
+			if !paced(span, r) {
+				return
+			}
+			if err := queue.dispatch(ctx, results, j, span); err != nil {
+				results.progressf("  %v; not updating %s\n", err, r.Name)
+				finish(span, r, bump.NotAttempted)
+			}
 			return
-		case lamtypes.LastUpdateStatusFailed:
-			fmt.Printf("%s update failed: %s\n", fn, aws.ToString(cfg.LastUpdateStatusReason))
+		}
+		if !opts.Force {
+			blocker, err := codeBlocker(ctx, j)
+			if err != nil && !errors.Is(err, errNotScanned) {
+				results.progressf("  code check error for %s: %v\n", r.Name, err)
+				r.Failure = bump.Classify(err)
+				finish(span, r, bump.Failed)
+				return
+			}
+			if blocker != "" {
+				results.progressf("Skipping %s: %s (--force to bump anyway)\n", r.Name, blocker)
+				r.Detail = blocker
+				finish(span, r, bump.Skipped)
+				return
+			}
+		}
+		if opts.NoWait && len(j.distributions) > 0 {
+			results.progressf("Skipping %s: Lambda@Edge is republished once its update is applied, which --no-wait does not wait for\n", r.Name)
+			finish(span, r, bump.Skipped)
 			return
 		}
-		if time.Now().After(deadline) {
-			fmt.Printf("Timed out waiting for %s\n", fn)
+		if dotnetMajorChange(r.Runtime, r.TargetRuntime) {
+			results.progressf("  note: %s keeps the assemblies built for %s; redeploy them rebuilt for %s\n", r.Name, r.Runtime, r.TargetRuntime)
+		}
+		if inventory.Family(r.Runtime) == "ruby" {
+			for _, note := range rubyGapNotes(r.Runtime, r.TargetRuntime) {
+				results.progressf("  note: %s: %s\n", r.Name, note)
+			}
+			// Ruby breakage is warned about, not blocked on: code-scan
+			// rates it before the run. The package is kept for
+			// --ruby-pre-hook.
+			var findings []codeFinding
+			zr, _, err := functionCode(ctx, j.cli, r.Name)
+			if err == nil {
+				j.code = zr
+				findings, err = rubyFindings(zr, r.Runtime, r.TargetRuntime)
+			}
+			if err != nil && !errors.Is(err, errNotScanned) {
+				results.progressf("  warning: cannot check the code of %s: %v\n", r.Name, err)
+			}
+			for _, f := range findings {
+				results.progressf("  warning: %s: %s\n", r.Name, f)
+			}
+		}
+		if !paced(span, r) {
 			return
 		}
-		time.Sleep(poll)
+		if opts.PreHook != "" {
+			if err := runHook(ctx, results, "pre-hook", opts.PreHook, r, "pending"); err != nil {
+				results.progressf("  %v; not updating %s\n", err, r.Name)
+				finish(span, r, bump.Failed)
+				return
+			}
+		}
+		if opts.RubyPreHook != "" && inventory.Family(r.Runtime) == "ruby" {
+			if err := runRubyHook(ctx, results, opts.RubyPreHook, j); err != nil {
+				results.progressf("  %v; not updating %s\n", err, r.Name)
+				finish(span, r, bump.Failed)
+				return
+			}
+		}
+		// done runs the post-hook, even on an interrupted run, once the
+		// update has been attempted.
+		done := func(o bump.Outcome, failure bump.FailureClass, detail string) {
+			r.Failure, r.Detail = failure, detail
+			if opts.PostHook != "" {
+				if err := runHook(context.WithoutCancel(ctx), results, "post-hook", opts.PostHook, r, string(o)); err != nil {
+					results.progressf("  warning: %v for %s\n", err, r.Name)
+				}
+			}
+			finish(span, r, o)
+		}
+		p, attempts, o, failure := startUpdate(ctx, results, j, opts, pace)
+		r.Attempts = attempts
+		if p == nil {
+			done(o, failure, "")
+			return
+		}
+		events.started(ctx, r)
+		if opts.NoWait {
+			// Only the call is traced: nothing waits.
+			endSpan(p.span, bump.Started)
+			r.RequestID = p.RequestID
+			results.progressf("%s update started (request ID %s)\n", r.Name, cmp.Or(p.RequestID, "unknown"))
+			done(bump.Started, "", "")
+			return
+		}
+		wctx := trace.ContextWithSpan(waitCtx, span)
+		if !opts.Async {
+			done(settle(wctx, j, p))
+			return
+		}
+		// With --async the worker moves on as soon as the update is issued.
+		waits.Add(1)
+		go func() {
+			defer waits.Done()
+			done(settle(wctx, j, p))
+		}()
+	}
+
+	jobs := make(chan bumpJob)
+	var workers, waits sync.WaitGroup
+	for range max(opts.Concurrency, 1) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for j := range jobs {
+				start(j, &waits)
+			}
+		}()
 	}
-}
 
-// output: AccountID-first; profile optional
-func printHeader(w *tabwriter.Writer, showProfile bool) {
-	if showProfile {
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", "AccountID", "Profile", "Region", "FunctionName", "CurrentRuntime")
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", "---------", "-------", "------", "------------", "--------------")
+	// Every candidate is held until discovery ends, so pre-flight
+	// validation sees the whole selection before anything changes, with
+	// --pick so the user can choose, and with --slack-approval so the
+	// approvers see it. Otherwise functions are handled page by page as
+	// ListFunctions returns them and, unless --all-results, up-to-date
+	// ones are only counted, so memory stays flat however large the
+	// account is.
+	held := opts.Pick || !opts.SkipValidation || opts.SlackApproval != ""
+	var candidates []bumpJob
+	for _, region := range opts.Regions {
+		cli, err := clients.Lambda(ctx, region)
+		if err != nil {
+			// Updates already issued in earlier regions are still seen
+			// through.
+			results.discoveryFailed(acctID, region, opts.FunctionName, err)
+			metrics.recordDiscoveryError(acctID, region)
+			continue
+		}
+		byRuntime := make(map[string]int)
+		err = inv.stream(ctx, cli, region, func(f inventory.Function) {
+			byRuntime[f.Runtime]++
+			r := functionResult{
+				AccountID: acctID,
+				Profile:   opts.Profile,
+				Region:    region,
+				Name:      f.Name,
+				Runtime:   f.Runtime,
+			}
+			if isEdgeReplica(region, f.Name) {
+				r.Edge = edgeReplica
+				results.add(r)
+				return
+			}
+			dists := edge[functionARN(r)]
+			if len(dists) > 0 {
+				r.Edge = edgeOrigin
+			}
+			target, ok := opts.Policy.Target(f.Name, f.Runtime)
+			override, overridden := overrides.lookup(region, f.Name)
+			if overridden && override.runtime != "" && !opts.Policy.Excluded(f.Name) {
+				to, err := inventory.ResolveTarget(override.runtime, f.Runtime)
+				if err != nil {
+					results.progressf("  warning: override for %s on line %d: %v\n", f.Name, override.line, err)
+				}
+				target, ok = to, err == nil && to != f.Runtime
+			}
+			if !ok {
+				results.add(r)
+				return
+			}
+			r.TargetRuntime = target
+			if f.Version != "" {
+				results.progressf("Skipping %s: %s\n", f.Name, publishedVersionReason(f.Name, opts.Qualifier, f.Version))
+				r.Outcome = bump.Skipped
+				results.add(r)
+				return
+			}
+			if why := rubyJumpError(f.Runtime, target); why != "" {
+				results.progressf("Skipping %s: %s\n", f.Name, why)
+				r.Outcome = bump.Skipped
+				results.add(r)
+				return
+			}
+			if !opts.AllowProd {
+				switch why, err := productionReason(ctx, cli, opts.Policy, r); {
+				case err != nil:
+					results.progressf("  production check error for %s: %v\n", f.Name, err)
+					r.Outcome, r.Failure = bump.Failed, bump.Classify(err)
+					results.add(r)
+					return
+				case why != "":
+					results.progressf("Skipping %s: production (%s) (--allow-prod to bump anyway)\n", f.Name, why)
+					r.Outcome = bump.Skipped
+					results.add(r)
+					return
+				}
+			}
+			if !opts.IncludeDisabled {
+				switch disabled, err := isDisabled(ctx, cli, f.Name); {
+				case err != nil:
+					results.progressf("  disabled check error for %s: %v\n", f.Name, err)
+					r.Outcome, r.Failure = bump.Failed, bump.Classify(err)
+					r.Detail = fmt.Sprintf("cannot tell whether it is disabled: %v", err)
+					results.add(r)
+					return
+				case disabled:
+					results.progressf("Skipping %s: disabled with a reserved concurrency of 0 (--include-disabled to bump anyway)\n", f.Name)
+					r.Outcome = bump.Disabled
+					results.add(r)
+					return
+				}
+			}
+			if lastMod != nil {
+				switch m, err := lastMod.lookup(ctx, region, f.Name); {
+				case err != nil:
+					results.progressf("  warning: cannot look up who last modified %s: %v\n", f.Name, err)
+				case m != nil:
+					r.LastModifiedBy = m.Principal
+					results.progressf("%s last modified by %s\n", f.Name, m)
+				}
+			}
+			var handler string
+			if goToProvided(f.Runtime, target) {
+				handler = goBootstrap
+			}
+			if overridden && override.handler != "" {
+				handler = override.handler
+			}
+			if handler != "" && handler != f.Handler {
+				// Kept in the run record, so undo can put it back.
+				r.Handler = f.Handler
+			}
+			j := bumpJob{cli: cli, result: r, distributions: dists, handler: handler, logGroup: f.LogGroup, opts: opts}
+			switch {
+			case overridden && override.waitTimeout > 0:
+				j.timeout = override.waitTimeout
+			case opts.ScaleWaitTimeout:
+				j.timeout = scaledWaitTimeout(opts.Timeout, f)
+			}
+			if j.timeout != 0 && j.timeout != opts.Timeout {
+				results.progressf("  wait timeout for %s: %s\n", f.Name, j.timeout)
+			}
+			rules := opts.Policy.Rules()
+			if layers, ok := rules.SwapLayers(f.Layers); ok {
+				j.layers = layers
+			}
+			if layerRes != nil {
+				layers, swaps, err := layerRes.resolve(ctx, cli, f.Layers, j.layers, target, f.Architecture)
+				if err != nil {
+					results.progressf("  warning: layers of %s not resolved: %v\n", f.Name, err)
+				}
+				for _, s := range swaps {
+					results.progressf("  %s layer of %s: %s → %s\n", s.vendor, f.Name, s.from, s.to)
+					if rules.Layers == nil {
+						rules.Layers = make(map[string]string)
+					}
+					rules.Layers[s.from] = s.to
+				}
+				if len(swaps) > 0 {
+					j.layers = layers
+				}
+			}
+			if env, ok := rules.ApplyEnv(f.Env); ok {
+				// Writing back variables that could not be read would
+				// wipe them.
+				if f.EnvError != "" {
+					results.progressf("Cannot change the environment of %s: %s\n", f.Name, f.EnvError)
+					r.Outcome = bump.Failed
+					results.add(r)
+					return
+				}
+				j.env = env
+			}
+			if opts.DescriptionNote {
+				note := bump.NoteFor(f.Runtime, target, inZone(time.Now(), time.UTC))
+				desc, err := bump.AddNote(f.Description, note)
+				if err != nil {
+					results.progressf("  warning: no description note for %s: %v\n", f.Name, err)
+				} else {
+					j.description, rules.Note = &desc, note
+				}
+			}
+			// Changes worked out from what discovery read are made only
+			// if the function has not changed since, or made again on top
+			// of what it has changed to.
+			if j.layers != nil || j.env != nil || j.description != nil {
+				j.rules, j.revision = rules, f.RevisionID
+			}
+			switch rule, err := eligibility.check(ctx, cli, r, f); {
+			case err != nil:
+				results.progressf("  eligibility error for %s: %v\n", f.Name, err)
+				r.Outcome, r.Failure = bump.Failed, bump.Classify(err)
+				results.add(r)
+				return
+			case rule != "":
+				results.progressf("Skipping %s: not eligible under rule %s\n", f.Name, rule)
+				r.Outcome = bump.Skipped
+				results.add(r)
+				return
+			}
+			switch reason, err := plugs.filter(ctx, r); {
+			case err != nil:
+				results.progressf("  filter error for %s: %v\n", f.Name, err)
+				r.Outcome, r.Failure = bump.Failed, bump.Classify(err)
+				r.Detail = "filter error: " + err.Error()
+				results.add(r)
+				return
+			case reason != "":
+				results.progressf("Skipping %s: %s\n", f.Name, reason)
+				r.Outcome = bump.Skipped
+				results.add(r)
+				return
+			}
+			if held {
+				candidates = append(candidates, j)
+				return
+			}
+			jobs <- j
+		})
+		if err == nil && ctx.Err() == nil {
+			metrics.observeInventory(acctID, region, byRuntime)
+			recordHistory(ctx, clients, opts, acctID, region, byRuntime)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		if err != nil {
+			if opts.FunctionName != "" && isNotFound(err) {
+				results.add(functionResult{AccountID: acctID, Profile: opts.Profile, Region: region, Name: opts.FunctionName})
+				continue
+			}
+			results.discoveryFailed(acctID, region, opts.FunctionName, err)
+			metrics.recordDiscoveryError(acctID, region)
+		}
+	}
+	if unmatched := overrides.unmatched(); len(unmatched) > 0 && opts.FunctionName == "" && ctx.Err() == nil {
+		results.progressf("warning: no function in %s matched the overrides for %s\n", strings.Join(opts.Regions, ", "), strings.Join(unmatched, ", "))
+	}
+	if opts.Pick && ctx.Err() == nil {
+		picked, err := pickFunctions(ctx, candidates)
+		if err != nil {
+			close(jobs)
+			return nil, err
+		}
+		for _, j := range candidates {
+			if !slices.ContainsFunc(picked, func(p bumpJob) bool { return functionARN(p.result) == functionARN(j.result) }) {
+				j.result.TargetRuntime = ""
+				results.add(j.result)
+			}
+		}
+		candidates = picked
+	}
+	var findings []preflightFinding
+	if !opts.SkipValidation && len(candidates) > 0 && ctx.Err() == nil {
+		findings = preflight(ctx, candidates, opts.PreflightChecks)
+		var b strings.Builder
+		writePreflight(&b, len(candidates), findings)
+		results.progressf("%s", b.String())
+	}
+	var approvalErr error
+	if approval := newSlackApproval(opts); approval != nil && len(findings) == 0 && len(candidates) > 0 && ctx.Err() == nil {
+		summary := fmt.Sprintf("run %s bumps %d functions in account %s (profile %s)", runID, len(candidates), acctID, opts.Profile)
+		results.progressf("Waiting for approval in Slack of %s...\n", summary)
+		d, err := approval.await(ctx, runID, summary, candidates)
+		switch {
+		case err != nil:
+			approvalErr = err
+		case !d.approved:
+			approvalErr = fmt.Errorf("%w: rejected by Slack user %s", errNotApproved, d.user)
+		default:
+			results.progressf("Approved by Slack user %s\n", d.user)
+		}
+	}
+	for _, j := range candidates {
+		if ctx.Err() != nil || len(findings) > 0 || approvalErr != nil {
+			j.result.Outcome = bump.NotAttempted
+			results.add(j.result)
+			continue
+		}
+		jobs <- j
+	}
+	close(jobs)
+	workers.Wait()
+	waits.Wait()
+	if queue != nil {
+		queue.collect(waitCtx, results, opts.PollEvery, finish)
+	}
+
+	rep := newRunReport(opts, acctID, started, ctx.Err() != nil, results.snapshot())
+	rep.RunID = runID
+	rep.Unlisted = results.unlistedCount()
+	rep.Classes[classUpToDate] += rep.Unlisted
+	rep.Errors = results.discoveryErrors()
+	rep.Stale = findStaleVersions(ctx, clients, rep.Results)
+	rep.Preflight = findings
+	if err := saveRunRecord(rep); err != nil {
+		fmt.Fprintln(os.Stderr, "warning: run record not saved, undo will not find it:", err)
 	} else {
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", "AccountID", "Region", "FunctionName", "CurrentRuntime")
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", "---------", "------", "------------", "--------------")
+		rep.saved = true
+	}
+	sendNotifications(ctx, notifiers, rep)
+	if ctx.Err() != nil {
+		return rep, stopped(ctx)
+	}
+	if len(rep.Errors) > 0 {
+		return rep, fmt.Errorf("%w: %d", errDiscovery, len(rep.Errors))
+	}
+	if len(findings) > 0 {
+		return rep, fmt.Errorf("%w: %d findings", errValidation, len(findings))
 	}
+	if approvalErr != nil {
+		return rep, approvalErr
+	}
+	return rep, nil
 }
 
-func printRow(w *tabwriter.Writer, accountID, profile, region, fn, rt string, showProfile bool) {
-	if rt == "" {
-		rt = "N/A"
+func validateList(opts *AWSOpts) error {
+	if err := validateCommon(opts); err != nil {
+		return err
 	}
-	if showProfile {
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", accountID, profile, region, fn, rt)
-	} else {
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", accountID, region, fn, rt)
+	if opts.JiraProject != "" && opts.JiraURL == "" {
+		return fmt.Errorf("--jira-project needs --jira-url")
+	}
+	if opts.ShowState && opts.Offline {
+		return fmt.Errorf("--show-state cannot be used with --offline: states are not cached")
+	}
+	if opts.ShowTriggers && opts.Offline {
+		return fmt.Errorf("--show-triggers cannot be used with --offline: triggers are not cached")
+	}
+	if opts.ShowRuntimeMgmt && opts.Offline {
+		return fmt.Errorf("--show-runtime-management cannot be used with --offline: runtime management is not cached")
+	}
+	if opts.IncludeVersions && (opts.Offline || opts.Qualifier != "") {
+		return fmt.Errorf("--include-versions cannot be used with --offline or --qualifier")
+	}
+	if opts.OpsItems != "" && opts.OpsItems != opsItemsPerFunction && opts.OpsItems != opsItemsPerTeam {
+		return fmt.Errorf("--opsitems must be %s or %s", opsItemsPerFunction, opsItemsPerTeam)
+	}
+	return nil
+}
+
+func validateBump(opts *AWSOpts) error {
+	if err := validateCommon(opts); err != nil {
+		return err
+	}
+	if len(opts.EmailTo) > 0 && opts.EmailFrom == "" {
+		return fmt.Errorf("--email-report needs --email-from")
+	}
+	if opts.Output != outputTable && opts.Output != outputPRComment {
+		return fmt.Errorf("--output must be %s or %s", outputTable, outputPRComment)
+	}
+	for _, c := range opts.PreflightChecks {
+		if !slices.Contains(preflightChecks, c) {
+			return fmt.Errorf("--preflight-checks: unknown check %q; want %s", c, strings.Join(preflightChecks, ", "))
+		}
+	}
+	if (opts.QueueURL == "") != (opts.ResultsQueueURL == "") {
+		return fmt.Errorf("--queue-url and --results-queue-url go together")
+	}
+	if opts.VerifyLogs != "" && (opts.QueueURL != "" || opts.NoWait) {
+		return fmt.Errorf("--verify-logs cannot be used with --queue-url or --no-wait")
+	}
+	if opts.VerifyLogs == "" && opts.VerifyLogsPayload != "" {
+		return fmt.Errorf("--verify-logs-payload needs --verify-logs")
+	}
+	if opts.VerifyLogsWindow <= 0 {
+		return fmt.Errorf("--verify-logs-window must be positive")
+	}
+	if err := validateKillSwitch(opts); err != nil {
+		return err
+	}
+	if opts.ToMinimumSupported && opts.MapFile != "" {
+		return fmt.Errorf("--to-minimum-supported cannot be used with --map")
+	}
+	// Workers make the updates, so what runs around each one locally
+	// cannot.
+	if opts.QueueURL != "" && (opts.PreHook != "" || opts.PostHook != "" || opts.RubyPreHook != "" || len(opts.Plugins) > 0 || opts.Async || opts.NoWait) {
+		return fmt.Errorf("--queue-url cannot be used with hooks, --plugin, --async or --no-wait")
+	}
+	if opts.SlackApproval != "" {
+		if len(opts.SlackApprovers) == 0 {
+			return fmt.Errorf("--slack-approval needs --slack-approvers")
+		}
+		if os.Getenv("SLACK_SIGNING_SECRET") == "" {
+			return fmt.Errorf("--slack-approval needs the Slack app's signing secret in SLACK_SIGNING_SECRET")
+		}
+		if opts.SlackApprovalTimeout <= 0 {
+			return fmt.Errorf("--slack-approval-timeout must be positive")
+		}
+	}
+	if opts.GitHubIssues != "" {
+		if strings.Count(opts.GitHubIssues, "/") != 1 {
+			return fmt.Errorf("--github-issues: want owner/name")
+		}
+		if os.Getenv("GITHUB_TOKEN") == "" {
+			return fmt.Errorf("--github-issues needs a token that can write issues in GITHUB_TOKEN")
+		}
+	}
+	return nil
+}
+
+func validateCommon(opts *AWSOpts) error {
+	switch opts.Source {
+	case sourceLambda:
+		if opts.Profile == "" {
+			return fmt.Errorf("--profile is required")
+		}
+		// Rows of --accounts-file may name their own.
+		if len(opts.Regions) == 0 && opts.AccountsFile == "" {
+			return fmt.Errorf("--regions is required: profile %s names no region and AWS_REGION is not set", opts.Profile)
+		}
+	case sourceResourceExplorer:
+		// Regions come from the search unless --regions narrows them.
+		if opts.Profile == "" {
+			return fmt.Errorf("--profile is required")
+		}
+		if !opts.All || opts.Offline {
+			return fmt.Errorf("--source %s needs --all and cannot be used with --offline", sourceResourceExplorer)
+		}
+	default:
+		return fmt.Errorf("--source must be %s or %s", sourceLambda, sourceResourceExplorer)
+	}
+	if opts.FunctionName == "" && !opts.All {
+		return fmt.Errorf("specify --function or --all")
+	}
+	if err := validateRedact(opts.Redact); err != nil {
+		return err
+	}
+	if opts.Qualifier != "" && (opts.FunctionName == "" || opts.Offline) {
+		return fmt.Errorf("--qualifier needs --function and cannot be used with --offline")
+	}
+	// The inventory cache is kept per profile, which every account shares.
+	if opts.AccountsFile != "" && (opts.CacheTTL > 0 || opts.Offline) {
+		return fmt.Errorf("--accounts-file cannot be used with --cache or --offline")
+	}
+	return nil
+}
+
+// runAttrs describes a whole run on its root span.
+func runAttrs(opts *AWSOpts) trace.SpanStartOption {
+	return trace.WithAttributes(
+		attribute.String("aws.profile", opts.Profile),
+		attribute.StringSlice("aws.regions", opts.Regions),
+	)
+}
+
+// setPolicy loads the run's runtime policy into opts and records it on the
+// run span.
+func setPolicy(ctx context.Context, span trace.Span, clients *clientFactory, opts *AWSOpts) error {
+	p, err := loadPolicy(ctx, clients, opts)
+	if err != nil {
+		return err
+	}
+	opts.Policy = p
+	span.SetAttributes(attribute.String("lambda.runtime_mappings", p.String()))
+	return nil
+}
+
+func resolveAccountID(ctx context.Context, clients *clientFactory) (string, error) {
+	cli, err := clients.STS(ctx)
+	if err != nil {
+		return "", err
 	}
+	return inventory.AccountID(ctx, cli)
 }
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestExecuteReleasesDeadline(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	var ran context.Context
+	cmd := newRootCmd()
+	cmd.SilenceErrors, cmd.SilenceUsage = true, true
+	cmd.AddCommand(&cobra.Command{Use: "fail", RunE: func(cmd *cobra.Command, _ []string) error {
+		ran = cmd.Context()
+		return errors.New("failed")
+	}})
+	cmd.SetArgs([]string{"fail", "--run-deadline", "1h"})
+	if err := execute(context.Background(), cmd); err == nil {
+		t.Fatal("want the command's error")
+	}
+	if ran == nil {
+		t.Fatal("command not run")
+	}
+	if _, ok := ran.Deadline(); !ok {
+		t.Fatal("run has no deadline")
+	}
+	if ran.Err() == nil {
+		t.Error("deadline of a failed run not released")
+	}
+	if waits, _ := ran.Value(waitContextKey{}).(context.Context); waits == nil || waits.Err() == nil {
+		t.Error("grace period of a failed run not released")
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// runMetrics is the Prometheus view of a process's runs. A nil *runMetrics
+// records nothing, so callers need not check whether --metrics-addr is set.
+type runMetrics struct {
+	reg       *prometheus.Registry
+	functions *prometheus.GaugeVec
+	updates   *prometheus.CounterVec
+	errors    *prometheus.CounterVec
+}
+
+func newRunMetrics() *runMetrics {
+	m := &runMetrics{
+		reg: prometheus.NewRegistry(),
+		functions: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "lambda_runtime_functions",
+			Help: "Functions per runtime as of the latest scan of an account and region.",
+		}, []string{"account_id", "region", "runtime"}),
+		updates: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "lambda_runtime_updates_total",
+			Help: "Runtime updates attempted, by outcome.",
+		}, []string{"account_id", "region", "outcome"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "lambda_runtime_discovery_errors_total",
+			Help: "Regions or functions that could not be listed or looked up.",
+		}, []string{"account_id", "region"}),
+	}
+	m.reg.MustRegister(m.functions, m.updates, m.errors)
+	return m
+}
+
+// observeInventory replaces the function counts for one account and region,
+// so runtimes that no longer have any functions drop out of the gauge.
+func (m *runMetrics) observeInventory(account, region string, byRuntime map[string]int) {
+	if m == nil {
+		return
+	}
+	m.functions.DeletePartialMatch(prometheus.Labels{"account_id": account, "region": region})
+	for rt, n := range byRuntime {
+		m.functions.WithLabelValues(account, region, rt).Set(float64(n))
+	}
+}
+
+// recordUpdate counts a finished bump. Functions that were skipped because
+// they were not on the source runtime are not updates and are ignored.
+func (m *runMetrics) recordUpdate(r functionResult) {
+	if m == nil || r.Outcome == "" {
+		return
+	}
+	m.updates.WithLabelValues(r.AccountID, r.Region, string(r.Outcome)).Inc()
+}
+
+// recordDiscoveryError counts a region or function that could not be
+// discovered.
+func (m *runMetrics) recordDiscoveryError(account, region string) {
+	if m == nil {
+		return
+	}
+	m.errors.WithLabelValues(account, region).Inc()
+}
+
+// startMetrics serves /metrics on addr until the returned stop func is
+// called. An empty addr disables metrics and returns nil.
+func startMetrics(ctx context.Context, addr string) (*runMetrics, func(), error) {
+	if addr == "" {
+		return nil, func() {}, nil
+	}
+	// Listen up front so a taken port fails the command instead of a
+	// background goroutine.
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("metrics listener: %w", err)
+	}
+	m := newRunMetrics()
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(m.reg, promhttp.HandlerOpts{}))
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Fprintln(os.Stderr, "warning: metrics server:", err)
+		}
+	}()
+	stop := func() {
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}
+	return m, stop, nil
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"update-lambda-runtime/pkg/bump"
+	"update-lambda-runtime/pkg/inventory"
+)
+
+// notifyTimeout bounds each notifier. Notifications are sent even when the
+// run was interrupted, so they run on a context detached from the signal.
+const notifyTimeout = 30 * time.Second
+
+// runReport is the outcome of a bump run as handed to notifiers.
+type runReport struct {
+	RunID       string             `json:"runId,omitempty"` // bump runs only
+	Profile     string             `json:"profile"`
+	Role        string             `json:"role,omitempty"` // assumed with the profile's credentials, by --accounts-file
+	AccountID   string             `json:"accountId"`
+	Regions     []string           `json:"regions"`
+	Mappings    map[string]string  `json:"mappings"`
+	StartedAt   time.Time          `json:"startedAt"`
+	FinishedAt  time.Time          `json:"finishedAt"`
+	Interrupted bool               `json:"interrupted"`
+	Counts      map[string]int     `json:"counts"`
+	Failures    map[string]int     `json:"failures,omitempty"` // failed and timed out functions per failure class
+	Classes     map[string]int     `json:"classes"`            // functions per class, for convergence across runs
+	Results     []functionResult   `json:"results"`
+	Unlisted    int                `json:"unlisted,omitempty"`      // up-to-date functions counted in Classes, not kept in Results
+	Errors      []discoveryError   `json:"errors,omitempty"`        // regions or functions not discovered
+	Stale       []staleVersion     `json:"staleVersions,omitempty"` // published versions left on the old runtime
+	Preflight   []preflightFinding `json:"preflight,omitempty"`     // why pre-flight validation stopped the run
+
+	saved bool // the run record was written, so undo can find the run
+}
+
+func newRunReport(opts *AWSOpts, accountID string, started time.Time, interrupted bool, results []functionResult) *runReport {
+	rep := &runReport{
+		Profile:     opts.Profile,
+		Role:        opts.AssumeRole,
+		AccountID:   accountID,
+		Regions:     opts.Regions,
+		Mappings:    opts.Policy.Mappings,
+		StartedAt:   started.UTC(),
+		FinishedAt:  time.Now().UTC(),
+		Interrupted: interrupted,
+		Counts:      make(map[string]int),
+		Failures:    make(map[string]int),
+		Classes:     make(map[string]int),
+		Results:     results,
+	}
+	now := time.Now()
+	for _, r := range results {
+		if r.Outcome != "" {
+			rep.Counts[string(r.Outcome)]++
+		}
+		if r.Failure != "" {
+			rep.Failures[string(r.Failure)]++
+		}
+		if c := classify(r, now); c != "" {
+			rep.Classes[c]++
+		}
+	}
+	return rep
+}
+
+// Classes of function a run ends with. Comparing their counts across runs
+// shows a fleet converging on the policy.
+const (
+	classUpToDate    = "up-to-date"  // needs nothing, or was just bumped
+	classNeedsBump   = "needs-bump"  // the policy maps it, and it was not bumped
+	classUnsupported = "unsupported" // on a deprecated runtime the policy does not map, or none
+	classSkipped     = "skipped"     // held back by a check or disabled
+)
+
+var functionClasses = []string{classUpToDate, classNeedsBump, classUnsupported, classSkipped}
+
+// classify returns r's class at now, or "" for Lambda@Edge replicas,
+// which their origin function stands for.
+func classify(r functionResult, now time.Time) string {
+	switch {
+	case r.Edge == edgeReplica:
+		return ""
+	case r.Outcome == bump.Skipped || r.Outcome == bump.Disabled:
+		return classSkipped
+	case r.Outcome == bump.Updated:
+		return classUpToDate
+	case r.TargetRuntime != "":
+		return classNeedsBump
+	case r.Runtime == "" || inventory.DeprecationStatus(r.Runtime, now) == inventory.Deprecated:
+		return classUnsupported
+	}
+	return classUpToDate
+}
+
+// classLine summarizes rep.Classes in functionClasses order.
+func (r *runReport) classLine() string {
+	parts := make([]string, len(functionClasses))
+	for i, c := range functionClasses {
+		parts[i] = fmt.Sprintf("%d %s", r.Classes[c], c)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// failureLine summarizes failures per class in bump.FailureClasses order,
+// leaving out classes with none. It is "" when nothing failed.
+func failureLine(counts map[string]int) string {
+	var parts []string
+	for _, c := range bump.FailureClasses {
+		if n := counts[string(c)]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, c))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// failures returns the results whose update did not succeed.
+func (r *runReport) failures() []functionResult {
+	var out []functionResult
+	for _, res := range r.Results {
+		if res.Outcome == bump.Failed || res.Outcome == bump.TimedOut {
+			out = append(out, res)
+		}
+	}
+	return out
+}
+
+// headline is a one-line summary shared by the chat-style notifiers.
+func (r *runReport) headline() string {
+	status := "completed"
+	switch {
+	case r.Interrupted:
+		status = "interrupted"
+	case len(r.failures()) > 0:
+		status = "completed with failures"
+	case len(r.Errors) > 0:
+		status = "completed with discovery errors"
+	}
+	return fmt.Sprintf("Lambda runtime bump %s on %s (%s) %s: %d updated, %d failed, %d timed out",
+		bump.FormatMappings(r.Mappings), r.AccountID, r.Profile, status,
+		r.Counts[string(bump.Updated)], r.Counts[string(bump.Failed)], r.Counts[string(bump.TimedOut)])
+}
+
+// notifier delivers the outcome of a bump run somewhere outside the terminal.
+type notifier interface {
+	notify(ctx context.Context, rep *runReport) error
+}
+
+// buildNotifiers returns the notifiers enabled by opts.
+func buildNotifiers(opts *AWSOpts, clients *clientFactory) []notifier {
+	var out []notifier
+	if opts.SlackWebhook != "" {
+		out = append(out, &slackNotifier{webhook: opts.SlackWebhook, perFailure: opts.SlackFailures})
+	}
+	if opts.SNSTopicARN != "" {
+		out = append(out, &snsNotifier{clients: clients, topicARN: opts.SNSTopicARN})
+	}
+	if len(opts.EmailTo) > 0 {
+		out = append(out, &emailNotifier{clients: clients, region: opts.Regions[0], from: opts.EmailFrom, to: opts.EmailTo})
+	}
+	if opts.PagerDutyKey != "" {
+		out = append(out, &pagerDutyNotifier{routingKey: opts.PagerDutyKey, url: pagerDutyEventsURL})
+	}
+	if opts.OpsgenieKey != "" {
+		out = append(out, &opsgenieNotifier{apiKey: opts.OpsgenieKey, team: opts.OpsgenieTeam, url: opsgenieAlertsURL})
+	}
+	if opts.SecurityHub {
+		out = append(out, &securityHubNotifier{clients: clients})
+	}
+	if opts.GitHubIssues != "" {
+		out = append(out, newGitHubIssueNotifier(opts, clients))
+	}
+	if opts.InventoryTable != "" {
+		out = append(out, &dynamoInventoryWriter{clients: clients, table: opts.InventoryTable, fallbackRegion: opts.Regions[0]})
+	}
+	return out
+}
+
+// sendNotifications runs every notifier, reporting failures as warnings:
+// a broken notification must not turn a successful run into a failed one.
+func sendNotifications(ctx context.Context, notifiers []notifier, rep *runReport) {
+	if len(notifiers) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), notifyTimeout)
+	defer cancel()
+	for _, n := range notifiers {
+		if err := n.notify(ctx, rep); err != nil {
+			fmt.Fprintln(os.Stderr, "warning: notification failed:", err)
+		}
+	}
+}
+
+// postJSON POSTs body as JSON and fails on any non-2xx response.
+func postJSON(ctx context.Context, url string, body any, header http.Header) error {
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	return postBytes(ctx, url, buf, header)
+}
+
+// postBytes POSTs an already encoded JSON body.
+func postBytes(ctx context.Context, url string, buf []byte, header http.Header) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("POST %s: %s: %s", req.URL.Host, resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
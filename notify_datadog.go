@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"update-lambda-runtime/pkg/bump"
+)
+
+// datadogNotifier sends run events and the runtime distribution to the
+// Datadog API. The API key comes from DD_API_KEY and the site from DD_SITE
+// (default datadoghq.com), as for the Datadog agent.
+type datadogNotifier struct {
+	apiKey string
+	site   string
+	// events is false for list, which only reports the distribution.
+	events bool
+}
+
+type datadogEvent struct {
+	Title          string   `json:"title"`
+	Text           string   `json:"text"`
+	AlertType      string   `json:"alert_type"`
+	AggregationKey string   `json:"aggregation_key"`
+	Tags           []string `json:"tags"`
+}
+
+type datadogSeries struct {
+	Metric string         `json:"metric"`
+	Type   int            `json:"type"`
+	Points []datadogPoint `json:"points"`
+	Tags   []string       `json:"tags"`
+}
+
+type datadogPoint struct {
+	Timestamp int64   `json:"timestamp"`
+	Value     float64 `json:"value"`
+}
+
+// Series types in the v2 metrics intake.
+const (
+	datadogCount = 1
+	datadogGauge = 3
+)
+
+func newDatadogNotifier(events bool) (*datadogNotifier, error) {
+	key := os.Getenv("DD_API_KEY")
+	if key == "" {
+		return nil, fmt.Errorf("--datadog needs DD_API_KEY")
+	}
+	site := os.Getenv("DD_SITE")
+	if site == "" {
+		site = "datadoghq.com"
+	}
+	return &datadogNotifier{apiKey: key, site: site, events: events}, nil
+}
+
+// started posts the run-start event.
+func (d *datadogNotifier) started(ctx context.Context, opts *AWSOpts, accountID string) error {
+	ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+	return d.event(ctx, datadogEvent{
+		Title:     fmt.Sprintf("Lambda runtime bump %s started on %s", opts.Policy, accountID),
+		Text:      fmt.Sprintf("Profile %s, regions %s", opts.Profile, strings.Join(opts.Regions, ", ")),
+		AlertType: "info",
+		Tags:      d.runTags(accountID, opts.Policy.Mappings),
+	})
+}
+
+func (d *datadogNotifier) notify(ctx context.Context, rep *runReport) error {
+	if err := d.submitSeries(ctx, rep); err != nil {
+		return err
+	}
+	if !d.events {
+		return nil
+	}
+	alert := "success"
+	switch {
+	case len(rep.failures()) > 0:
+		alert = "error"
+	case rep.Interrupted:
+		alert = "warning"
+	}
+	tags := d.runTags(rep.AccountID, rep.Mappings)
+	if err := d.event(ctx, datadogEvent{Title: rep.headline(), Text: rep.headline(), AlertType: alert, Tags: tags}); err != nil {
+		return err
+	}
+	for _, f := range rep.failures() {
+		err := d.event(ctx, datadogEvent{
+			Title:     fmt.Sprintf("Lambda runtime bump %s: %s", f.Outcome, f.Name),
+			Text:      fmt.Sprintf("%s in %s/%s %s bumping %s → %s", f.Name, f.AccountID, f.Region, f.Outcome, f.Runtime, f.TargetRuntime),
+			AlertType: "error",
+			Tags:      append(slices.Clip(tags), "region:"+f.Region, "function:"+f.Name),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// submitSeries sends functions per runtime as they stand after the run, so
+// updated functions count under the target runtime, plus bump outcomes.
+func (d *datadogNotifier) submitSeries(ctx context.Context, rep *runReport) error {
+	type key struct{ account, region, runtime string }
+	dist := make(map[key]int)
+	for _, r := range rep.Results {
+		rt := r.Runtime
+		if r.Outcome == bump.Updated {
+			rt = r.TargetRuntime
+		}
+		dist[key{r.AccountID, r.Region, rt}]++
+	}
+	now := time.Now().Unix()
+	var series []datadogSeries
+	for k, n := range dist {
+		series = append(series, datadogSeries{
+			Metric: "lambda_runtime.functions",
+			Type:   datadogGauge,
+			Points: []datadogPoint{{Timestamp: now, Value: float64(n)}},
+			Tags:   []string{"account_id:" + k.account, "region:" + k.region, "runtime:" + k.runtime},
+		})
+	}
+	for outcome, n := range rep.Counts {
+		series = append(series, datadogSeries{
+			Metric: "lambda_runtime.updates",
+			Type:   datadogCount,
+			Points: []datadogPoint{{Timestamp: now, Value: float64(n)}},
+			Tags:   []string{"account_id:" + rep.AccountID, "outcome:" + outcome},
+		})
+	}
+	if len(series) == 0 {
+		return nil
+	}
+	if err := postJSON(ctx, d.url("/api/v2/series"), map[string]any{"series": series}, d.header()); err != nil {
+		return fmt.Errorf("datadog: %w", err)
+	}
+	return nil
+}
+
+func (d *datadogNotifier) event(ctx context.Context, ev datadogEvent) error {
+	ev.AggregationKey = eventSource
+	if err := postJSON(ctx, d.url("/api/v1/events"), ev, d.header()); err != nil {
+		return fmt.Errorf("datadog: %w", err)
+	}
+	return nil
+}
+
+// runTags tags an event with the account and every runtime the run moves
+// functions from and to.
+func (d *datadogNotifier) runTags(accountID string, mappings map[string]string) []string {
+	tags := []string{"account_id:" + accountID}
+	for _, from := range slices.Sorted(maps.Keys(mappings)) {
+		tags = append(tags, "source_runtime:"+from, "target_runtime:"+mappings[from])
+	}
+	return tags
+}
+
+func (d *datadogNotifier) url(path string) string {
+	return "https://api." + d.site + path
+}
+
+func (d *datadogNotifier) header() http.Header {
+	return http.Header{"Dd-Api-Key": {d.apiKey}}
+}
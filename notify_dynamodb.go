@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"update-lambda-runtime/pkg/bump"
+	"update-lambda-runtime/pkg/inventory"
+)
+
+// dynamoBatch is the most writes BatchWriteItem accepts per call.
+const dynamoBatch = 25
+
+// dynamoInventoryWriter upserts one item per function into a DynamoDB table
+// keyed by the string partition key functionArn, so the table always holds
+// the latest runtime seen for every function any run has looked at.
+type dynamoInventoryWriter struct {
+	clients        *clientFactory
+	table          string
+	fallbackRegion string
+}
+
+// dynamoClient returns a client for the region of table: the one in its
+// ARN, else fallbackRegion.
+func dynamoClient(ctx context.Context, clients *clientFactory, table, fallbackRegion string) (*dynamodb.Client, error) {
+	region := fallbackRegion
+	if strings.HasPrefix(table, "arn:") {
+		a, err := arn.Parse(table)
+		if err != nil {
+			return nil, fmt.Errorf("table %q: %w", table, err)
+		}
+		region = a.Region
+	}
+	cfg, err := clients.Config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return dynamodb.NewFromConfig(cfg, func(o *dynamodb.Options) {
+		o.Region = region
+	}), nil
+}
+
+func (d *dynamoInventoryWriter) notify(ctx context.Context, rep *runReport) error {
+	cli, err := dynamoClient(ctx, d.clients, d.table, d.fallbackRegion)
+	if err != nil {
+		return fmt.Errorf("dynamodb: %w", err)
+	}
+
+	now := time.Now().UTC()
+	var writes []ddbtypes.WriteRequest
+	for _, r := range rep.Results {
+		// Record what the function runs once this run is done with it.
+		rt := r.Runtime
+		if r.Outcome == bump.Updated {
+			rt = r.TargetRuntime
+		}
+		item := map[string]ddbtypes.AttributeValue{
+			"functionArn":                 &ddbtypes.AttributeValueMemberS{Value: functionARN(r)},
+			"accountId":                   &ddbtypes.AttributeValueMemberS{Value: r.AccountID},
+			"region":                      &ddbtypes.AttributeValueMemberS{Value: r.Region},
+			"functionName":                &ddbtypes.AttributeValueMemberS{Value: r.Name},
+			"runtime":                     &ddbtypes.AttributeValueMemberS{Value: rt},
+			"lastSeen":                    &ddbtypes.AttributeValueMemberS{Value: now.Format(time.RFC3339)},
+			"inventory.DeprecationStatus": &ddbtypes.AttributeValueMemberS{Value: inventory.DeprecationStatus(rt, now)},
+		}
+		if dep, ok := inventory.DeprecationDate(rt); ok {
+			item["inventory.DeprecationDate"] = &ddbtypes.AttributeValueMemberS{Value: dep.Format(time.DateOnly)}
+		}
+		if r.Outcome == bump.Updated && rep.RunID != "" {
+			item["lastRunId"] = &ddbtypes.AttributeValueMemberS{Value: rep.RunID}
+		}
+		writes = append(writes, ddbtypes.WriteRequest{PutRequest: &ddbtypes.PutRequest{Item: item}})
+	}
+
+	for batch := range slices.Chunk(writes, dynamoBatch) {
+		pending := map[string][]ddbtypes.WriteRequest{d.table: batch}
+		// Throttled writes come back unprocessed rather than as an error;
+		// resubmit them with a growing pause until the batch drains.
+		for attempt := 0; len(pending) > 0; attempt++ {
+			if attempt > 0 {
+				select {
+				case <-ctx.Done():
+					return fmt.Errorf("dynamodb: %w", ctx.Err())
+				case <-time.After(time.Duration(attempt) * 200 * time.Millisecond):
+				}
+			}
+			out, err := cli.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{RequestItems: pending})
+			if err != nil {
+				return fmt.Errorf("dynamodb: %w", err)
+			}
+			pending = out.UnprocessedItems
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2"
+	sestypes "github.com/aws/aws-sdk-go-v2/service/sesv2/types"
+)
+
+// emailNotifier sends the HTML report, with the CSV attached, through SES.
+// The sender must be a verified SES identity in the region used.
+type emailNotifier struct {
+	clients *clientFactory
+	region  string
+	from    string
+	to      []string
+}
+
+func (e *emailNotifier) notify(ctx context.Context, rep *runReport) error {
+	var html, csv bytes.Buffer
+	if err := writeHTMLReport(&html, rep); err != nil {
+		return fmt.Errorf("email: %w", err)
+	}
+	if err := writeCSVReport(&csv, rep); err != nil {
+		return fmt.Errorf("email: %w", err)
+	}
+
+	cfg, err := e.clients.Config(ctx)
+	if err != nil {
+		return fmt.Errorf("email: %w", err)
+	}
+	cli := sesv2.NewFromConfig(cfg, func(o *sesv2.Options) {
+		if o.Region == "" {
+			o.Region = e.region
+		}
+	})
+	_, err = cli.SendEmail(ctx, &sesv2.SendEmailInput{
+		FromEmailAddress: aws.String(e.from),
+		Destination:      &sestypes.Destination{ToAddresses: e.to},
+		Content: &sestypes.EmailContent{
+			Simple: &sestypes.Message{
+				Subject: &sestypes.Content{Data: aws.String(rep.headline()), Charset: aws.String("UTF-8")},
+				Body: &sestypes.Body{
+					Html: &sestypes.Content{Data: aws.String(html.String()), Charset: aws.String("UTF-8")},
+				},
+				Attachments: []sestypes.Attachment{{
+					FileName:           aws.String(fmt.Sprintf("lambda-runtime-bump-%s-%s.csv", rep.AccountID, inZone(rep.FinishedAt, time.UTC).Format("20060102-150405"))),
+					ContentType:        aws.String("text/csv"),
+					ContentDisposition: sestypes.AttachmentContentDispositionAttachment,
+					RawContent:         csv.Bytes(),
+				}},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("email: send: %w", err)
+	}
+	return nil
+}
+
+func (e *emailNotifier) alertNew(ctx context.Context, accountID string, fns []functionResult) error {
+	cfg, err := e.clients.Config(ctx)
+	if err != nil {
+		return fmt.Errorf("email: %w", err)
+	}
+	cli := sesv2.NewFromConfig(cfg, func(o *sesv2.Options) {
+		if o.Region == "" {
+			o.Region = e.region
+		}
+	})
+	_, err = cli.SendEmail(ctx, &sesv2.SendEmailInput{
+		FromEmailAddress: aws.String(e.from),
+		Destination:      &sestypes.Destination{ToAddresses: e.to},
+		Content: &sestypes.EmailContent{
+			Simple: &sestypes.Message{
+				Subject: &sestypes.Content{Data: aws.String(fmt.Sprintf("%d new Lambda functions on deprecated runtimes in %s", len(fns), accountID)), Charset: aws.String("UTF-8")},
+				Body: &sestypes.Body{
+					Text: &sestypes.Content{Data: aws.String(alertText(accountID, fns)), Charset: aws.String("UTF-8")},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("email: send: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	ebtypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+)
+
+// eventSource is the Source of every event this tool puts on a bus.
+const eventSource = "update-lambda-runtime"
+
+// eventBridgeSink puts each lifecycle event on an EventBridge bus, with the
+// event type as DetailType so rules can match individual transitions.
+type eventBridgeSink struct {
+	cli *eventbridge.Client
+	bus string
+}
+
+// newEventBridgeSink targets bus, given as a name or ARN. A bare name is
+// resolved in fallbackRegion.
+func newEventBridgeSink(ctx context.Context, clients *clientFactory, bus, fallbackRegion string) (*eventBridgeSink, error) {
+	region := fallbackRegion
+	if strings.HasPrefix(bus, "arn:") {
+		a, err := arn.Parse(bus)
+		if err != nil {
+			return nil, fmt.Errorf("event bus %q: %w", bus, err)
+		}
+		region = a.Region
+	}
+	cfg, err := clients.Config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cli := eventbridge.NewFromConfig(cfg, func(o *eventbridge.Options) {
+		o.Region = region
+	})
+	return &eventBridgeSink{cli: cli, bus: bus}, nil
+}
+
+func (s *eventBridgeSink) emit(ctx context.Context, ev lifecycleEvent) error {
+	detail, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	out, err := s.cli.PutEvents(ctx, &eventbridge.PutEventsInput{
+		Entries: []ebtypes.PutEventsRequestEntry{{
+			EventBusName: aws.String(s.bus),
+			Source:       aws.String(eventSource),
+			DetailType:   aws.String(ev.Type),
+			Detail:       aws.String(string(detail)),
+			Time:         aws.Time(ev.Time),
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("eventbridge: %w", err)
+	}
+	if out.FailedEntryCount > 0 && len(out.Entries) > 0 {
+		return fmt.Errorf("eventbridge: %s", aws.ToString(out.Entries[0].ErrorMessage))
+	}
+	return nil
+}
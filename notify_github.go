@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+
+	"update-lambda-runtime/pkg/bump"
+)
+
+// githubAPIURL is the GitHub REST API, unless GITHUB_API_URL names a
+// GitHub Enterprise Server's, as it does in GitHub Actions.
+const githubAPIURL = "https://api.github.com"
+
+// githubIssueLabel marks every issue this tool opens, so later runs
+// comment on the open one instead of opening duplicates.
+const githubIssueLabel = "update-lambda-runtime"
+
+// githubOpenIssuesPages caps how many pages of a repository's open
+// issues are read to find the ones already opened.
+const githubOpenIssuesPages = 10
+
+// githubIssueNotifier opens a GitHub issue for each function a bump could
+// not move: one that failed verification after its update, or that was
+// held back by a code incompatibility or a layer that does not support
+// the target runtime. The repository comes from a tag on the function,
+// else the default one; the owner named by another tag is assigned, or
+// mentioned when it is an org/team. A function whose issue is still open
+// gets a comment instead. It authenticates with GITHUB_TOKEN.
+type githubIssueNotifier struct {
+	apiURL      string
+	token       string
+	defaultRepo string
+	repoTag     string
+	ownerTag    string
+	tags        func(ctx context.Context, r functionResult) (map[string]string, error)
+}
+
+func newGitHubIssueNotifier(opts *AWSOpts, clients *clientFactory) *githubIssueNotifier {
+	return &githubIssueNotifier{
+		apiURL:      cmp.Or(os.Getenv("GITHUB_API_URL"), githubAPIURL),
+		token:       os.Getenv("GITHUB_TOKEN"),
+		defaultRepo: opts.GitHubIssues,
+		repoTag:     opts.GitHubRepoTag,
+		ownerTag:    opts.GitHubOwnerTag,
+		tags: func(ctx context.Context, r functionResult) (map[string]string, error) {
+			cli, err := clients.Lambda(ctx, r.Region)
+			if err != nil {
+				return nil, err
+			}
+			out, err := cli.ListTags(ctx, &lambda.ListTagsInput{Resource: aws.String(functionARN(r))})
+			if err != nil {
+				return nil, err
+			}
+			return out.Tags, nil
+		},
+	}
+}
+
+// githubIssueCase is a function an issue is opened for, and why.
+type githubIssueCase struct {
+	fn     functionResult
+	kind   string // "failed verification" or "blocked"
+	detail string
+}
+
+// githubIssueCases picks the functions of rep that need an issue, in
+// result order: those failing verification, those a code check skipped,
+// and those pre-flight validation found layers for that do not support
+// the target runtime.
+func githubIssueCases(rep *runReport) []githubIssueCase {
+	var out []githubIssueCase
+	for _, r := range rep.Results {
+		switch {
+		case r.Outcome == bump.Failed && r.Failure == bump.VerificationFailed:
+			out = append(out, githubIssueCase{r, "failed verification", r.Detail})
+		case r.Outcome == bump.Skipped && r.Detail != "":
+			out = append(out, githubIssueCase{r, "blocked", r.Detail})
+		}
+	}
+	for _, f := range rep.Preflight {
+		if f.Check != checkLayers {
+			continue
+		}
+		for _, r := range rep.Results {
+			if r.Region == f.Region && r.Name == f.Function {
+				out = append(out, githubIssueCase{r, "blocked", "layers: " + f.Message})
+				break
+			}
+		}
+	}
+	return out
+}
+
+func (g *githubIssueNotifier) notify(ctx context.Context, rep *runReport) error {
+	cases := githubIssueCases(rep)
+	open := make(map[string]map[string]int) // repository → title → issue number
+	var errs []error
+	for _, c := range cases {
+		tags, err := g.tags(ctx, c.fn)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tags for %s: %w", c.fn.Name, err))
+			continue
+		}
+		repo := cmp.Or(tags[g.repoTag], g.defaultRepo)
+		if repo == "" {
+			errs = append(errs, fmt.Errorf("%s has no %s tag and no default repository is set", c.fn.Name, g.repoTag))
+			continue
+		}
+		if _, ok := open[repo]; !ok {
+			if open[repo], err = g.openIssues(ctx, repo); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", repo, err))
+				continue
+			}
+		}
+		title := fmt.Sprintf("Lambda runtime bump %s: %s (%s/%s)", c.kind, c.fn.Name, c.fn.AccountID, c.fn.Region)
+		body := githubIssueBody(rep, c, tags[g.ownerTag])
+		if n, ok := open[repo][title]; ok {
+			if err := g.call(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/issues/%d/comments", repo, n), map[string]string{"body": body}, nil); err != nil {
+				errs = append(errs, fmt.Errorf("%s#%d: %w", repo, n, err))
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "GitHub %s#%d: commented for %s\n", repo, n, c.fn.Name)
+			continue
+		}
+		issue := map[string]any{"title": title, "body": body, "labels": []string{githubIssueLabel}}
+		if owner := tags[g.ownerTag]; owner != "" && !strings.Contains(owner, "/") {
+			issue["assignees"] = []string{owner}
+		}
+		var created struct {
+			Number int `json:"number"`
+		}
+		if err := g.call(ctx, http.MethodPost, "/repos/"+repo+"/issues", issue, &created); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s: %w", repo, c.fn.Name, err))
+			continue
+		}
+		open[repo][title] = created.Number
+		fmt.Fprintf(os.Stderr, "GitHub %s#%d: opened for %s\n", repo, created.Number, c.fn.Name)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("github issues: %w", err)
+	}
+	return nil
+}
+
+// githubIssueBody describes what happened to c's function in rep, for its
+// owner to act on.
+func githubIssueBody(rep *runReport, c githubIssueCase, owner string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Bumping `%s` from `%s` to `%s` %s.\n\n", c.fn.Name, c.fn.Runtime, c.fn.TargetRuntime, c.kind)
+	fmt.Fprintf(&b, "| | |\n|---|---|\n")
+	fmt.Fprintf(&b, "| Function | `%s` |\n| Account | %s |\n| Region | %s |\n", functionARN(c.fn), c.fn.AccountID, c.fn.Region)
+	if rep.RunID != "" {
+		fmt.Fprintf(&b, "| Run | `%s` |\n", rep.RunID)
+	}
+	if c.fn.Failure != "" {
+		fmt.Fprintf(&b, "| Failure | %s |\n", c.fn.Failure)
+	}
+	fmt.Fprintf(&b, "\n**Diagnostics**\n\n```\n%s\n```\n\n", cmp.Or(c.detail, "no details recorded"))
+	switch {
+	case c.fn.Outcome == bump.Failed:
+		b.WriteString("The function was updated, then rejected by verification. Check it still works on the new runtime, or roll it back with `update-lambda-runtime undo")
+		if rep.RunID != "" {
+			b.WriteString(" " + rep.RunID)
+		}
+		b.WriteString("`.\n")
+	default:
+		b.WriteString("The function was left on its runtime. Fix the incompatibility and run the bump again, or bump it anyway with `--force`.\n")
+	}
+	if strings.Contains(owner, "/") {
+		fmt.Fprintf(&b, "\ncc @%s\n", owner)
+	}
+	return b.String()
+}
+
+// openIssues maps the titles of repo's open issues this tool opened to
+// their numbers.
+func (g *githubIssueNotifier) openIssues(ctx context.Context, repo string) (map[string]int, error) {
+	titles := make(map[string]int)
+	for page := 1; page <= githubOpenIssuesPages; page++ {
+		var issues []struct {
+			Number int    `json:"number"`
+			Title  string `json:"title"`
+		}
+		q := url.Values{"labels": {githubIssueLabel}, "state": {"open"}, "per_page": {"100"}, "page": {fmt.Sprint(page)}}
+		if err := g.call(ctx, http.MethodGet, "/repos/"+repo+"/issues?"+q.Encode(), nil, &issues); err != nil {
+			return nil, err
+		}
+		for _, is := range issues {
+			titles[is.Title] = is.Number
+		}
+		if len(issues) < 100 {
+			break
+		}
+	}
+	return titles, nil
+}
+
+// call sends a GitHub REST request and decodes a JSON response into out.
+func (g *githubIssueNotifier) call(ctx context.Context, method, path string, body, out any) error {
+	var rd io.Reader
+	if body != nil {
+		buf, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		rd = bytes.NewReader(buf)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(g.apiURL, "/")+path, rd)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if g.token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s: %s: %s", method, req.URL.Path, resp.Status, bytes.TrimSpace(msg))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"update-lambda-runtime/pkg/bump"
+)
+
+func TestGitHubIssueNotifier(t *testing.T) {
+	type request struct {
+		method, path string
+		body         map[string]any
+	}
+	var got []request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			http.Error(w, "no token", http.StatusUnauthorized)
+			return
+		}
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		got = append(got, request{r.Method, r.URL.Path, body})
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/acme/payments/issues":
+			json.NewEncoder(w).Encode([]map[string]any{{"number": 7, "title": "Lambda runtime bump failed verification: charge (123456789012/us-east-1)"}})
+		case r.Method == http.MethodGet:
+			w.Write([]byte("[]"))
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/issues"):
+			w.Write([]byte(`{"number": 42}`))
+		}
+	}))
+	defer srv.Close()
+
+	tags := map[string]map[string]string{
+		"charge": {"github-repo": "acme/payments", "team": "acme/payments-team"},
+		"report": {"team": "jo"},
+		"batch":  {},
+	}
+	g := &githubIssueNotifier{
+		apiURL:      srv.URL,
+		token:       "tok",
+		defaultRepo: "acme/platform",
+		repoTag:     "github-repo",
+		ownerTag:    "team",
+		tags: func(_ context.Context, r functionResult) (map[string]string, error) {
+			return tags[r.Name], nil
+		},
+	}
+	fn := func(name string, o bump.Outcome) functionResult {
+		return functionResult{AccountID: "123456789012", Region: "us-east-1", Name: name, Runtime: "python3.9", TargetRuntime: "python3.12", Outcome: o}
+	}
+	charge := fn("charge", bump.Failed)
+	charge.Failure, charge.Detail = bump.VerificationFailed, "failed verification by smoke: status 500"
+	report := fn("report", bump.Skipped)
+	report.Detail = "uses the AWS SDK for JavaScript v2, which nodejs18.x does not bundle"
+	rep := &runReport{RunID: "run-1", Results: []functionResult{
+		charge,
+		report,
+		fn("ok", bump.Updated),
+		fn("prod", bump.Skipped), // skipped without a blocker, e.g. production
+		fn("batch", bump.NotAttempted),
+	}, Preflight: []preflightFinding{{Region: "us-east-1", Function: "batch", Check: checkLayers, Message: "layer deps:3 does not support python3.12"}}}
+
+	if err := g.notify(context.Background(), rep); err != nil {
+		t.Fatal(err)
+	}
+	var posts []request
+	for _, r := range got {
+		if r.method == http.MethodPost {
+			posts = append(posts, r)
+		}
+	}
+	if len(posts) != 3 {
+		t.Fatalf("posts %+v, want a comment on charge's open issue and issues for report and batch", posts)
+	}
+	if posts[0].path != "/repos/acme/payments/issues/7/comments" || !strings.Contains(posts[0].body["body"].(string), "smoke: status 500") || !strings.Contains(posts[0].body["body"].(string), "cc @acme/payments-team") {
+		t.Errorf("charge: %+v, want a comment with the diagnostics mentioning the team", posts[0])
+	}
+	if posts[1].path != "/repos/acme/platform/issues" || !slices.Equal(posts[1].body["assignees"].([]any), []any{"jo"}) {
+		t.Errorf("report: %+v, want an issue in the default repository assigned to jo", posts[1])
+	}
+	if body := posts[2].body["body"].(string); !strings.Contains(body, "layer deps:3 does not support") || !strings.HasPrefix(posts[2].body["title"].(string), "Lambda runtime bump blocked: batch") {
+		t.Errorf("batch: %+v, want an issue for the layer finding", posts[2])
+	}
+}
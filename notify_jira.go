@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// jiraLabel marks every issue this tool opens, so later runs find and update
+// them instead of opening duplicates.
+const jiraLabel = "update-lambda-runtime"
+
+// jiraNotifier keeps one open Jira issue per owning team listing the team's
+// functions on runtimes in the deprecation calendar. Teams come from a tag
+// on each function; the due date is the earliest deprecation among them.
+// It talks to the Jira Cloud REST API with credentials from JIRA_USER and
+// JIRA_API_TOKEN (basic auth), or JIRA_API_TOKEN alone as a bearer token.
+type jiraNotifier struct {
+	clients  *clientFactory
+	baseURL  string
+	project  string
+	groupTag string
+}
+
+func (j *jiraNotifier) notify(ctx context.Context, rep *runReport) error {
+	groups, err := groupByOwner(ctx, j.clients, j.groupTag, deprecatedFunctions(rep))
+	if err != nil {
+		return fmt.Errorf("jira: %w", err)
+	}
+
+	for _, group := range slices.Sorted(maps.Keys(groups)) {
+		key, err := j.upsert(ctx, group, groups[group])
+		if err != nil {
+			return fmt.Errorf("jira: %s: %w", group, err)
+		}
+		fmt.Fprintf(os.Stderr, "Jira %s: %d functions for %s\n", key, len(groups[group]), group)
+	}
+	return nil
+}
+
+// upsert updates the group's open issue, or opens one, and returns its key.
+func (j *jiraNotifier) upsert(ctx context.Context, group string, entries []deprecatedFunction) (string, error) {
+	groupLabel := "owner-" + jiraLabelSafe.ReplaceAllString(group, "-")
+
+	var b strings.Builder
+	if group == untaggedGroup {
+		fmt.Fprintf(&b, "Lambda functions without a %s tag", j.groupTag)
+	} else {
+		fmt.Fprintf(&b, "Lambda functions tagged %s=%s", j.groupTag, group)
+	}
+	b.WriteString(" still run a deprecated runtime. Update them before the runtime's deprecation date.\n\n")
+	b.WriteString("||Account||Region||Function||Runtime||Deprecated||\n")
+	for _, e := range entries {
+		fmt.Fprintf(&b, "|%s|%s|%s|%s|%s|\n", e.fn.AccountID, e.fn.Region, e.fn.Name, e.fn.Runtime, e.deprecated.Format(time.DateOnly))
+	}
+	fields := map[string]any{
+		"summary":     fmt.Sprintf("Lambda functions on deprecated runtimes: %s", group),
+		"description": b.String(),
+		"duedate":     entries[0].deprecated.Format(time.DateOnly),
+	}
+
+	jql := fmt.Sprintf(`project = %q AND labels = %q AND labels = %q AND statusCategory != Done`, j.project, jiraLabel, groupLabel)
+	var found struct {
+		Issues []struct {
+			Key string `json:"key"`
+		} `json:"issues"`
+	}
+	if err := j.call(ctx, http.MethodGet, "/rest/api/2/search/jql?maxResults=1&fields=key&jql="+url.QueryEscape(jql), nil, &found); err != nil {
+		return "", err
+	}
+	if len(found.Issues) > 0 {
+		key := found.Issues[0].Key
+		return key, j.call(ctx, http.MethodPut, "/rest/api/2/issue/"+key, map[string]any{"fields": fields}, nil)
+	}
+
+	fields["project"] = map[string]string{"key": j.project}
+	fields["issuetype"] = map[string]string{"name": "Task"}
+	fields["labels"] = []string{jiraLabel, groupLabel}
+	var created struct {
+		Key string `json:"key"`
+	}
+	err := j.call(ctx, http.MethodPost, "/rest/api/2/issue", map[string]any{"fields": fields}, &created)
+	return created.Key, err
+}
+
+// jiraLabelSafe matches the characters Jira does not allow in labels.
+var jiraLabelSafe = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// call sends a Jira REST request and decodes a JSON response into out.
+func (j *jiraNotifier) call(ctx context.Context, method, path string, body, out any) error {
+	var rd io.Reader
+	if body != nil {
+		buf, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		rd = bytes.NewReader(buf)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(j.baseURL, "/")+path, rd)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if user := os.Getenv("JIRA_USER"); user != "" {
+		req.SetBasicAuth(user, os.Getenv("JIRA_API_TOKEN"))
+	} else if tok := os.Getenv("JIRA_API_TOKEN"); tok != "" {
+		req.Header.Set("Authorization", "Bearer "+tok)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s: %s: %s", method, req.URL.Path, resp.Status, bytes.TrimSpace(msg))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"update-lambda-runtime/pkg/bump"
+)
+
+// opsgenieAlertsURL is the Opsgenie Alert API endpoint (US instance).
+const opsgenieAlertsURL = "https://api.opsgenie.com/v2/alerts"
+
+// opsgenieMessageMax is the longest alert message Opsgenie accepts.
+const opsgenieMessageMax = 130
+
+// opsgenieNotifier opens an Opsgenie alert, routed to a team when one is
+// set, when a run leaves functions failed or timed out. Clean runs send
+// nothing.
+type opsgenieNotifier struct {
+	apiKey string
+	team   string
+	url    string
+}
+
+type opsgenieResponder struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type opsgenieAlert struct {
+	Message     string              `json:"message"`
+	Alias       string              `json:"alias"`
+	Description string              `json:"description"`
+	Responders  []opsgenieResponder `json:"responders,omitempty"`
+	Tags        []string            `json:"tags"`
+	Details     map[string]string   `json:"details"`
+	Entity      string              `json:"entity"`
+	Source      string              `json:"source"`
+	Priority    string              `json:"priority"`
+}
+
+func (o *opsgenieNotifier) notify(ctx context.Context, rep *runReport) error {
+	failed := rep.failures()
+	if len(failed) == 0 {
+		return nil
+	}
+	var b strings.Builder
+	for _, f := range failed {
+		fmt.Fprintf(&b, "%s in %s/%s %s bumping %s → %s\n", f.Name, f.AccountID, f.Region, f.Outcome, f.Runtime, f.TargetRuntime)
+	}
+	msg := rep.headline()
+	if r := []rune(msg); len(r) > opsgenieMessageMax {
+		msg = string(r[:opsgenieMessageMax-1]) + "…"
+	}
+	// As with PagerDuty, repeated failing runs for the same account and
+	// mappings add to one open alert instead of raising another.
+	alert := opsgenieAlert{
+		Message:     msg,
+		Alias:       fmt.Sprintf("%s/%s/%s", eventSource, rep.AccountID, bump.FormatMappings(rep.Mappings)),
+		Description: b.String(),
+		Tags:        []string{"lambda", eventSource},
+		Details: map[string]string{
+			"profile": rep.Profile,
+			"regions": strings.Join(rep.Regions, ","),
+			"failed":  fmt.Sprint(len(failed)),
+		},
+		Entity:   rep.AccountID,
+		Source:   eventSource,
+		Priority: "P2",
+	}
+	if o.team != "" {
+		alert.Responders = []opsgenieResponder{{Name: o.team, Type: "team"}}
+	}
+	header := http.Header{"Authorization": {"GenieKey " + o.apiKey}}
+	if err := postJSON(ctx, o.url, alert, header); err != nil {
+		return fmt.Errorf("opsgenie: %w", err)
+	}
+	return nil
+}
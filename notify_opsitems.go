@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// OpsItem granularity for --opsitems.
+const (
+	opsItemsPerFunction = "function"
+	opsItemsPerTeam     = "team"
+)
+
+// opsItemKey is the searchable operational data key holding each OpsItem's
+// deduplication key, so later runs update the open item instead of adding
+// another.
+const opsItemKey = "updateLambdaRuntimeKey"
+
+// opsItemDescriptionMax is the longest OpsItem description SSM accepts.
+const opsItemDescriptionMax = 2048
+
+// opsItemNotifier keeps one open OpsCenter OpsItem per function on a
+// runtime in the deprecation calendar, or one per owning team (from a tag,
+// as for Jira). Per-function items live in the function's region and link
+// it as a resource; per-team items live in the first of --regions.
+type opsItemNotifier struct {
+	clients        *clientFactory
+	perTeam        bool
+	groupTag       string
+	fallbackRegion string
+}
+
+func (o *opsItemNotifier) notify(ctx context.Context, rep *runReport) error {
+	fns := deprecatedFunctions(rep)
+	if !o.perTeam {
+		for _, e := range fns {
+			key := eventSource + "/" + functionARN(e.fn)
+			title := fmt.Sprintf("Lambda function %s runs deprecated runtime %s", e.fn.Name, e.fn.Runtime)
+			id, err := o.upsert(ctx, e.fn.Region, key, title, opsItemDescription("This function still runs a deprecated runtime. Update it before the deprecation date.", []deprecatedFunction{e}), functionARN(e.fn))
+			if err != nil {
+				return fmt.Errorf("opsitems: %s: %w", e.fn.Name, err)
+			}
+			fmt.Fprintf(os.Stderr, "OpsItem %s: %s\n", id, e.fn.Name)
+		}
+		return nil
+	}
+
+	groups, err := groupByOwner(ctx, o.clients, o.groupTag, fns)
+	if err != nil {
+		return fmt.Errorf("opsitems: %w", err)
+	}
+	for _, group := range slices.Sorted(maps.Keys(groups)) {
+		who := fmt.Sprintf("Lambda functions tagged %s=%s", o.groupTag, group)
+		if group == untaggedGroup {
+			who = fmt.Sprintf("Lambda functions without a %s tag", o.groupTag)
+		}
+		intro := who + " still run a deprecated runtime. Update them before the runtime's deprecation date."
+		title := fmt.Sprintf("Lambda functions on deprecated runtimes: %s", group)
+		id, err := o.upsert(ctx, o.fallbackRegion, eventSource+"/owner/"+group, title, opsItemDescription(intro, groups[group]), "")
+		if err != nil {
+			return fmt.Errorf("opsitems: %s: %w", group, err)
+		}
+		fmt.Fprintf(os.Stderr, "OpsItem %s: %d functions for %s\n", id, len(groups[group]), group)
+	}
+	return nil
+}
+
+// upsert updates the open OpsItem carrying key in region, or creates one,
+// and returns its ID. resourceARN, when set, is linked to a new item.
+func (o *opsItemNotifier) upsert(ctx context.Context, region, key, title, description, resourceARN string) (string, error) {
+	cfg, err := o.clients.Config(ctx)
+	if err != nil {
+		return "", err
+	}
+	cli := ssm.NewFromConfig(cfg, func(opt *ssm.Options) {
+		opt.Region = region
+	})
+
+	match, err := json.Marshal(map[string]string{"key": opsItemKey, "value": key})
+	if err != nil {
+		return "", err
+	}
+	found, err := cli.DescribeOpsItems(ctx, &ssm.DescribeOpsItemsInput{
+		OpsItemFilters: []ssmtypes.OpsItemFilter{
+			{Key: ssmtypes.OpsItemFilterKeyOperationalData, Values: []string{string(match)}, Operator: ssmtypes.OpsItemFilterOperatorEqual},
+			{Key: ssmtypes.OpsItemFilterKeyStatus, Values: []string{string(ssmtypes.OpsItemStatusOpen)}, Operator: ssmtypes.OpsItemFilterOperatorEqual},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(found.OpsItemSummaries) > 0 {
+		id := aws.ToString(found.OpsItemSummaries[0].OpsItemId)
+		_, err := cli.UpdateOpsItem(ctx, &ssm.UpdateOpsItemInput{
+			OpsItemId:   aws.String(id),
+			Title:       aws.String(title),
+			Description: aws.String(description),
+		})
+		return id, err
+	}
+
+	data := map[string]ssmtypes.OpsItemDataValue{
+		opsItemKey: {Type: ssmtypes.OpsItemDataTypeSearchableString, Value: aws.String(key)},
+	}
+	if resourceARN != "" {
+		res, err := json.Marshal([]map[string]string{{"arn": resourceARN}})
+		if err != nil {
+			return "", err
+		}
+		data["/aws/resources"] = ssmtypes.OpsItemDataValue{Type: ssmtypes.OpsItemDataTypeSearchableString, Value: aws.String(string(res))}
+	}
+	out, err := cli.CreateOpsItem(ctx, &ssm.CreateOpsItemInput{
+		Title:           aws.String(title),
+		Description:     aws.String(description),
+		Source:          aws.String(eventSource),
+		Category:        aws.String("Security"),
+		Severity:        aws.String("2"),
+		OperationalData: data,
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(out.OpsItemId), nil
+}
+
+// opsItemDescription lists entries below intro, cut short to fit the
+// description limit.
+func opsItemDescription(intro string, entries []deprecatedFunction) string {
+	var b strings.Builder
+	b.WriteString(intro + "\n\n")
+	for i, e := range entries {
+		line := fmt.Sprintf("- %s %s/%s: %s, deprecated %s\n", e.fn.Name, e.fn.AccountID, e.fn.Region, e.fn.Runtime, e.deprecated.Format(time.DateOnly))
+		more := fmt.Sprintf("…and %d more\n", len(entries)-i)
+		if b.Len()+len(line)+len(more) > opsItemDescriptionMax {
+			b.WriteString(more)
+			break
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"update-lambda-runtime/pkg/bump"
+)
+
+// pagerDutyEventsURL is the PagerDuty Events API v2 endpoint.
+const pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// pagerDutyNotifier triggers a PagerDuty incident when a run leaves
+// functions failed or timed out. Clean runs send nothing.
+type pagerDutyNotifier struct {
+	routingKey string
+	url        string
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string           `json:"routing_key"`
+	EventAction string           `json:"event_action"`
+	DedupKey    string           `json:"dedup_key"`
+	Payload     pagerDutyPayload `json:"payload"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string         `json:"summary"`
+	Source        string         `json:"source"`
+	Severity      string         `json:"severity"`
+	Component     string         `json:"component"`
+	CustomDetails map[string]any `json:"custom_details"`
+}
+
+func (p *pagerDutyNotifier) notify(ctx context.Context, rep *runReport) error {
+	failed := rep.failures()
+	if len(failed) == 0 {
+		return nil
+	}
+	// Repeated failing runs for the same account and runtime mappings
+	// update one open incident instead of paging again.
+	ev := pagerDutyEvent{
+		RoutingKey:  p.routingKey,
+		EventAction: "trigger",
+		DedupKey:    fmt.Sprintf("%s/%s/%s", eventSource, rep.AccountID, bump.FormatMappings(rep.Mappings)),
+		Payload: pagerDutyPayload{
+			Summary:   rep.headline(),
+			Source:    rep.AccountID,
+			Severity:  "error",
+			Component: "lambda",
+			CustomDetails: map[string]any{
+				"profile": rep.Profile,
+				"regions": rep.Regions,
+				"failed":  failed,
+			},
+		},
+	}
+	if err := postJSON(ctx, p.url, ev, nil); err != nil {
+		return fmt.Errorf("pagerduty: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/securityhub"
+	shtypes "github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+
+	"update-lambda-runtime/pkg/bump"
+)
+
+// securityHubBatch is the most findings BatchImportFindings accepts per
+// call, and securityHubFilterValues the most values GetFindings takes for
+// a filter field.
+const (
+	securityHubBatch        = 100
+	securityHubFilterValues = 20
+)
+
+// securityHubAPI is the part of the Security Hub client the notifier uses.
+type securityHubAPI interface {
+	securityhub.GetFindingsAPIClient
+	BatchImportFindings(ctx context.Context, in *securityhub.BatchImportFindingsInput, optFns ...func(*securityhub.Options)) (*securityhub.BatchImportFindingsOutput, error)
+}
+
+// securityHubNotifier imports one ASFF finding per function on the source
+// runtime into Security Hub in the function's own account and region. The
+// finding ID is stable per function and runtime, so re-imports update the
+// same finding, keeping the CreatedAt of its first import so its age is
+// right; once the function has been bumped the finding is re-imported as
+// PASSED and archived, which Security Hub turns into RESOLVED.
+type securityHubNotifier struct {
+	clients *clientFactory
+}
+
+func (s *securityHubNotifier) notify(ctx context.Context, rep *runReport) error {
+	cfg, err := s.clients.Config(ctx)
+	if err != nil {
+		return fmt.Errorf("securityhub: %w", err)
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	byRegion := make(map[string][]shtypes.AwsSecurityFinding)
+	for _, r := range rep.Results {
+		target, ok := rep.Mappings[r.Runtime]
+		if !ok {
+			continue
+		}
+		byRegion[r.Region] = append(byRegion[r.Region], runtimeFinding(r, target, now))
+	}
+
+	for region, findings := range byRegion {
+		cli := securityhub.NewFromConfig(cfg, func(o *securityhub.Options) {
+			o.Region = region
+		})
+		if err := importFindings(ctx, cli, findings); err != nil {
+			return fmt.Errorf("securityhub: %s: %w", region, err)
+		}
+	}
+	return nil
+}
+
+// importFindings imports findings in batches, each finding already in
+// Security Hub keeping the CreatedAt it was first imported with.
+func importFindings(ctx context.Context, cli securityHubAPI, findings []shtypes.AwsSecurityFinding) error {
+	for batch := range slices.Chunk(findings, securityHubBatch) {
+		if err := keepCreatedAt(ctx, cli, batch); err != nil {
+			return err
+		}
+		out, err := cli.BatchImportFindings(ctx, &securityhub.BatchImportFindingsInput{Findings: batch})
+		if err != nil {
+			return err
+		}
+		if n := aws.ToInt32(out.FailedCount); n > 0 {
+			return fmt.Errorf("%d findings rejected: %s", n, aws.ToString(out.FailedFindings[0].ErrorMessage))
+		}
+	}
+	return nil
+}
+
+// keepCreatedAt sets the CreatedAt of the findings Security Hub already
+// has to theirs.
+func keepCreatedAt(ctx context.Context, cli securityhub.GetFindingsAPIClient, findings []shtypes.AwsSecurityFinding) error {
+	created := make(map[string]*string)
+	for chunk := range slices.Chunk(findings, securityHubFilterValues) {
+		ids := make([]shtypes.StringFilter, len(chunk))
+		for i, f := range chunk {
+			ids[i] = shtypes.StringFilter{Value: f.Id, Comparison: shtypes.StringFilterComparisonEquals}
+		}
+		pages := securityhub.NewGetFindingsPaginator(cli, &securityhub.GetFindingsInput{Filters: &shtypes.AwsSecurityFindingFilters{Id: ids}})
+		for pages.HasMorePages() {
+			page, err := pages.NextPage(ctx)
+			if err != nil {
+				return fmt.Errorf("get findings: %w", err)
+			}
+			for _, f := range page.Findings {
+				created[aws.ToString(f.Id)] = f.CreatedAt
+			}
+		}
+	}
+	for i, f := range findings {
+		if at, ok := created[aws.ToString(f.Id)]; ok && at != nil {
+			findings[i].CreatedAt = at
+		}
+	}
+	return nil
+}
+
+// runtimeFinding describes r's function running a deprecated runtime. It is
+// marked resolved when r's update succeeded.
+func runtimeFinding(r functionResult, target, now string) shtypes.AwsSecurityFinding {
+	fnARN := functionARN(r)
+	compliance, state := shtypes.ComplianceStatusFailed, shtypes.RecordStateActive
+	if r.Outcome == bump.Updated {
+		compliance, state = shtypes.ComplianceStatusPassed, shtypes.RecordStateArchived
+	}
+	return shtypes.AwsSecurityFinding{
+		SchemaVersion: aws.String("2018-10-08"),
+		Id:            aws.String(fnARN + "/deprecated-runtime/" + r.Runtime),
+		ProductArn:    aws.String(fmt.Sprintf("arn:%s:securityhub:%s:%s:product/%s/default", partitionOf(r.Region), r.Region, r.AccountID, r.AccountID)),
+		GeneratorId:   aws.String(eventSource + "/deprecated-runtime"),
+		AwsAccountId:  aws.String(r.AccountID),
+		Types:         []string{"Software and Configuration Checks/AWS Security Best Practices"},
+		CreatedAt:     aws.String(now),
+		UpdatedAt:     aws.String(now),
+		Severity:      &shtypes.Severity{Label: shtypes.SeverityLabelMedium},
+		Title:         aws.String(fmt.Sprintf("Lambda function %s runs deprecated runtime %s", r.Name, r.Runtime)),
+		Description:   aws.String(fmt.Sprintf("Function %s in %s still runs %s. Update it to %s.", r.Name, r.Region, r.Runtime, target)),
+		Remediation: &shtypes.Remediation{Recommendation: &shtypes.Recommendation{
+			Text: aws.String(fmt.Sprintf("Run update-lambda-runtime bump --function %s --source-runtime %s --target-runtime %s", r.Name, r.Runtime, target)),
+		}},
+		Resources: []shtypes.Resource{{
+			Type:   aws.String("AwsLambdaFunction"),
+			Id:     aws.String(fnARN),
+			Region: aws.String(r.Region),
+		}},
+		Compliance:  &shtypes.Compliance{Status: compliance},
+		RecordState: state,
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/securityhub"
+	shtypes "github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+
+	"update-lambda-runtime/pkg/bump"
+)
+
+// fakeSecurityHub keeps imported findings by ID, as Security Hub does.
+type fakeSecurityHub struct {
+	findings map[string]shtypes.AwsSecurityFinding
+}
+
+func (f *fakeSecurityHub) GetFindings(_ context.Context, in *securityhub.GetFindingsInput, _ ...func(*securityhub.Options)) (*securityhub.GetFindingsOutput, error) {
+	out := &securityhub.GetFindingsOutput{}
+	for _, id := range in.Filters.Id {
+		if got, ok := f.findings[aws.ToString(id.Value)]; ok {
+			out.Findings = append(out.Findings, got)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeSecurityHub) BatchImportFindings(_ context.Context, in *securityhub.BatchImportFindingsInput, _ ...func(*securityhub.Options)) (*securityhub.BatchImportFindingsOutput, error) {
+	for _, finding := range in.Findings {
+		f.findings[aws.ToString(finding.Id)] = finding
+	}
+	return &securityhub.BatchImportFindingsOutput{FailedCount: aws.Int32(0)}, nil
+}
+
+func TestImportFindings(t *testing.T) {
+	hub := &fakeSecurityHub{findings: make(map[string]shtypes.AwsSecurityFinding)}
+	r := functionResult{AccountID: "123456789012", Region: "cn-north-1", Name: "api", Runtime: "python3.8"}
+	ctx := context.Background()
+	if err := importFindings(ctx, hub, []shtypes.AwsSecurityFinding{runtimeFinding(r, "python3.12", "2026-01-01T00:00:00Z")}); err != nil {
+		t.Fatal(err)
+	}
+	r.Outcome = bump.Updated
+	if err := importFindings(ctx, hub, []shtypes.AwsSecurityFinding{runtimeFinding(r, "python3.12", "2026-02-01T00:00:00Z")}); err != nil {
+		t.Fatal(err)
+	}
+	if len(hub.findings) != 1 {
+		t.Fatalf("%d findings, want the one re-imported", len(hub.findings))
+	}
+	for id, f := range hub.findings {
+		if !strings.HasPrefix(id, "arn:aws-cn:lambda:cn-north-1:") || !strings.HasPrefix(aws.ToString(f.ProductArn), "arn:aws-cn:securityhub:") {
+			t.Errorf("finding %s of product %s, want China ARNs", id, aws.ToString(f.ProductArn))
+		}
+		if aws.ToString(f.CreatedAt) != "2026-01-01T00:00:00Z" || aws.ToString(f.UpdatedAt) != "2026-02-01T00:00:00Z" || f.RecordState != shtypes.RecordStateArchived {
+			t.Errorf("finding created %s, updated %s, %s; want the first CreatedAt kept", aws.ToString(f.CreatedAt), aws.ToString(f.UpdatedAt), f.RecordState)
+		}
+	}
+
+	for region, want := range map[string]string{"us-east-1": "aws", "us-gov-west-1": "aws-us-gov", "us-isob-east-1": "aws-iso-b", "us-iso-east-1": "aws-iso"} {
+		if got := partitionOf(region); got != want {
+			t.Errorf("partitionOf(%s) = %s, want %s", region, got, want)
+		}
+	}
+}
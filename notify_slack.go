@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// slackNotifier posts the run summary to a Slack incoming webhook and,
+// when perFailure is set, one extra message per failed function.
+type slackNotifier struct {
+	webhook    string
+	perFailure bool
+}
+
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+func (s *slackNotifier) notify(ctx context.Context, rep *runReport) error {
+	var b strings.Builder
+	b.WriteString(rep.headline())
+	if failed := rep.failures(); len(failed) > 0 {
+		fmt.Fprintf(&b, "\nFailed (%s):", failureLine(rep.Failures))
+		for _, f := range failed {
+			fmt.Fprintf(&b, "\n• `%s` (%s) %s: %s", f.Name, f.Region, f.Outcome, f.Failure)
+		}
+	}
+	if err := postJSON(ctx, s.webhook, slackMessage{Text: b.String()}, nil); err != nil {
+		return fmt.Errorf("slack: %w", err)
+	}
+	if !s.perFailure {
+		return nil
+	}
+	for _, f := range rep.failures() {
+		msg := slackMessage{Text: fmt.Sprintf(":x: `%s` in %s/%s %s bumping %s → %s",
+			f.Name, rep.AccountID, f.Region, f.Outcome, f.Runtime, f.TargetRuntime)}
+		if err := postJSON(ctx, s.webhook, msg, nil); err != nil {
+			return fmt.Errorf("slack: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *slackNotifier) alertNew(ctx context.Context, accountID string, fns []functionResult) error {
+	if err := postJSON(ctx, s.webhook, slackMessage{Text: ":warning: " + alertText(accountID, fns)}, nil); err != nil {
+		return fmt.Errorf("slack: %w", err)
+	}
+	return nil
+}
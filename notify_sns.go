@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	snstypes "github.com/aws/aws-sdk-go-v2/service/sns/types"
+)
+
+// snsNotifier publishes the run report as a JSON message to an SNS topic,
+// leaving fan-out (email, Lambda, chat bridges) to the topic's subscribers.
+type snsNotifier struct {
+	clients  *clientFactory
+	topicARN string
+}
+
+func (s *snsNotifier) notify(ctx context.Context, rep *runReport) error {
+	topic, err := arn.Parse(s.topicARN)
+	if err != nil {
+		return fmt.Errorf("sns: topic %q: %w", s.topicARN, err)
+	}
+	cfg, err := s.clients.Config(ctx)
+	if err != nil {
+		return fmt.Errorf("sns: %w", err)
+	}
+	cli := sns.NewFromConfig(cfg, func(o *sns.Options) {
+		o.Region = topic.Region
+	})
+
+	msg, err := json.Marshal(rep)
+	if err != nil {
+		return fmt.Errorf("sns: %w", err)
+	}
+	status := "success"
+	if rep.Interrupted || len(rep.failures()) > 0 {
+		status = "failure"
+	}
+	// SNS subjects are limited to 100 characters.
+	subject := fmt.Sprintf("Lambda runtime bump %s: %s", status, rep.AccountID)
+	_, err = cli.Publish(ctx, &sns.PublishInput{
+		TopicArn: aws.String(s.topicARN),
+		Subject:  aws.String(subject),
+		Message:  aws.String(string(msg)),
+		MessageAttributes: map[string]snstypes.MessageAttributeValue{
+			"status": {DataType: aws.String("String"), StringValue: aws.String(status)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("sns: publish: %w", err)
+	}
+	return nil
+}
+
+// snsAlert is the message alertNew publishes.
+type snsAlert struct {
+	Event     string           `json:"event"`
+	AccountID string           `json:"accountId"`
+	Functions []functionResult `json:"functions"`
+}
+
+func (s *snsNotifier) alertNew(ctx context.Context, accountID string, fns []functionResult) error {
+	topic, err := arn.Parse(s.topicARN)
+	if err != nil {
+		return fmt.Errorf("sns: topic %q: %w", s.topicARN, err)
+	}
+	cfg, err := s.clients.Config(ctx)
+	if err != nil {
+		return fmt.Errorf("sns: %w", err)
+	}
+	cli := sns.NewFromConfig(cfg, func(o *sns.Options) {
+		o.Region = topic.Region
+	})
+	msg, err := json.Marshal(snsAlert{Event: "deprecated-runtime.new", AccountID: accountID, Functions: fns})
+	if err != nil {
+		return fmt.Errorf("sns: %w", err)
+	}
+	_, err = cli.Publish(ctx, &sns.PublishInput{
+		TopicArn: aws.String(s.topicARN),
+		Subject:  aws.String("New Lambda functions on deprecated runtimes: " + accountID),
+		Message:  aws.String(string(msg)),
+		MessageAttributes: map[string]snstypes.MessageAttributeValue{
+			"status": {DataType: aws.String("String"), StringValue: aws.String("alert")},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("sns: publish: %w", err)
+	}
+	return nil
+}
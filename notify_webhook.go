@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"time"
+)
+
+// Webhook event names, selectable with --webhook-events.
+const (
+	webhookRunStarted  = "run.started"
+	webhookResult      = "function.result"
+	webhookRunFinished = "run.finished"
+)
+
+var webhookEvents = []string{webhookRunStarted, webhookResult, webhookRunFinished}
+
+// webhookSender POSTs JSON callbacks to a user endpoint. When a secret is
+// set every body is signed with HMAC-SHA256, sent GitHub-style as
+// "X-Signature-256: sha256=<hex>" so receivers can reuse their verifiers.
+// A nil *webhookSender sends nothing.
+type webhookSender struct {
+	url    string
+	secret string
+	events []string
+}
+
+type webhookPayload struct {
+	Event string    `json:"event"`
+	Time  time.Time `json:"time"`
+	Data  any       `json:"data"`
+}
+
+type webhookRun struct {
+	Profile   string            `json:"profile"`
+	AccountID string            `json:"accountId"`
+	Regions   []string          `json:"regions"`
+	Mappings  map[string]string `json:"mappings"`
+}
+
+func newWebhookSender(opts *AWSOpts) (*webhookSender, error) {
+	if opts.WebhookURL == "" {
+		return nil, nil
+	}
+	for _, e := range opts.WebhookEvents {
+		if !slices.Contains(webhookEvents, e) {
+			return nil, fmt.Errorf("--webhook-events: unknown event %q (want %v)", e, webhookEvents)
+		}
+	}
+	return &webhookSender{url: opts.WebhookURL, secret: opts.WebhookSecret, events: opts.WebhookEvents}, nil
+}
+
+func (w *webhookSender) started(ctx context.Context, opts *AWSOpts, accountID string) {
+	w.send(ctx, webhookRunStarted, webhookRun{
+		Profile:   opts.Profile,
+		AccountID: accountID,
+		Regions:   opts.Regions,
+		Mappings:  opts.Policy.Mappings,
+	})
+}
+
+// result reports one function's outcome. Functions the runtime policy left
+// alone have no outcome and are not reported.
+func (w *webhookSender) result(ctx context.Context, r functionResult) {
+	if r.Outcome != "" {
+		w.send(ctx, webhookResult, r)
+	}
+}
+
+// notify sends run.finished, making the sender usable as a notifier.
+func (w *webhookSender) notify(ctx context.Context, rep *runReport) error {
+	return w.post(ctx, webhookRunFinished, rep)
+}
+
+// send delivers an event as it happens, reporting failures as warnings.
+func (w *webhookSender) send(ctx context.Context, event string, data any) {
+	if w == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), notifyTimeout)
+	defer cancel()
+	if err := w.post(ctx, event, data); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+}
+
+func (w *webhookSender) post(ctx context.Context, event string, data any) error {
+	if !slices.Contains(w.events, event) {
+		return nil
+	}
+	buf, err := json.Marshal(webhookPayload{Event: event, Time: time.Now().UTC(), Data: data})
+	if err != nil {
+		return err
+	}
+	header := http.Header{"X-Webhook-Event": {event}}
+	if w.secret != "" {
+		mac := hmac.New(sha256.New, []byte(w.secret))
+		mac.Write(buf)
+		header.Set("X-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	if err := postBytes(ctx, w.url, buf, header); err != nil {
+		return fmt.Errorf("webhook %s: %w", event, err)
+	}
+	return nil
+}
@@ -0,0 +1,422 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"update-lambda-runtime/pkg/bump"
+	"update-lambda-runtime/pkg/inventory"
+)
+
+// Result formats for bump --output.
+const (
+	outputTable     = "table"
+	outputPRComment = "pr-comment"
+)
+
+const (
+	accountIDWidth = 12
+	// Lambda caps function names at 64 characters, which lets the name
+	// column be sized before any function has been seen.
+	functionNameWidth = 64
+	// The longest runtime identifier, for tables printed as functions
+	// stream in.
+	runtimeNameWidth = len("provided.al2023")
+)
+
+// Table layouts for --align.
+const (
+	alignAuto   = "auto"
+	alignAlways = "always"
+	alignNever  = "never"
+)
+
+// tableAlign is --align, set before any command runs.
+var tableAlign = alignAuto
+
+// displayZone is --timezone, set before any command runs; nil leaves every
+// timestamp in the zone it is shown in by default.
+var displayZone *time.Location
+
+// inZone returns t as it is shown to people: in --timezone, or else in def.
+// JSON keeps the time as recorded.
+func inZone(t time.Time, def *time.Location) time.Time {
+	return t.In(cmp.Or(displayZone, def))
+}
+
+// plainOutput reports whether tables written to w are tab-separated rather
+// than padded into columns, so grep, cut and awk see one field per column.
+// Only stdout and other files are: with --align auto that is when they are
+// not a terminal. Tables built into reports and messages stay aligned.
+func plainOutput(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok || tableAlign == alignAlways {
+		return false
+	}
+	if tableAlign == alignNever {
+		return true
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice == 0
+}
+
+// table prints aligned rows as soon as they are produced. Unlike tabwriter
+// it never buffers: every column but the last has a width fixed up front.
+// A plain table separates columns with a tab and has no rule under its
+// header. Every row starts with indent.
+type table struct {
+	w      io.Writer
+	widths []int
+	plain  bool
+	indent string
+}
+
+func newTable(w io.Writer, widths ...int) *table {
+	return &table{w: w, widths: widths, plain: plainOutput(w)}
+}
+
+func (t *table) row(cols ...string) {
+	if t.plain {
+		io.WriteString(t.w, t.indent+strings.Join(cols, "\t")+"\n")
+		return
+	}
+	var b strings.Builder
+	b.WriteString(t.indent)
+	for i, c := range cols {
+		if i == len(cols)-1 {
+			b.WriteString(c)
+			break
+		}
+		fmt.Fprintf(&b, "%-*s  ", t.widths[i], c)
+	}
+	b.WriteByte('\n')
+	io.WriteString(t.w, b.String())
+}
+
+// header prints the column names underlined with dashes.
+func (t *table) header(cols ...string) {
+	if t.plain {
+		t.row(cols...)
+		return
+	}
+	rule := make([]string, len(cols))
+	for i, c := range cols {
+		rule[i] = strings.Repeat("-", len(c))
+	}
+	t.row(cols...)
+	t.row(rule...)
+}
+
+// newFunctionTable sizes the function table for opts: profile and region
+// widths come from the flags, the rest from the known maximums. extra gives
+// the widths of any columns that follow CurrentRuntime.
+func newFunctionTable(w io.Writer, opts *AWSOpts, extra ...int) *table {
+	regionWidth := len("Region")
+	for _, r := range opts.Regions {
+		regionWidth = max(regionWidth, len(r))
+	}
+	widths := []int{accountIDWidth}
+	if opts.ShowProfile {
+		widths = append(widths, max(len("Profile"), len(opts.Profile)))
+	}
+	widths = append(widths, regionWidth, functionNameWidth)
+	return newTable(w, append(widths, extra...)...)
+}
+
+// output: AccountID-first; profile optional; extra columns trail the runtime
+func printHeader(t *table, showProfile bool, extra ...string) {
+	cols := []string{"AccountID"}
+	if showProfile {
+		cols = append(cols, "Profile")
+	}
+	cols = append(cols, "Region", "FunctionName", "CurrentRuntime")
+	t.header(append(cols, extra...)...)
+}
+
+func printRow(t *table, accountID, profile, region, fn, rt string, showProfile bool, extra ...string) {
+	if rt == "" {
+		rt = "N/A"
+	}
+	cols := []string{accountID}
+	if showProfile {
+		cols = append(cols, profile)
+	}
+	cols = append(cols, region, fn, rt)
+	t.row(append(cols, extra...)...)
+}
+
+// listTable prints the list table a row at a time as functions are found.
+// Discovery errors get an "error" row, with the reason on errw.
+type listTable struct {
+	w, errw io.Writer
+	opts    *AWSOpts
+	tbl     *table
+}
+
+func newListTable(w, errw io.Writer, opts *AWSOpts) *listTable {
+	return &listTable{w: w, errw: errw, opts: opts}
+}
+
+// listColumns gives the widths of the runtime column and of the columns
+// after it for --show-state, --show-runtime-management, each tag named by
+// --show-tags and --show-triggers, and the names of the latter.
+func listColumns(opts *AWSOpts) ([]int, []string) {
+	widths := []int{runtimeNameWidth}
+	var cols []string
+	if opts.ShowState {
+		widths = append(widths, len("Inactive"), len("LastUpdateStatus"))
+		cols = append(cols, "State", "LastUpdateStatus")
+	}
+	if opts.ShowRuntimeMgmt {
+		widths = append(widths, runtimeUpdatesWidth)
+		cols = append(cols, "UpdateRuntimeOn")
+	}
+	for _, key := range opts.ShowTags {
+		widths = append(widths, max(tagWidth, len(key)))
+	}
+	cols = append(cols, opts.ShowTags...)
+	if opts.ShowTriggers {
+		widths = append(widths, triggersWidth)
+		cols = append(cols, "Triggers")
+	}
+	return widths, cols
+}
+
+// listValues gives r's values for the columns listColumns names.
+func listValues(opts *AWSOpts, r functionResult) []string {
+	var values []string
+	if opts.ShowState {
+		values = append(values, cmp.Or(r.State, "?"), cmp.Or(r.LastUpdateStatus, "?"))
+	}
+	if opts.ShowRuntimeMgmt {
+		values = append(values, runtimeUpdates(r))
+	}
+	for _, key := range opts.ShowTags {
+		values = append(values, cmp.Or(r.Tags[key], "-"))
+	}
+	if opts.ShowTriggers {
+		values = append(values, triggerList(r))
+	}
+	return values
+}
+
+// start sizes the table.
+func (t *listTable) start() {
+	widths, cols := listColumns(t.opts)
+	t.tbl = newFunctionTable(t.w, t.opts, widths...)
+	printHeader(t.tbl, t.opts.ShowProfile, cols...)
+}
+
+func (t *listTable) listed(r functionResult) {
+	printRow(t.tbl, r.AccountID, r.Profile, r.Region, r.Name, r.Runtime, t.opts.ShowProfile, listValues(t.opts, r)...)
+}
+
+func (t *listTable) failed(e discoveryError) {
+	printRow(t.tbl, e.AccountID, t.opts.Profile, e.Region, cmp.Or(e.Function, "*"), "error", t.opts.ShowProfile)
+	fmt.Fprintf(t.errw, "Error discovering %s: %s\n", cmp.Or(e.Function, "functions in "+e.Region), e.Error)
+}
+
+// treeTable prints function rows under account and region headings for
+// --group, closing each region and account with a subtotal. Rows must come
+// grouped, as list finds them and writeBumpTable sorts them. Each row is
+// counted under a class, such as its deprecation status or its outcome;
+// subtotals give the classes in order, then any others alphabetically.
+type treeTable struct {
+	w       io.Writer
+	widths  []int    // of CurrentRuntime and the columns after it
+	extra   []string // names of the columns after CurrentRuntime
+	classes []string
+
+	tbl             *table
+	account, region string
+	regions         int
+	regionTally     map[string]int
+	accountTally    map[string]int
+}
+
+func newTreeTable(w io.Writer, classes []string, widths []int, extra ...string) *treeTable {
+	return &treeTable{w: w, widths: widths, extra: extra, classes: classes}
+}
+
+func (t *treeTable) row(accountID, profile, region, fn, rt, class string, extra ...string) {
+	if accountID != t.account {
+		t.closeAccount()
+		t.account, t.accountTally = accountID, make(map[string]int)
+		heading := "Account " + accountID
+		if profile != "" {
+			heading += " (profile " + profile + ")"
+		}
+		fmt.Fprintln(t.w, heading)
+	}
+	if region != t.region {
+		t.closeRegion()
+		t.region, t.regionTally = region, make(map[string]int)
+		fmt.Fprintf(t.w, "  Region %s\n", region)
+		t.tbl = newTable(t.w, append([]int{functionNameWidth}, t.widths...)...)
+		t.tbl.indent = "    "
+		t.tbl.header(append([]string{"FunctionName", "CurrentRuntime"}, t.extra...)...)
+	}
+	if rt == "" {
+		rt = "N/A"
+	}
+	t.tbl.row(append([]string{fn, rt}, extra...)...)
+	t.regionTally[class]++
+}
+
+func (t *treeTable) closeRegion() {
+	if t.region == "" {
+		return
+	}
+	fmt.Fprintf(t.w, "    Subtotal: %s\n", t.subtotal(t.regionTally))
+	for class, n := range t.regionTally {
+		t.accountTally[class] += n
+	}
+	t.regions++
+	t.region = ""
+}
+
+func (t *treeTable) closeAccount() {
+	t.closeRegion()
+	if t.account == "" {
+		return
+	}
+	regions := "regions"
+	if t.regions == 1 {
+		regions = "region"
+	}
+	fmt.Fprintf(t.w, "  Total for %s: %s in %d %s\n", t.account, t.subtotal(t.accountTally), t.regions, regions)
+	t.account, t.regions = "", 0
+}
+
+// finish closes the groups still open.
+func (t *treeTable) finish() {
+	t.closeAccount()
+}
+
+func (t *treeTable) subtotal(tally map[string]int) string {
+	var total int
+	var parts []string
+	order := slices.Clone(t.classes)
+	for _, class := range slices.Sorted(maps.Keys(tally)) {
+		if !slices.Contains(order, class) {
+			order = append(order, class)
+		}
+	}
+	for _, class := range order {
+		if n := tally[class]; n > 0 {
+			total += n
+			parts = append(parts, fmt.Sprintf("%d %s", n, class))
+		}
+	}
+	return fmt.Sprintf("%d functions (%s)", total, strings.Join(parts, ", "))
+}
+
+// treeList is the list table for --group, whose subtotals count functions
+// by deprecation status.
+type treeList struct {
+	errw io.Writer
+	opts *AWSOpts
+	tree *treeTable
+	now  time.Time
+}
+
+func newTreeList(w, errw io.Writer, opts *AWSOpts) *treeList {
+	widths, cols := listColumns(opts)
+	tree := newTreeTable(w, []string{inventory.Deprecated, inventory.Deprecating, inventory.Supported}, widths, cols...)
+	return &treeList{errw: errw, opts: opts, tree: tree, now: time.Now()}
+}
+
+func (t *treeList) start() {}
+
+func (t *treeList) listed(r functionResult) {
+	class := "unknown"
+	if r.Runtime != "" {
+		class = inventory.DeprecationStatus(r.Runtime, t.now)
+	}
+	t.tree.row(r.AccountID, r.Profile, r.Region, r.Name, r.Runtime, class, listValues(t.opts, r)...)
+}
+
+func (t *treeList) failed(e discoveryError) {
+	t.tree.row(e.AccountID, t.opts.Profile, e.Region, cmp.Or(e.Function, "*"), "error", "errors")
+	fmt.Fprintf(t.errw, "Error discovering %s: %s\n", cmp.Or(e.Function, "functions in "+e.Region), e.Error)
+}
+
+// writeBumpTable prints the results of a bump run in a stable (account,
+// region, name) order, whatever order the updates completed in, followed
+// by the outcome summary.
+func writeBumpTable(w io.Writer, opts *AWSOpts, rep *runReport) {
+	results := slices.Clone(rep.Results)
+	sortResults(results)
+	runtimeWidth := len("CurrentRuntime")
+	for _, r := range results {
+		runtimeWidth = max(runtimeWidth, len(r.Runtime))
+	}
+
+	fmt.Fprintln(w)
+	var tbl *table
+	var tree *treeTable
+	if opts.Group {
+		classes := make([]string, len(bump.Outcomes))
+		for i, o := range bump.Outcomes {
+			classes[i] = string(o)
+		}
+		tree = newTreeTable(w, classes, []int{runtimeWidth}, "Result")
+	} else {
+		tbl = newFunctionTable(w, opts, runtimeWidth)
+		printHeader(tbl, opts.ShowProfile, "Result")
+	}
+	summary := &bumpSummary{}
+	now := time.Now()
+	for _, r := range results {
+		// Functions the run left alone show where they stand instead.
+		result := classify(r, now)
+		if r.Outcome != "" {
+			result = string(r.Outcome)
+			summary.add(r)
+		}
+		if r.Failure != "" {
+			result += " (" + string(r.Failure) + ")"
+		}
+		switch {
+		case r.Edge == edgeReplica:
+			result = "Lambda@Edge replica"
+		case r.Edge == edgeOrigin && r.Outcome == "":
+			result = "Lambda@Edge"
+		case r.Edge == edgeOrigin:
+			result += " (Lambda@Edge)"
+		}
+		if r.Attempts > 1 {
+			result += fmt.Sprintf(" after %d attempts", r.Attempts)
+		}
+		if tree != nil {
+			// Functions left alone count as where they stand.
+			tree.row(r.AccountID, r.Profile, r.Region, r.Name, r.Runtime, cmp.Or(string(r.Outcome), classify(r, now), "Lambda@Edge replica"), result)
+			continue
+		}
+		printRow(tbl, r.AccountID, r.Profile, r.Region, r.Name, r.Runtime, opts.ShowProfile, result)
+	}
+	if tree != nil {
+		tree.finish()
+	}
+	for _, e := range rep.Errors {
+		if tbl == nil {
+			fmt.Fprintf(w, "error: discovering %s in %s/%s\n", cmp.Or(e.Function, "functions"), e.AccountID, e.Region)
+			continue
+		}
+		printRow(tbl, e.AccountID, opts.Profile, e.Region, cmp.Or(e.Function, "*"), "", opts.ShowProfile, "error")
+	}
+	if rep.Unlisted > 0 {
+		fmt.Fprintf(w, "%d up-to-date functions not listed (--all-results lists them)\n", rep.Unlisted)
+	}
+	summary.print(w)
+	fmt.Fprintf(w, "Functions: %s\n", rep.classLine())
+	if len(rep.Errors) > 0 {
+		fmt.Fprintf(w, "Discovery errors: %d (see above); those functions were not considered\n", len(rep.Errors))
+	}
+}
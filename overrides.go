@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"update-lambda-runtime/pkg/inventory"
+)
+
+// targetOverride is one row of an --overrides file: the runtime, and
+// optionally the handler, a function moves to instead of what the runtime
+// policy maps its runtime to, and how long to wait for its update instead
+// of --wait-timeout.
+type targetOverride struct {
+	function, region string // region "" matches every region
+	runtime, handler string // runtime "" keeps the policy's target
+	waitTimeout      time.Duration
+	line             int
+	matched          bool
+}
+
+// targetOverrides is an --overrides file, e.g. a migration sheet reviewed
+// by the teams owning the functions:
+//
+//	function,region,target_runtime,handler,wait_timeout
+//	orders-api,us-east-1,nodejs20.x,,
+//	billing-worker,,python3.12,app.main.handler,
+//	ml-scorer,us-east-1,,,20m
+//
+// The header row and the handler and wait_timeout columns are optional. A
+// row with a wait_timeout may leave target_runtime blank to keep the
+// policy's. Exclusions still apply to the functions it names.
+type targetOverrides []*targetOverride
+
+// loadOverrides reads and checks an --overrides file.
+func loadOverrides(path string) (targetOverrides, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("--overrides: %w", err)
+	}
+	defer f.Close()
+	o, err := parseOverrides(f)
+	if err != nil {
+		return nil, fmt.Errorf("--overrides %s: %w", path, err)
+	}
+	return o, nil
+}
+
+func parseOverrides(r io.Reader) (targetOverrides, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.Comment = '#'
+	var out targetOverrides
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		for i := range rec {
+			rec[i] = strings.TrimSpace(rec[i])
+		}
+		if len(out) == 0 && strings.EqualFold(rec[0], "function") {
+			continue
+		}
+		if len(rec) < 3 || len(rec) > 5 {
+			return nil, fmt.Errorf("line %d: want function,region,target_runtime[,handler[,wait_timeout]], got %d fields", line, len(rec))
+		}
+		o := &targetOverride{function: rec[0], region: rec[1], line: line}
+		if rec[2] != "" {
+			o.runtime = inventory.Normalize(rec[2])
+		}
+		if len(rec) >= 4 {
+			o.handler = rec[3]
+		}
+		if len(rec) == 5 && rec[4] != "" {
+			d, err := time.ParseDuration(rec[4])
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("line %d: wait_timeout %q: want a duration such as 15m", line, rec[4])
+			}
+			o.waitTimeout = d
+		}
+		if o.function == "" || o.runtime == "" && o.waitTimeout == 0 {
+			return nil, fmt.Errorf("line %d: function and target_runtime or wait_timeout are required", line)
+		}
+		if o.runtime == "" && o.handler != "" {
+			return nil, fmt.Errorf("line %d: a handler needs a target_runtime", line)
+		}
+		// A bare latest keyword depends on the function's own runtime.
+		if o.runtime != "" && o.runtime != inventory.LatestKeyword {
+			if _, err := inventory.ResolveTarget(o.runtime, ""); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+		if i := slices.IndexFunc(out, func(p *targetOverride) bool { return p.function == o.function && p.region == o.region }); i >= 0 {
+			return nil, fmt.Errorf("line %d: %s is already overridden on line %d", line, o.function, out[i].line)
+		}
+		out = append(out, o)
+	}
+}
+
+// lookup returns the override for the function name in region, preferring
+// one for that region over one for every region, and marks it used.
+func (o targetOverrides) lookup(region, name string) (*targetOverride, bool) {
+	var found *targetOverride
+	for _, p := range o {
+		if p.function == name && (p.region == region || p.region == "" && found == nil) {
+			found = p
+		}
+	}
+	if found == nil {
+		return nil, false
+	}
+	found.matched = true
+	return found, true
+}
+
+// unmatched lists the overrides no function in the run matched, which
+// usually means a name or region in the sheet is wrong.
+func (o targetOverrides) unmatched() []string {
+	var out []string
+	for _, p := range o {
+		if !p.matched {
+			out = append(out, fmt.Sprintf("%s (line %d)", p.function, p.line))
+		}
+	}
+	return out
+}
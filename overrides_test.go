@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseOverrides(t *testing.T) {
+	o, err := parseOverrides(strings.NewReader(`function,region,target_runtime,handler
+# reviewed by the orders team
+orders-api,us-east-1,nodejs20.x
+orders-api,,nodejs22.x,index.main
+billing, eu-west-1 ,latest
+ml-scorer,us-east-1,,,20m
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(o) != 4 {
+		t.Fatalf("got %d overrides, want 4", len(o))
+	}
+	if p, ok := o.lookup("us-east-1", "orders-api"); !ok || p.runtime != "nodejs20.x" {
+		t.Errorf("us-east-1 orders-api = %+v, want the region's own override", p)
+	}
+	if p, ok := o.lookup("eu-west-1", "orders-api"); !ok || p.runtime != "nodejs22.x" || p.handler != "index.main" {
+		t.Errorf("eu-west-1 orders-api = %+v, want the every-region override", p)
+	}
+	if _, ok := o.lookup("eu-west-1", "billing"); !ok {
+		t.Error("billing not found in eu-west-1")
+	}
+	if p, ok := o.lookup("us-east-1", "ml-scorer"); !ok || p.runtime != "" || p.waitTimeout != 20*time.Minute {
+		t.Errorf("ml-scorer = %+v, want only a 20m wait timeout", p)
+	}
+	o[2].matched = false
+	if got := o.unmatched(); len(got) != 1 || !strings.HasPrefix(got[0], "billing") {
+		t.Errorf("unmatched = %v, want billing", got)
+	}
+
+	for _, bad := range []string{
+		"orders-api,us-east-1\n",
+		"orders-api,us-east-1,nodejs20.x\norders-api,us-east-1,nodejs22.x\n",
+		",us-east-1,nodejs20.x\n",
+		"orders-api,,latest-cobol\n",
+		"orders-api,,,\n",
+		"orders-api,,,index.main,5m\n",
+		"orders-api,,nodejs20.x,,soon\n",
+	} {
+		if _, err := parseOverrides(strings.NewReader(bad)); err == nil {
+			t.Errorf("parseOverrides(%q) succeeded", bad)
+		}
+	}
+}
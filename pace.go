@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// newUpdatePace meters the updates a run issues for --updates-per-minute,
+// however many workers issue them. It is nil, letting every update through,
+// when perMinute is not positive.
+func newUpdatePace(perMinute float64) *rate.Limiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Every(time.Duration(float64(time.Minute)/perMinute)), 1)
+}
+
+// awaitPace blocks until pace lets another update through, returning early
+// with the context's error when it ends first.
+func awaitPace(ctx context.Context, pace *rate.Limiter) error {
+	if pace == nil {
+		return nil
+	}
+	return pace.Wait(ctx)
+}
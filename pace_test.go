@@ -0,0 +1,26 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestUpdatePace(t *testing.T) {
+	if newUpdatePace(0) != nil {
+		t.Fatal("pace without --updates-per-minute")
+	}
+	pace := newUpdatePace(6)
+	if got := time.Duration(float64(time.Second) / float64(pace.Limit())); got != 10*time.Second {
+		t.Fatalf("interval = %v, want 10s", got)
+	}
+	ctx := context.Background()
+	if err := awaitPace(ctx, pace); err != nil {
+		t.Fatalf("first update waited: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if err := awaitPace(ctx, pace); err == nil {
+		t.Fatal("second update was not held back")
+	}
+}
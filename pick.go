@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// pickFunctions lets the user choose which of jobs to bump. It runs fzf in
+// multi-select mode when it is installed and falls back to a line-based
+// prompt otherwise. Both need an interactive terminal.
+func pickFunctions(ctx context.Context, jobs []bumpJob) ([]bumpJob, error) {
+	if fi, err := os.Stdin.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		return nil, errors.New("--pick needs an interactive terminal")
+	}
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+	lines := make([]string, len(jobs))
+	for i, j := range jobs {
+		lines[i] = pickLine(i, j.result)
+	}
+	var chosen []int
+	var err error
+	if path, lookErr := exec.LookPath("fzf"); lookErr == nil {
+		chosen, err = pickFzf(ctx, path, lines)
+	} else {
+		chosen, err = pickPrompt(os.Stdin, os.Stderr, lines)
+	}
+	if err != nil {
+		return nil, err
+	}
+	out := make([]bumpJob, 0, len(chosen))
+	for _, i := range chosen {
+		out = append(out, jobs[i])
+	}
+	return out, nil
+}
+
+// pickLine is the picker entry for r. The leading index is hidden from fzf
+// and maps a selected line back to its job.
+func pickLine(i int, r functionResult) string {
+	return fmt.Sprintf("%d\t%s\t%s\t%s → %s", i, r.Region, r.Name, r.Runtime, r.TargetRuntime)
+}
+
+func pickFzf(ctx context.Context, path string, lines []string) ([]int, error) {
+	cmd := exec.CommandContext(ctx, path, "--multi", "--delimiter", "\t", "--with-nth", "2..",
+		"--prompt", "bump> ", "--header", "TAB selects, ENTER confirms, ESC bumps nothing")
+	cmd.Stdin = strings.NewReader(strings.Join(lines, "\n"))
+	cmd.Stderr = os.Stderr // fzf draws on the terminal through stderr
+	out, err := cmd.Output()
+	var exit *exec.ExitError
+	// fzf exits 1 when nothing matched and 130 when cancelled.
+	if errors.As(err, &exit) && (exit.ExitCode() == 1 || exit.ExitCode() == 130) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fzf: %w", err)
+	}
+	var chosen []int
+	for line := range bytes.Lines(out) {
+		idx, _, _ := strings.Cut(string(line), "\t")
+		if i, err := strconv.Atoi(idx); err == nil {
+			chosen = append(chosen, i)
+		}
+	}
+	return chosen, nil
+}
+
+// pickPrompt narrows lines with fuzzy queries until the user selects from
+// the numbered matches.
+func pickPrompt(in io.Reader, out io.Writer, lines []string) ([]int, error) {
+	sc := bufio.NewScanner(in)
+	matches := make([]int, len(lines))
+	for i := range lines {
+		matches[i] = i
+	}
+	for {
+		for n, i := range matches {
+			_, label, _ := strings.Cut(lines[i], "\t")
+			fmt.Fprintf(out, "%4d  %s\n", n+1, strings.ReplaceAll(label, "\t", "  "))
+		}
+		fmt.Fprint(out, "Numbers/ranges to bump (e.g. 1,3-5), 'a' for all shown, '/text' to filter, empty for none: ")
+		if !sc.Scan() {
+			return nil, sc.Err()
+		}
+		answer := strings.TrimSpace(sc.Text())
+		switch {
+		case answer == "":
+			return nil, nil
+		case answer == "a":
+			return matches, nil
+		case strings.HasPrefix(answer, "/"):
+			query := strings.TrimPrefix(answer, "/")
+			var narrowed []int
+			for _, i := range matches {
+				if fuzzyMatch(query, lines[i]) {
+					narrowed = append(narrowed, i)
+				}
+			}
+			if len(narrowed) == 0 {
+				fmt.Fprintln(out, "No matches.")
+				continue
+			}
+			matches = narrowed
+			continue
+		}
+		picked, err := parseSelection(answer, len(matches))
+		if err != nil {
+			fmt.Fprintln(out, err)
+			continue
+		}
+		chosen := make([]int, len(picked))
+		for n, p := range picked {
+			chosen[n] = matches[p]
+		}
+		return chosen, nil
+	}
+}
+
+// fuzzyMatch reports whether query's characters appear in s in order,
+// ignoring case, the way fzf matches by default.
+func fuzzyMatch(query, s string) bool {
+	s = strings.ToLower(s)
+	for _, c := range strings.ToLower(query) {
+		i := strings.IndexRune(s, c)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(string(c)):]
+	}
+	return true
+}
+
+// parseSelection turns "1,3-5" into zero-based indexes below n.
+func parseSelection(s string, n int) ([]int, error) {
+	var out []int
+	for part := range strings.SplitSeq(s, ",") {
+		lo, hi, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		if !isRange {
+			hi = lo
+		}
+		a, err1 := strconv.Atoi(strings.TrimSpace(lo))
+		b, err2 := strconv.Atoi(strings.TrimSpace(hi))
+		if err1 != nil || err2 != nil || a < 1 || b > n || a > b {
+			return nil, fmt.Errorf("invalid selection %q (choose from 1-%d)", part, n)
+		}
+		for i := a; i <= b; i++ {
+			if !slices.Contains(out, i-1) {
+				out = append(out, i-1)
+			}
+		}
+	}
+	return out, nil
+}
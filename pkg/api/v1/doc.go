@@ -0,0 +1,5 @@
+// Package apiv1 holds the gRPC API serve exposes with --grpc-listen,
+// generated from runtime.proto.
+package apiv1
+
+//go:generate protoc -I ../../.. --go_out=../../.. --go_opt=paths=source_relative --go-grpc_out=../../.. --go-grpc_opt=paths=source_relative pkg/api/v1/runtime.proto
@@ -0,0 +1,870 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: pkg/api/v1/runtime.proto
+
+// The gRPC API of serve --grpc-listen: the inventory, plan and bump flows
+// of the CLI, with progress streamed as it happens.
+
+package apiv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Function is one discovered function. target_runtime is set for
+// functions the runtime policy bumps, outcome once a bump has handled it.
+type Function struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AccountId     string                 `protobuf:"bytes,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	Profile       string                 `protobuf:"bytes,2,opt,name=profile,proto3" json:"profile,omitempty"`
+	Region        string                 `protobuf:"bytes,3,opt,name=region,proto3" json:"region,omitempty"`
+	Name          string                 `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
+	Runtime       string                 `protobuf:"bytes,5,opt,name=runtime,proto3" json:"runtime,omitempty"`
+	Edge          string                 `protobuf:"bytes,6,opt,name=edge,proto3" json:"edge,omitempty"`
+	TargetRuntime string                 `protobuf:"bytes,7,opt,name=target_runtime,json=targetRuntime,proto3" json:"target_runtime,omitempty"`
+	Outcome       string                 `protobuf:"bytes,8,opt,name=outcome,proto3" json:"outcome,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Function) Reset() {
+	*x = Function{}
+	mi := &file_pkg_api_v1_runtime_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Function) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Function) ProtoMessage() {}
+
+func (x *Function) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_v1_runtime_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Function.ProtoReflect.Descriptor instead.
+func (*Function) Descriptor() ([]byte, []int) {
+	return file_pkg_api_v1_runtime_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Function) GetAccountId() string {
+	if x != nil {
+		return x.AccountId
+	}
+	return ""
+}
+
+func (x *Function) GetProfile() string {
+	if x != nil {
+		return x.Profile
+	}
+	return ""
+}
+
+func (x *Function) GetRegion() string {
+	if x != nil {
+		return x.Region
+	}
+	return ""
+}
+
+func (x *Function) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Function) GetRuntime() string {
+	if x != nil {
+		return x.Runtime
+	}
+	return ""
+}
+
+func (x *Function) GetEdge() string {
+	if x != nil {
+		return x.Edge
+	}
+	return ""
+}
+
+func (x *Function) GetTargetRuntime() string {
+	if x != nil {
+		return x.TargetRuntime
+	}
+	return ""
+}
+
+func (x *Function) GetOutcome() string {
+	if x != nil {
+		return x.Outcome
+	}
+	return ""
+}
+
+// DiscoveryError is a region that could not be listed, or a function that
+// could not be looked up in it.
+type DiscoveryError struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AccountId     string                 `protobuf:"bytes,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	Region        string                 `protobuf:"bytes,2,opt,name=region,proto3" json:"region,omitempty"`
+	Function      string                 `protobuf:"bytes,3,opt,name=function,proto3" json:"function,omitempty"`
+	Error         string                 `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DiscoveryError) Reset() {
+	*x = DiscoveryError{}
+	mi := &file_pkg_api_v1_runtime_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DiscoveryError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DiscoveryError) ProtoMessage() {}
+
+func (x *DiscoveryError) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_v1_runtime_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DiscoveryError.ProtoReflect.Descriptor instead.
+func (*DiscoveryError) Descriptor() ([]byte, []int) {
+	return file_pkg_api_v1_runtime_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *DiscoveryError) GetAccountId() string {
+	if x != nil {
+		return x.AccountId
+	}
+	return ""
+}
+
+func (x *DiscoveryError) GetRegion() string {
+	if x != nil {
+		return x.Region
+	}
+	return ""
+}
+
+func (x *DiscoveryError) GetFunction() string {
+	if x != nil {
+		return x.Function
+	}
+	return ""
+}
+
+func (x *DiscoveryError) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type InventoryRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Defaults to the server's --regions.
+	Regions       []string `protobuf:"bytes,1,rep,name=regions,proto3" json:"regions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InventoryRequest) Reset() {
+	*x = InventoryRequest{}
+	mi := &file_pkg_api_v1_runtime_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InventoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InventoryRequest) ProtoMessage() {}
+
+func (x *InventoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_v1_runtime_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InventoryRequest.ProtoReflect.Descriptor instead.
+func (*InventoryRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_v1_runtime_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *InventoryRequest) GetRegions() []string {
+	if x != nil {
+		return x.Regions
+	}
+	return nil
+}
+
+type InventoryEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*InventoryEvent_Function
+	//	*InventoryEvent_Error
+	Event         isInventoryEvent_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InventoryEvent) Reset() {
+	*x = InventoryEvent{}
+	mi := &file_pkg_api_v1_runtime_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InventoryEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InventoryEvent) ProtoMessage() {}
+
+func (x *InventoryEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_v1_runtime_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InventoryEvent.ProtoReflect.Descriptor instead.
+func (*InventoryEvent) Descriptor() ([]byte, []int) {
+	return file_pkg_api_v1_runtime_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *InventoryEvent) GetEvent() isInventoryEvent_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *InventoryEvent) GetFunction() *Function {
+	if x != nil {
+		if x, ok := x.Event.(*InventoryEvent_Function); ok {
+			return x.Function
+		}
+	}
+	return nil
+}
+
+func (x *InventoryEvent) GetError() *DiscoveryError {
+	if x != nil {
+		if x, ok := x.Event.(*InventoryEvent_Error); ok {
+			return x.Error
+		}
+	}
+	return nil
+}
+
+type isInventoryEvent_Event interface {
+	isInventoryEvent_Event()
+}
+
+type InventoryEvent_Function struct {
+	Function *Function `protobuf:"bytes,1,opt,name=function,proto3,oneof"`
+}
+
+type InventoryEvent_Error struct {
+	Error *DiscoveryError `protobuf:"bytes,2,opt,name=error,proto3,oneof"`
+}
+
+func (*InventoryEvent_Function) isInventoryEvent_Event() {}
+
+func (*InventoryEvent_Error) isInventoryEvent_Event() {}
+
+// BumpRequest selects functions like the bump command's flags; unset
+// fields fall back to the server's. Giving runtimes replaces the server's
+// --policy for the request.
+type BumpRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Regions       []string               `protobuf:"bytes,1,rep,name=regions,proto3" json:"regions,omitempty"`
+	Function      string                 `protobuf:"bytes,2,opt,name=function,proto3" json:"function,omitempty"`
+	All           bool                   `protobuf:"varint,3,opt,name=all,proto3" json:"all,omitempty"`
+	SourceRuntime string                 `protobuf:"bytes,4,opt,name=source_runtime,json=sourceRuntime,proto3" json:"source_runtime,omitempty"`
+	TargetRuntime string                 `protobuf:"bytes,5,opt,name=target_runtime,json=targetRuntime,proto3" json:"target_runtime,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BumpRequest) Reset() {
+	*x = BumpRequest{}
+	mi := &file_pkg_api_v1_runtime_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BumpRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BumpRequest) ProtoMessage() {}
+
+func (x *BumpRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_v1_runtime_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BumpRequest.ProtoReflect.Descriptor instead.
+func (*BumpRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_v1_runtime_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *BumpRequest) GetRegions() []string {
+	if x != nil {
+		return x.Regions
+	}
+	return nil
+}
+
+func (x *BumpRequest) GetFunction() string {
+	if x != nil {
+		return x.Function
+	}
+	return ""
+}
+
+func (x *BumpRequest) GetAll() bool {
+	if x != nil {
+		return x.All
+	}
+	return false
+}
+
+func (x *BumpRequest) GetSourceRuntime() string {
+	if x != nil {
+		return x.SourceRuntime
+	}
+	return ""
+}
+
+func (x *BumpRequest) GetTargetRuntime() string {
+	if x != nil {
+		return x.TargetRuntime
+	}
+	return ""
+}
+
+type JobRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobRequest) Reset() {
+	*x = JobRequest{}
+	mi := &file_pkg_api_v1_runtime_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobRequest) ProtoMessage() {}
+
+func (x *JobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_v1_runtime_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobRequest.ProtoReflect.Descriptor instead.
+func (*JobRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_v1_runtime_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *JobRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type Job struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// running, succeeded or failed.
+	State      string                 `protobuf:"bytes,2,opt,name=state,proto3" json:"state,omitempty"`
+	Request    *BumpRequest           `protobuf:"bytes,3,opt,name=request,proto3" json:"request,omitempty"`
+	StartedAt  *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	FinishedAt *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=finished_at,json=finishedAt,proto3" json:"finished_at,omitempty"`
+	Error      string                 `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
+	// Set once the job has ended: the run to undo, every function the run
+	// discovered, the regions it could not list and outcome counts.
+	RunId         string            `protobuf:"bytes,7,opt,name=run_id,json=runId,proto3" json:"run_id,omitempty"`
+	Results       []*Function       `protobuf:"bytes,8,rep,name=results,proto3" json:"results,omitempty"`
+	Errors        []*DiscoveryError `protobuf:"bytes,9,rep,name=errors,proto3" json:"errors,omitempty"`
+	Counts        map[string]int32  `protobuf:"bytes,10,rep,name=counts,proto3" json:"counts,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Job) Reset() {
+	*x = Job{}
+	mi := &file_pkg_api_v1_runtime_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Job) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Job) ProtoMessage() {}
+
+func (x *Job) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_v1_runtime_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Job.ProtoReflect.Descriptor instead.
+func (*Job) Descriptor() ([]byte, []int) {
+	return file_pkg_api_v1_runtime_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Job) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Job) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *Job) GetRequest() *BumpRequest {
+	if x != nil {
+		return x.Request
+	}
+	return nil
+}
+
+func (x *Job) GetStartedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartedAt
+	}
+	return nil
+}
+
+func (x *Job) GetFinishedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.FinishedAt
+	}
+	return nil
+}
+
+func (x *Job) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Job) GetRunId() string {
+	if x != nil {
+		return x.RunId
+	}
+	return ""
+}
+
+func (x *Job) GetResults() []*Function {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+func (x *Job) GetErrors() []*DiscoveryError {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
+func (x *Job) GetCounts() map[string]int32 {
+	if x != nil {
+		return x.Counts
+	}
+	return nil
+}
+
+// FunctionEvent is a function starting its update, or its update ending.
+type FunctionEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// lambda-runtime-bump.started, .updated or .failed.
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Time          *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=time,proto3" json:"time,omitempty"`
+	Function      *Function              `protobuf:"bytes,3,opt,name=function,proto3" json:"function,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FunctionEvent) Reset() {
+	*x = FunctionEvent{}
+	mi := &file_pkg_api_v1_runtime_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FunctionEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FunctionEvent) ProtoMessage() {}
+
+func (x *FunctionEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_v1_runtime_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FunctionEvent.ProtoReflect.Descriptor instead.
+func (*FunctionEvent) Descriptor() ([]byte, []int) {
+	return file_pkg_api_v1_runtime_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *FunctionEvent) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *FunctionEvent) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *FunctionEvent) GetFunction() *Function {
+	if x != nil {
+		return x.Function
+	}
+	return nil
+}
+
+// JobEvent is one step of a job's stream: the job as it is first, then a
+// function event as each function starts and ends its update, and the job
+// again once it has ended.
+type JobEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*JobEvent_Function
+	//	*JobEvent_Job
+	Event         isJobEvent_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobEvent) Reset() {
+	*x = JobEvent{}
+	mi := &file_pkg_api_v1_runtime_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobEvent) ProtoMessage() {}
+
+func (x *JobEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_v1_runtime_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobEvent.ProtoReflect.Descriptor instead.
+func (*JobEvent) Descriptor() ([]byte, []int) {
+	return file_pkg_api_v1_runtime_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *JobEvent) GetEvent() isJobEvent_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *JobEvent) GetFunction() *FunctionEvent {
+	if x != nil {
+		if x, ok := x.Event.(*JobEvent_Function); ok {
+			return x.Function
+		}
+	}
+	return nil
+}
+
+func (x *JobEvent) GetJob() *Job {
+	if x != nil {
+		if x, ok := x.Event.(*JobEvent_Job); ok {
+			return x.Job
+		}
+	}
+	return nil
+}
+
+type isJobEvent_Event interface {
+	isJobEvent_Event()
+}
+
+type JobEvent_Function struct {
+	Function *FunctionEvent `protobuf:"bytes,1,opt,name=function,proto3,oneof"`
+}
+
+type JobEvent_Job struct {
+	Job *Job `protobuf:"bytes,2,opt,name=job,proto3,oneof"`
+}
+
+func (*JobEvent_Function) isJobEvent_Event() {}
+
+func (*JobEvent_Job) isJobEvent_Event() {}
+
+var File_pkg_api_v1_runtime_proto protoreflect.FileDescriptor
+
+const file_pkg_api_v1_runtime_proto_rawDesc = "" +
+	"\n" +
+	"\x18pkg/api/v1/runtime.proto\x12\x16updatelambdaruntime.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xde\x01\n" +
+	"\bFunction\x12\x1d\n" +
+	"\n" +
+	"account_id\x18\x01 \x01(\tR\taccountId\x12\x18\n" +
+	"\aprofile\x18\x02 \x01(\tR\aprofile\x12\x16\n" +
+	"\x06region\x18\x03 \x01(\tR\x06region\x12\x12\n" +
+	"\x04name\x18\x04 \x01(\tR\x04name\x12\x18\n" +
+	"\aruntime\x18\x05 \x01(\tR\aruntime\x12\x12\n" +
+	"\x04edge\x18\x06 \x01(\tR\x04edge\x12%\n" +
+	"\x0etarget_runtime\x18\a \x01(\tR\rtargetRuntime\x12\x18\n" +
+	"\aoutcome\x18\b \x01(\tR\aoutcome\"y\n" +
+	"\x0eDiscoveryError\x12\x1d\n" +
+	"\n" +
+	"account_id\x18\x01 \x01(\tR\taccountId\x12\x16\n" +
+	"\x06region\x18\x02 \x01(\tR\x06region\x12\x1a\n" +
+	"\bfunction\x18\x03 \x01(\tR\bfunction\x12\x14\n" +
+	"\x05error\x18\x04 \x01(\tR\x05error\",\n" +
+	"\x10InventoryRequest\x12\x18\n" +
+	"\aregions\x18\x01 \x03(\tR\aregions\"\x99\x01\n" +
+	"\x0eInventoryEvent\x12>\n" +
+	"\bfunction\x18\x01 \x01(\v2 .updatelambdaruntime.v1.FunctionH\x00R\bfunction\x12>\n" +
+	"\x05error\x18\x02 \x01(\v2&.updatelambdaruntime.v1.DiscoveryErrorH\x00R\x05errorB\a\n" +
+	"\x05event\"\xa3\x01\n" +
+	"\vBumpRequest\x12\x18\n" +
+	"\aregions\x18\x01 \x03(\tR\aregions\x12\x1a\n" +
+	"\bfunction\x18\x02 \x01(\tR\bfunction\x12\x10\n" +
+	"\x03all\x18\x03 \x01(\bR\x03all\x12%\n" +
+	"\x0esource_runtime\x18\x04 \x01(\tR\rsourceRuntime\x12%\n" +
+	"\x0etarget_runtime\x18\x05 \x01(\tR\rtargetRuntime\"\x1c\n" +
+	"\n" +
+	"JobRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x87\x04\n" +
+	"\x03Job\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05state\x18\x02 \x01(\tR\x05state\x12=\n" +
+	"\arequest\x18\x03 \x01(\v2#.updatelambdaruntime.v1.BumpRequestR\arequest\x129\n" +
+	"\n" +
+	"started_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tstartedAt\x12;\n" +
+	"\vfinished_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"finishedAt\x12\x14\n" +
+	"\x05error\x18\x06 \x01(\tR\x05error\x12\x15\n" +
+	"\x06run_id\x18\a \x01(\tR\x05runId\x12:\n" +
+	"\aresults\x18\b \x03(\v2 .updatelambdaruntime.v1.FunctionR\aresults\x12>\n" +
+	"\x06errors\x18\t \x03(\v2&.updatelambdaruntime.v1.DiscoveryErrorR\x06errors\x12?\n" +
+	"\x06counts\x18\n" +
+	" \x03(\v2'.updatelambdaruntime.v1.Job.CountsEntryR\x06counts\x1a9\n" +
+	"\vCountsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x01\"\x91\x01\n" +
+	"\rFunctionEvent\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12.\n" +
+	"\x04time\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12<\n" +
+	"\bfunction\x18\x03 \x01(\v2 .updatelambdaruntime.v1.FunctionR\bfunction\"\x89\x01\n" +
+	"\bJobEvent\x12C\n" +
+	"\bfunction\x18\x01 \x01(\v2%.updatelambdaruntime.v1.FunctionEventH\x00R\bfunction\x12/\n" +
+	"\x03job\x18\x02 \x01(\v2\x1b.updatelambdaruntime.v1.JobH\x00R\x03jobB\a\n" +
+	"\x05event2\xb6\x03\n" +
+	"\x0eRuntimeService\x12_\n" +
+	"\tInventory\x12(.updatelambdaruntime.v1.InventoryRequest\x1a&.updatelambdaruntime.v1.InventoryEvent0\x01\x12U\n" +
+	"\x04Plan\x12#.updatelambdaruntime.v1.BumpRequest\x1a&.updatelambdaruntime.v1.InventoryEvent0\x01\x12P\n" +
+	"\x05Apply\x12#.updatelambdaruntime.v1.BumpRequest\x1a .updatelambdaruntime.v1.JobEvent0\x01\x12I\n" +
+	"\x06Status\x12\".updatelambdaruntime.v1.JobRequest\x1a\x1b.updatelambdaruntime.v1.Job\x12O\n" +
+	"\x05Watch\x12\".updatelambdaruntime.v1.JobRequest\x1a .updatelambdaruntime.v1.JobEvent0\x01B(Z&update-lambda-runtime/pkg/api/v1;apiv1b\x06proto3"
+
+var (
+	file_pkg_api_v1_runtime_proto_rawDescOnce sync.Once
+	file_pkg_api_v1_runtime_proto_rawDescData []byte
+)
+
+func file_pkg_api_v1_runtime_proto_rawDescGZIP() []byte {
+	file_pkg_api_v1_runtime_proto_rawDescOnce.Do(func() {
+		file_pkg_api_v1_runtime_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pkg_api_v1_runtime_proto_rawDesc), len(file_pkg_api_v1_runtime_proto_rawDesc)))
+	})
+	return file_pkg_api_v1_runtime_proto_rawDescData
+}
+
+var file_pkg_api_v1_runtime_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_pkg_api_v1_runtime_proto_goTypes = []any{
+	(*Function)(nil),              // 0: updatelambdaruntime.v1.Function
+	(*DiscoveryError)(nil),        // 1: updatelambdaruntime.v1.DiscoveryError
+	(*InventoryRequest)(nil),      // 2: updatelambdaruntime.v1.InventoryRequest
+	(*InventoryEvent)(nil),        // 3: updatelambdaruntime.v1.InventoryEvent
+	(*BumpRequest)(nil),           // 4: updatelambdaruntime.v1.BumpRequest
+	(*JobRequest)(nil),            // 5: updatelambdaruntime.v1.JobRequest
+	(*Job)(nil),                   // 6: updatelambdaruntime.v1.Job
+	(*FunctionEvent)(nil),         // 7: updatelambdaruntime.v1.FunctionEvent
+	(*JobEvent)(nil),              // 8: updatelambdaruntime.v1.JobEvent
+	nil,                           // 9: updatelambdaruntime.v1.Job.CountsEntry
+	(*timestamppb.Timestamp)(nil), // 10: google.protobuf.Timestamp
+}
+var file_pkg_api_v1_runtime_proto_depIdxs = []int32{
+	0,  // 0: updatelambdaruntime.v1.InventoryEvent.function:type_name -> updatelambdaruntime.v1.Function
+	1,  // 1: updatelambdaruntime.v1.InventoryEvent.error:type_name -> updatelambdaruntime.v1.DiscoveryError
+	4,  // 2: updatelambdaruntime.v1.Job.request:type_name -> updatelambdaruntime.v1.BumpRequest
+	10, // 3: updatelambdaruntime.v1.Job.started_at:type_name -> google.protobuf.Timestamp
+	10, // 4: updatelambdaruntime.v1.Job.finished_at:type_name -> google.protobuf.Timestamp
+	0,  // 5: updatelambdaruntime.v1.Job.results:type_name -> updatelambdaruntime.v1.Function
+	1,  // 6: updatelambdaruntime.v1.Job.errors:type_name -> updatelambdaruntime.v1.DiscoveryError
+	9,  // 7: updatelambdaruntime.v1.Job.counts:type_name -> updatelambdaruntime.v1.Job.CountsEntry
+	10, // 8: updatelambdaruntime.v1.FunctionEvent.time:type_name -> google.protobuf.Timestamp
+	0,  // 9: updatelambdaruntime.v1.FunctionEvent.function:type_name -> updatelambdaruntime.v1.Function
+	7,  // 10: updatelambdaruntime.v1.JobEvent.function:type_name -> updatelambdaruntime.v1.FunctionEvent
+	6,  // 11: updatelambdaruntime.v1.JobEvent.job:type_name -> updatelambdaruntime.v1.Job
+	2,  // 12: updatelambdaruntime.v1.RuntimeService.Inventory:input_type -> updatelambdaruntime.v1.InventoryRequest
+	4,  // 13: updatelambdaruntime.v1.RuntimeService.Plan:input_type -> updatelambdaruntime.v1.BumpRequest
+	4,  // 14: updatelambdaruntime.v1.RuntimeService.Apply:input_type -> updatelambdaruntime.v1.BumpRequest
+	5,  // 15: updatelambdaruntime.v1.RuntimeService.Status:input_type -> updatelambdaruntime.v1.JobRequest
+	5,  // 16: updatelambdaruntime.v1.RuntimeService.Watch:input_type -> updatelambdaruntime.v1.JobRequest
+	3,  // 17: updatelambdaruntime.v1.RuntimeService.Inventory:output_type -> updatelambdaruntime.v1.InventoryEvent
+	3,  // 18: updatelambdaruntime.v1.RuntimeService.Plan:output_type -> updatelambdaruntime.v1.InventoryEvent
+	8,  // 19: updatelambdaruntime.v1.RuntimeService.Apply:output_type -> updatelambdaruntime.v1.JobEvent
+	6,  // 20: updatelambdaruntime.v1.RuntimeService.Status:output_type -> updatelambdaruntime.v1.Job
+	8,  // 21: updatelambdaruntime.v1.RuntimeService.Watch:output_type -> updatelambdaruntime.v1.JobEvent
+	17, // [17:22] is the sub-list for method output_type
+	12, // [12:17] is the sub-list for method input_type
+	12, // [12:12] is the sub-list for extension type_name
+	12, // [12:12] is the sub-list for extension extendee
+	0,  // [0:12] is the sub-list for field type_name
+}
+
+func init() { file_pkg_api_v1_runtime_proto_init() }
+func file_pkg_api_v1_runtime_proto_init() {
+	if File_pkg_api_v1_runtime_proto != nil {
+		return
+	}
+	file_pkg_api_v1_runtime_proto_msgTypes[3].OneofWrappers = []any{
+		(*InventoryEvent_Function)(nil),
+		(*InventoryEvent_Error)(nil),
+	}
+	file_pkg_api_v1_runtime_proto_msgTypes[8].OneofWrappers = []any{
+		(*JobEvent_Function)(nil),
+		(*JobEvent_Job)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pkg_api_v1_runtime_proto_rawDesc), len(file_pkg_api_v1_runtime_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pkg_api_v1_runtime_proto_goTypes,
+		DependencyIndexes: file_pkg_api_v1_runtime_proto_depIdxs,
+		MessageInfos:      file_pkg_api_v1_runtime_proto_msgTypes,
+	}.Build()
+	File_pkg_api_v1_runtime_proto = out.File
+	file_pkg_api_v1_runtime_proto_goTypes = nil
+	file_pkg_api_v1_runtime_proto_depIdxs = nil
+}
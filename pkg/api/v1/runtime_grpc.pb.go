@@ -0,0 +1,307 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: pkg/api/v1/runtime.proto
+
+// The gRPC API of serve --grpc-listen: the inventory, plan and bump flows
+// of the CLI, with progress streamed as it happens.
+
+package apiv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	RuntimeService_Inventory_FullMethodName = "/updatelambdaruntime.v1.RuntimeService/Inventory"
+	RuntimeService_Plan_FullMethodName      = "/updatelambdaruntime.v1.RuntimeService/Plan"
+	RuntimeService_Apply_FullMethodName     = "/updatelambdaruntime.v1.RuntimeService/Apply"
+	RuntimeService_Status_FullMethodName    = "/updatelambdaruntime.v1.RuntimeService/Status"
+	RuntimeService_Watch_FullMethodName     = "/updatelambdaruntime.v1.RuntimeService/Watch"
+)
+
+// RuntimeServiceClient is the client API for RuntimeService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type RuntimeServiceClient interface {
+	// Inventory streams every function in the requested regions as it is
+	// discovered, and every region that could not be listed.
+	Inventory(ctx context.Context, in *InventoryRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[InventoryEvent], error)
+	// Plan streams the functions the runtime policy would bump, each with
+	// the runtime it would move to. Nothing is changed.
+	Plan(ctx context.Context, in *BumpRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[InventoryEvent], error)
+	// Apply starts a bump job and streams its progress until it ends. The
+	// job keeps running if the stream is cancelled; Watch picks it up again
+	// by the ID in the first event.
+	Apply(ctx context.Context, in *BumpRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[JobEvent], error)
+	// Status returns a job as it is now.
+	Status(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (*Job, error)
+	// Watch streams a job's progress from now until it ends.
+	Watch(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[JobEvent], error)
+}
+
+type runtimeServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRuntimeServiceClient(cc grpc.ClientConnInterface) RuntimeServiceClient {
+	return &runtimeServiceClient{cc}
+}
+
+func (c *runtimeServiceClient) Inventory(ctx context.Context, in *InventoryRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[InventoryEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &RuntimeService_ServiceDesc.Streams[0], RuntimeService_Inventory_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[InventoryRequest, InventoryEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RuntimeService_InventoryClient = grpc.ServerStreamingClient[InventoryEvent]
+
+func (c *runtimeServiceClient) Plan(ctx context.Context, in *BumpRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[InventoryEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &RuntimeService_ServiceDesc.Streams[1], RuntimeService_Plan_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[BumpRequest, InventoryEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RuntimeService_PlanClient = grpc.ServerStreamingClient[InventoryEvent]
+
+func (c *runtimeServiceClient) Apply(ctx context.Context, in *BumpRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[JobEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &RuntimeService_ServiceDesc.Streams[2], RuntimeService_Apply_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[BumpRequest, JobEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RuntimeService_ApplyClient = grpc.ServerStreamingClient[JobEvent]
+
+func (c *runtimeServiceClient) Status(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (*Job, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Job)
+	err := c.cc.Invoke(ctx, RuntimeService_Status_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *runtimeServiceClient) Watch(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[JobEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &RuntimeService_ServiceDesc.Streams[3], RuntimeService_Watch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[JobRequest, JobEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RuntimeService_WatchClient = grpc.ServerStreamingClient[JobEvent]
+
+// RuntimeServiceServer is the server API for RuntimeService service.
+// All implementations must embed UnimplementedRuntimeServiceServer
+// for forward compatibility.
+type RuntimeServiceServer interface {
+	// Inventory streams every function in the requested regions as it is
+	// discovered, and every region that could not be listed.
+	Inventory(*InventoryRequest, grpc.ServerStreamingServer[InventoryEvent]) error
+	// Plan streams the functions the runtime policy would bump, each with
+	// the runtime it would move to. Nothing is changed.
+	Plan(*BumpRequest, grpc.ServerStreamingServer[InventoryEvent]) error
+	// Apply starts a bump job and streams its progress until it ends. The
+	// job keeps running if the stream is cancelled; Watch picks it up again
+	// by the ID in the first event.
+	Apply(*BumpRequest, grpc.ServerStreamingServer[JobEvent]) error
+	// Status returns a job as it is now.
+	Status(context.Context, *JobRequest) (*Job, error)
+	// Watch streams a job's progress from now until it ends.
+	Watch(*JobRequest, grpc.ServerStreamingServer[JobEvent]) error
+	mustEmbedUnimplementedRuntimeServiceServer()
+}
+
+// UnimplementedRuntimeServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedRuntimeServiceServer struct{}
+
+func (UnimplementedRuntimeServiceServer) Inventory(*InventoryRequest, grpc.ServerStreamingServer[InventoryEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method Inventory not implemented")
+}
+func (UnimplementedRuntimeServiceServer) Plan(*BumpRequest, grpc.ServerStreamingServer[InventoryEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method Plan not implemented")
+}
+func (UnimplementedRuntimeServiceServer) Apply(*BumpRequest, grpc.ServerStreamingServer[JobEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method Apply not implemented")
+}
+func (UnimplementedRuntimeServiceServer) Status(context.Context, *JobRequest) (*Job, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Status not implemented")
+}
+func (UnimplementedRuntimeServiceServer) Watch(*JobRequest, grpc.ServerStreamingServer[JobEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedRuntimeServiceServer) mustEmbedUnimplementedRuntimeServiceServer() {}
+func (UnimplementedRuntimeServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeRuntimeServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RuntimeServiceServer will
+// result in compilation errors.
+type UnsafeRuntimeServiceServer interface {
+	mustEmbedUnimplementedRuntimeServiceServer()
+}
+
+func RegisterRuntimeServiceServer(s grpc.ServiceRegistrar, srv RuntimeServiceServer) {
+	// If the following call pancis, it indicates UnimplementedRuntimeServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&RuntimeService_ServiceDesc, srv)
+}
+
+func _RuntimeService_Inventory_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(InventoryRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RuntimeServiceServer).Inventory(m, &grpc.GenericServerStream[InventoryRequest, InventoryEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RuntimeService_InventoryServer = grpc.ServerStreamingServer[InventoryEvent]
+
+func _RuntimeService_Plan_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(BumpRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RuntimeServiceServer).Plan(m, &grpc.GenericServerStream[BumpRequest, InventoryEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RuntimeService_PlanServer = grpc.ServerStreamingServer[InventoryEvent]
+
+func _RuntimeService_Apply_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(BumpRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RuntimeServiceServer).Apply(m, &grpc.GenericServerStream[BumpRequest, JobEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RuntimeService_ApplyServer = grpc.ServerStreamingServer[JobEvent]
+
+func _RuntimeService_Status_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(JobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RuntimeServiceServer).Status(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RuntimeService_Status_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RuntimeServiceServer).Status(ctx, req.(*JobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RuntimeService_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(JobRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RuntimeServiceServer).Watch(m, &grpc.GenericServerStream[JobRequest, JobEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RuntimeService_WatchServer = grpc.ServerStreamingServer[JobEvent]
+
+// RuntimeService_ServiceDesc is the grpc.ServiceDesc for RuntimeService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RuntimeService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "updatelambdaruntime.v1.RuntimeService",
+	HandlerType: (*RuntimeServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Status",
+			Handler:    _RuntimeService_Status_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Inventory",
+			Handler:       _RuntimeService_Inventory_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Plan",
+			Handler:       _RuntimeService_Plan_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Apply",
+			Handler:       _RuntimeService_Apply_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Watch",
+			Handler:       _RuntimeService_Watch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "pkg/api/v1/runtime.proto",
+}
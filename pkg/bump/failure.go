@@ -0,0 +1,74 @@
+package bump
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	lamtypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/aws/smithy-go"
+)
+
+// FailureClass is the kind of cause behind a Failed or TimedOut outcome,
+// so a run's failures can be counted by cause.
+type FailureClass string
+
+const (
+	AccessDenied       FailureClass = "AccessDenied"       // the caller, or the function's role or key, lacks a permission
+	Throttling         FailureClass = "Throttling"         // Lambda or another service throttled the calls
+	ResourceConflict   FailureClass = "ResourceConflict"   // another update of the function was in progress
+	InvalidRuntime     FailureClass = "InvalidRuntime"     // Lambda refused the target runtime
+	Timeout            FailureClass = "Timeout"            // the update did not settle in time
+	VerificationFailed FailureClass = "VerificationFailed" // updated, but a verifier rejected the result
+	OtherFailure       FailureClass = "Other"
+)
+
+// FailureClasses lists every class in the order summaries show them.
+var FailureClasses = []FailureClass{AccessDenied, Throttling, ResourceConflict, InvalidRuntime, Timeout, VerificationFailed, OtherFailure}
+
+// Classify returns the class of err, an error from an AWS call or one
+// wrapping it. Errors that are not from AWS are OtherFailure.
+func Classify(err error) FailureClass {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		code := apiErr.ErrorCode()
+		switch {
+		case strings.Contains(code, "AccessDenied") || code == "UnauthorizedOperation" || code == "UnrecognizedClientException" ||
+			code == "ExpiredToken" || code == "ExpiredTokenException" || code == "InvalidClientTokenId":
+			return AccessDenied
+		case code == "TooManyRequestsException" || retry.IsErrorThrottles(retry.DefaultThrottles).IsErrorThrottle(err) == aws.TrueTernary:
+			return Throttling
+		case code == "ResourceConflictException" || code == "ResourceInUseException" || code == "ResourceNotReadyException":
+			return ResourceConflict
+		case code == "InvalidParameterValueException" && strings.Contains(strings.ToLower(apiErr.ErrorMessage()), "runtime"):
+			return InvalidRuntime
+		}
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return Timeout
+	}
+	return OtherFailure
+}
+
+// Failure classifies u given the outcome it settled on, "" unless that
+// is Failed or TimedOut.
+func (u *Update) Failure(o Outcome) FailureClass {
+	switch {
+	case o == TimedOut:
+		return Timeout
+	case o != Failed:
+		return ""
+	case u.Err != nil:
+		return Classify(u.Err)
+	}
+	switch u.ReasonCode {
+	case lamtypes.LastUpdateStatusReasonCodeInvalidRuntime:
+		return InvalidRuntime
+	case lamtypes.LastUpdateStatusReasonCodeInsufficientRolePermissions, lamtypes.LastUpdateStatusReasonCodeKMSKeyAccessDenied,
+		lamtypes.LastUpdateStatusReasonCodeImageAccessDenied:
+		return AccessDenied
+	}
+	return OtherFailure
+}
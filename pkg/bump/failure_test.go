@@ -0,0 +1,51 @@
+package bump
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	lamtypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/aws/smithy-go"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		err  error
+		want FailureClass
+	}{
+		{&smithy.GenericAPIError{Code: "AccessDeniedException", Message: "not authorized to perform: lambda:UpdateFunctionConfiguration"}, AccessDenied},
+		{&lamtypes.TooManyRequestsException{Message: aws.String("Rate exceeded")}, Throttling},
+		{&smithy.GenericAPIError{Code: "ThrottlingException"}, Throttling},
+		{fmt.Errorf("update: %w", &lamtypes.ResourceConflictException{Message: aws.String("An update is in progress")}), ResourceConflict},
+		{&lamtypes.InvalidParameterValueException{Message: aws.String("The runtime parameter of python3.99 is not supported")}, InvalidRuntime},
+		{&lamtypes.InvalidParameterValueException{Message: aws.String("Layers are not compatible")}, OtherFailure},
+		{errors.New("pre-hook exited 1"), OtherFailure},
+	}
+	for _, tt := range tests {
+		if got := Classify(tt.err); got != tt.want {
+			t.Errorf("Classify(%v) = %s, want %s", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestUpdateFailure(t *testing.T) {
+	tests := []struct {
+		u    Update
+		o    Outcome
+		want FailureClass
+	}{
+		{Update{Status: lamtypes.LastUpdateStatusSuccessful}, Updated, ""},
+		{Update{}, TimedOut, Timeout},
+		{Update{Status: lamtypes.LastUpdateStatusFailed, ReasonCode: lamtypes.LastUpdateStatusReasonCodeInvalidRuntime}, Failed, InvalidRuntime},
+		{Update{Status: lamtypes.LastUpdateStatusFailed, ReasonCode: lamtypes.LastUpdateStatusReasonCodeKMSKeyAccessDenied}, Failed, AccessDenied},
+		{Update{Status: lamtypes.LastUpdateStatusFailed, ReasonCode: lamtypes.LastUpdateStatusReasonCodeEniLimitExceeded}, Failed, OtherFailure},
+		{Update{Err: &lamtypes.TooManyRequestsException{}}, Failed, Throttling},
+	}
+	for _, tt := range tests {
+		if got := tt.u.Failure(tt.o); got != tt.want {
+			t.Errorf("%+v.Failure(%s) = %s, want %s", tt.u, tt.o, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package bump
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// MaxDescription is the longest description Lambda accepts.
+const MaxDescription = 256
+
+// noteSep separates a note from the rest of a description.
+const noteSep = " | "
+
+// noteRE matches a note Note wrote, with the separator before it.
+var noteRE = regexp.MustCompile(`(?:\s*\|\s*)?runtime bumped (\S+)→(\S+) on \d{4}-\d{2}-\d{2} by update-lambda-runtime`)
+
+// Note returns desc with a note of the move from one runtime to another on
+// day appended, e.g. "Orders API | runtime bumped python3.9→python3.12 on
+// 2025-01-10 by update-lambda-runtime". An earlier note is replaced, so
+// the description records only the latest bump. It fails when the result
+// would be longer than Lambda allows.
+func Note(desc, from, to string, day time.Time) (string, error) {
+	return AddNote(desc, NoteFor(from, to, day))
+}
+
+// NoteFor is the note Note appends for the move from one runtime to
+// another on day.
+func NoteFor(from, to string, day time.Time) string {
+	return fmt.Sprintf("runtime bumped %s→%s on %s by update-lambda-runtime", from, to, day.Format(time.DateOnly))
+}
+
+// AddNote returns desc with note, as NoteFor wrote it, appended in place of
+// any earlier one.
+func AddNote(desc, note string) (string, error) {
+	out := note
+	if rest := strings.TrimSpace(noteRE.ReplaceAllString(desc, "")); rest != "" {
+		out = rest + noteSep + note
+	}
+	if n := len([]rune(out)); n > MaxDescription {
+		return "", fmt.Errorf("description with the note would be %d characters, over Lambda's %d", n, MaxDescription)
+	}
+	return out, nil
+}
+
+// Unnote returns desc without the note of a move from one runtime to
+// another, and whether it had one. Notes of other moves are kept.
+func Unnote(desc, from, to string) (string, bool) {
+	var found bool
+	out := noteRE.ReplaceAllStringFunc(desc, func(note string) string {
+		m := noteRE.FindStringSubmatch(note)
+		if m[1] != from || m[2] != to {
+			return note
+		}
+		found = true
+		return ""
+	})
+	return strings.TrimSpace(out), found
+}
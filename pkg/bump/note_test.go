@@ -0,0 +1,35 @@
+package bump
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNote(t *testing.T) {
+	day := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		desc, want string
+	}{
+		{"", "runtime bumped python3.9→python3.12 on 2025-01-10 by update-lambda-runtime"},
+		{"Orders API", "Orders API | runtime bumped python3.9→python3.12 on 2025-01-10 by update-lambda-runtime"},
+		{"Orders API | runtime bumped python3.8→python3.9 on 2023-05-02 by update-lambda-runtime", "Orders API | runtime bumped python3.9→python3.12 on 2025-01-10 by update-lambda-runtime"},
+	}
+	for _, tt := range tests {
+		got, err := Note(tt.desc, "python3.9", "python3.12", day)
+		if err != nil || got != tt.want {
+			t.Errorf("Note(%q) = %q, %v; want %q", tt.desc, got, err, tt.want)
+		}
+	}
+	if _, err := Note(strings.Repeat("x", 200), "python3.9", "python3.12", day); err == nil {
+		t.Error("Note made a description over Lambda's limit")
+	}
+
+	noted, _ := Note("Orders API", "python3.9", "python3.12", day)
+	if got, ok := Unnote(noted, "python3.9", "python3.12"); !ok || got != "Orders API" {
+		t.Errorf("Unnote = %q, %v; want the description without the note", got, ok)
+	}
+	if got, ok := Unnote(noted, "python3.8", "python3.9"); ok || got != noted {
+		t.Errorf("Unnote removed the note of another move: %q", got)
+	}
+}
@@ -0,0 +1,238 @@
+package bump
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+
+	"update-lambda-runtime/pkg/inventory"
+)
+
+// Policy decides which functions a run bumps and to what, as in the JSON
+// document
+//
+//	{"mappings": {"python3.9": "python3.12", "nodejs16.x": "nodejs20.x"},
+//	 "exclude": ["legacy-*"],
+//	 "layers": {"arn:aws:lambda:us-east-1:123456789012:layer:common-py39":
+//	            "arn:aws:lambda:us-east-1:123456789012:layer:common-py312:4"},
+//	 "env": {"set": {"PYTHONPATH": "/opt/python"}, "unset": ["LEGACY_MODE"]},
+//	 "production": {"accounts": ["210987654321"], "tags": {"env": "prod"}}}
+//
+// Mapping targets may be latest keywords (see inventory.ResolveTarget).
+// Exclusions are function name patterns in path.Match syntax. Layers maps
+// a layer, either one version of it or every version when the ARN has no
+// version, to the layer version that replaces it in the same update. Env
+// changes environment variables in the same update. Production names the
+// functions a run refuses to bump unless told to; DefaultProduction when
+// the policy does not say.
+type Policy struct {
+	Mappings   map[string]string `json:"mappings"`
+	Exclude    []string          `json:"exclude"`
+	Layers     map[string]string `json:"layers"`
+	Env        EnvRules          `json:"env"`
+	Production *Production       `json:"production"`
+}
+
+// Production says which functions are production: those in one of
+// Accounts, with a name ending in one of Suffixes, or carrying one of Tags
+// (values compared without regard to case).
+type Production struct {
+	Accounts []string          `json:"accounts"`
+	Suffixes []string          `json:"suffixes"`
+	Tags     map[string]string `json:"tags"`
+}
+
+// DefaultProduction guards functions tagged env=prod or named *-prod.
+var DefaultProduction = Production{Suffixes: []string{"-prod"}, Tags: map[string]string{"env": "prod"}}
+
+// Match returns why the function name in account accountID is production,
+// or "" when it is not. tags is only called when the account and name
+// rules did not match and there are tag rules to check.
+func (p *Production) Match(accountID, name string, tags func() (map[string]string, error)) (string, error) {
+	if p == nil {
+		return "", nil
+	}
+	if slices.Contains(p.Accounts, accountID) {
+		return "account " + accountID, nil
+	}
+	for _, suffix := range p.Suffixes {
+		if strings.HasSuffix(name, suffix) {
+			return "name ends in " + suffix, nil
+		}
+	}
+	if len(p.Tags) == 0 {
+		return "", nil
+	}
+	have, err := tags()
+	if err != nil {
+		return "", err
+	}
+	for _, k := range slices.Sorted(maps.Keys(p.Tags)) {
+		if v, ok := have[k]; ok && strings.EqualFold(v, p.Tags[k]) {
+			return "tag " + k + "=" + v, nil
+		}
+	}
+	return "", nil
+}
+
+// EnvRules are the environment variable changes made with a bump.
+type EnvRules struct {
+	Set   map[string]string `json:"set"`
+	Unset []string          `json:"unset"`
+}
+
+// Validate checks the policy's targets, exclusions, layer swaps and
+// environment changes.
+func (p *Policy) Validate() error {
+	for from, to := range p.Mappings {
+		if _, err := inventory.ResolveTarget(to, from); err != nil {
+			return err
+		}
+	}
+	for _, pat := range p.Exclude {
+		if _, err := path.Match(pat, ""); err != nil {
+			return fmt.Errorf("exclude %q: %w", pat, err)
+		}
+	}
+	for from, to := range p.Layers {
+		if err := validateLayerSwap(from, to); err != nil {
+			return err
+		}
+	}
+	for _, k := range p.Env.Unset {
+		if _, ok := p.Env.Set[k]; ok {
+			return fmt.Errorf("environment variable %s is both set and unset", k)
+		}
+	}
+	return nil
+}
+
+// Target returns the runtime a function named name on runtime rt should be
+// moved to, and false when the policy leaves it alone. A latest keyword is
+// resolved against the runtime calendar; functions already on the latest
+// runtime are left alone.
+func (p *Policy) Target(name, rt string) (string, bool) {
+	to, ok := p.Mappings[rt]
+	if !ok || p.Excluded(name) {
+		return "", false
+	}
+	// Keywords were checked when the policy was validated.
+	to, err := inventory.ResolveTarget(to, rt)
+	if err != nil || to == rt {
+		return "", false
+	}
+	return to, true
+}
+
+// Excluded reports whether the function named name matches an exclusion.
+func (p *Policy) Excluded(name string) bool {
+	for _, pat := range p.Exclude {
+		if ok, _ := path.Match(pat, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// SwapLayers returns layers with every layer the policy maps replaced, and
+// whether anything changed. Order is kept: it decides which layer's files
+// win when they overlap.
+func (p *Policy) SwapLayers(layers []string) ([]string, bool) {
+	return p.Rules().SwapLayers(layers)
+}
+
+// ApplyEnv returns vars with the policy's environment changes made, and
+// whether anything changed.
+func (p *Policy) ApplyEnv(vars map[string]string) (map[string]string, bool) {
+	return p.Rules().ApplyEnv(vars)
+}
+
+// Rules returns the policy's layer swaps and environment changes.
+func (p *Policy) Rules() *Rules {
+	return &Rules{Layers: maps.Clone(p.Layers), Env: p.Env}
+}
+
+// Rules are the layer swaps, environment changes and description note of
+// one update, kept as rules rather than their result so that they can be
+// made again on top of the configuration the function has by the time it
+// is updated. Layers is as in Policy; Note is the note AddNote puts in the
+// description, "" for none.
+type Rules struct {
+	Layers map[string]string `json:"layers,omitempty"`
+	Env    EnvRules          `json:"env"`
+	Note   string            `json:"note,omitempty"`
+}
+
+// SwapLayers returns layers with every layer the rules map replaced, and
+// whether anything changed.
+func (r *Rules) SwapLayers(layers []string) ([]string, bool) {
+	out := slices.Clone(layers)
+	changed := false
+	for i, l := range layers {
+		to, ok := r.Layers[l]
+		if !ok {
+			to, ok = r.Layers[UnversionedLayer(l)]
+		}
+		if ok && to != l {
+			out[i], changed = to, true
+		}
+	}
+	return out, changed
+}
+
+// ApplyEnv returns vars with the rules' environment changes made, and
+// whether anything changed.
+func (r *Rules) ApplyEnv(vars map[string]string) (map[string]string, bool) {
+	out := maps.Clone(vars)
+	if out == nil {
+		out = make(map[string]string)
+	}
+	maps.Copy(out, r.Env.Set)
+	for _, k := range r.Env.Unset {
+		delete(out, k)
+	}
+	return out, !maps.Equal(out, vars)
+}
+
+// changesEnv reports whether the rules change any environment variable.
+func (r *Rules) changesEnv() bool {
+	return len(r.Env.Set) > 0 || len(r.Env.Unset) > 0
+}
+
+// String lists the mappings as "a → b, c → d", sorted by source runtime.
+func (p *Policy) String() string {
+	return FormatMappings(p.Mappings)
+}
+
+// FormatMappings lists runtime mappings as "a → b, c → d", sorted by
+// source runtime.
+func FormatMappings(m map[string]string) string {
+	var parts []string
+	for _, from := range slices.Sorted(maps.Keys(m)) {
+		parts = append(parts, from+" → "+m[from])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// UnversionedLayer strips the version from a layer version ARN
+// (arn:aws:lambda:<region>:<account>:layer:<name>:<version>).
+func UnversionedLayer(arn string) string {
+	if strings.Count(arn, ":") == 7 {
+		return arn[:strings.LastIndex(arn, ":")]
+	}
+	return arn
+}
+
+func validateLayerSwap(from, to string) error {
+	if a, err := arn.Parse(from); err != nil || !strings.HasPrefix(a.Resource, "layer:") {
+		return fmt.Errorf("layer map: %q is not a layer ARN", from)
+	}
+	if a, err := arn.Parse(to); err != nil || !strings.HasPrefix(a.Resource, "layer:") || UnversionedLayer(to) == to {
+		return fmt.Errorf("layer map: %q is not a layer version ARN", to)
+	}
+	return nil
+}
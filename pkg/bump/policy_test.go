@@ -0,0 +1,118 @@
+package bump
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+const (
+	commonPy39  = "arn:aws:lambda:us-east-1:123456789012:layer:common-py39"
+	commonPy312 = "arn:aws:lambda:us-east-1:123456789012:layer:common-py312:4"
+	otherLayer  = "arn:aws:lambda:us-east-1:123456789012:layer:other:7"
+)
+
+func TestPolicyTarget(t *testing.T) {
+	p := &Policy{
+		Mappings: map[string]string{"python3.9": "python3.12", "python3.12": "python3.12"},
+		Exclude:  []string{"legacy-*"},
+	}
+	tests := []struct {
+		name, rt string
+		want     string
+		ok       bool
+	}{
+		{"api", "python3.9", "python3.12", true},
+		{"legacy-api", "python3.9", "", false},
+		{"api", "nodejs18.x", "", false},
+		{"api", "python3.12", "", false}, // already there
+	}
+	for _, tt := range tests {
+		got, ok := p.Target(tt.name, tt.rt)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Target(%q, %q) = %q, %t, want %q, %t", tt.name, tt.rt, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestProductionMatch(t *testing.T) {
+	p := &Production{Accounts: []string{"210987654321"}, Suffixes: []string{"-prod"}, Tags: map[string]string{"env": "prod"}}
+	tests := []struct {
+		account, name string
+		tags          map[string]string
+		want          string
+		lookedUp      bool
+	}{
+		{"210987654321", "api", nil, "account 210987654321", false},
+		{"123456789012", "api-prod", nil, "name ends in -prod", false},
+		{"123456789012", "api", map[string]string{"env": "Prod"}, "tag env=Prod", true},
+		{"123456789012", "api", map[string]string{"env": "dev"}, "", true},
+	}
+	for _, tt := range tests {
+		var lookedUp bool
+		got, err := p.Match(tt.account, tt.name, func() (map[string]string, error) {
+			lookedUp = true
+			return tt.tags, nil
+		})
+		if err != nil || got != tt.want || lookedUp != tt.lookedUp {
+			t.Errorf("Match(%q, %q) = %q, %v (tags looked up: %t), want %q (%t)", tt.account, tt.name, got, err, lookedUp, tt.want, tt.lookedUp)
+		}
+	}
+	var off *Production
+	if got, _ := off.Match("210987654321", "api-prod", nil); got != "" {
+		t.Errorf("nil rules matched: %q", got)
+	}
+}
+
+func TestPolicySwapLayers(t *testing.T) {
+	p := &Policy{Layers: map[string]string{commonPy39: commonPy312}}
+	got, changed := p.SwapLayers([]string{otherLayer, commonPy39 + ":3"})
+	if !changed || !slices.Equal(got, []string{otherLayer, commonPy312}) {
+		t.Errorf("SwapLayers = %v, %t; want the versioned match swapped in place", got, changed)
+	}
+	if _, changed := p.SwapLayers([]string{otherLayer}); changed {
+		t.Error("SwapLayers changed unmapped layers")
+	}
+}
+
+func TestPolicyApplyEnv(t *testing.T) {
+	p := &Policy{Env: EnvRules{Set: map[string]string{"PYTHONPATH": "/opt/python"}, Unset: []string{"LEGACY_MODE"}}}
+	vars := map[string]string{"LEGACY_MODE": "1", "KEEP": "x"}
+	got, changed := p.ApplyEnv(vars)
+	want := map[string]string{"PYTHONPATH": "/opt/python", "KEEP": "x"}
+	if !changed || !maps.Equal(got, want) {
+		t.Errorf("ApplyEnv = %v, %t, want %v", got, changed, want)
+	}
+	if vars["LEGACY_MODE"] != "1" {
+		t.Error("ApplyEnv modified its argument")
+	}
+	if _, changed := p.ApplyEnv(want); changed {
+		t.Error("ApplyEnv reported a change to an environment already in shape")
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		p    Policy
+		ok   bool
+	}{
+		{"valid", Policy{Mappings: map[string]string{"python3.9": "latest"}, Layers: map[string]string{commonPy39: commonPy312}}, true},
+		{"unknown family", Policy{Mappings: map[string]string{"python3.9": "latest-cobol"}}, false},
+		{"bad exclude", Policy{Exclude: []string{"["}}, false},
+		{"unversioned target layer", Policy{Layers: map[string]string{commonPy39: UnversionedLayer(commonPy312)}}, false},
+		{"set and unset", Policy{Env: EnvRules{Set: map[string]string{"A": "1"}, Unset: []string{"A"}}}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.p.Validate(); (err == nil) != tt.ok {
+			t.Errorf("%s: Validate() = %v", tt.name, err)
+		}
+	}
+}
+
+func TestFormatMappings(t *testing.T) {
+	got := FormatMappings(map[string]string{"python3.9": "python3.12", "nodejs16.x": "nodejs20.x"})
+	if want := "nodejs16.x → nodejs20.x, python3.9 → python3.12"; got != want {
+		t.Errorf("FormatMappings = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,67 @@
+package bump
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	lamtypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+// Retry is the retry budget of an update call. The SDK already retries
+// each API call a few times; Retry goes on from there, for failures that
+// last longer, such as an update of the function another deployment still
+// has in progress.
+type Retry struct {
+	// MaxAttempts is how many times the call is made at most; 1 or less
+	// makes it once.
+	MaxAttempts int
+	// Delay is the wait before the first retry, doubling after each.
+	Delay time.Duration
+	// OnRetry, when set, is told of each failed attempt about to be
+	// retried and how long until it is.
+	OnRetry func(attempt int, err error, wait time.Duration)
+	// Pace, when set, is waited on before each retry, so retries count
+	// against the same rate limit as first calls. An error from it ends
+	// the retries.
+	Pace func(ctx context.Context) error
+}
+
+// Retryable reports whether an update call that failed with err may succeed
+// if made again: Lambda throttled it, another update of the function was in
+// progress, or the service or network failed.
+func Retryable(err error) bool {
+	var conflict *lamtypes.ResourceConflictException
+	var notReady *lamtypes.ResourceNotReadyException
+	if errors.As(err, &conflict) || errors.As(err, &notReady) {
+		return true
+	}
+	return retry.IsErrorRetryables(retry.DefaultRetryables).IsErrorRetryable(err) == aws.TrueTernary
+}
+
+// Start issues req's update as Start does, making the call again while it
+// fails for a reason that may pass and the budget lasts. It returns the
+// number of calls made with the update, or with the last error.
+func (r Retry) Start(ctx context.Context, cli LambdaAPI, req Request, timeout time.Duration) (*Update, int, error) {
+	wait := r.Delay
+	for attempt := 1; ; attempt++ {
+		u, err := Start(ctx, cli, req, timeout)
+		if err == nil || attempt >= r.MaxAttempts || !Retryable(err) || ctx.Err() != nil {
+			return u, attempt, err
+		}
+		if r.OnRetry != nil {
+			r.OnRetry(attempt, err, wait)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, attempt, err
+		case <-time.After(wait):
+		}
+		if r.Pace != nil && r.Pace(ctx) != nil {
+			return nil, attempt, err
+		}
+		wait *= 2
+	}
+}
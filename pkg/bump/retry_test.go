@@ -0,0 +1,89 @@
+package bump
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lamtypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+// flakyLambda fails the first failures update calls with err.
+type flakyLambda struct {
+	fakeLambda
+	err      error
+	failures int
+	calls    int
+}
+
+func (f *flakyLambda) UpdateFunctionConfiguration(ctx context.Context, in *lambda.UpdateFunctionConfigurationInput, optFns ...func(*lambda.Options)) (*lambda.UpdateFunctionConfigurationOutput, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return nil, f.err
+	}
+	return f.fakeLambda.UpdateFunctionConfiguration(ctx, in, optFns...)
+}
+
+func TestRetryStart(t *testing.T) {
+	conflict := &lamtypes.ResourceConflictException{Message: aws.String("An update is in progress")}
+	throttled := &lamtypes.TooManyRequestsException{Message: aws.String("Rate exceeded")}
+	invalid := &lamtypes.InvalidParameterValueException{Message: aws.String("bad runtime")}
+	tests := []struct {
+		name         string
+		err          error
+		failures     int
+		maxAttempts  int
+		wantAttempts int
+		wantErr      bool
+	}{
+		{"first try", nil, 0, 3, 1, false},
+		{"conflict passes", conflict, 2, 3, 3, false},
+		{"throttled passes", throttled, 1, 3, 2, false},
+		{"budget spent", conflict, 5, 3, 3, true},
+		{"one attempt", throttled, 1, 1, 1, true},
+		{"not retryable", invalid, 1, 3, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := &flakyLambda{err: tt.err, failures: tt.failures}
+			var retried int
+			r := Retry{MaxAttempts: tt.maxAttempts, Delay: time.Millisecond, OnRetry: func(int, error, time.Duration) { retried++ }}
+			u, attempts, err := r.Start(context.Background(), cli, Request{Function: "f", Runtime: "python3.12"}, time.Minute)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && u == nil {
+				t.Fatal("no update returned")
+			}
+			if err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("err = %v, want %v", err, tt.err)
+			}
+			if attempts != tt.wantAttempts || retried != attempts-1 {
+				t.Errorf("attempts = %d with %d retries, want %d", attempts, retried, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestRetryStartPace(t *testing.T) {
+	conflict := &lamtypes.ResourceConflictException{Message: aws.String("An update is in progress")}
+	cli := &flakyLambda{err: conflict, failures: 2}
+	var paced int
+	r := Retry{MaxAttempts: 5, Delay: time.Millisecond, Pace: func(context.Context) error {
+		paced++
+		if paced == 2 {
+			return context.Canceled
+		}
+		return nil
+	}}
+	_, attempts, err := r.Start(context.Background(), cli, Request{Function: "f", Runtime: "python3.12"}, time.Minute)
+	if !errors.Is(err, conflict) {
+		t.Errorf("err = %v, want the last update error", err)
+	}
+	if attempts != 2 || cli.calls != 2 || paced != 2 {
+		t.Errorf("attempts, calls, paced = %d, %d, %d; want 2, 2, 2: each retry waits on the pace", attempts, cli.calls, paced)
+	}
+}
@@ -0,0 +1,292 @@
+// Package bump moves Lambda functions to a new runtime, swapping layers and
+// environment variables in the same update, and waits for Lambda to apply
+// it.
+package bump
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lamtypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+// Outcome is how a single function's bump ended.
+type Outcome string
+
+const (
+	Updated      Outcome = "updated"
+	Started      Outcome = "started" // issued with --no-wait and not waited on
+	Failed       Outcome = "failed"
+	TimedOut     Outcome = "timed out"
+	Interrupted  Outcome = "interrupted"
+	NotAttempted Outcome = "not attempted"
+	Skipped      Outcome = "skipped"  // held back by a safety check
+	Disabled     Outcome = "disabled" // reserved concurrency of 0, left alone
+)
+
+// Outcomes lists every outcome in the order summaries report them.
+var Outcomes = []Outcome{Updated, Started, Failed, TimedOut, Interrupted, NotAttempted, Skipped, Disabled}
+
+// LambdaAPI is the part of the Lambda API an update calls. *lambda.Client
+// implements it; tests substitute a fake.
+type LambdaAPI interface {
+	GetFunctionConfiguration(ctx context.Context, in *lambda.GetFunctionConfigurationInput, optFns ...func(*lambda.Options)) (*lambda.GetFunctionConfigurationOutput, error)
+	UpdateFunctionConfiguration(ctx context.Context, in *lambda.UpdateFunctionConfigurationInput, optFns ...func(*lambda.Options)) (*lambda.UpdateFunctionConfigurationOutput, error)
+}
+
+// Request is one function's runtime update. Layers, Env and Description
+// are the function's new layer list, environment and description, nil when
+// they are left as they are; Handler is its new handler, empty to keep it.
+//
+// RevisionID, when set, is the revision of the configuration Layers, Env
+// and Description were worked out from, and Rules how: the update is made
+// only while the function is still at that revision. When it has changed
+// since, Start reads the configuration again, makes Rules afresh on top of
+// it and retries, so changes made in between are kept rather than written
+// over. With Rules and no RevisionID, Start reads the configuration first.
+type Request struct {
+	Function    string
+	Runtime     string
+	Handler     string
+	Layers      []string
+	Env         map[string]string
+	Description *string
+	RevisionID  string
+	Rules       *Rules
+}
+
+// maxRebases is how many times Start reads a function's configuration
+// again after it changed under an update, before giving up.
+const maxRebases = 3
+
+// Update is a change Lambda has accepted, tracked until its
+// LastUpdateStatus settles or Deadline passes. RequestID is the ID of the
+// update call, when it made one. Err is set when polling the status failed.
+type Update struct {
+	Function   string
+	RequestID  string
+	Deadline   time.Time
+	Status     lamtypes.LastUpdateStatus
+	Reason     string
+	ReasonCode lamtypes.LastUpdateStatusReasonCode
+	Err        error
+
+	cli LambdaAPI
+}
+
+// Start issues req's update and returns it for waiting on, allowing it
+// timeout to settle.
+func Start(ctx context.Context, cli LambdaAPI, req Request, timeout time.Duration) (*Update, error) {
+	if req.Rules != nil && req.RevisionID == "" {
+		if err := req.rebase(ctx, cli); err != nil {
+			return nil, err
+		}
+	}
+	for rebases := 0; ; rebases++ {
+		out, err := cli.UpdateFunctionConfiguration(ctx, req.input())
+		var changed *lamtypes.PreconditionFailedException
+		if errors.As(err, &changed) && req.RevisionID != "" && rebases < maxRebases {
+			if err := req.rebase(ctx, cli); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		u := Track(cli, req.Function, timeout, out.LastUpdateStatus, out.LastUpdateStatusReason)
+		u.ReasonCode = out.LastUpdateStatusReasonCode
+		u.RequestID, _ = awsmiddleware.GetRequestIDMetadata(out.ResultMetadata)
+		return u, nil
+	}
+}
+
+func (req *Request) input() *lambda.UpdateFunctionConfigurationInput {
+	in := &lambda.UpdateFunctionConfigurationInput{
+		FunctionName: aws.String(req.Function),
+		Runtime:      lamtypes.Runtime(req.Runtime),
+	}
+	if req.Handler != "" {
+		in.Handler = aws.String(req.Handler)
+	}
+	if req.Layers != nil {
+		in.Layers = req.Layers
+	}
+	if req.Env != nil {
+		in.Environment = &lamtypes.Environment{Variables: req.Env}
+	}
+	in.Description = req.Description
+	if req.RevisionID != "" {
+		in.RevisionId = aws.String(req.RevisionID)
+	}
+	return in
+}
+
+// rebase reads the function's current configuration and works req's
+// layers, environment and description out again from it with req.Rules,
+// for the update to be made at its revision.
+func (req *Request) rebase(ctx context.Context, cli LambdaAPI) error {
+	cfg, err := cli.GetFunctionConfiguration(ctx, &lambda.GetFunctionConfigurationInput{
+		FunctionName: aws.String(req.Function),
+	})
+	if err != nil {
+		return err
+	}
+	req.RevisionID = aws.ToString(cfg.RevisionId)
+	r := req.Rules
+	if r == nil {
+		return nil
+	}
+	var layers []string
+	for _, l := range cfg.Layers {
+		layers = append(layers, aws.ToString(l.Arn))
+	}
+	req.Layers = nil
+	if swapped, ok := r.SwapLayers(layers); ok {
+		req.Layers = swapped
+	}
+	req.Env = nil
+	if r.changesEnv() {
+		var vars map[string]string
+		if cfg.Environment != nil {
+			// Writing back variables that could not be read would wipe
+			// them.
+			if cfg.Environment.Error != nil {
+				return fmt.Errorf("cannot change the environment: %s", aws.ToString(cfg.Environment.Error.Message))
+			}
+			vars = cfg.Environment.Variables
+		}
+		if env, ok := r.ApplyEnv(vars); ok {
+			req.Env = env
+		}
+	}
+	req.Description = nil
+	if r.Note != "" {
+		desc, err := AddNote(aws.ToString(cfg.Description), r.Note)
+		if err != nil {
+			return err
+		}
+		req.Description = &desc
+	}
+	return nil
+}
+
+// Track follows any update of fn Lambda accepted with the given status,
+// such as a code upload. The update response already carries the status, so
+// an update that settles immediately is never polled.
+func Track(cli LambdaAPI, fn string, timeout time.Duration, status lamtypes.LastUpdateStatus, reason *string) *Update {
+	return &Update{
+		Function: fn,
+		Deadline: time.Now().Add(timeout),
+		Status:   status,
+		Reason:   aws.ToString(reason),
+		cli:      cli,
+	}
+}
+
+// Settled reports the outcome once the update has finished, failed to be
+// polled or run out of time.
+func (u *Update) Settled() (Outcome, bool) {
+	switch {
+	case u.Err != nil:
+		return Failed, true
+	case u.Status == lamtypes.LastUpdateStatusSuccessful:
+		return Updated, true
+	case u.Status == lamtypes.LastUpdateStatusFailed:
+		return Failed, true
+	case time.Now().After(u.Deadline):
+		return TimedOut, true
+	}
+	return "", false
+}
+
+// Refresh polls the function's LastUpdateStatus. A poll cut short by ctx
+// is not an error.
+func (u *Update) Refresh(ctx context.Context) {
+	cfg, err := u.cli.GetFunctionConfiguration(ctx, &lambda.GetFunctionConfigurationInput{
+		FunctionName: aws.String(u.Function),
+	})
+	switch {
+	case err == nil:
+		u.Status, u.Reason, u.ReasonCode = cfg.LastUpdateStatus, aws.ToString(cfg.LastUpdateStatusReason), cfg.LastUpdateStatusReasonCode
+	case ctx.Err() == nil:
+		u.Err = err
+	}
+}
+
+// Wait polls u at intervals of every until it settles. If ctx is cancelled first
+// the outcome is Interrupted; the update carries on in AWS.
+func (u *Update) Wait(ctx context.Context, every time.Duration) Outcome {
+	tick := time.NewTicker(every)
+	defer tick.Stop()
+	for {
+		if o, ok := u.Settled(); ok {
+			return o
+		}
+		select {
+		case <-ctx.Done():
+			return Interrupted
+		case <-tick.C:
+			u.Refresh(ctx)
+		}
+	}
+}
+
+// maxWaitDelay caps the backoff between the waiter's polls, so a long
+// update is still noticed soon after it settles.
+const maxWaitDelay = 30 * time.Second
+
+// Await waits for u with the SDK's FunctionUpdatedV2 waiter, which polls
+// GetFunction with a jittered backoff from every up to maxWaitDelay. A
+// failed update, a GetFunction error and the waiter stopping short of the
+// deadline all end the waiter with an error; Await then polls as Wait
+// does, which tells them apart and picks up Lambda's reason. A client
+// without GetFunction is only polled.
+func (u *Update) Await(ctx context.Context, every time.Duration) Outcome {
+	cli, ok := u.cli.(lambda.GetFunctionAPIClient)
+	if !ok {
+		return u.Wait(ctx, every)
+	}
+	if o, ok := u.Settled(); ok {
+		return o
+	}
+	w := lambda.NewFunctionUpdatedV2Waiter(cli, func(o *lambda.FunctionUpdatedV2WaiterOptions) {
+		o.MinDelay = every
+		o.MaxDelay = max(every, maxWaitDelay)
+	})
+	_, err := w.WaitForOutput(ctx, &lambda.GetFunctionInput{FunctionName: aws.String(u.Function)}, time.Until(u.Deadline))
+	switch {
+	case err == nil:
+		u.Status, u.Reason = lamtypes.LastUpdateStatusSuccessful, ""
+		return Updated
+	case ctx.Err() != nil:
+		return Interrupted
+	}
+	u.Refresh(ctx)
+	return u.Wait(ctx, every)
+}
+
+// Run updates one function and waits for the update to settle. The error
+// explains a Failed outcome when Lambda gave a reason.
+func Run(ctx context.Context, cli LambdaAPI, req Request, every, timeout time.Duration) (Outcome, error) {
+	u, err := Start(ctx, cli, req, timeout)
+	if err != nil {
+		if ctx.Err() != nil {
+			return NotAttempted, err
+		}
+		return Failed, err
+	}
+	o := u.Await(ctx, every)
+	switch {
+	case o != Failed:
+		return o, nil
+	case u.Err != nil:
+		return o, u.Err
+	}
+	return o, errors.New(u.Reason)
+}
@@ -0,0 +1,282 @@
+package bump
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lamtypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+// fakeLambda accepts updates with status InProgress and then reports each
+// of statuses in turn, one per poll, repeating the last.
+type fakeLambda struct {
+	updateErr error
+	pollErr   error
+	statuses  []lamtypes.LastUpdateStatus
+	reason    string
+
+	updates []*lambda.UpdateFunctionConfigurationInput
+	polls   int
+}
+
+func (f *fakeLambda) UpdateFunctionConfiguration(ctx context.Context, in *lambda.UpdateFunctionConfigurationInput, _ ...func(*lambda.Options)) (*lambda.UpdateFunctionConfigurationOutput, error) {
+	if f.updateErr != nil {
+		return nil, f.updateErr
+	}
+	f.updates = append(f.updates, in)
+	return &lambda.UpdateFunctionConfigurationOutput{LastUpdateStatus: lamtypes.LastUpdateStatusInProgress}, nil
+}
+
+func (f *fakeLambda) GetFunctionConfiguration(ctx context.Context, in *lambda.GetFunctionConfigurationInput, _ ...func(*lambda.Options)) (*lambda.GetFunctionConfigurationOutput, error) {
+	if f.pollErr != nil {
+		return nil, f.pollErr
+	}
+	status := f.statuses[min(f.polls, len(f.statuses)-1)]
+	f.polls++
+	out := &lambda.GetFunctionConfigurationOutput{LastUpdateStatus: status}
+	if f.reason != "" {
+		out.LastUpdateStatusReason = aws.String(f.reason)
+	}
+	return out, nil
+}
+
+func TestStartRequest(t *testing.T) {
+	cli := &fakeLambda{}
+	req := Request{
+		Function: "f",
+		Runtime:  "python3.12",
+		Handler:  "bootstrap",
+		Layers:   []string{"arn:aws:lambda:us-east-1:123456789012:layer:l:2"},
+		Env:      map[string]string{"K": "V"},
+	}
+	u, err := Start(context.Background(), cli, req, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Status != lamtypes.LastUpdateStatusInProgress {
+		t.Errorf("status = %q, want InProgress", u.Status)
+	}
+	in := cli.updates[0]
+	if aws.ToString(in.FunctionName) != "f" || in.Runtime != lamtypes.RuntimePython312 {
+		t.Errorf("update %s to %s", aws.ToString(in.FunctionName), in.Runtime)
+	}
+	if !slices.Equal(in.Layers, req.Layers) || in.Environment == nil || in.Environment.Variables["K"] != "V" || aws.ToString(in.Handler) != "bootstrap" {
+		t.Errorf("layers %v, environment %+v, handler %q not passed on", in.Layers, in.Environment, aws.ToString(in.Handler))
+	}
+
+	if _, err := Start(context.Background(), cli, Request{Function: "g", Runtime: "python3.12"}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if in := cli.updates[1]; in.Layers != nil || in.Environment != nil || in.Handler != nil {
+		t.Errorf("runtime-only request changed layers %v, environment %+v or handler", in.Layers, in.Environment)
+	}
+}
+
+// revisedLambda is a function at revision, whose configuration is cfg;
+// updates made at another revision fail as Lambda fails them.
+type revisedLambda struct {
+	fakeLambda
+	cfg lambda.GetFunctionConfigurationOutput
+
+	reads int
+}
+
+func (f *revisedLambda) GetFunctionConfiguration(ctx context.Context, in *lambda.GetFunctionConfigurationInput, _ ...func(*lambda.Options)) (*lambda.GetFunctionConfigurationOutput, error) {
+	f.reads++
+	cfg := f.cfg
+	return &cfg, nil
+}
+
+func (f *revisedLambda) UpdateFunctionConfiguration(ctx context.Context, in *lambda.UpdateFunctionConfigurationInput, optFns ...func(*lambda.Options)) (*lambda.UpdateFunctionConfigurationOutput, error) {
+	if aws.ToString(in.RevisionId) != aws.ToString(f.cfg.RevisionId) {
+		return nil, &lamtypes.PreconditionFailedException{Message: aws.String("The Revision Id provided does not match the latest Revision Id")}
+	}
+	return f.fakeLambda.UpdateFunctionConfiguration(ctx, in, optFns...)
+}
+
+func TestStartRebase(t *testing.T) {
+	const old, shared = "arn:aws:lambda:us-east-1:123456789012:layer:common-py39:3", "arn:aws:lambda:us-east-1:123456789012:layer:shared:1"
+	const py312 = "arn:aws:lambda:us-east-1:123456789012:layer:common-py312:4"
+	rules := &Rules{
+		Layers: map[string]string{UnversionedLayer(old): py312},
+		Env:    EnvRules{Set: map[string]string{"PYTHONPATH": "/opt/python"}, Unset: []string{"LEGACY_MODE"}},
+		Note:   NoteFor("python3.9", "python3.12", time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)),
+	}
+	// Another deployment added a layer, a variable and a description
+	// after discovery read revision 1.
+	cli := &revisedLambda{cfg: lambda.GetFunctionConfigurationOutput{
+		RevisionId:  aws.String("2"),
+		Layers:      []lamtypes.Layer{{Arn: aws.String(old)}, {Arn: aws.String(shared)}},
+		Environment: &lamtypes.EnvironmentResponse{Variables: map[string]string{"LEGACY_MODE": "1", "DB": "orders"}},
+		Description: aws.String("Orders API"),
+	}}
+	stale := "stale"
+	req := Request{
+		Function:    "f",
+		Runtime:     "python3.12",
+		Layers:      []string{py312},
+		Env:         map[string]string{"PYTHONPATH": "/opt/python"},
+		Description: &stale,
+		RevisionID:  "1",
+		Rules:       rules,
+	}
+	if _, err := Start(context.Background(), cli, req, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if len(cli.updates) != 1 || cli.reads != 1 {
+		t.Fatalf("%d updates after %d reads, want 1 after re-reading once", len(cli.updates), cli.reads)
+	}
+	in := cli.updates[0]
+	if aws.ToString(in.RevisionId) != "2" || !slices.Equal(in.Layers, []string{py312, shared}) {
+		t.Errorf("update at revision %s with layers %v, want revision 2 keeping the new layer", aws.ToString(in.RevisionId), in.Layers)
+	}
+	if env := in.Environment.Variables; len(env) != 2 || env["DB"] != "orders" || env["PYTHONPATH"] != "/opt/python" {
+		t.Errorf("environment %v, want the new variable kept and the rules made", env)
+	}
+	if desc := aws.ToString(in.Description); desc != "Orders API | "+rules.Note {
+		t.Errorf("description %q, want the new one with the note", desc)
+	}
+
+	// A worker has no revision: the configuration is read first.
+	cli.reads, cli.updates = 0, nil
+	if _, err := Start(context.Background(), cli, Request{Function: "f", Runtime: "python3.12", Rules: rules}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if cli.reads != 1 || aws.ToString(cli.updates[0].RevisionId) != "2" {
+		t.Errorf("%d reads, update at revision %q; want the update made at the revision read", cli.reads, aws.ToString(cli.updates[0].RevisionId))
+	}
+
+	cli.cfg.Environment.Error = &lamtypes.EnvironmentError{ErrorCode: aws.String("KMSAccessDeniedException"), Message: aws.String("access denied")}
+	if _, err := Start(context.Background(), cli, Request{Function: "f", Runtime: "python3.12", RevisionID: "1", Rules: rules}, time.Minute); err == nil {
+		t.Error("updated an environment that could not be read")
+	}
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name    string
+		cli     *fakeLambda
+		timeout time.Duration
+		want    Outcome
+		wantErr string
+	}{
+		{
+			name: "updated after polling",
+			cli:  &fakeLambda{statuses: []lamtypes.LastUpdateStatus{lamtypes.LastUpdateStatusInProgress, lamtypes.LastUpdateStatusSuccessful}},
+			want: Updated,
+		},
+		{
+			name:    "failed with reason",
+			cli:     &fakeLambda{statuses: []lamtypes.LastUpdateStatus{lamtypes.LastUpdateStatusFailed}, reason: "layer incompatible"},
+			want:    Failed,
+			wantErr: "layer incompatible",
+		},
+		{
+			name:    "update rejected",
+			cli:     &fakeLambda{updateErr: errors.New("InvalidParameterValueException")},
+			want:    Failed,
+			wantErr: "InvalidParameterValueException",
+		},
+		{
+			name:    "poll error",
+			cli:     &fakeLambda{pollErr: errors.New("AccessDenied")},
+			want:    Failed,
+			wantErr: "AccessDenied",
+		},
+		{
+			name:    "timed out",
+			cli:     &fakeLambda{statuses: []lamtypes.LastUpdateStatus{lamtypes.LastUpdateStatusInProgress}},
+			timeout: 5 * time.Millisecond,
+			want:    TimedOut,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Run(context.Background(), tt.cli, Request{Function: "f", Runtime: "python3.12"}, time.Millisecond, cmp.Or(tt.timeout, time.Minute))
+			if got != tt.want {
+				t.Errorf("outcome = %q, want %q", got, tt.want)
+			}
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("err = %v", err)
+			case tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr):
+				t.Errorf("err = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cli := &fakeLambda{updateErr: context.Canceled}
+	if got, _ := Run(ctx, cli, Request{Function: "f", Runtime: "python3.12"}, time.Millisecond, time.Minute); got != NotAttempted {
+		t.Errorf("outcome = %q, want %q", got, NotAttempted)
+	}
+
+	u := Track(&fakeLambda{statuses: []lamtypes.LastUpdateStatus{lamtypes.LastUpdateStatusInProgress}}, "f", time.Minute, lamtypes.LastUpdateStatusInProgress, nil)
+	if got := u.Wait(ctx, time.Millisecond); got != Interrupted {
+		t.Errorf("Wait = %q, want %q", got, Interrupted)
+	}
+}
+
+func TestTrackSettledImmediately(t *testing.T) {
+	cli := &fakeLambda{}
+	u := Track(cli, "f", time.Minute, lamtypes.LastUpdateStatusSuccessful, nil)
+	if got := u.Wait(context.Background(), time.Millisecond); got != Updated {
+		t.Errorf("Wait = %q, want %q", got, Updated)
+	}
+	if cli.polls != 0 {
+		t.Errorf("polled %d times for an update that had already settled", cli.polls)
+	}
+}
+
+// fakeWaiterLambda also answers GetFunction, which the SDK waiter polls,
+// with each of statuses in turn.
+type fakeWaiterLambda struct {
+	fakeLambda
+	waiterStatuses []lamtypes.LastUpdateStatus
+	gets           int
+}
+
+func (f *fakeWaiterLambda) GetFunction(ctx context.Context, in *lambda.GetFunctionInput, _ ...func(*lambda.Options)) (*lambda.GetFunctionOutput, error) {
+	status := f.waiterStatuses[min(f.gets, len(f.waiterStatuses)-1)]
+	f.gets++
+	return &lambda.GetFunctionOutput{Configuration: &lamtypes.FunctionConfiguration{LastUpdateStatus: status}}, nil
+}
+
+func TestAwait(t *testing.T) {
+	inProgress, ok, failed := lamtypes.LastUpdateStatusInProgress, lamtypes.LastUpdateStatusSuccessful, lamtypes.LastUpdateStatusFailed
+
+	cli := &fakeWaiterLambda{waiterStatuses: []lamtypes.LastUpdateStatus{inProgress, inProgress, ok}}
+	u := Track(cli, "f", time.Minute, inProgress, nil)
+	if got := u.Await(context.Background(), time.Millisecond); got != Updated {
+		t.Errorf("Await = %q, want %q", got, Updated)
+	}
+	if cli.gets != 3 || cli.polls != 0 {
+		t.Errorf("%d GetFunction and %d GetFunctionConfiguration calls, want 3 and 0", cli.gets, cli.polls)
+	}
+
+	// The waiter gives up on a failed update; the poll after it reads why.
+	cli = &fakeWaiterLambda{
+		fakeLambda:     fakeLambda{statuses: []lamtypes.LastUpdateStatus{failed}, reason: "layer incompatible"},
+		waiterStatuses: []lamtypes.LastUpdateStatus{failed},
+	}
+	u = Track(cli, "f", time.Minute, inProgress, nil)
+	if got := u.Await(context.Background(), time.Millisecond); got != Failed || u.Reason != "layer incompatible" {
+		t.Errorf("Await = %q with reason %q, want %q with Lambda's reason", got, u.Reason, Failed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	u = Track(&fakeWaiterLambda{waiterStatuses: []lamtypes.LastUpdateStatus{inProgress}}, "f", time.Minute, inProgress, nil)
+	if got := u.Await(ctx, time.Millisecond); got != Interrupted {
+		t.Errorf("Await = %q, want %q", got, Interrupted)
+	}
+}
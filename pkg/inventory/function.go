@@ -0,0 +1,204 @@
+package inventory
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lamtypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer is resolved through the global provider, so discovery spans join
+// whatever trace the caller's context carries.
+var tracer = otel.Tracer("update-lambda-runtime")
+
+// LambdaAPI is the part of the Lambda API discovery calls. *lambda.Client
+// implements it; tests substitute a fake.
+type LambdaAPI interface {
+	lambda.ListFunctionsAPIClient
+	GetFunctionConfiguration(ctx context.Context, in *lambda.GetFunctionConfigurationInput, optFns ...func(*lambda.Options)) (*lambda.GetFunctionConfigurationOutput, error)
+}
+
+// STSAPI is the part of the STS API used to identify the account.
+type STSAPI interface {
+	GetCallerIdentity(ctx context.Context, in *sts.GetCallerIdentityInput, optFns ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error)
+}
+
+// AccountID returns the account cli's credentials belong to.
+func AccountID(ctx context.Context, cli STSAPI) (string, error) {
+	out, err := cli.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(out.Account), nil
+}
+
+// regionOf is the region cli calls, or "" when it does not say, as with
+// a test fake.
+func regionOf(cli LambdaAPI) string {
+	if c, ok := cli.(interface{ Options() lambda.Options }); ok {
+		return c.Options().Region
+	}
+	return ""
+}
+
+// Function is the part of a function's configuration the list and
+// bump flows act on. It is filled from whichever API call discovered the
+// function, so callers never need to look it up again.
+type Function struct {
+	Name         string   `json:"name"`
+	Runtime      string   `json:"runtime"`
+	Architecture string   `json:"architecture,omitempty"`
+	PackageType  string   `json:"packageType,omitempty"`
+	Handler      string   `json:"handler,omitempty"`
+	Description  string   `json:"description,omitempty"`
+	Version      string   `json:"version,omitempty"`    // published version; "" for $LATEST
+	MemorySize   int32    `json:"memorySize,omitempty"` // MB
+	Layers       []string `json:"layers,omitempty"`     // layer version ARNs
+	CodeSize     int64    `json:"codeSize,omitempty"`   // bytes of the deployment package
+	VPC          bool     `json:"vpc,omitempty"`        // attached to VPC subnets
+	LogGroup     string   `json:"logGroup,omitempty"`   // where it logs, when Lambda says
+
+	// Env is never cached: variables may hold secrets. EnvError is set
+	// when Lambda could not decrypt them.
+	Env      map[string]string `json:"-"`
+	EnvError string            `json:"-"`
+
+	// State and LastUpdateStatus are only set by Describe: ListFunctions
+	// leaves them out. They are not cached either, since they change
+	// with every deployment, nor is RevisionID, the revision of the
+	// configuration the rest was read from.
+	State            string `json:"-"`
+	LastUpdateStatus string `json:"-"`
+	RevisionID       string `json:"-"`
+}
+
+// FromConfiguration extracts a Function from a configuration returned by
+// ListFunctions or GetFunctionConfiguration.
+func FromConfiguration(c lamtypes.FunctionConfiguration) Function {
+	fn := Function{
+		Name:             aws.ToString(c.FunctionName),
+		Runtime:          string(c.Runtime),
+		Architecture:     string(lamtypes.ArchitectureX8664),
+		PackageType:      string(c.PackageType),
+		Handler:          aws.ToString(c.Handler),
+		Description:      aws.ToString(c.Description),
+		MemorySize:       aws.ToInt32(c.MemorySize),
+		CodeSize:         c.CodeSize,
+		VPC:              c.VpcConfig != nil && len(c.VpcConfig.SubnetIds) > 0,
+		State:            string(c.State),
+		LastUpdateStatus: string(c.LastUpdateStatus),
+		RevisionID:       aws.ToString(c.RevisionId),
+	}
+	if v := aws.ToString(c.Version); v != "$LATEST" {
+		fn.Version = v
+	}
+	if len(c.Architectures) > 0 {
+		fn.Architecture = string(c.Architectures[0])
+	}
+	if c.LoggingConfig != nil {
+		fn.LogGroup = aws.ToString(c.LoggingConfig.LogGroup)
+	}
+	for _, l := range c.Layers {
+		fn.Layers = append(fn.Layers, aws.ToString(l.Arn))
+	}
+	if c.Environment != nil {
+		fn.Env = c.Environment.Variables
+		if c.Environment.Error != nil {
+			fn.EnvError = aws.ToString(c.Environment.Error.Message)
+		}
+	}
+	return fn
+}
+
+// Stream calls visit for every function cli lists, as soon as the
+// page carrying it arrives, or only for the function called name when it
+// is set, which like Describe may be qualified. ListFunctions pages
+// already carry each runtime, so no per-function GetFunctionConfiguration
+// call is made. A function that cannot be looked up is not visited; the
+// error is returned instead.
+func Stream(ctx context.Context, cli LambdaAPI, name string, visit func(Function)) (err error) {
+	ctx, span := tracer.Start(ctx, "discover", trace.WithAttributes(semconv.CloudRegion(regionOf(cli))))
+	defer func() {
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	if name != "" {
+		fn, err := Describe(ctx, cli, name)
+		if err != nil {
+			return err
+		}
+		visit(fn)
+		return nil
+	}
+
+	p := lambda.NewListFunctionsPaginator(cli, &lambda.ListFunctionsInput{})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+		for _, c := range page.Functions {
+			visit(FromConfiguration(c))
+		}
+	}
+	return nil
+}
+
+// Describe looks up a single function by name, which may be qualified
+// with a version or alias ("name:prod") to describe what that runs. On
+// error the returned function carries only the name.
+func Describe(ctx context.Context, cli LambdaAPI, name string) (Function, error) {
+	fn := Function{Name: name}
+	cfg, err := cli.GetFunctionConfiguration(ctx, &lambda.GetFunctionConfigurationInput{
+		FunctionName: aws.String(name),
+	})
+	if err == nil {
+		fn = FromConfiguration(lamtypes.FunctionConfiguration{
+			FunctionName:     cfg.FunctionName,
+			Runtime:          cfg.Runtime,
+			Architectures:    cfg.Architectures,
+			PackageType:      cfg.PackageType,
+			Handler:          cfg.Handler,
+			Description:      cfg.Description,
+			Version:          cfg.Version,
+			MemorySize:       cfg.MemorySize,
+			CodeSize:         cfg.CodeSize,
+			VpcConfig:        cfg.VpcConfig,
+			State:            cfg.State,
+			LastUpdateStatus: cfg.LastUpdateStatus,
+			Layers:           cfg.Layers,
+			Environment:      cfg.Environment,
+			LoggingConfig:    cfg.LoggingConfig,
+			RevisionId:       cfg.RevisionId,
+		})
+	}
+	return fn, err
+}
+
+// Versions calls visit for every published version of the function called
+// name, oldest first, each with the runtime it was published with. $LATEST
+// is left out: Stream and Describe already return it.
+func Versions(ctx context.Context, cli lambda.ListVersionsByFunctionAPIClient, name string, visit func(Function)) error {
+	p := lambda.NewListVersionsByFunctionPaginator(cli, &lambda.ListVersionsByFunctionInput{FunctionName: aws.String(name)})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+		for _, c := range page.Versions {
+			if fn := FromConfiguration(c); fn.Version != "" {
+				visit(fn)
+			}
+		}
+	}
+	return nil
+}
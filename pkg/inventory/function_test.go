@@ -0,0 +1,223 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lamtypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// fakeLambda serves ListFunctions from pages, continuing with the page
+// index as the marker, and GetFunctionConfiguration from the same
+// functions. ListVersionsByFunction returns versions[name] one per page.
+type fakeLambda struct {
+	pages    [][]lamtypes.FunctionConfiguration
+	versions map[string][]lamtypes.FunctionConfiguration
+	listErr  error
+	gets     []string
+}
+
+func (f *fakeLambda) ListFunctions(ctx context.Context, in *lambda.ListFunctionsInput, _ ...func(*lambda.Options)) (*lambda.ListFunctionsOutput, error) {
+	if f.listErr != nil {
+		return nil, f.listErr
+	}
+	i, _ := strconv.Atoi(aws.ToString(in.Marker))
+	out := &lambda.ListFunctionsOutput{Functions: f.pages[i]}
+	if i+1 < len(f.pages) {
+		out.NextMarker = aws.String(strconv.Itoa(i + 1))
+	}
+	return out, nil
+}
+
+func (f *fakeLambda) GetFunctionConfiguration(ctx context.Context, in *lambda.GetFunctionConfigurationInput, _ ...func(*lambda.Options)) (*lambda.GetFunctionConfigurationOutput, error) {
+	name := aws.ToString(in.FunctionName)
+	f.gets = append(f.gets, name)
+	for _, page := range f.pages {
+		for _, c := range page {
+			if aws.ToString(c.FunctionName) == name {
+				return &lambda.GetFunctionConfigurationOutput{
+					FunctionName:  c.FunctionName,
+					Runtime:       c.Runtime,
+					Architectures: c.Architectures,
+					Layers:        c.Layers,
+					Environment:   c.Environment,
+					LoggingConfig: c.LoggingConfig,
+					RevisionId:    c.RevisionId,
+				}, nil
+			}
+		}
+	}
+	return nil, &lamtypes.ResourceNotFoundException{Message: aws.String("Function not found: " + name)}
+}
+
+func (f *fakeLambda) ListVersionsByFunction(ctx context.Context, in *lambda.ListVersionsByFunctionInput, _ ...func(*lambda.Options)) (*lambda.ListVersionsByFunctionOutput, error) {
+	if f.listErr != nil {
+		return nil, f.listErr
+	}
+	vs := f.versions[aws.ToString(in.FunctionName)]
+	i, _ := strconv.Atoi(aws.ToString(in.Marker))
+	out := &lambda.ListVersionsByFunctionOutput{Versions: vs[i : i+1]}
+	if i+1 < len(vs) {
+		out.NextMarker = aws.String(strconv.Itoa(i + 1))
+	}
+	return out, nil
+}
+
+func config(name string, rt lamtypes.Runtime) lamtypes.FunctionConfiguration {
+	return lamtypes.FunctionConfiguration{FunctionName: aws.String(name), Runtime: rt}
+}
+
+func TestStreamAllPages(t *testing.T) {
+	cli := &fakeLambda{pages: [][]lamtypes.FunctionConfiguration{
+		{config("a", lamtypes.RuntimePython39), config("b", lamtypes.RuntimeNodejs18x)},
+		{config("c", lamtypes.RuntimePython312)},
+	}}
+	var got []string
+	err := Stream(context.Background(), cli, "", func(f Function) {
+		got = append(got, f.Name+"="+f.Runtime)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a=python3.9", "b=nodejs18.x", "c=python3.12"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if len(cli.gets) != 0 {
+		t.Errorf("GetFunctionConfiguration called for %v; pages carry the runtime", cli.gets)
+	}
+}
+
+func TestStreamNamed(t *testing.T) {
+	cli := &fakeLambda{pages: [][]lamtypes.FunctionConfiguration{
+		{config("a", lamtypes.RuntimePython39), config("b", lamtypes.RuntimeNodejs18x)},
+	}}
+	var got []Function
+	if err := Stream(context.Background(), cli, "b", func(f Function) { got = append(got, f) }); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Name != "b" || got[0].Runtime != "nodejs18.x" {
+		t.Errorf("got %+v, want only b on nodejs18.x", got)
+	}
+}
+
+func TestStreamNamedError(t *testing.T) {
+	cli := &fakeLambda{pages: [][]lamtypes.FunctionConfiguration{nil}}
+	err := Stream(context.Background(), cli, "gone", func(Function) { t.Error("visit called") })
+	var notFound *lamtypes.ResourceNotFoundException
+	if !errors.As(err, &notFound) {
+		t.Errorf("err = %v, want ResourceNotFoundException", err)
+	}
+}
+
+func TestStreamListError(t *testing.T) {
+	cli := &fakeLambda{listErr: errors.New("AccessDenied")}
+	err := Stream(context.Background(), cli, "", func(Function) { t.Error("visit called") })
+	if err == nil || err.Error() != "AccessDenied" {
+		t.Errorf("err = %v, want AccessDenied", err)
+	}
+}
+
+func TestDescribeLogGroup(t *testing.T) {
+	c := config("a", lamtypes.RuntimePython39)
+	c.LoggingConfig = &lamtypes.LoggingConfig{LogGroup: aws.String("/custom/a")}
+	c.RevisionId = aws.String("rev-1")
+	fn, err := Describe(context.Background(), &fakeLambda{pages: [][]lamtypes.FunctionConfiguration{{c}}}, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fn.LogGroup != "/custom/a" || fn.RevisionID != "rev-1" {
+		t.Errorf("LogGroup, RevisionID = %q, %q; want /custom/a, rev-1", fn.LogGroup, fn.RevisionID)
+	}
+}
+
+func TestDescribeNotFound(t *testing.T) {
+	fn, err := Describe(context.Background(), &fakeLambda{pages: [][]lamtypes.FunctionConfiguration{nil}}, "gone")
+	var notFound *lamtypes.ResourceNotFoundException
+	if !errors.As(err, &notFound) {
+		t.Fatalf("err = %v, want ResourceNotFoundException", err)
+	}
+	if fn.Name != "gone" || fn.Runtime != "" {
+		t.Errorf("got %+v, want only the name", fn)
+	}
+}
+
+func TestFromConfiguration(t *testing.T) {
+	fn := FromConfiguration(lamtypes.FunctionConfiguration{
+		FunctionName:  aws.String("f"),
+		Runtime:       lamtypes.RuntimePython312,
+		Architectures: []lamtypes.Architecture{lamtypes.ArchitectureArm64},
+		Layers:        []lamtypes.Layer{{Arn: aws.String("arn:aws:lambda:us-east-1:123456789012:layer:l:1")}},
+		Environment: &lamtypes.EnvironmentResponse{
+			Variables: map[string]string{"K": "V"},
+			Error:     &lamtypes.EnvironmentError{Message: aws.String("KMS denied")},
+		},
+	})
+	if fn.Architecture != "arm64" || len(fn.Layers) != 1 || fn.Env["K"] != "V" || fn.EnvError != "KMS denied" {
+		t.Errorf("got %+v", fn)
+	}
+	if got := FromConfiguration(config("g", lamtypes.RuntimePython312)).Architecture; got != "x86_64" {
+		t.Errorf("default architecture = %q, want x86_64", got)
+	}
+	c := config("g", lamtypes.RuntimePython312)
+	c.State, c.LastUpdateStatus = lamtypes.StatePending, lamtypes.LastUpdateStatusInProgress
+	if fn := FromConfiguration(c); fn.State != "Pending" || fn.LastUpdateStatus != "InProgress" {
+		t.Errorf("state = %s/%s, want Pending/InProgress", fn.State, fn.LastUpdateStatus)
+	}
+	for v, want := range map[string]string{"$LATEST": "", "7": "7"} {
+		c.Version = aws.String(v)
+		if got := FromConfiguration(c).Version; got != want {
+			t.Errorf("version of %s = %q, want %q", v, got, want)
+		}
+	}
+}
+
+func TestVersions(t *testing.T) {
+	version := func(v string, rt lamtypes.Runtime) lamtypes.FunctionConfiguration {
+		c := config("a", rt)
+		c.Version = aws.String(v)
+		return c
+	}
+	cli := &fakeLambda{versions: map[string][]lamtypes.FunctionConfiguration{"a": {
+		version("$LATEST", lamtypes.RuntimePython312),
+		version("1", lamtypes.RuntimePython38),
+		version("2", lamtypes.RuntimePython39),
+	}}}
+	var got []string
+	if err := Versions(context.Background(), cli, "a", func(f Function) {
+		got = append(got, f.Version+"="+f.Runtime)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"1=python3.8", "2=python3.9"}; !slices.Equal(got, want) {
+		t.Errorf("versions = %v, want %v", got, want)
+	}
+}
+
+type fakeSTS struct {
+	account string
+	err     error
+}
+
+func (f fakeSTS) GetCallerIdentity(context.Context, *sts.GetCallerIdentityInput, ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &sts.GetCallerIdentityOutput{Account: aws.String(f.account)}, nil
+}
+
+func TestAccountID(t *testing.T) {
+	id, err := AccountID(context.Background(), fakeSTS{account: "123456789012"})
+	if err != nil || id != "123456789012" {
+		t.Errorf("AccountID = %q, %v", id, err)
+	}
+	if _, err := AccountID(context.Background(), fakeSTS{err: errors.New("ExpiredToken")}); err == nil {
+		t.Error("want the STS error")
+	}
+}
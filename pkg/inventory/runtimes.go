@@ -0,0 +1,285 @@
+// Package inventory discovers Lambda functions and classifies their
+// runtimes against the AWS Lambda runtime calendar.
+package inventory
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Phases is one runtime's entry in the AWS Lambda runtime calendar.
+// Deprecation is the date the runtime stops receiving security patches;
+// after BlockCreate no new functions can use it and after BlockUpdate
+// existing ones can no longer be updated. Dates AWS has not announced are
+// empty.
+type Phases struct {
+	Runtime     string `json:"runtime"`
+	Deprecation string `json:"deprecation,omitempty"`
+	BlockCreate string `json:"blockCreate,omitempty"`
+	BlockUpdate string `json:"blockUpdate,omitempty"`
+}
+
+// builtinCalendar is the AWS Lambda runtime calendar as published in the
+// Lambda runtimes documentation.
+var builtinCalendar = []Phases{
+	{"nodejs12.x", "2023-03-31", "", ""},
+	{"nodejs14.x", "2023-12-04", "", ""},
+	{"nodejs16.x", "2024-06-12", "2026-02-28", "2026-03-31"},
+	{"nodejs18.x", "2025-09-01", "2026-02-03", "2026-03-09"},
+	{"nodejs20.x", "2026-04-30", "2026-06-01", "2026-07-01"},
+	{"nodejs22.x", "", "", ""},
+	{"python3.7", "2023-12-04", "", ""},
+	{"python3.8", "2024-10-14", "2026-02-28", "2026-03-31"},
+	{"python3.9", "2025-12-15", "", ""},
+	{"python3.10", "", "", ""},
+	{"python3.11", "", "", ""},
+	{"python3.12", "", "", ""},
+	{"python3.13", "", "", ""},
+	{"java8", "2024-01-08", "", ""},
+	{"java8.al2", "", "", ""},
+	{"java11", "", "", ""},
+	{"java17", "", "", ""},
+	{"java21", "", "", ""},
+	{"go1.x", "2024-01-08", "", ""},
+	{"provided", "2024-01-08", "", ""},
+	{"provided.al2", "", "", ""},
+	{"provided.al2023", "", "", ""},
+	{"ruby2.7", "2023-12-07", "", ""},
+	{"ruby3.2", "2026-03-31", "2026-04-30", "2026-05-31"},
+	{"ruby3.3", "", "", ""},
+	{"ruby3.4", "", "", ""},
+	{"dotnet6", "2024-12-20", "", ""},
+	{"dotnet7", "2024-05-14", "", ""},
+	{"dotnet8", "2026-11-10", "2026-12-10", "2027-01-11"},
+}
+
+// calendar is the runtime calendar in use. Its phases are replaced, never
+// changed in place, so a reader may keep them after unlocking.
+var calendar = struct {
+	sync.RWMutex
+	phases []Phases
+}{phases: builtinCalendar}
+
+// Calendar returns a copy of the runtime calendar runtimes are classified
+// against: the built-in one, unless WithCalendar replaced it.
+func Calendar() []Phases {
+	return slices.Clone(currentCalendar())
+}
+
+// WithCalendar classifies runtimes against phases, e.g. a calendar
+// refreshed from AWS, instead of the built-in one, and returns a function
+// putting back the calendar it replaced. phases is copied.
+func WithCalendar(phases []Phases) (restore func()) {
+	calendar.Lock()
+	defer calendar.Unlock()
+	prev := calendar.phases
+	calendar.phases = slices.Clone(phases)
+	return func() {
+		calendar.Lock()
+		defer calendar.Unlock()
+		calendar.phases = prev
+	}
+}
+
+func currentCalendar() []Phases {
+	calendar.RLock()
+	defer calendar.RUnlock()
+	return calendar.phases
+}
+
+// Entry returns rt's calendar entry.
+func Entry(rt string) (Phases, bool) {
+	cal := currentCalendar()
+	i := slices.IndexFunc(cal, func(p Phases) bool { return p.Runtime == rt })
+	if i < 0 {
+		return Phases{}, false
+	}
+	return cal[i], true
+}
+
+// DeprecationDate returns when rt is (or was) deprecated, if AWS has
+// announced it.
+func DeprecationDate(rt string) (time.Time, bool) {
+	p, ok := Entry(rt)
+	if !ok || p.Deprecation == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.DateOnly, p.Deprecation)
+	return t, err == nil
+}
+
+// Deprecation states of a runtime, as stored in inventory records.
+const (
+	Supported   = "supported"
+	Deprecating = "deprecating"
+	Deprecated  = "deprecated"
+)
+
+// DeprecationStatus classifies rt at now: deprecated once its deprecation
+// date has passed, deprecating while that date is announced but ahead.
+func DeprecationStatus(rt string, now time.Time) string {
+	d, ok := DeprecationDate(rt)
+	switch {
+	case !ok:
+		return Supported
+	case now.Before(d):
+		return Deprecating
+	}
+	return Deprecated
+}
+
+// LatestKeyword as a target runtime stands for the newest supported runtime
+// of the function's own family; "latest-<family>" (e.g. latest-python,
+// latest-nodejs) names the family explicitly.
+const LatestKeyword = "latest"
+
+// Family is the language part of a runtime identifier: "python"
+// for python3.12, "nodejs" for nodejs20.x, "provided" for provided.al2023.
+func Family(rt string) string {
+	i := strings.IndexFunc(rt, func(r rune) bool { return !unicode.IsLetter(r) })
+	if i < 0 {
+		return rt
+	}
+	return rt[:i]
+}
+
+// Version is the numbers in a runtime identifier after its family,
+// so python3.12 is [3 12] and provided.al2023 is [2023].
+func Version(rt string) []int {
+	var out []int
+	for _, f := range strings.FieldsFunc(strings.TrimPrefix(rt, Family(rt)), func(r rune) bool { return !unicode.IsDigit(r) }) {
+		n, _ := strconv.Atoi(f)
+		out = append(out, n)
+	}
+	return out
+}
+
+// Latest returns the newest runtime of family in the calendar that
+// is not yet deprecated at now.
+func Latest(family string, now time.Time) (string, bool) {
+	var best string
+	for _, p := range currentCalendar() {
+		if Family(p.Runtime) != family || DeprecationStatus(p.Runtime, now) == Deprecated {
+			continue
+		}
+		if best == "" || slices.Compare(Version(p.Runtime), Version(best)) > 0 {
+			best = p.Runtime
+		}
+	}
+	return best, best != ""
+}
+
+// MinimumSupported returns the oldest runtime newer than rt that is not
+// yet deprecated at now: of rt's family, else of the family succeeding it,
+// so python3.8 moves to python3.9 while that is supported and go1.x to
+// provided.al2.
+func MinimumSupported(rt string, now time.Time) (string, bool) {
+	for _, family := range []string{Family(rt), successors[Family(rt)]} {
+		var best string
+		for _, p := range currentCalendar() {
+			if family == "" || Family(p.Runtime) != family || DeprecationStatus(p.Runtime, now) == Deprecated {
+				continue
+			}
+			if family == Family(rt) && slices.Compare(Version(p.Runtime), Version(rt)) <= 0 {
+				continue
+			}
+			if best == "" || slices.Compare(Version(p.Runtime), Version(best)) < 0 {
+				best = p.Runtime
+			}
+		}
+		if best != "" {
+			return best, true
+		}
+	}
+	return "", false
+}
+
+// successors names the family that takes over from a retired one with no
+// newer runtime of its own: Go functions run on the OS-only runtimes.
+var successors = map[string]string{"go": "provided"}
+
+// ResolveTarget turns a target that may be a latest keyword into a runtime
+// for a function currently on rt. For go1.x, latest is the newest OS-only
+// runtime.
+func ResolveTarget(target, rt string) (string, error) {
+	family, ok := strings.CutPrefix(target, LatestKeyword)
+	if !ok {
+		return target, nil
+	}
+	if family == "" {
+		family = cmp.Or(successors[Family(rt)], Family(rt))
+	} else if family, ok = strings.CutPrefix(family, "-"); !ok {
+		return target, nil // a runtime that merely starts with "latest"
+	}
+	latest, ok := Latest(family, time.Now())
+	if !ok {
+		return "", fmt.Errorf("%s: no supported %s runtime in the calendar", target, family)
+	}
+	return latest, nil
+}
+
+// familyShorthands are the short family names runtimes may be written
+// with.
+var familyShorthands = map[string]string{"py": "python", "node": "nodejs", "rb": "ruby", "net": "dotnet"}
+
+// Shorthands maps each short way of writing a calendar runtime to its
+// identifier: py312, py3.12 and python312 for python3.12, node20 and
+// nodejs20 for nodejs20.x, rb33 for ruby3.3, net8 for dotnet8 and al2023
+// for provided.al2023. It follows the calendar, refreshed or not.
+func Shorthands() map[string]string {
+	names := make(map[string][]string) // family → its short names
+	for short, family := range familyShorthands {
+		names[family] = append(names[family], short)
+	}
+	out := make(map[string]string)
+	add := func(alias, rt string) {
+		if _, taken := out[alias]; !taken && alias != rt {
+			out[alias] = rt
+		}
+	}
+	for _, p := range currentCalendar() {
+		family := Family(p.Runtime)
+		rest := strings.TrimPrefix(p.Runtime, family)
+		if family == "provided" {
+			if image := strings.TrimPrefix(rest, "."); image != "" {
+				add(image, p.Runtime)
+			}
+			continue
+		}
+		dotted := strings.TrimSuffix(rest, ".x")
+		for _, name := range append([]string{family}, names[family]...) {
+			add(name+rest, p.Runtime)
+			add(name+dotted, p.Runtime)
+			add(name+strings.ReplaceAll(dotted, ".", ""), p.Runtime)
+		}
+	}
+	return out
+}
+
+// Normalize turns a runtime as a person may write it into its identifier:
+// a shorthand such as py312 or node20, any case, and latest-py for
+// latest-python. Identifiers, and anything it does not recognise, are
+// returned as they are, lower-cased, so runtimes missing from the calendar
+// still work.
+func Normalize(rt string) string {
+	s := strings.ToLower(strings.TrimSpace(rt))
+	if _, ok := Entry(s); ok {
+		return s
+	}
+	if family, ok := strings.CutPrefix(s, LatestKeyword+"-"); ok {
+		if full, ok := familyShorthands[family]; ok {
+			return LatestKeyword + "-" + full
+		}
+		return s
+	}
+	if full, ok := Shorthands()[s]; ok {
+		return full
+	}
+	return s
+}
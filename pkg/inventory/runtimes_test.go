@@ -0,0 +1,143 @@
+package inventory
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestFamilyAndVersion(t *testing.T) {
+	tests := []struct {
+		rt      string
+		family  string
+		version []int
+	}{
+		{"python3.12", "python", []int{3, 12}},
+		{"nodejs20.x", "nodejs", []int{20}},
+		{"provided.al2023", "provided", []int{2023}},
+		{"java8.al2", "java", []int{8, 2}},
+		{"provided", "provided", nil},
+	}
+	for _, tt := range tests {
+		if got := Family(tt.rt); got != tt.family {
+			t.Errorf("Family(%q) = %q, want %q", tt.rt, got, tt.family)
+		}
+		if got := Version(tt.rt); !slices.Equal(got, tt.version) {
+			t.Errorf("Version(%q) = %v, want %v", tt.rt, got, tt.version)
+		}
+	}
+}
+
+func TestDeprecationStatus(t *testing.T) {
+	at := func(s string) time.Time {
+		d, _ := time.Parse(time.DateOnly, s)
+		return d
+	}
+	tests := []struct {
+		rt   string
+		now  time.Time
+		want string
+	}{
+		{"python3.8", at("2026-01-01"), Deprecated},
+		{"nodejs20.x", at("2026-01-01"), Deprecating},
+		{"nodejs20.x", at("2026-05-01"), Deprecated},
+		{"not-a-runtime", at("2026-01-01"), Supported},
+	}
+	for _, tt := range tests {
+		if got := DeprecationStatus(tt.rt, tt.now); got != tt.want {
+			t.Errorf("DeprecationStatus(%q, %s) = %q, want %q", tt.rt, tt.now.Format(time.DateOnly), got, tt.want)
+		}
+	}
+}
+
+func TestResolveTarget(t *testing.T) {
+	latest, ok := Latest("python", time.Now())
+	if !ok {
+		t.Fatal("no supported python runtime in the calendar")
+	}
+	tests := []struct {
+		target, rt, want string
+	}{
+		{"python3.12", "python3.9", "python3.12"},
+		{LatestKeyword, "python3.9", latest},
+		{"latest-python", "nodejs18.x", latest},
+		{"latestish", "python3.9", "latestish"},
+		{LatestKeyword, "go1.x", "provided.al2023"},
+	}
+	for _, tt := range tests {
+		got, err := ResolveTarget(tt.target, tt.rt)
+		if err != nil || got != tt.want {
+			t.Errorf("ResolveTarget(%q, %q) = %q, %v, want %q", tt.target, tt.rt, got, err, tt.want)
+		}
+	}
+	if _, err := ResolveTarget("latest-cobol", "python3.9"); err == nil {
+		t.Error("ResolveTarget(latest-cobol): want an error")
+	}
+}
+
+func TestMinimumSupported(t *testing.T) {
+	at := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC) // python3.9 and nodejs18.x still supported
+	for rt, want := range map[string]string{
+		"python3.8":  "python3.9",
+		"nodejs16.x": "nodejs18.x",
+		"go1.x":      "provided.al2",
+		"java8":      "java8.al2",
+		"dotnet6":    "dotnet8",
+	} {
+		if got, ok := MinimumSupported(rt, at); !ok || got != want {
+			t.Errorf("MinimumSupported(%s) = %q, %v, want %s", rt, got, ok, want)
+		}
+	}
+	later := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC) // python3.9 deprecated
+	if got, _ := MinimumSupported("python3.8", later); got != "python3.10" {
+		t.Errorf("MinimumSupported(python3.8) after python3.9's deprecation = %q, want python3.10", got)
+	}
+	if got, ok := MinimumSupported("nodejs22.x", at); ok {
+		t.Errorf("MinimumSupported(nodejs22.x) = %q, want none newer", got)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	for in, want := range map[string]string{
+		"python3.12":  "python3.12",
+		"py312":       "python3.12",
+		"PY3.9":       "python3.9",
+		"python310":   "python3.10",
+		"node20":      "nodejs20.x",
+		"nodejs22":    "nodejs22.x",
+		"node20.x":    "nodejs20.x",
+		"rb33":        "ruby3.3",
+		"net8":        "dotnet8",
+		"java21":      "java21",
+		"al2023":      "provided.al2023",
+		"latest":      "latest",
+		"latest-py":   "latest-python",
+		"latest-node": "latest-nodejs",
+		"python2.7":   "python2.7",
+		"cobol85":     "cobol85",
+	} {
+		if got := Normalize(in); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestWithCalendar(t *testing.T) {
+	cal := Calendar()
+	cal[0].Deprecation = "2000-01-01"
+	if p, _ := Entry(cal[0].Runtime); p.Deprecation == "2000-01-01" {
+		t.Fatal("changing the copy Calendar returned changed the calendar")
+	}
+
+	restore := WithCalendar([]Phases{{Runtime: "python3.14"}})
+	if _, ok := Entry("python3.14"); !ok {
+		t.Error("runtime of the calendar given not found")
+	}
+	if _, ok := Entry("python3.12"); ok {
+		t.Error("runtime of the built-in calendar still found")
+	}
+	restore()
+	if _, ok := Entry("python3.12"); !ok || len(Calendar()) != len(builtinCalendar) {
+		t.Error("built-in calendar not restored")
+	}
+}
@@ -0,0 +1,150 @@
+package report
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"update-lambda-runtime/pkg/inventory"
+)
+
+// CycloneDX property names of the inventory's facts, under the aws:lambda
+// namespace CycloneDX's property taxonomy leaves to producers.
+const (
+	propAccount     = "aws:lambda:account-id"
+	propProfile     = "aws:lambda:profile"
+	propRegion      = "aws:lambda:region"
+	propError       = "aws:lambda:error"
+	propRuntime     = "aws:lambda:runtime"
+	propStatus      = "aws:lambda:runtime:deprecation-status"
+	propDeprecation = "aws:lambda:runtime:eol-date" // end of security patches
+	propBlockCreate = "aws:lambda:runtime:block-function-create-date"
+	propBlockUpdate = "aws:lambda:runtime:block-function-update-date"
+)
+
+// cdxBOM is the part of a CycloneDX 1.5 BOM the inventory fills in.
+type cdxBOM struct {
+	BOMFormat    string          `json:"bomFormat"`
+	SpecVersion  string          `json:"specVersion"`
+	SerialNumber string          `json:"serialNumber"`
+	Version      int             `json:"version"`
+	Metadata     cdxMetadata     `json:"metadata"`
+	Components   []cdxComponent  `json:"components"`
+	Dependencies []cdxDependency `json:"dependencies"`
+}
+
+type cdxMetadata struct {
+	Timestamp string `json:"timestamp"`
+	Tools     struct {
+		Components []cdxComponent `json:"components"`
+	} `json:"tools"`
+	Properties []cdxProperty `json:"properties,omitempty"`
+}
+
+type cdxComponent struct {
+	Type       string        `json:"type"`
+	BOMRef     string        `json:"bom-ref,omitempty"`
+	Group      string        `json:"group,omitempty"`
+	Name       string        `json:"name"`
+	Version    string        `json:"version,omitempty"`
+	Properties []cdxProperty `json:"properties,omitempty"`
+}
+
+type cdxProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type cdxDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+// WriteCycloneDX writes inv as a CycloneDX 1.5 JSON BOM: each function an
+// application component depending on its runtime, and each runtime a
+// platform component carrying its deprecation status and calendar dates,
+// so SBOM tooling sees Lambda runtime exposure beside application
+// dependencies. Accounts and regions that could not be read are recorded
+// as metadata properties.
+func WriteCycloneDX(w io.Writer, inv *Inventory) error {
+	bom := cdxBOM{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + newUUID(),
+		Version:      1,
+		Dependencies: []cdxDependency{},
+		Components:   []cdxComponent{},
+	}
+	bom.Metadata.Timestamp = inv.GeneratedAt.UTC().Format(time.RFC3339)
+	bom.Metadata.Tools.Components = []cdxComponent{{Type: "application", Name: "update-lambda-runtime"}}
+
+	var runtimes []string
+	for _, a := range inv.Accounts {
+		if a.Error != "" {
+			bom.Metadata.Properties = append(bom.Metadata.Properties, cdxProperty{propError, fmt.Sprintf("%s (%s): %s", a.AccountID, a.Profile, a.Error)})
+		}
+		for _, r := range a.Regions {
+			if r.Error != "" {
+				bom.Metadata.Properties = append(bom.Metadata.Properties, cdxProperty{propError, fmt.Sprintf("%s %s: %s", a.AccountID, r.Region, r.Error)})
+			}
+			for _, rt := range r.Runtimes {
+				ref := runtimeRef(rt.Runtime)
+				if !slices.Contains(runtimes, rt.Runtime) {
+					runtimes = append(runtimes, rt.Runtime)
+				}
+				for _, fn := range rt.Functions {
+					fnRef := fmt.Sprintf("lambda:%s/%s/%s", a.AccountID, r.Region, fn)
+					bom.Components = append(bom.Components, cdxComponent{
+						Type:   "application",
+						BOMRef: fnRef,
+						Group:  a.AccountID + "/" + r.Region,
+						Name:   fn,
+						Properties: []cdxProperty{
+							{propAccount, a.AccountID},
+							{propProfile, a.Profile},
+							{propRegion, r.Region},
+							{propStatus, rt.Status},
+						},
+					})
+					bom.Dependencies = append(bom.Dependencies, cdxDependency{Ref: fnRef, DependsOn: []string{ref}})
+				}
+			}
+		}
+	}
+	slices.Sort(runtimes)
+	for _, rt := range runtimes {
+		// python3.12 is version 3.12 of python, provided.al2023 version al2023 of provided.
+		family := inventory.Family(rt)
+		c := cdxComponent{Type: "platform", BOMRef: runtimeRef(rt), Group: "aws-lambda", Name: family, Version: strings.TrimPrefix(strings.TrimPrefix(rt, family), ".")}
+		c.Properties = append(c.Properties, cdxProperty{propRuntime, rt}, cdxProperty{propStatus, inventory.DeprecationStatus(rt, inv.GeneratedAt)})
+		if p, ok := inventory.Entry(rt); ok {
+			for _, d := range []cdxProperty{{propDeprecation, p.Deprecation}, {propBlockCreate, p.BlockCreate}, {propBlockUpdate, p.BlockUpdate}} {
+				if d.Value != "" {
+					c.Properties = append(c.Properties, d)
+				}
+			}
+		}
+		bom.Components = append(bom.Components, c)
+		bom.Dependencies = append(bom.Dependencies, cdxDependency{Ref: c.BOMRef})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(bom)
+}
+
+func runtimeRef(rt string) string { return "aws-lambda-runtime:" + rt }
+
+// newUUID returns a random (version 4) UUID, as CycloneDX serial numbers
+// are.
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
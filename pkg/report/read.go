@@ -0,0 +1,158 @@
+package report
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"update-lambda-runtime/pkg/inventory"
+)
+
+// Read parses an inventory written by Write as JSON, JSON lines or CSV.
+// Other tools' inventories read too: a CSV needs region, function_name and
+// runtime columns, and a JSON line the Row fields of those names. Rows'
+// statuses are worked out again as of now rather than trusted, and rows
+// with no account or profile are left for the caller to assign.
+func Read(r io.Reader, format string, now time.Time) (*Inventory, error) {
+	switch format {
+	case JSON:
+		br := bufio.NewReader(r)
+		// A JSON lines export starts with a row rather than a document.
+		var probe struct {
+			Accounts     json.RawMessage `json:"accounts"`
+			FunctionName string          `json:"function_name"`
+		}
+		head, _ := br.Peek(1 << 16)
+		if line, _, _ := bytes.Cut(head, []byte("\n")); json.Unmarshal(line, &probe) == nil && probe.FunctionName != "" {
+			return readJSONL(br, now)
+		}
+		var inv Inventory
+		if err := json.NewDecoder(br).Decode(&inv); err != nil {
+			return nil, fmt.Errorf("read inventory: %w", err)
+		}
+		if inv.Accounts == nil {
+			return nil, errors.New("read inventory: no accounts in document")
+		}
+		return &inv, nil
+	case JSONL:
+		return readJSONL(r, now)
+	case CSV:
+		return readCSV(r, now)
+	}
+	return nil, fmt.Errorf("cannot read a %q inventory", format)
+}
+
+func readJSONL(r io.Reader, now time.Time) (*Inventory, error) {
+	var rows []Row
+	dec := json.NewDecoder(r)
+	for dec.More() {
+		var row Row
+		if err := dec.Decode(&row); err != nil {
+			return nil, fmt.Errorf("read inventory line %d: %w", len(rows)+1, err)
+		}
+		rows = append(rows, row)
+	}
+	return FromRows(rows, now)
+}
+
+func readCSV(r io.Reader, now time.Time) (*Inventory, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read inventory header: %w", err)
+	}
+	col := make(map[string]int)
+	for i, name := range header {
+		col[strings.TrimSpace(strings.ToLower(name))] = i
+	}
+	for _, name := range []string{"region", "function_name", "runtime"} {
+		if _, ok := col[name]; !ok {
+			return nil, fmt.Errorf("read inventory: no %s column", name)
+		}
+	}
+	field := func(rec []string, name string) string {
+		if i, ok := col[name]; ok && i < len(rec) {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+	var rows []Row
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read inventory: %w", err)
+		}
+		rows = append(rows, Row{
+			SnapshotTime: field(rec, "snapshot_time"),
+			AccountID:    field(rec, "account_id"),
+			Profile:      field(rec, "profile"),
+			Region:       field(rec, "region"),
+			FunctionName: field(rec, "function_name"),
+			Runtime:      field(rec, "runtime"),
+		})
+	}
+	return FromRows(rows, now)
+}
+
+// FromRows folds flat rows back into an inventory, the reverse of Rows.
+// It is generated at the latest snapshot time of the rows, or zero when
+// they have none.
+func FromRows(rows []Row, now time.Time) (*Inventory, error) {
+	type accountKey struct{ id, profile string }
+	byAccount := make(map[accountKey]map[string]map[string][]string) // → region → runtime → functions
+	var order []accountKey
+	var generated time.Time
+	regions := make(map[string]bool)
+	for i, row := range rows {
+		if row.Region == "" || row.FunctionName == "" {
+			return nil, fmt.Errorf("read inventory row %d: region and function_name are required", i+1)
+		}
+		if row.SnapshotTime != "" {
+			t, err := time.Parse(time.DateTime, row.SnapshotTime)
+			if err != nil {
+				return nil, fmt.Errorf("read inventory row %d: snapshot_time: %w", i+1, err)
+			}
+			if t.After(generated) {
+				generated = t
+			}
+		}
+		k := accountKey{row.AccountID, row.Profile}
+		if byAccount[k] == nil {
+			byAccount[k] = make(map[string]map[string][]string)
+			order = append(order, k)
+		}
+		if byAccount[k][row.Region] == nil {
+			byAccount[k][row.Region] = make(map[string][]string)
+		}
+		byAccount[k][row.Region][row.Runtime] = append(byAccount[k][row.Region][row.Runtime], row.FunctionName)
+		regions[row.Region] = true
+	}
+	inv := New(slices.Sorted(maps.Keys(regions)), generated)
+	for _, k := range order {
+		a := Account{AccountID: k.id, Profile: k.profile}
+		for _, region := range slices.Sorted(maps.Keys(byAccount[k])) {
+			ri := Region{Region: region}
+			for _, rt := range slices.Sorted(maps.Keys(byAccount[k][region])) {
+				names := byAccount[k][region][rt]
+				slices.Sort(names)
+				ri.Runtimes = append(ri.Runtimes, Runtime{Runtime: rt, Status: inventory.DeprecationStatus(rt, now), Functions: names})
+			}
+			a.Regions = append(a.Regions, ri)
+		}
+		inv.Add(a)
+	}
+	inv.Sort()
+	return inv, nil
+}
@@ -0,0 +1,90 @@
+// Package report builds the consolidated runtime inventory of several
+// accounts, grouped account → region → runtime, and writes it as JSON,
+// JSON lines, CSV or HTML.
+package report
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"update-lambda-runtime/pkg/inventory"
+)
+
+// Inventory is the consolidated runtime inventory of every swept account.
+type Inventory struct {
+	GeneratedAt time.Time      `json:"generatedAt"`
+	Regions     []string       `json:"regions"`
+	Totals      map[string]int `json:"totals"` // functions per runtime across all accounts
+	Accounts    []Account      `json:"accounts"`
+}
+
+// Account is one account's part of the inventory. Error is set, and
+// Regions empty, when the account could not be read at all.
+type Account struct {
+	AccountID string   `json:"accountId,omitempty"`
+	Profile   string   `json:"profile"`
+	Error     string   `json:"error,omitempty"`
+	Regions   []Region `json:"regions,omitempty"`
+}
+
+// Region is one region of an account. Error is set when listing its
+// functions failed part-way; Runtimes then holds what was listed.
+type Region struct {
+	Region   string    `json:"region"`
+	Error    string    `json:"error,omitempty"`
+	Runtimes []Runtime `json:"runtimes,omitempty"`
+}
+
+// Runtime groups the functions of a region on one runtime, with the
+// runtime's deprecation status.
+type Runtime struct {
+	Runtime   string   `json:"runtime"`
+	Status    string   `json:"status"`
+	Functions []string `json:"functions"`
+}
+
+// New starts an inventory of regions generated at now.
+func New(regions []string, now time.Time) *Inventory {
+	return &Inventory{GeneratedAt: now.UTC(), Regions: regions, Totals: make(map[string]int)}
+}
+
+// ScanRegion lists the functions in region, which cli calls, and groups
+// them by runtime, classified at now. A listing error is returned and recorded in
+// the region.
+func ScanRegion(ctx context.Context, cli inventory.LambdaAPI, region string, now time.Time) (Region, error) {
+	r := Region{Region: region}
+	byRuntime := make(map[string][]string)
+	err := inventory.Stream(ctx, cli, "", func(f inventory.Function) {
+		byRuntime[f.Runtime] = append(byRuntime[f.Runtime], f.Name)
+	})
+	if err != nil {
+		r.Error = err.Error()
+	}
+	for _, rt := range slices.Sorted(maps.Keys(byRuntime)) {
+		names := byRuntime[rt]
+		slices.Sort(names)
+		r.Runtimes = append(r.Runtimes, Runtime{Runtime: rt, Status: inventory.DeprecationStatus(rt, now), Functions: names})
+	}
+	return r, err
+}
+
+// Add appends a swept account and counts its functions into the totals.
+func (inv *Inventory) Add(a Account) {
+	for _, r := range a.Regions {
+		for _, rt := range r.Runtimes {
+			inv.Totals[rt.Runtime] += len(rt.Functions)
+		}
+	}
+	inv.Accounts = append(inv.Accounts, a)
+}
+
+// Sort orders the accounts by ID, keeping accounts that could not be read
+// first in the order they were added.
+func (inv *Inventory) Sort() {
+	slices.SortStableFunc(inv.Accounts, func(a, b Account) int {
+		return strings.Compare(a.AccountID, b.AccountID)
+	})
+}
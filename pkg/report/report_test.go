@@ -0,0 +1,226 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lamtypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+// fakeLambda lists functions in a single page, then fails with err if set.
+type fakeLambda struct {
+	functions map[string]lamtypes.Runtime
+	err       error
+}
+
+func (f fakeLambda) ListFunctions(ctx context.Context, in *lambda.ListFunctionsInput, _ ...func(*lambda.Options)) (*lambda.ListFunctionsOutput, error) {
+	if in.Marker != nil {
+		return nil, f.err
+	}
+	out := &lambda.ListFunctionsOutput{}
+	for name, rt := range f.functions {
+		out.Functions = append(out.Functions, lamtypes.FunctionConfiguration{FunctionName: aws.String(name), Runtime: rt})
+	}
+	if f.err != nil {
+		out.NextMarker = aws.String("next")
+	}
+	return out, nil
+}
+
+func (f fakeLambda) GetFunctionConfiguration(context.Context, *lambda.GetFunctionConfigurationInput, ...func(*lambda.Options)) (*lambda.GetFunctionConfigurationOutput, error) {
+	return nil, errors.New("unexpected GetFunctionConfiguration")
+}
+
+var now = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func TestScanRegion(t *testing.T) {
+	cli := fakeLambda{functions: map[string]lamtypes.Runtime{
+		"b": lamtypes.RuntimePython38,
+		"a": lamtypes.RuntimePython38,
+		"c": lamtypes.RuntimePython312,
+	}}
+	r, err := ScanRegion(context.Background(), cli, "eu-west-1", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Region != "eu-west-1" || len(r.Runtimes) != 2 {
+		t.Fatalf("got %+v", r)
+	}
+	old := r.Runtimes[1]
+	if old.Runtime != "python3.8" || old.Status != "deprecated" || strings.Join(old.Functions, ",") != "a,b" {
+		t.Errorf("python3.8 group = %+v, want a,b deprecated", old)
+	}
+}
+
+func TestScanRegionPartial(t *testing.T) {
+	cli := fakeLambda{functions: map[string]lamtypes.Runtime{"a": lamtypes.RuntimeNodejs20x}, err: errors.New("ThrottlingException")}
+	r, err := ScanRegion(context.Background(), cli, "us-east-1", now)
+	if err == nil || r.Error != "ThrottlingException" {
+		t.Errorf("err = %v, region error %q; want the listing error recorded", err, r.Error)
+	}
+	if len(r.Runtimes) != 1 {
+		t.Errorf("runtimes = %+v, want the functions listed before the error", r.Runtimes)
+	}
+}
+
+func sample() *Inventory {
+	inv := New([]string{"us-east-1"}, now)
+	inv.Add(Account{AccountID: "222222222222", Profile: "prod", Regions: []Region{{
+		Region:   "us-east-1",
+		Runtimes: []Runtime{{Runtime: "python3.8", Status: "deprecated", Functions: []string{"api", "worker"}}},
+	}}})
+	inv.Add(Account{Profile: "broken", Error: "no credentials"})
+	inv.Add(Account{AccountID: "111111111111", Profile: "dev", Regions: []Region{{
+		Region:   "us-east-1",
+		Runtimes: []Runtime{{Runtime: "python3.8", Status: "deprecated", Functions: []string{"api"}}},
+	}}})
+	inv.Sort()
+	return inv
+}
+
+func TestAddAndSort(t *testing.T) {
+	inv := sample()
+	if inv.Totals["python3.8"] != 3 {
+		t.Errorf("totals = %v, want 3 on python3.8", inv.Totals)
+	}
+	var order []string
+	for _, a := range inv.Accounts {
+		order = append(order, a.Profile)
+	}
+	if got := strings.Join(order, ","); got != "broken,dev,prod" {
+		t.Errorf("account order %s, want broken,dev,prod", got)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	inv := sample()
+	tests := []struct {
+		format string
+		check  func(string) error
+	}{
+		{CSV, func(s string) error {
+			lines := strings.Split(strings.TrimSpace(s), "\n")
+			if len(lines) != 4 || lines[1] != "111111111111,dev,us-east-1,python3.8,deprecated,api" {
+				return errors.New("want a header and three function rows")
+			}
+			return nil
+		}},
+		{JSONL, func(s string) error {
+			lines := strings.Split(strings.TrimSpace(s), "\n")
+			var row Row
+			if err := json.Unmarshal([]byte(lines[0]), &row); err != nil {
+				return err
+			}
+			if len(lines) != 3 || row.SnapshotTime != "2026-01-01 12:00:00" || row.FunctionName != "api" {
+				return errors.New("want three rows, the first dev's api")
+			}
+			return nil
+		}},
+		{JSON, func(s string) error {
+			var got Inventory
+			if err := json.Unmarshal([]byte(s), &got); err != nil {
+				return err
+			}
+			if len(got.Accounts) != 3 || got.Accounts[0].Error != "no credentials" {
+				return errors.New("want every account, failed ones included")
+			}
+			return nil
+		}},
+		{HTML, func(s string) error {
+			if !strings.Contains(s, `<tr class="deprecated"><td>python3.8</td>`) || !strings.Contains(s, "no credentials") {
+				return errors.New("want deprecated rows highlighted and account errors shown")
+			}
+			return nil
+		}},
+		{CycloneDX, func(s string) error {
+			var bom cdxBOM
+			if err := json.Unmarshal([]byte(s), &bom); err != nil {
+				return err
+			}
+			apps, deps := 0, make(map[string][]string)
+			for _, c := range bom.Components {
+				if c.Type == "application" {
+					apps++
+				}
+			}
+			for _, d := range bom.Dependencies {
+				deps[d.Ref] = d.DependsOn
+			}
+			i := slices.IndexFunc(bom.Components, func(c cdxComponent) bool { return c.BOMRef == runtimeRef("python3.8") })
+			if bom.BOMFormat != "CycloneDX" || apps != 3 || i < 0 || len(bom.Metadata.Properties) != 1 {
+				return errors.New("want a BOM of three functions, their runtimes and the failed account")
+			}
+			py38 := bom.Components[i]
+			if py38.Name != "python" || py38.Version != "3.8" || !slices.Contains(py38.Properties, cdxProperty{propDeprecation, "2024-10-14"}) {
+				return errors.New("want python3.8 as version 3.8 of python with its EOL date")
+			}
+			if got := deps["lambda:111111111111/us-east-1/api"]; !slices.Equal(got, []string{py38.BOMRef}) {
+				return errors.New("want api to depend on python3.8")
+			}
+			return nil
+		}},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := Write(&buf, inv, tt.format); err != nil {
+			t.Fatalf("%s: %v", tt.format, err)
+		}
+		if err := tt.check(buf.String()); err != nil {
+			t.Errorf("%s: %v\n%s", tt.format, err, buf.String())
+		}
+	}
+	if err := Write(&bytes.Buffer{}, inv, "xml"); err == nil {
+		t.Error("Write(xml): want an error")
+	}
+}
+
+func TestRead(t *testing.T) {
+	inv := sample()
+	for _, format := range []string{JSON, JSONL, CSV} {
+		var buf bytes.Buffer
+		if err := Write(&buf, inv, format); err != nil {
+			t.Fatal(err)
+		}
+		// JSON lines are recognised when read as JSON too.
+		readAs := []string{format}
+		if format == JSONL {
+			readAs = append(readAs, JSON)
+		}
+		for _, as := range readAs {
+			got, err := Read(bytes.NewReader(buf.Bytes()), as, now)
+			if err != nil {
+				t.Fatalf("%s as %s: %v", format, as, err)
+			}
+			rows := Rows(got)
+			if len(rows) != 3 || got.Totals["python3.8"] != 3 {
+				t.Fatalf("%s as %s: %d rows, totals %v; want all three functions back", format, as, len(rows), got.Totals)
+			}
+			if r := rows[0]; r.AccountID != "111111111111" || r.Profile != "dev" || r.FunctionName != "api" || r.Runtime != "python3.8" {
+				t.Errorf("%s as %s: first row %+v, want dev's api", format, as, r)
+			}
+		}
+	}
+
+	third := "Region,Function_Name,Runtime\nus-east-1,a,python3.8\n"
+	got, err := Read(strings.NewReader(third), CSV, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := got.Accounts; len(a) != 1 || a[0].AccountID != "" || a[0].Regions[0].Runtimes[0].Status != "deprecated" {
+		t.Errorf("third-party CSV read as %+v", a)
+	}
+	if !got.GeneratedAt.IsZero() {
+		t.Errorf("GeneratedAt = %v, want zero without snapshot times", got.GeneratedAt)
+	}
+	if _, err := Read(strings.NewReader("name,runtime\na,python3.8\n"), CSV, now); err == nil {
+		t.Error("CSV without a region column: want an error")
+	}
+}
@@ -0,0 +1,124 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"maps"
+	"slices"
+)
+
+// Document formats Write supports.
+const (
+	JSON  = "json"
+	JSONL = "jsonl"
+	CSV   = "csv"
+	HTML  = "html"
+	// CycloneDX is a CycloneDX 1.5 JSON BOM, for SBOM tooling.
+	CycloneDX = "cyclonedx"
+)
+
+// Formats lists every format Write supports.
+var Formats = []string{JSON, JSONL, CSV, HTML, CycloneDX}
+
+// Write writes inv to w in format.
+func Write(w io.Writer, inv *Inventory, format string) error {
+	switch format {
+	case JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(inv)
+	case JSONL:
+		return WriteJSONL(w, inv)
+	case CSV:
+		return WriteCSV(w, inv)
+	case HTML:
+		return htmlTemplate.Execute(w, inv)
+	case CycloneDX:
+		return WriteCycloneDX(w, inv)
+	}
+	return fmt.Errorf("unknown report format %q", format)
+}
+
+// Row is one function in a flat inventory export. Column names are
+// snake_case so Athena and Glue crawlers map them without renaming.
+type Row struct {
+	SnapshotTime string `json:"snapshot_time"`
+	AccountID    string `json:"account_id"`
+	Profile      string `json:"profile"`
+	Region       string `json:"region"`
+	FunctionName string `json:"function_name"`
+	Runtime      string `json:"runtime"`
+	Status       string `json:"status"`
+}
+
+// Rows flattens inv into one row per function, in document order.
+func Rows(inv *Inventory) []Row {
+	var rows []Row
+	snapshot := inv.GeneratedAt.Format("2006-01-02 15:04:05")
+	for _, a := range inv.Accounts {
+		for _, r := range a.Regions {
+			for _, rt := range r.Runtimes {
+				for _, fn := range rt.Functions {
+					rows = append(rows, Row{snapshot, a.AccountID, a.Profile, r.Region, fn, rt.Runtime, rt.Status})
+				}
+			}
+		}
+	}
+	return rows
+}
+
+// WriteJSONL writes inv as newline-delimited JSON, the layout Athena's
+// JSON SerDe reads.
+func WriteJSONL(w io.Writer, inv *Inventory) error {
+	enc := json.NewEncoder(w)
+	for _, row := range Rows(inv) {
+		if err := enc.Encode(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteCSV writes one row per function, in document order.
+func WriteCSV(w io.Writer, inv *Inventory) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"account_id", "profile", "region", "runtime", "status", "function_name"})
+	for _, row := range Rows(inv) {
+		cw.Write([]string{row.AccountID, row.Profile, row.Region, row.Runtime, row.Status, row.FunctionName})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+var htmlTemplate = template.Must(template.New("inventory").Funcs(template.FuncMap{
+	"sorted": func(m map[string]int) []string { return slices.Sorted(maps.Keys(m)) },
+}).Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Lambda runtime inventory</title>
+<style>
+body{font-family:sans-serif}
+table{border-collapse:collapse;margin-bottom:1em}
+th,td{border:1px solid #ccc;padding:4px 8px;text-align:left;vertical-align:top}
+.deprecated{background:#fdd}
+.deprecating{background:#ffd}
+.error{color:#a00}
+</style></head>
+<body>
+<h2>Lambda runtime inventory</h2>
+<p>Generated {{.GeneratedAt.Format "2006-01-02 15:04:05 MST"}} across regions {{range $i, $r := .Regions}}{{if $i}}, {{end}}{{$r}}{{end}}.</p>
+<table>
+<tr><th>Runtime</th><th>Functions</th></tr>
+{{range $rt := sorted .Totals}}<tr><td>{{$rt}}</td><td>{{index $.Totals $rt}}</td></tr>
+{{end}}</table>
+{{range .Accounts}}<h3>{{if .AccountID}}{{.AccountID}} ({{.Profile}}){{else}}{{.Profile}}{{end}}</h3>
+{{if .Error}}<p class="error">{{.Error}}</p>
+{{end}}{{range .Regions}}<h4>{{.Region}}</h4>
+{{if .Error}}<p class="error">{{.Error}}</p>
+{{end}}{{if .Runtimes}}<table>
+<tr><th>Runtime</th><th>Status</th><th>Count</th><th>Functions</th></tr>
+{{range .Runtimes}}<tr class="{{.Status}}"><td>{{.Runtime}}</td><td>{{.Status}}</td><td>{{len .Functions}}</td><td>{{range $i, $f := .Functions}}{{if $i}}, {{end}}{{$f}}{{end}}</td></tr>
+{{end}}</table>
+{{end}}{{end}}{{end}}</body></html>
+`))
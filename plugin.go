@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// Plugin hooks. A plugin is any executable named with --plugin. For every
+// call the tool runs it with one JSON request on stdin, carrying the hook
+// in "hook", and reads one JSON response from stdout; stderr is passed
+// through. At start-up the describe hook asks which of the others the
+// plugin handles.
+const (
+	hookDescribe = "describe"
+	hookFilter   = "filter" // may a matching function be bumped?
+	hookVerify   = "verify" // is a function healthy after its update?
+	hookNotify   = "notify" // the run report, once the run ends
+)
+
+var pluginHooks = []string{hookFilter, hookVerify, hookNotify}
+
+// pluginProtocol is sent with every request so plugins can reject a
+// protocol they do not understand.
+const pluginProtocol = 1
+
+// pluginTimeout bounds a single plugin call.
+const pluginTimeout = 30 * time.Second
+
+type pluginRequest struct {
+	Protocol int             `json:"protocol"`
+	Hook     string          `json:"hook"`
+	Function *functionResult `json:"function,omitempty"` // filter, verify
+	Run      *runReport      `json:"run,omitempty"`      // notify
+}
+
+// pluginResponse is the answer to any hook: describe fills Name and Hooks,
+// filter sets Allow and verify OK. Reason explains a refusal.
+type pluginResponse struct {
+	Name   string   `json:"name"`
+	Hooks  []string `json:"hooks"`
+	Allow  bool     `json:"allow"`
+	OK     bool     `json:"ok"`
+	Reason string   `json:"reason"`
+}
+
+type plugin struct {
+	path  string
+	name  string
+	hooks []string
+}
+
+// plugins are the loaded --plugin executables, consulted in flag order.
+type plugins []*plugin
+
+// loadPlugins describes every plugin in paths.
+func loadPlugins(ctx context.Context, paths []string) (plugins, error) {
+	var out plugins
+	for _, path := range paths {
+		p := &plugin{path: path, name: filepath.Base(path)}
+		resp, err := p.call(ctx, pluginRequest{Hook: hookDescribe})
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range resp.Hooks {
+			if !slices.Contains(pluginHooks, h) {
+				return nil, fmt.Errorf("plugin %s: unknown hook %q (want %v)", p.name, h, pluginHooks)
+			}
+		}
+		if resp.Name != "" {
+			p.name = resp.Name
+		}
+		p.hooks = resp.Hooks
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+func (p *plugin) call(ctx context.Context, req pluginRequest) (pluginResponse, error) {
+	req.Protocol = pluginProtocol
+	in, err := json.Marshal(req)
+	if err != nil {
+		return pluginResponse{}, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, pluginTimeout)
+	defer cancel()
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.path)
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stdout = &out
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return pluginResponse{}, fmt.Errorf("plugin %s %s: %w", p.name, req.Hook, err)
+	}
+	var resp pluginResponse
+	if err := json.Unmarshal(out.Bytes(), &resp); err != nil {
+		return pluginResponse{}, fmt.Errorf("plugin %s %s: bad response: %w", p.name, req.Hook, err)
+	}
+	return resp, nil
+}
+
+// filter asks every filter plugin whether r may be bumped. It returns why
+// not, prefixed with the plugin's name, or "" when all of them allow it.
+func (ps plugins) filter(ctx context.Context, r functionResult) (string, error) {
+	return ps.ask(ctx, hookFilter, r, func(resp pluginResponse) bool { return resp.Allow })
+}
+
+// verify asks every verify plugin whether r is healthy after its update,
+// returning why not as filter does.
+func (ps plugins) verify(ctx context.Context, r functionResult) (string, error) {
+	return ps.ask(ctx, hookVerify, r, func(resp pluginResponse) bool { return resp.OK })
+}
+
+func (ps plugins) ask(ctx context.Context, hook string, r functionResult, pass func(pluginResponse) bool) (string, error) {
+	for _, p := range ps {
+		if !slices.Contains(p.hooks, hook) {
+			continue
+		}
+		resp, err := p.call(ctx, pluginRequest{Hook: hook, Function: &r})
+		if err != nil {
+			return "", err
+		}
+		if !pass(resp) {
+			return fmt.Sprintf("%s: %s", p.name, resp.Reason), nil
+		}
+	}
+	return "", nil
+}
+
+// notifiers wraps the notify plugins for sendNotifications.
+func (ps plugins) notifiers() []notifier {
+	var out []notifier
+	for _, p := range ps {
+		if slices.Contains(p.hooks, hookNotify) {
+			out = append(out, pluginNotifier{p})
+		}
+	}
+	return out
+}
+
+type pluginNotifier struct{ p *plugin }
+
+func (n pluginNotifier) notify(ctx context.Context, rep *runReport) error {
+	_, err := n.p.call(ctx, pluginRequest{Hook: hookNotify, Run: rep})
+	return err
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writePlugin writes a shell plugin that answers each hook with the
+// matching response line.
+func writePlugin(t *testing.T, responses map[string]string) string {
+	t.Helper()
+	script := "#!/bin/sh\nreq=$(cat)\ncase \"$req\" in\n"
+	for hook, resp := range responses {
+		script += "*'\"hook\":\"" + hook + "\"'*) echo '" + resp + "' ;;\n"
+	}
+	script += "*) exit 3 ;;\nesac\n"
+	path := filepath.Join(t.TempDir(), "plugin")
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPluginFilterAndVerify(t *testing.T) {
+	deny := writePlugin(t, map[string]string{
+		hookDescribe: `{"name":"owners","hooks":["filter"]}`,
+		hookFilter:   `{"allow":false,"reason":"no owner tag"}`,
+	})
+	verifyOnly := writePlugin(t, map[string]string{
+		hookDescribe: `{"hooks":["verify"]}`,
+		hookVerify:   `{"ok":true}`,
+	})
+	ps, err := loadPlugins(context.Background(), []string{verifyOnly, deny})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := functionResult{Name: "api", Runtime: "python3.9", TargetRuntime: "python3.12"}
+	reason, err := ps.filter(context.Background(), r)
+	if err != nil || reason != "owners: no owner tag" {
+		t.Errorf("filter = %q, %v; want the owners plugin's refusal", reason, err)
+	}
+	reason, err = ps.verify(context.Background(), r)
+	if err != nil || reason != "" {
+		t.Errorf("verify = %q, %v; want a pass", reason, err)
+	}
+	if n := len(ps.notifiers()); n != 0 {
+		t.Errorf("%d notifiers from plugins without the notify hook", n)
+	}
+}
+
+func TestPluginErrors(t *testing.T) {
+	unknown := writePlugin(t, map[string]string{hookDescribe: `{"hooks":["approve"]}`})
+	if _, err := loadPlugins(context.Background(), []string{unknown}); err == nil || !strings.Contains(err.Error(), `unknown hook "approve"`) {
+		t.Errorf("err = %v, want the unknown hook named", err)
+	}
+
+	broken := writePlugin(t, map[string]string{
+		hookDescribe: `{"hooks":["filter"]}`,
+		hookFilter:   `not json`,
+	})
+	ps, err := loadPlugins(context.Background(), []string{broken})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ps.filter(context.Background(), functionResult{Name: "api"}); err == nil {
+		t.Error("filter: want an error for a malformed response")
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+
+	"update-lambda-runtime/pkg/bump"
+	"update-lambda-runtime/pkg/inventory"
+)
+
+// ssmScheme prefixes --policy values naming an SSM parameter.
+const ssmScheme = "ssm://"
+
+// loadPolicy returns the policy for opts. By default it is the single
+// --source-runtime → --target-runtime mapping; --policy replaces it with a
+// JSON document in the form bump.Policy describes, and the pairs of --map
+// are added to it, replacing the flag pair when there is no --policy.
+// --to-minimum-supported replaces the mappings with minimumSupportedMappings.
+// --layer-map entries are added to its layer swaps, and --set-env and
+// --unset-env to its environment changes.
+// An "ssm://<name>" --policy value is read from Parameter Store (SecureString
+// parameters are decrypted) in the profile's region, or the first of
+// --regions; anything else is a local file path.
+func loadPolicy(ctx context.Context, clients *clientFactory, opts *AWSOpts) (*bump.Policy, error) {
+	p := &bump.Policy{Mappings: map[string]string{opts.SourceRuntime: opts.TargetRuntime}}
+	if opts.PolicyFile != "" {
+		var err error
+		if p, err = readPolicy(ctx, clients, opts); err != nil {
+			return nil, err
+		}
+	}
+	if opts.MapFile != "" {
+		pairs, err := readMapFile(opts.MapFile)
+		if err != nil {
+			return nil, err
+		}
+		if opts.PolicyFile == "" {
+			p.Mappings = pairs
+		} else {
+			maps.Copy(p.Mappings, pairs)
+		}
+	}
+	if opts.ToMinimumSupported {
+		p.Mappings = minimumSupportedMappings(time.Now())
+	}
+	// Shorthands such as py312 or node20 become the identifiers Lambda
+	// reports.
+	mappings := make(map[string]string, len(p.Mappings))
+	for from, to := range p.Mappings {
+		from, to = inventory.Normalize(from), inventory.Normalize(to)
+		if _, ok := inventory.Entry(to); !ok && !strings.HasPrefix(to, inventory.LatestKeyword) {
+			fmt.Fprintf(os.Stderr, "warning: target runtime %s is not in the runtime calendar\n", to)
+		}
+		mappings[from] = to
+	}
+	p.Mappings = mappings
+	if p.Layers == nil {
+		p.Layers = make(map[string]string)
+	}
+	maps.Copy(p.Layers, opts.LayerMap)
+	if p.Env.Set == nil {
+		p.Env.Set = make(map[string]string)
+	}
+	for _, kv := range opts.SetEnv {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("--set-env %q: want KEY=VALUE", kv)
+		}
+		p.Env.Set[k] = v
+	}
+	p.Env.Unset = append(p.Env.Unset, opts.UnsetEnv...)
+	if p.Production == nil {
+		prod := bump.DefaultProduction
+		p.Production = &prod
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// minimumSupportedMappings maps every runtime of the calendar deprecated at
+// now to the oldest runtime of its family that is not, the smallest change
+// that leaves it.
+func minimumSupportedMappings(now time.Time) map[string]string {
+	out := make(map[string]string)
+	for _, p := range inventory.Calendar() {
+		if inventory.DeprecationStatus(p.Runtime, now) != inventory.Deprecated {
+			continue
+		}
+		if to, ok := inventory.MinimumSupported(p.Runtime, now); ok {
+			out[p.Runtime] = to
+		}
+	}
+	return out
+}
+
+// readPolicy reads and checks the --policy document.
+func readPolicy(ctx context.Context, clients *clientFactory, opts *AWSOpts) (*bump.Policy, error) {
+	var doc []byte
+	if name, ok := strings.CutPrefix(opts.PolicyFile, ssmScheme); ok {
+		value, err := readParameter(ctx, clients, name, opts.Regions)
+		if err != nil {
+			return nil, fmt.Errorf("--policy %s: %w", opts.PolicyFile, err)
+		}
+		doc = []byte(value)
+	} else {
+		var err error
+		if doc, err = os.ReadFile(opts.PolicyFile); err != nil {
+			return nil, fmt.Errorf("--policy: %w", err)
+		}
+	}
+	var p bump.Policy
+	if _, err := decodeDocument("policy", doc, &p); err != nil {
+		return nil, fmt.Errorf("--policy %s: %w", opts.PolicyFile, err)
+	}
+	return &p, nil
+}
+
+// readMapFile reads a --map file: source → target runtime pairs as YAML
+// or JSON, e.g.
+//
+//	python3.8: python3.12
+//	nodejs16.x: nodejs20.x
+func readMapFile(name string) (map[string]string, error) {
+	doc, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("--map: %w", err)
+	}
+	var pairs map[string]string
+	if _, err := decodeDocument("map", doc, &pairs); err != nil {
+		return nil, fmt.Errorf("--map %s: %w", name, err)
+	}
+	return pairs, nil
+}
+
+func readParameter(ctx context.Context, clients *clientFactory, name string, regions []string) (string, error) {
+	cfg, err := clients.Config(ctx)
+	if err != nil {
+		return "", err
+	}
+	if cfg.Region == "" && len(regions) > 0 {
+		cfg.Region = regions[0]
+	}
+	out, err := ssm.NewFromConfig(cfg).GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return "", err
+	}
+	if out.Parameter == nil {
+		return "", fmt.Errorf("parameter %s has no value", name)
+	}
+	return aws.ToString(out.Parameter.Value), nil
+}
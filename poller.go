@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"update-lambda-runtime/pkg/bump"
+)
+
+// Wait strategies, as named by --wait-strategy.
+const (
+	waitWaiter = "waiter" // the SDK's FunctionUpdatedV2 waiter per update
+	waitPoll   = "poll"   // updatePoller
+)
+
+// updateWaiter waits on the updates of a run. run serves track until ctx
+// is cancelled.
+type updateWaiter interface {
+	track(p *pendingUpdate) <-chan bump.Outcome
+	run(ctx context.Context)
+}
+
+// newUpdateWaiter returns the waiter --wait-strategy names.
+func newUpdateWaiter(opts *AWSOpts) updateWaiter {
+	if opts.WaitStrategy == waitPoll {
+		return newUpdatePoller(opts.PollEvery)
+	}
+	return newSDKWaiter(opts.PollEvery)
+}
+
+// updatePoller is the single scheduler that waits on every pending update.
+// Each interval it polls all of them from one loop, with the requests paced
+// by the clients' rate limiter, instead of every waiter sleeping and polling
+// on its own.
+type updatePoller struct {
+	interval time.Duration
+	add      chan tracked
+	stopped  chan struct{}
+}
+
+type tracked struct {
+	p    *pendingUpdate
+	done chan bump.Outcome
+}
+
+func (t tracked) finish(o bump.Outcome) {
+	endSpan(t.p.span, o)
+	t.done <- o
+}
+
+func newUpdatePoller(interval time.Duration) *updatePoller {
+	return &updatePoller{
+		interval: interval,
+		add:      make(chan tracked),
+		stopped:  make(chan struct{}),
+	}
+}
+
+// track hands p to the scheduler. The returned channel receives p's outcome
+// once it settles, or bump.Interrupted if the run is cancelled first.
+func (u *updatePoller) track(p *pendingUpdate) <-chan bump.Outcome {
+	t := tracked{p: p, done: make(chan bump.Outcome, 1)}
+	select {
+	case u.add <- t:
+	case <-u.stopped:
+		p.log.progressf("Stopped waiting for %s; the update continues in AWS\n", p.Function)
+		t.finish(bump.Interrupted)
+	}
+	return t.done
+}
+
+// run schedules polls until ctx is cancelled. Waits still pending at that
+// point are abandoned; those updates were accepted by Lambda and finish on
+// their own.
+func (u *updatePoller) run(ctx context.Context) {
+	defer close(u.stopped)
+	var pending []tracked
+	tick := time.NewTicker(u.interval)
+	defer tick.Stop()
+	for {
+		select {
+		case t := <-u.add:
+			if o, ok := t.p.settled(); ok {
+				t.finish(o)
+				continue
+			}
+			pending = append(pending, t)
+		case <-tick.C:
+			remaining := pending[:0]
+			for _, t := range pending {
+				if ctx.Err() != nil {
+					remaining = append(remaining, t)
+					continue
+				}
+				t.p.refresh(ctx)
+				if o, ok := t.p.settled(); ok {
+					t.finish(o)
+					continue
+				}
+				remaining = append(remaining, t)
+			}
+			pending = remaining
+		case <-ctx.Done():
+			for _, t := range pending {
+				t.p.log.progressf("Stopped waiting for %s; the update continues in AWS\n", t.p.Function)
+				t.finish(bump.Interrupted)
+			}
+			return
+		}
+	}
+}
+
+// sdkWaiter waits on each update with the SDK's FunctionUpdatedV2 waiter,
+// which backs off between polls from interval, so long updates cost fewer
+// calls than polling at a fixed interval.
+type sdkWaiter struct {
+	interval time.Duration
+	add      chan tracked
+	stopped  chan struct{}
+}
+
+func newSDKWaiter(interval time.Duration) *sdkWaiter {
+	return &sdkWaiter{
+		interval: interval,
+		add:      make(chan tracked),
+		stopped:  make(chan struct{}),
+	}
+}
+
+func (w *sdkWaiter) track(p *pendingUpdate) <-chan bump.Outcome {
+	t := tracked{p: p, done: make(chan bump.Outcome, 1)}
+	select {
+	case w.add <- t:
+	case <-w.stopped:
+		p.log.progressf("Stopped waiting for %s; the update continues in AWS\n", p.Function)
+		t.finish(bump.Interrupted)
+	}
+	return t.done
+}
+
+// run starts a waiter for every tracked update until ctx is cancelled,
+// which interrupts the waiters still going.
+func (w *sdkWaiter) run(ctx context.Context) {
+	var wg sync.WaitGroup
+	defer func() {
+		close(w.stopped)
+		wg.Wait()
+	}()
+	for {
+		select {
+		case t := <-w.add:
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				t.finish(t.p.await(ctx, w.interval))
+			}()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lamtypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+
+	"update-lambda-runtime/pkg/inventory"
+)
+
+// Pre-flight checks, as named by --preflight-checks.
+const (
+	checkRuntime = "runtime" // the target runtime can still be moved to
+	checkLayers  = "layers"  // every layer declares the target runtime
+	checkPackage = "package" // the function is a zip package
+	checkState   = "state"   // the function is active and not mid-update
+	checkIaC     = "iac"     // no CloudFormation stack would revert the change
+)
+
+// preflightChecks lists every check, in the order findings are reported.
+var preflightChecks = []string{checkRuntime, checkLayers, checkPackage, checkState, checkIaC}
+
+// cfnStackNameTag names the CloudFormation stack that created a function.
+const cfnStackNameTag = "aws:cloudformation:stack-name"
+
+// errValidation is returned by bump when pre-flight validation found
+// problems, so nothing was changed.
+var errValidation = errors.New("pre-flight validation failed")
+
+// preflightFinding is a problem pre-flight validation found with a function
+// about to be bumped.
+type preflightFinding struct {
+	Region   string `json:"region"`
+	Function string `json:"functionName"`
+	Check    string `json:"check"`
+	Message  string `json:"message"`
+}
+
+// preflight runs checks against every job before any is started, looking
+// functions up listLookups at a time, and returns the findings in job
+// order. A function that cannot be looked up is a finding itself.
+func preflight(ctx context.Context, jobs []bumpJob, checks []string) []preflightFinding {
+	found := make([][]preflightFinding, len(jobs))
+	now := time.Now()
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, listLookups)
+	for i, j := range jobs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			found[i] = checkJob(ctx, j, checks, now)
+		}()
+	}
+	wg.Wait()
+	return slices.Concat(found...)
+}
+
+// checkJob runs checks against j's function.
+func checkJob(ctx context.Context, j bumpJob, checks []string, now time.Time) []preflightFinding {
+	r := j.result
+	finding := func(check, format string, args ...any) preflightFinding {
+		return preflightFinding{Region: r.Region, Function: r.Name, Check: check, Message: fmt.Sprintf(format, args...)}
+	}
+	f, err := inventory.Describe(ctx, j.cli, r.Name)
+	if err != nil {
+		return []preflightFinding{finding("lookup", "%v", err)}
+	}
+	var tags map[string]string
+	if slices.Contains(checks, checkIaC) {
+		out, err := j.cli.ListTags(ctx, &lambda.ListTagsInput{Resource: aws.String(functionARN(r))})
+		if err != nil {
+			return []preflightFinding{finding(checkIaC, "cannot read tags: %v", err)}
+		}
+		tags = out.Tags
+	}
+	var findings []preflightFinding
+	for _, c := range configFindings(r.TargetRuntime, f, tags, checks, now) {
+		findings = append(findings, finding(c.check, "%s", c.message))
+	}
+	if slices.Contains(checks, checkLayers) {
+		layers := f.Layers
+		if j.layers != nil {
+			layers = j.layers
+		}
+		lf, err := layerFindings(ctx, j.cli, layers, r.TargetRuntime, f.Architecture)
+		if err != nil {
+			findings = append(findings, finding(checkLayers, "%v", err))
+		}
+		for _, l := range lf {
+			findings = append(findings, finding(checkLayers, "%s %s", l.File, l.Message))
+		}
+	}
+	return findings
+}
+
+// configFinding is a check's finding before it is tied to a function.
+type configFinding struct {
+	check, message string
+}
+
+// configFindings runs the checks that need only the function's
+// configuration f and tags against a move to target.
+func configFindings(target string, f inventory.Function, tags map[string]string, checks []string, now time.Time) []configFinding {
+	var out []configFinding
+	if slices.Contains(checks, checkRuntime) {
+		if p, ok := inventory.Entry(target); ok {
+			switch phase := runtimePhase(p, now); phase {
+			case runtimeUpdateBlocked:
+				out = append(out, configFinding{checkRuntime, fmt.Sprintf("Lambda no longer lets functions move to %s", target)})
+			case runtimeCreateBlocked, inventory.Deprecated:
+				out = append(out, configFinding{checkRuntime, fmt.Sprintf("%s is deprecated; pick a supported target", target)})
+			}
+		}
+	}
+	if slices.Contains(checks, checkPackage) && f.PackageType == string(lamtypes.PackageTypeImage) {
+		out = append(out, configFinding{checkPackage, "a container image: its runtime comes with the image, so rebuild it"})
+	}
+	if slices.Contains(checks, checkState) {
+		switch {
+		case f.State != "" && f.State != string(lamtypes.StateActive) && f.State != string(lamtypes.StateInactive):
+			out = append(out, configFinding{checkState, fmt.Sprintf("state is %s", f.State)})
+		case f.LastUpdateStatus == string(lamtypes.LastUpdateStatusInProgress):
+			out = append(out, configFinding{checkState, "another update is in progress"})
+		}
+	}
+	if id, ok := tags[cfnLogicalIDTag]; ok && slices.Contains(checks, checkIaC) {
+		stack := "a CloudFormation stack"
+		if name := tags[cfnStackNameTag]; name != "" {
+			stack = "CloudFormation stack " + name
+		}
+		out = append(out, configFinding{checkIaC, fmt.Sprintf("resource %s of %s, whose next deployment puts the old runtime back; change the template too", id, stack)})
+	}
+	return out
+}
+
+// writePreflight prints what preflight found in checked functions.
+func writePreflight(w io.Writer, checked int, findings []preflightFinding) {
+	if len(findings) == 0 {
+		fmt.Fprintf(w, "Pre-flight: %d functions passed every check\n", checked)
+		return
+	}
+	fmt.Fprintf(w, "Pre-flight: %d findings in %d functions checked; nothing was changed (--skip-validation to apply anyway):\n", len(findings), checked)
+	for _, f := range findings {
+		fmt.Fprintf(w, "  %s/%s  %s: %s\n", f.Region, f.Function, f.Check, f.Message)
+	}
+}
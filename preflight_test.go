@@ -0,0 +1,41 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"update-lambda-runtime/pkg/inventory"
+)
+
+func TestConfigFindings(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	active := inventory.Function{PackageType: "Zip", State: "Active", LastUpdateStatus: "Successful"}
+	tests := []struct {
+		name   string
+		target string
+		f      inventory.Function
+		tags   map[string]string
+		want   []string // checks with findings
+	}{
+		{"clean", "python3.12", active, map[string]string{"team": "platform"}, nil},
+		{"deprecated target", "python3.7", active, nil, []string{checkRuntime}},
+		{"image", "python3.12", inventory.Function{PackageType: "Image", State: "Active"}, nil, []string{checkPackage}},
+		{"pending", "python3.12", inventory.Function{PackageType: "Zip", State: "Pending"}, nil, []string{checkState}},
+		{"updating", "python3.12", inventory.Function{PackageType: "Zip", State: "Active", LastUpdateStatus: "InProgress"}, nil, []string{checkState}},
+		{"inactive", "python3.12", inventory.Function{PackageType: "Zip", State: "Inactive"}, nil, nil},
+		{"cloudformation", "python3.12", active, map[string]string{cfnLogicalIDTag: "ApiFn", cfnStackNameTag: "app"}, []string{checkIaC}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, f := range configFindings(tt.target, tt.f, tt.tags, preflightChecks, now) {
+			got = append(got, f.check)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: findings from %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if got := configFindings("python3.7", active, nil, []string{checkState}, now); len(got) != 0 {
+		t.Errorf("runtime checked without being asked for: %v", got)
+	}
+}
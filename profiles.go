@@ -0,0 +1,170 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/ssocreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// Results of checking a profile.
+const (
+	profileOK         = "ok"
+	profileSSOExpired = "SSO session expired"
+	profileSSOLogin   = "not logged in to SSO"
+	profileError      = "error"
+)
+
+// profileCheck is what profiles found out about one profile: how it gets
+// credentials, and whether they resolve and to whom.
+type profileCheck struct {
+	kind, region   string
+	account, arn   string
+	result, detail string
+	ssoExpires     time.Time // when the cached SSO token expires
+	ssoCached      bool      // an SSO token is cached at all
+}
+
+// runProfiles checks every profile sweptProfiles picks: that it resolves
+// credentials, to which account and principal, and for SSO profiles
+// whether the session has expired. It fails when any profile does not
+// resolve, so a multi-account run can be gated on it.
+func runProfiles(ctx context.Context, opts *AWSOpts, w io.Writer) error {
+	profiles, err := sweptProfiles(opts)
+	if err != nil {
+		return err
+	}
+	configFile, credentialsFile, err := sharedConfigFiles()
+	if err != nil {
+		return err
+	}
+	found := make([]profileCheck, len(profiles))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, listLookups)
+	for i, profile := range profiles {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			found[i] = checkProfile(ctx, opts, profile, configFile, credentialsFile)
+		}()
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return stopped(ctx)
+	}
+
+	profileWidth, arnWidth := len("Profile"), len("Principal")
+	for i, p := range profiles {
+		profileWidth, arnWidth = max(profileWidth, len(p)), max(arnWidth, len(found[i].arn))
+	}
+	tbl := newTable(w, profileWidth, len("credential-process"), len("ap-southeast-1"), len("123456789012"), arnWidth)
+	tbl.header("Profile", "Type", "Region", "AccountID", "Principal", "Result")
+	counts := make(map[string]int)
+	for i, p := range profiles {
+		c := found[i]
+		counts[c.result]++
+		result := c.result
+		if c.detail != "" {
+			result += ": " + c.detail
+		}
+		tbl.row(p, c.kind, cmp.Or(c.region, "-"), cmp.Or(c.account, "-"), cmp.Or(c.arn, "-"), result)
+	}
+	fmt.Fprintf(w, "\nSummary: %d ok, %d SSO expired or not logged in, %d failed\n",
+		counts[profileOK], counts[profileSSOExpired]+counts[profileSSOLogin], counts[profileError])
+	if bad := len(profiles) - counts[profileOK]; bad > 0 {
+		return fmt.Errorf("%d of %d profiles do not resolve credentials", bad, len(profiles))
+	}
+	return nil
+}
+
+// checkProfile reads profile's settings and asks STS who its credentials
+// belong to.
+func checkProfile(ctx context.Context, opts *AWSOpts, profile, configFile, credentialsFile string) profileCheck {
+	sc, err := config.LoadSharedConfigProfile(ctx, profile, func(o *config.LoadSharedConfigOptions) {
+		o.ConfigFiles = []string{configFile}
+		o.CredentialsFiles = []string{credentialsFile}
+	})
+	if err != nil {
+		return profileCheck{kind: "-", result: profileError, detail: err.Error()}
+	}
+	c := profileCheck{kind: credentialKind(sc), region: sc.Region}
+	ssoKey := sc.SSOStartURL
+	if sc.SSOSessionName != "" {
+		ssoKey = sc.SSOSessionName
+	}
+	if ssoKey != "" {
+		c.ssoExpires, c.ssoCached = cachedSSOExpiry(ssoKey)
+	}
+
+	cli, err := newClientFactory(profile, opts.APITimeout, opts.MaxRPS).STS(ctx)
+	var out *sts.GetCallerIdentityOutput
+	if err == nil {
+		out, err = cli.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	}
+	switch {
+	case err == nil:
+		c.result, c.account, c.arn = profileOK, aws.ToString(out.Account), aws.ToString(out.Arn)
+		// The SDK refreshes an expired token when it can, so the file is
+		// read again for the expiry now in force.
+		if ssoKey != "" {
+			if t, ok := cachedSSOExpiry(ssoKey); ok {
+				c.detail = "SSO session until " + inZone(t, time.Local).Format(time.DateTime)
+			}
+		}
+	case ssoKey != "" && !c.ssoCached:
+		c.result, c.detail = profileSSOLogin, "aws sso login --profile "+profile
+	case ssoKey != "" && time.Now().After(c.ssoExpires):
+		c.result = profileSSOExpired
+		c.detail = fmt.Sprintf("at %s; aws sso login --profile %s", inZone(c.ssoExpires, time.Local).Format(time.DateTime), profile)
+	default:
+		c.result, c.detail = profileError, err.Error()
+	}
+	return c
+}
+
+// credentialKind names how a profile gets its credentials.
+func credentialKind(sc config.SharedConfig) string {
+	switch {
+	case sc.SSOSessionName != "" || sc.SSOStartURL != "":
+		return "sso"
+	case sc.RoleARN != "" && sc.WebIdentityTokenFile != "":
+		return "web-identity"
+	case sc.RoleARN != "":
+		return "assume-role"
+	case sc.CredentialProcess != "":
+		return "credential-process"
+	case sc.Credentials.HasKeys():
+		return "static"
+	}
+	return "default-chain"
+}
+
+// cachedSSOExpiry reads when the SSO token cached for key, an sso-session
+// name or legacy start URL, expires, as aws sso login wrote it.
+func cachedSSOExpiry(key string) (time.Time, bool) {
+	path, err := ssocreds.StandardCachedTokenFilepath(key)
+	if err != nil {
+		return time.Time{}, false
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return time.Time{}, false
+	}
+	var token struct {
+		ExpiresAt time.Time `json:"expiresAt"`
+	}
+	if json.Unmarshal(b, &token) != nil || token.ExpiresAt.IsZero() {
+		return time.Time{}, false
+	}
+	return token.ExpiresAt, true
+}
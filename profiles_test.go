@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCheckProfileSSO(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configFile := filepath.Join(home, "config")
+	err := os.WriteFile(configFile, []byte(`[profile expired]
+sso_session = corp
+sso_account_id = 210987654321
+sso_role_name = Admin
+region = eu-west-1
+
+[profile never]
+sso_session = other
+sso_account_id = 210987654321
+sso_role_name = Admin
+
+[sso-session corp]
+sso_start_url = https://corp.awsapps.com/start
+sso_region = us-east-1
+
+[sso-session other]
+sso_start_url = https://other.awsapps.com/start
+sso_region = us-east-1
+`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha1.Sum([]byte("corp"))
+	cache := filepath.Join(home, ".aws", "sso", "cache")
+	if err := os.MkdirAll(cache, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	expired := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	token := `{"accessToken":"x","expiresAt":"` + expired.Format(time.RFC3339) + `"}`
+	if err := os.WriteFile(filepath.Join(cache, hex.EncodeToString(sum[:])+".json"), []byte(token), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AWS_CONFIG_FILE", configFile)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(home, "credentials"))
+
+	opts := &AWSOpts{APITimeout: 5 * time.Second}
+	c := checkProfile(context.Background(), opts, "expired", configFile, filepath.Join(home, "credentials"))
+	if c.kind != "sso" || c.region != "eu-west-1" || c.result != profileSSOExpired || !c.ssoExpires.Equal(expired) {
+		t.Errorf("expired profile: %+v", c)
+	}
+	c = checkProfile(context.Background(), opts, "never", configFile, filepath.Join(home, "credentials"))
+	if c.result != profileSSOLogin {
+		t.Errorf("profile never logged in: %+v", c)
+	}
+}
@@ -0,0 +1,417 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudfront"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"go.opentelemetry.io/otel/trace"
+
+	"update-lambda-runtime/pkg/bump"
+)
+
+// workItem is one function's update, sent by a bump run with --queue-url
+// for a worker to carry out. It carries the layer, environment and
+// description changes as rules, null when there are none, never the
+// function's variables: the worker reads the configuration when it makes
+// the update and makes the rules on top of it, at that revision.
+type workItem struct {
+	RunID         string         `json:"runId"`
+	ResultsQueue  string         `json:"resultsQueueUrl"`
+	Function      functionResult `json:"function"`
+	Rules         *bump.Rules    `json:"rules,omitempty"`
+	Handler       string         `json:"handler,omitempty"`
+	Distributions []string       `json:"distributions,omitempty"`
+	Force         bool           `json:"force,omitempty"`
+	WaitTimeout   time.Duration  `json:"waitTimeout,omitempty"` // 0: the worker's --wait-timeout
+}
+
+// workResult is a worker's report of one work item: the function with its
+// outcome, and the progress lines the update wrote.
+type workResult struct {
+	RunID    string         `json:"runId"`
+	Function functionResult `json:"function"`
+	Progress string         `json:"progress,omitempty"`
+}
+
+// queueRegion is the region in an SQS queue URL
+// (https://sqs.us-east-1.amazonaws.com/123456789012/name), or "" when the
+// URL does not name one.
+func queueRegion(queueURL string) string {
+	u, err := url.Parse(queueURL)
+	if err != nil {
+		return ""
+	}
+	parts := strings.Split(u.Hostname(), ".")
+	switch {
+	case len(parts) > 2 && parts[0] == "sqs":
+		return parts[1]
+	case len(parts) > 2 && parts[1] == "queue":
+		return parts[0]
+	}
+	return ""
+}
+
+// sqsClient returns a client for the region of queueURL.
+func sqsClient(ctx context.Context, clients *clientFactory, queueURL string) (*sqs.Client, error) {
+	cfg, err := clients.Config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return sqs.NewFromConfig(cfg, func(o *sqs.Options) {
+		if r := queueRegion(queueURL); r != "" {
+			o.Region = r
+		} else if o.Region == "" {
+			o.Region = stsRegion
+		}
+	}), nil
+}
+
+// workQueue is the coordinator side of a bump run with --queue-url: it
+// sends every function to the work queue instead of updating it, then
+// collects the outcomes workers report on the results queue.
+type workQueue struct {
+	cli        *sqs.Client
+	url        string
+	resultsURL string
+	runID      string
+	force      bool
+
+	mu         sync.Mutex
+	dispatched map[string]dispatchedItem
+}
+
+type dispatchedItem struct {
+	span trace.Span
+	r    functionResult
+}
+
+func newWorkQueue(ctx context.Context, clients *clientFactory, opts *AWSOpts, runID string) (*workQueue, error) {
+	cli, err := sqsClient(ctx, clients, opts.QueueURL)
+	if err != nil {
+		return nil, fmt.Errorf("work queue: %w", err)
+	}
+	return &workQueue{
+		cli:        cli,
+		url:        opts.QueueURL,
+		resultsURL: opts.ResultsQueueURL,
+		runID:      runID,
+		force:      opts.Force,
+		dispatched: make(map[string]dispatchedItem),
+	}, nil
+}
+
+func itemKey(r functionResult) string {
+	return r.Region + "/" + r.Name
+}
+
+// dispatch sends j to the work queue; span stays open until its outcome is
+// collected.
+func (q *workQueue) dispatch(ctx context.Context, log *resultCollector, j bumpJob, span trace.Span) error {
+	body, err := json.Marshal(q.item(j))
+	if err != nil {
+		return err
+	}
+	q.mu.Lock()
+	q.dispatched[itemKey(j.result)] = dispatchedItem{span, j.result}
+	q.mu.Unlock()
+	if _, err := q.cli.SendMessage(ctx, &sqs.SendMessageInput{QueueUrl: aws.String(q.url), MessageBody: aws.String(string(body))}); err != nil {
+		q.take(itemKey(j.result))
+		return fmt.Errorf("work queue: %w", err)
+	}
+	log.progressf("Queued %s for %s\n", j.result.Name, j.result.TargetRuntime)
+	return nil
+}
+
+// item is the work item of j.
+func (q *workQueue) item(j bumpJob) workItem {
+	return workItem{
+		RunID:         q.runID,
+		ResultsQueue:  q.resultsURL,
+		Function:      j.result,
+		Rules:         j.rules,
+		Handler:       j.handler,
+		Distributions: j.distributions,
+		Force:         q.force,
+		WaitTimeout:   j.timeout,
+	}
+}
+
+func (q *workQueue) take(key string) (dispatchedItem, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	d, ok := q.dispatched[key]
+	delete(q.dispatched, key)
+	return d, ok
+}
+
+func (q *workQueue) outstanding() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.dispatched)
+}
+
+// collect receives workers' results until every dispatched function has
+// one, handing each to finish. Results of other runs are left for them.
+// When ctx ends first, the functions still out are finished as
+// interrupted: workers may yet update them.
+func (q *workQueue) collect(ctx context.Context, log *resultCollector, pollEvery time.Duration, finish func(trace.Span, functionResult, bump.Outcome)) {
+	if n := q.outstanding(); n > 0 {
+		log.progressf("Waiting for workers to report %d functions...\n", n)
+	}
+	for q.outstanding() > 0 && ctx.Err() == nil {
+		out, err := q.cli.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(q.resultsURL),
+			MaxNumberOfMessages: 10,
+			WaitTimeSeconds:     20,
+		})
+		if err != nil {
+			if ctx.Err() == nil {
+				log.progressf("  results queue error: %v\n", err)
+				sleepCtx(ctx, pollEvery)
+			}
+			continue
+		}
+		for _, m := range out.Messages {
+			var res workResult
+			if err := json.Unmarshal([]byte(aws.ToString(m.Body)), &res); err != nil || res.RunID != q.runID {
+				continue
+			}
+			// Deleted even when the function was already reported:
+			// SQS delivers at least once.
+			if _, err := q.cli.DeleteMessage(context.WithoutCancel(ctx), &sqs.DeleteMessageInput{QueueUrl: aws.String(q.resultsURL), ReceiptHandle: m.ReceiptHandle}); err != nil {
+				log.progressf("  warning: results queue: %v\n", err)
+			}
+			d, ok := q.take(itemKey(res.Function))
+			if !ok {
+				continue
+			}
+			log.progressf("%s", res.Progress)
+			r := res.Function
+			r.Profile = d.r.Profile
+			finish(d.span, r, res.Function.Outcome)
+		}
+	}
+	q.mu.Lock()
+	left := q.dispatched
+	q.dispatched = nil
+	q.mu.Unlock()
+	for _, d := range left {
+		log.progressf("Stopped waiting for %s; a worker may still update it\n", d.r.Name)
+		finish(d.span, d.r, bump.Interrupted)
+	}
+}
+
+// sleepCtx waits for d or until ctx ends.
+func sleepCtx(ctx context.Context, d time.Duration) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+	case <-t.C:
+	}
+}
+
+// queueWorker carries out work items: the update a bump run would have
+// made, in the worker's account, with the worker's wait settings.
+type queueWorker struct {
+	opts      *AWSOpts
+	clients   *clientFactory
+	accountID string
+	queue     *sqs.Client
+	cf        *cloudfront.Client
+	poller    updateWaiter
+}
+
+// runWorker serves the work queue of bump runs started with --queue-url:
+// it long-polls opts.QueueURL, updating up to --concurrency functions at a
+// time and reporting each to the run's results queue. It runs until ctx
+// ends or, with --exit-when-empty, the queue is empty. Run inside Lambda
+// with an SQS trigger, it handles the invocation's batch instead.
+func runWorker(ctx context.Context, opts *AWSOpts) error {
+	batch := lambdaBatchFrom(ctx)
+	if opts.Profile == "" || (opts.QueueURL == "" && batch == nil) {
+		return fmt.Errorf("--profile and --queue-url are required")
+	}
+	clients := newClientFactory(opts.Profile, opts.APITimeout, opts.MaxRPS)
+	acctID, err := resolveAccountID(ctx, clients)
+	if err != nil {
+		return fmt.Errorf("resolve account id: %w", err)
+	}
+	cfg, err := clients.Config(ctx)
+	if err != nil {
+		return err
+	}
+	w := &queueWorker{
+		opts:      opts,
+		clients:   clients,
+		accountID: acctID,
+		cf:        cloudfront.NewFromConfig(cfg, func(o *cloudfront.Options) { o.Region = edgeRegion }),
+		poller:    newUpdateWaiter(opts),
+	}
+	// Each item names its results queue, whose region is set per call.
+	if w.queue, err = sqsClient(ctx, clients, opts.QueueURL); err != nil {
+		return err
+	}
+	waitCtx, stopPolling := waitContext(ctx)
+	defer stopPolling()
+	go w.poller.run(waitCtx)
+
+	sem := make(chan struct{}, max(opts.Concurrency, 1))
+	var wg sync.WaitGroup
+	if batch != nil {
+		var mu sync.Mutex
+		for _, rec := range batch.records {
+			sem <- struct{}{}
+			wg.Add(1)
+			go func() {
+				defer func() { <-sem; wg.Done() }()
+				if !w.handle(ctx, rec.Body) {
+					mu.Lock()
+					batch.failures = append(batch.failures, events.SQSBatchItemFailure{ItemIdentifier: rec.MessageId})
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "Working the queue %s as account %s\n", opts.QueueURL, acctID)
+	for ctx.Err() == nil {
+		out, err := w.queue.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(opts.QueueURL),
+			MaxNumberOfMessages: int32(min(max(opts.Concurrency, 1), 10)),
+			WaitTimeSeconds:     20,
+		})
+		if err != nil {
+			if ctx.Err() == nil {
+				fmt.Fprintln(os.Stderr, "warning: work queue:", err)
+				sleepCtx(ctx, opts.PollEvery)
+			}
+			continue
+		}
+		if len(out.Messages) == 0 && opts.ExitWhenEmpty {
+			break
+		}
+		for _, m := range out.Messages {
+			sem <- struct{}{}
+			wg.Add(1)
+			go func() {
+				defer func() { <-sem; wg.Done() }()
+				if w.handle(ctx, aws.ToString(m.Body)) {
+					if _, err := w.queue.DeleteMessage(context.WithoutCancel(ctx), &sqs.DeleteMessageInput{QueueUrl: aws.String(opts.QueueURL), ReceiptHandle: m.ReceiptHandle}); err != nil {
+						fmt.Fprintln(os.Stderr, "warning: work queue:", err)
+					}
+				}
+			}()
+		}
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return stopped(ctx)
+	}
+	return nil
+}
+
+// handle carries out one work item and reports it, returning whether the
+// message is done with. An item whose result could not be sent is kept,
+// so it is delivered again once its visibility timeout lapses.
+func (w *queueWorker) handle(ctx context.Context, body string) bool {
+	var item workItem
+	if err := json.Unmarshal([]byte(body), &item); err != nil || item.ResultsQueue == "" {
+		fmt.Fprintf(os.Stderr, "warning: dropping a malformed work item: %.200s\n", body)
+		return true
+	}
+	var progress bytes.Buffer
+	log := newResultCollector(&progress)
+	r := item.Function
+	r.Outcome, r.Failure, r.Detail, r.Attempts = w.update(ctx, log, item)
+	// Output of concurrent items is not interleaved.
+	os.Stdout.Write(progress.Bytes())
+
+	res, err := json.Marshal(workResult{RunID: item.RunID, Function: r, Progress: progress.String()})
+	if err == nil {
+		_, err = w.queue.SendMessage(context.WithoutCancel(ctx), &sqs.SendMessageInput{
+			QueueUrl:    aws.String(item.ResultsQueue),
+			MessageBody: aws.String(string(res)),
+		}, func(o *sqs.Options) {
+			if region := queueRegion(item.ResultsQueue); region != "" {
+				o.Region = region
+			}
+		})
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: reporting %s to run %s: %v\n", r.Name, item.RunID, err)
+		return false
+	}
+	return true
+}
+
+// update makes item's update as bump would: the package checks unless the
+// run was forced, the update, the wait and any Lambda@Edge republishing.
+// It returns the outcome, its failure class, the code blocker that held it
+// back or the distributions left half associated if any, and the number of
+// update calls made.
+func (w *queueWorker) update(ctx context.Context, log *resultCollector, item workItem) (bump.Outcome, bump.FailureClass, string, int) {
+	r := item.Function
+	if r.AccountID != w.accountID {
+		log.progressf("  %s is in account %s, but this worker runs as %s\n", r.Name, r.AccountID, w.accountID)
+		return bump.Failed, bump.OtherFailure, "", 0
+	}
+	cli, err := w.clients.Lambda(ctx, r.Region)
+	if err != nil {
+		log.progressf("  update error for %s: %v\n", r.Name, err)
+		return bump.Failed, bump.Classify(err), "", 0
+	}
+	j := bumpJob{cli: cli, result: r, rules: item.Rules, handler: item.Handler, distributions: item.Distributions, timeout: item.WaitTimeout, opts: w.opts}
+	if !item.Force {
+		blocker, err := codeBlocker(ctx, j)
+		if err != nil && !errors.Is(err, errNotScanned) {
+			log.progressf("  code check error for %s: %v\n", r.Name, err)
+			return bump.Failed, bump.Classify(err), "", 0
+		}
+		if blocker != "" {
+			log.progressf("Skipping %s: %s (--force to bump anyway)\n", r.Name, blocker)
+			return bump.Skipped, "", blocker, 0
+		}
+	}
+	p, attempts, o, failure := startUpdate(ctx, log, j, w.opts, nil)
+	if p == nil {
+		return o, failure, "", attempts
+	}
+	o = <-w.poller.track(p)
+	if o != bump.Updated {
+		return o, p.Failure(o), "", attempts
+	}
+	var detail string
+	if len(j.distributions) > 0 {
+		o, detail = deployEdge(ctx, log, w.cf, j, w.opts.PollEvery, w.opts.EdgeTimeout)
+	}
+	return o, "", detail, attempts
+}
+
+// lambdaBatch is the SQS batch of a Lambda invocation, for runWorker to
+// handle, and the messages it failed to.
+type lambdaBatch struct {
+	records  []events.SQSMessage
+	failures []events.SQSBatchItemFailure
+}
+
+type lambdaBatchKey struct{}
+
+func lambdaBatchFrom(ctx context.Context) *lambdaBatch {
+	b, _ := ctx.Value(lambdaBatchKey{}).(*lambdaBatch)
+	return b
+}
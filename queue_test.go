@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"update-lambda-runtime/pkg/bump"
+)
+
+func TestQueueRegion(t *testing.T) {
+	for url, want := range map[string]string{
+		"https://sqs.eu-west-1.amazonaws.com/123456789012/work":   "eu-west-1",
+		"https://us-east-2.queue.amazonaws.com/123456789012/work": "us-east-2",
+		"http://127.0.0.1:4566/123456789012/work":                 "",
+	} {
+		if got := queueRegion(url); got != want {
+			t.Errorf("queueRegion(%q) = %q, want %q", url, got, want)
+		}
+	}
+}
+
+func TestWorkItemCarriesRules(t *testing.T) {
+	// The environment worked out at discovery holds the function's
+	// variables; only the rules may go on the queue.
+	rules := &bump.Rules{Env: bump.EnvRules{Set: map[string]string{"FLAG": "on"}, Unset: []string{"LEGACY_MODE"}}}
+	j := bumpJob{
+		result:   functionResult{Name: "orders"},
+		env:      map[string]string{"DB_PASSWORD": "hunter2", "FLAG": "on"},
+		rules:    rules,
+		revision: "1",
+	}
+	q := &workQueue{runID: "run", resultsURL: "https://sqs.us-east-1.amazonaws.com/123456789012/results"}
+	body, err := json.Marshal(q.item(j))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(body), "hunter2") || strings.Contains(string(body), "DB_PASSWORD") {
+		t.Errorf("work item carries the function's variables: %s", body)
+	}
+	var got workItem
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Rules == nil || !reflect.DeepEqual(*got.Rules, *rules) {
+		t.Errorf("rules %+v, want %+v", got.Rules, rules)
+	}
+
+	if body, _ := json.Marshal(q.item(bumpJob{})); strings.Contains(string(body), "rules") {
+		t.Errorf("runtime-only item %s carries rules", body)
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sync"
+
+	"update-lambda-runtime/pkg/report"
+)
+
+// --redact modes.
+const (
+	redactHash = "hash" // stable pseudonyms, so functions can still be told apart
+	redactMask = "mask" // account IDs keep their last four digits, names are dropped
+)
+
+// redactKeyEnv names the environment variable holding the key pseudonyms
+// are derived with. Without it every run uses a new random key, so the
+// same function gets a different pseudonym in each run's output.
+const redactKeyEnv = "ULR_REDACT_KEY"
+
+var (
+	accountIDPattern   = regexp.MustCompile(`\b\d{12}\b`)
+	functionARNPattern = regexp.MustCompile(`(:function:)([A-Za-z0-9_-]+)`)
+
+	// redactKey is the key of this run, generated once so every account
+	// swept in it gets the same pseudonyms.
+	redactKey = sync.OnceValue(func() []byte {
+		if k := os.Getenv(redactKeyEnv); k != "" {
+			return []byte(k)
+		}
+		k := make([]byte, 32)
+		rand.Read(k)
+		return k
+	})
+)
+
+// redactor hides account IDs and function and other resource names in
+// what a run shows or writes, so runtime distribution data can be shared
+// outside the organization. Profiles, regions and runtimes are kept. A nil
+// redactor changes nothing.
+type redactor struct {
+	mode string
+	key  []byte
+}
+
+func newRedactor(mode string) *redactor {
+	if mode == "" {
+		return nil
+	}
+	return &redactor{mode: mode, key: redactKey()}
+}
+
+func validateRedact(mode string) error {
+	if mode != "" && mode != redactHash && mode != redactMask {
+		return fmt.Errorf("--redact must be %s or %s", redactHash, redactMask)
+	}
+	return nil
+}
+
+// pseudonym derives a short stand-in for value, which is kind-specific
+// so an account ID and a function of the same name do not match.
+func (r *redactor) pseudonym(kind, value string) string {
+	mac := hmac.New(sha256.New, r.key)
+	mac.Write([]byte(kind + "\x00" + value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// account redacts an account ID: a 12-character pseudonym, or the last
+// four digits.
+func (r *redactor) account(id string) string {
+	switch {
+	case r == nil || id == "":
+		return id
+	case r.mode == redactMask && len(id) > 4:
+		return "********" + id[len(id)-4:]
+	case r.mode == redactMask:
+		return "********"
+	}
+	return "a-" + r.pseudonym("account", id)[:10]
+}
+
+// name redacts the name of a function or another resource.
+func (r *redactor) name(name string) string {
+	switch {
+	case r == nil || name == "" || name == "*":
+		return name
+	case r.mode == redactMask:
+		return redactedValue
+	}
+	return "fn-" + r.pseudonym("name", name)[:8]
+}
+
+// text redacts account IDs and function names in free text, such as an
+// error message or an ARN.
+func (r *redactor) text(s string) string {
+	if r == nil {
+		return s
+	}
+	s = functionARNPattern.ReplaceAllStringFunc(s, func(m string) string {
+		sub := functionARNPattern.FindStringSubmatch(m)
+		return sub[1] + r.name(sub[2])
+	})
+	return accountIDPattern.ReplaceAllStringFunc(s, r.account)
+}
+
+func (r *redactor) result(fr functionResult) functionResult {
+	if r == nil {
+		return fr
+	}
+	fr.AccountID = r.account(fr.AccountID)
+	fr.Name = r.name(fr.Name)
+	fr.LastModifiedBy = r.text(fr.LastModifiedBy)
+	fr.Detail = r.text(fr.Detail)
+	if fr.Triggers != nil {
+		fr.Triggers = slices.Clone(fr.Triggers)
+		for i := range fr.Triggers {
+			fr.Triggers[i].Source = r.name(fr.Triggers[i].Source)
+		}
+	}
+	return fr
+}
+
+func (r *redactor) discoveryError(e discoveryError) discoveryError {
+	if r == nil {
+		return e
+	}
+	e.AccountID = r.account(e.AccountID)
+	e.Function = r.name(e.Function)
+	e.Error = r.text(e.Error)
+	return e
+}
+
+// report returns a copy of rep with its accounts and functions redacted.
+func (r *redactor) report(rep *runReport) *runReport {
+	if r == nil {
+		return rep
+	}
+	out := *rep
+	out.AccountID = r.account(rep.AccountID)
+	out.Role = r.text(rep.Role)
+	out.Results = make([]functionResult, len(rep.Results))
+	for i, fr := range rep.Results {
+		out.Results[i] = r.result(fr)
+	}
+	out.Errors = make([]discoveryError, len(rep.Errors))
+	for i, e := range rep.Errors {
+		out.Errors[i] = r.discoveryError(e)
+	}
+	out.Stale = make([]staleVersion, len(rep.Stale))
+	for i, s := range rep.Stale {
+		s.AccountID, s.Function = r.account(s.AccountID), r.name(s.Function)
+		out.Stale[i] = s
+	}
+	out.Preflight = make([]preflightFinding, len(rep.Preflight))
+	for i, f := range rep.Preflight {
+		f.Function, f.Message = r.name(f.Function), r.text(f.Message)
+		out.Preflight[i] = f
+	}
+	return &out
+}
+
+// inventory redacts inv in place.
+func (r *redactor) inventory(inv *report.Inventory) {
+	if r == nil {
+		return
+	}
+	for i := range inv.Accounts {
+		a := &inv.Accounts[i]
+		a.AccountID, a.Error = r.account(a.AccountID), r.text(a.Error)
+		for j := range a.Regions {
+			reg := &a.Regions[j]
+			reg.Error = r.text(reg.Error)
+			for k := range reg.Runtimes {
+				fns := reg.Runtimes[k].Functions
+				for n := range fns {
+					fns[n] = r.name(fns[n])
+				}
+				slices.Sort(fns)
+			}
+		}
+	}
+}
+
+// redactedSink redacts what a list run shows before passing it on.
+type redactedSink struct {
+	listSink
+	r *redactor
+}
+
+func (s redactedSink) listed(fr functionResult) { s.listSink.listed(s.r.result(fr)) }
+func (s redactedSink) failed(e discoveryError)  { s.listSink.failed(s.r.discoveryError(e)) }
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"update-lambda-runtime/pkg/report"
+)
+
+func TestRedactor(t *testing.T) {
+	hash := &redactor{mode: redactHash, key: []byte("k")}
+	if a, b := hash.name("orders"), hash.name("orders"); a != b || a == "orders" || !strings.HasPrefix(a, "fn-") {
+		t.Errorf("name pseudonyms %q, %q, want one stable fn- pseudonym", a, b)
+	}
+	if hash.name("orders") == hash.name("payments") {
+		t.Error("two functions got the same pseudonym")
+	}
+	if id := hash.account("123456789012"); len(id) != 12 || strings.Contains(id, "9012") {
+		t.Errorf("account pseudonym %q, want 12 characters hiding the ID", id)
+	}
+	if other := (&redactor{mode: redactHash, key: []byte("other")}).name("orders"); other == hash.name("orders") {
+		t.Error("pseudonyms do not depend on the key")
+	}
+
+	mask := &redactor{mode: redactMask, key: []byte("k")}
+	if got := mask.account("123456789012"); got != "********9012" {
+		t.Errorf("masked account %q, want ********9012", got)
+	}
+	if got := mask.name("orders"); got != redactedValue {
+		t.Errorf("masked name %q, want %s", got, redactedValue)
+	}
+
+	msg := "AccessDeniedException: arn:aws:sts::123456789012:assumed-role/ops/jo is not authorized on arn:aws:lambda:us-east-1:123456789012:function:orders:live"
+	got := hash.text(msg)
+	if strings.Contains(got, "123456789012") || strings.Contains(got, ":orders") {
+		t.Errorf("text still names the account or function: %s", got)
+	}
+	if !strings.Contains(got, ":function:"+hash.name("orders")+":live") {
+		t.Errorf("text %s, want the function's pseudonym in its ARN", got)
+	}
+
+	var none *redactor
+	if r := none.result(functionResult{AccountID: "123456789012", Name: "orders"}); r.AccountID != "123456789012" || r.Name != "orders" {
+		t.Errorf("a nil redactor changed %+v", r)
+	}
+}
+
+func TestRedactInventory(t *testing.T) {
+	inv := &report.Inventory{Totals: map[string]int{"python3.12": 2}, Accounts: []report.Account{{
+		AccountID: "123456789012",
+		Profile:   "prod",
+		Regions: []report.Region{{Region: "us-east-1", Runtimes: []report.Runtime{
+			{Runtime: "python3.12", Functions: []string{"orders", "payments"}},
+		}}},
+	}}}
+	r := &redactor{mode: redactHash, key: []byte("k")}
+	r.inventory(inv)
+	a := inv.Accounts[0]
+	fns := a.Regions[0].Runtimes[0].Functions
+	if a.AccountID == "123456789012" || a.Profile != "prod" || len(fns) != 2 || inv.Totals["python3.12"] != 2 {
+		t.Fatalf("inventory redacted to %+v, want the account hidden and the profile and counts kept", a)
+	}
+	for _, fn := range fns {
+		if fn == "orders" || fn == "payments" {
+			t.Errorf("function %s not redacted", fn)
+		}
+	}
+}
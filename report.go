@@ -0,0 +1,138 @@
+package main
+
+import (
+	"cmp"
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"update-lambda-runtime/pkg/bump"
+)
+
+// writeCSVReport writes one row per function in the run.
+func writeCSVReport(w io.Writer, rep *runReport) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"account_id", "profile", "region", "function_name", "runtime", "target_runtime", "outcome", "failure"})
+	for _, r := range rep.Results {
+		cw.Write([]string{r.AccountID, r.Profile, r.Region, r.Name, r.Runtime, r.TargetRuntime, string(r.Outcome), string(r.Failure)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+var htmlReport = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Lambda runtime bump report</title>
+<style>
+body{font-family:sans-serif}
+table{border-collapse:collapse}
+th,td{border:1px solid #ccc;padding:4px 8px;text-align:left}
+.failed,.timed{background:#fdd}
+.updated{background:#dfd}
+</style></head>
+<body>
+<h2>{{.Headline}}</h2>
+<p>Profile <b>{{.Profile}}</b>, account <b>{{.AccountID}}</b>, regions {{range $i, $r := .Regions}}{{if $i}}, {{end}}{{$r}}{{end}}.<br>
+Started {{.StartedAt.Format "2006-01-02 15:04:05 MST"}}, finished {{.FinishedAt.Format "2006-01-02 15:04:05 MST"}}.</p>
+{{with .FailureLine}}<p>Failures: {{.}}.</p>
+{{end}}<table>
+<tr><th>Region</th><th>Function</th><th>Runtime</th><th>Outcome</th></tr>
+{{range .Results}}<tr class="{{.Outcome}}"><td>{{.Region}}</td><td>{{.Name}}</td><td>{{.Runtime}}</td><td>{{if .Outcome}}{{.Outcome}}{{with .Failure}} ({{.}}){{end}}{{else}}-{{end}}</td></tr>
+{{end}}</table>
+</body></html>
+`))
+
+// writeHTMLReport renders the run as a standalone HTML page.
+func writeHTMLReport(w io.Writer, rep *runReport) error {
+	return htmlReport.Execute(w, struct {
+		*runReport
+		Headline, FailureLine string
+		StartedAt, FinishedAt time.Time
+	}{rep, rep.headline(), failureLine(rep.Failures), inZone(rep.StartedAt, time.UTC), inZone(rep.FinishedAt, time.UTC)})
+}
+
+// writePRComment renders the run as GitHub-flavoured Markdown for a pull
+// request or issue comment: the headline and outcome counts up front,
+// failures listed openly and the full function table folded away.
+func writePRComment(w io.Writer, rep *runReport) error {
+	icon := ":white_check_mark:"
+	switch {
+	case len(rep.failures()) > 0 || len(rep.Errors) > 0:
+		icon = ":x:"
+	case rep.Interrupted:
+		icon = ":warning:"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "### %s %s\n\n", icon, rep.headline())
+	fmt.Fprintf(&b, "Run `%s`: profile `%s`, account `%s`, regions %s. Finished %s.\n\n",
+		rep.RunID, rep.Profile, rep.AccountID, mdCodeList(rep.Regions), inZone(rep.FinishedAt, time.UTC).Format("2006-01-02 15:04:05 MST"))
+
+	b.WriteString("| Outcome | Functions |\n|---|---:|\n")
+	for _, o := range bump.Outcomes {
+		fmt.Fprintf(&b, "| %s | %d |\n", o, rep.Counts[string(o)])
+	}
+	fmt.Fprintf(&b, "\nFunctions: %s.\n", rep.classLine())
+	if line := failureLine(rep.Failures); line != "" {
+		fmt.Fprintf(&b, "\nFailures by cause: %s.\n", line)
+	}
+
+	results := slices.Clone(rep.Results)
+	sortResults(results)
+	if failed := rep.failures(); len(failed) > 0 {
+		sortResults(failed)
+		fmt.Fprintf(&b, "\n#### Failures (%d)\n\n", len(failed))
+		writeMDResults(&b, failed)
+	}
+	if len(rep.Errors) > 0 {
+		fmt.Fprintf(&b, "\n#### Discovery errors (%d)\n\n| Account | Region | Function | Error |\n|---|---|---|---|\n", len(rep.Errors))
+		for _, e := range rep.Errors {
+			fmt.Fprintf(&b, "| %s | %s | `%s` | %s |\n", e.AccountID, e.Region, cmp.Or(e.Function, "*"), strings.ReplaceAll(e.Error, "|", "\\|"))
+		}
+	}
+	if len(rep.Preflight) > 0 {
+		fmt.Fprintf(&b, "\n#### Pre-flight findings (%d), nothing was changed\n\n| Region | Function | Check | Finding |\n|---|---|---|---|\n", len(rep.Preflight))
+		for _, f := range rep.Preflight {
+			fmt.Fprintf(&b, "| %s | `%s` | %s | %s |\n", f.Region, f.Function, f.Check, strings.ReplaceAll(f.Message, "|", "\\|"))
+		}
+	}
+	if len(rep.Stale) > 0 {
+		fmt.Fprintf(&b, "\n#### Published versions still on the old runtime (%d)\n\n| Account | Region | Function | Version | Runtime | Aliases |\n|---|---|---|---|---|---|\n", len(rep.Stale))
+		for _, s := range rep.Stale {
+			fmt.Fprintf(&b, "| %s | %s | `%s` | %s | `%s` | %s |\n", s.AccountID, s.Region, s.Function, s.Version, s.Runtime, cmp.Or(mdCodeList(s.Aliases), "-"))
+		}
+	}
+	fmt.Fprintf(&b, "\n<details>\n<summary>All functions (%d)</summary>\n\n", len(results))
+	writeMDResults(&b, results)
+	b.WriteString("\n</details>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeMDResults(b *strings.Builder, results []functionResult) {
+	b.WriteString("| Account | Region | Function | Runtime | Target | Outcome |\n|---|---|---|---|---|---|\n")
+	for _, r := range results {
+		target, outcome := "-", "-"
+		if r.TargetRuntime != "" {
+			target = "`" + r.TargetRuntime + "`"
+		}
+		if r.Outcome != "" {
+			outcome = string(r.Outcome)
+		}
+		if r.Failure != "" {
+			outcome += " (" + string(r.Failure) + ")"
+		}
+		fmt.Fprintf(b, "| %s | %s | `%s` | `%s` | %s | %s |\n", r.AccountID, r.Region, r.Name, r.Runtime, target, outcome)
+	}
+}
+
+// mdCodeList formats items as a comma-separated list of code spans.
+func mdCodeList(items []string) string {
+	quoted := make([]string, len(items))
+	for i, s := range items {
+		quoted[i] = "`" + s + "`"
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// newRunID identifies a bump run: its UTC start time, sortable, and a
+// random suffix so runs started in the same second do not collide.
+func newRunID(started time.Time) string {
+	b := make([]byte, 3)
+	rand.Read(b)
+	return started.UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(b)
+}
+
+// runsDir holds one JSON record per bump run, the runReport with every
+// function's runtime before and after it, which undo reads back.
+func runsDir() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("locate cache dir: %w", err)
+	}
+	return filepath.Join(base, "update-lambda-runtime", "runs"), nil
+}
+
+// saveRunRecord stores rep under its run ID.
+func saveRunRecord(rep *runReport) error {
+	dir, err := runsDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(rep, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, rep.RunID+".json"), append(b, '\n'), 0o600)
+}
+
+// loadRunRecord reads the record of run id.
+func loadRunRecord(id string) (*runReport, error) {
+	dir, err := runsDir()
+	if err != nil {
+		return nil, err
+	}
+	if id == "" || filepath.Base(id) != id {
+		return nil, fmt.Errorf("invalid run ID %q", id)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, id+".json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("no record of run %s in %s", id, dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var rep runReport
+	if err := json.Unmarshal(b, &rep); err != nil {
+		return nil, fmt.Errorf("run %s: %w", id, err)
+	}
+	return &rep, nil
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lamtypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+
+	"update-lambda-runtime/pkg/inventory"
+)
+
+// eachFunction calls visit for every function opts selects, across its
+// regions, under a run span named name. The runtime policy is loaded first
+// so visitors can honour its exclusions.
+func eachFunction(ctx context.Context, opts *AWSOpts, name string, visit func(ctx context.Context, cli *lambda.Client, r functionResult)) error {
+	ctx, span := tracer.Start(ctx, name, runAttrs(opts))
+	defer span.End()
+
+	clients := newClientFactory(opts.Profile, opts.APITimeout, opts.MaxRPS)
+	if err := setPolicy(ctx, span, clients, opts); err != nil {
+		return err
+	}
+	acctID, err := resolveAccountID(ctx, clients)
+	if err != nil {
+		return fmt.Errorf("resolve account id: %w", err)
+	}
+	inv, err := newDiscoverer(ctx, clients, opts)
+	if err != nil {
+		return err
+	}
+	for _, region := range opts.Regions {
+		cli, err := clients.Lambda(ctx, region)
+		if err != nil {
+			return err
+		}
+		err = inv.stream(ctx, cli, region, func(f inventory.Function) {
+			visit(ctx, cli, functionResult{
+				AccountID:    acctID,
+				Profile:      opts.Profile,
+				Region:       region,
+				Name:         f.Name,
+				Runtime:      f.Runtime,
+				Architecture: f.Architecture,
+			})
+		})
+		if ctx.Err() != nil {
+			return stopped(ctx)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", region, err)
+		}
+	}
+	return nil
+}
+
+// runRuntimeManagementList prints each function's runtime update mode and,
+// when it is pinned, the runtime version it is pinned to.
+func runRuntimeManagementList(ctx context.Context, opts *AWSOpts, w io.Writer) error {
+	if err := validateCommon(opts); err != nil {
+		return err
+	}
+	tbl := newFunctionTable(w, opts, runtimeNameWidth, len("UpdateRuntimeOn"))
+	printHeader(tbl, opts.ShowProfile, "UpdateRuntimeOn", "RuntimeVersionArn")
+	return eachFunction(ctx, opts, "runtime-management-list", func(ctx context.Context, cli *lambda.Client, r functionResult) {
+		mode, version := "-", "-"
+		if r.Runtime != "" { // container images have no managed runtime
+			out, err := cli.GetRuntimeManagementConfig(ctx, &lambda.GetRuntimeManagementConfigInput{
+				FunctionName: aws.String(r.Name),
+			})
+			if err != nil {
+				mode = "error: " + err.Error()
+			} else {
+				mode = string(out.UpdateRuntimeOn)
+				if out.RuntimeVersionArn != nil {
+					version = aws.ToString(out.RuntimeVersionArn)
+				}
+			}
+		}
+		printRow(tbl, r.AccountID, r.Profile, r.Region, r.Name, r.Runtime, opts.ShowProfile, mode, version)
+	})
+}
+
+// runRuntimeManagementSet applies opts.RuntimeMode to every selected
+// function on a managed runtime, or only to those on opts.RuntimeFilter.
+// Runtime version ARNs are specific to one runtime and region, so Manual
+// needs --runtime and a single region.
+func runRuntimeManagementSet(ctx context.Context, opts *AWSOpts, w io.Writer) error {
+	if err := validateRuntimeMode(opts); err != nil {
+		return err
+	}
+	return applyRuntimeMode(ctx, opts, w, "runtime-management-set", false)
+}
+
+// runRuntimeManagementPin pins the functions on opts.RuntimeFilter to
+// opts.RuntimeVersionARN, typically the previous version when a runtime
+// patch misbehaves.
+func runRuntimeManagementPin(ctx context.Context, opts *AWSOpts, w io.Writer) error {
+	opts.RuntimeMode = string(lamtypes.UpdateRuntimeOnManual)
+	if err := validateRuntimeMode(opts); err != nil {
+		return err
+	}
+	return applyRuntimeMode(ctx, opts, w, "runtime-management-pin", false)
+}
+
+// runRuntimeManagementUnpin returns pinned functions to opts.RuntimeMode,
+// Auto unless given. Functions that are not pinned are left as they are.
+func runRuntimeManagementUnpin(ctx context.Context, opts *AWSOpts, w io.Writer) error {
+	opts.RuntimeMode = cmp.Or(opts.RuntimeMode, string(lamtypes.UpdateRuntimeOnAuto))
+	if opts.RuntimeMode == string(lamtypes.UpdateRuntimeOnManual) {
+		return fmt.Errorf("--mode must be %s or %s", lamtypes.UpdateRuntimeOnAuto, lamtypes.UpdateRuntimeOnFunctionUpdate)
+	}
+	if err := validateRuntimeMode(opts); err != nil {
+		return err
+	}
+	return applyRuntimeMode(ctx, opts, w, "runtime-management-unpin", true)
+}
+
+// applyRuntimeMode puts opts.RuntimeMode on every selected function on a
+// managed runtime (on opts.RuntimeFilter when set) that the policy does not
+// exclude. With onlyPinned, functions not in Manual mode are skipped.
+func applyRuntimeMode(ctx context.Context, opts *AWSOpts, w io.Writer, name string, onlyPinned bool) error {
+	mode := lamtypes.UpdateRuntimeOn(opts.RuntimeMode)
+	tbl := newFunctionTable(w, opts, runtimeNameWidth)
+	printHeader(tbl, opts.ShowProfile, "Result")
+	var set, failed int
+	err := eachFunction(ctx, opts, name, func(ctx context.Context, cli *lambda.Client, r functionResult) {
+		result := "-"
+		defer func() {
+			printRow(tbl, r.AccountID, r.Profile, r.Region, r.Name, r.Runtime, opts.ShowProfile, result)
+		}()
+		if r.Runtime == "" || (opts.RuntimeFilter != "" && r.Runtime != opts.RuntimeFilter) || opts.Policy.Excluded(r.Name) {
+			return
+		}
+		if onlyPinned {
+			out, err := cli.GetRuntimeManagementConfig(ctx, &lambda.GetRuntimeManagementConfigInput{
+				FunctionName: aws.String(r.Name),
+			})
+			if err != nil {
+				result = "failed: " + err.Error()
+				failed++
+				return
+			}
+			if out.UpdateRuntimeOn != lamtypes.UpdateRuntimeOnManual {
+				return
+			}
+		}
+		in := &lambda.PutRuntimeManagementConfigInput{
+			FunctionName:    aws.String(r.Name),
+			UpdateRuntimeOn: mode,
+		}
+		if opts.RuntimeVersionARN != "" {
+			in.RuntimeVersionArn = aws.String(opts.RuntimeVersionARN)
+		}
+		if _, err := cli.PutRuntimeManagementConfig(ctx, in); err != nil {
+			result = "failed: " + err.Error()
+			failed++
+			return
+		}
+		result = "set to " + opts.RuntimeMode
+		set++
+	})
+	fmt.Fprintf(w, "\nSummary: %d set, %d failed\n", set, failed)
+	return err
+}
+
+func validateRuntimeMode(opts *AWSOpts) error {
+	if err := validateCommon(opts); err != nil {
+		return err
+	}
+	mode := lamtypes.UpdateRuntimeOn(opts.RuntimeMode)
+	if !slices.Contains(mode.Values(), mode) {
+		return fmt.Errorf("--mode must be %s, %s or %s", lamtypes.UpdateRuntimeOnAuto, lamtypes.UpdateRuntimeOnFunctionUpdate, lamtypes.UpdateRuntimeOnManual)
+	}
+	if mode != lamtypes.UpdateRuntimeOnManual {
+		if opts.RuntimeVersionARN != "" {
+			return fmt.Errorf("--runtime-version-arn only applies to --mode %s", lamtypes.UpdateRuntimeOnManual)
+		}
+		return nil
+	}
+	if opts.RuntimeVersionARN == "" || opts.RuntimeFilter == "" {
+		return fmt.Errorf("pinning (--mode %s) needs --runtime-version-arn and --runtime", lamtypes.UpdateRuntimeOnManual)
+	}
+	a, err := arn.Parse(opts.RuntimeVersionARN)
+	if err != nil || !strings.HasPrefix(a.Resource, "runtime:") {
+		return fmt.Errorf("--runtime-version-arn %q is not a runtime version ARN", opts.RuntimeVersionARN)
+	}
+	if len(opts.Regions) != 1 || opts.Regions[0] != a.Region {
+		return fmt.Errorf("--runtime-version-arn is for %s; pass --regions %s only", a.Region, a.Region)
+	}
+	return nil
+}
+
+// runtimeUpdatesWidth sizes the UpdateRuntimeOn column of list, as wide as
+// a pinned function's "Manual (<version>)".
+const runtimeUpdatesWidth = len("Manual (0cdcfbdefbc5)")
+
+// runtimeUpdates gives r's UpdateRuntimeOn cell: the mode, with the start
+// of the pinned version's hash when Manual, "-" for container images and
+// "?" when it could not be looked up.
+func runtimeUpdates(r functionResult) string {
+	switch {
+	case r.Runtime == "":
+		return "-"
+	case r.UpdateRuntimeOn == "":
+		return "?"
+	case r.RuntimeVersion == "":
+		return r.UpdateRuntimeOn
+	}
+	version := r.RuntimeVersion[strings.LastIndex(r.RuntimeVersion, ":")+1:]
+	return fmt.Sprintf("%s (%s)", r.UpdateRuntimeOn, version[:min(len(version), 12)])
+}
+
+// fillRuntimeManagement looks up the runtime update mode and any pinned
+// version of the functions in rs, all in region, listLookups at a time.
+// Container images have no managed runtime and are left out.
+func fillRuntimeManagement(ctx context.Context, clients *clientFactory, region string, rs []functionResult) error {
+	cli, err := clients.Lambda(ctx, region)
+	if err != nil {
+		return err
+	}
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, listLookups)
+	for i := range rs {
+		if rs[i].Runtime == "" {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			out, err := cli.GetRuntimeManagementConfig(ctx, &lambda.GetRuntimeManagementConfigInput{FunctionName: aws.String(rs[i].Name)})
+			if err != nil {
+				if ctx.Err() == nil {
+					fmt.Fprintf(os.Stderr, "warning: runtime management of %s: %v\n", rs[i].Name, err)
+				}
+				return
+			}
+			rs[i].UpdateRuntimeOn, rs[i].RuntimeVersion = string(out.UpdateRuntimeOn), aws.ToString(out.RuntimeVersionArn)
+		}()
+	}
+	wg.Wait()
+	return nil
+}
@@ -0,0 +1,20 @@
+package main
+
+import "testing"
+
+func TestRuntimeUpdates(t *testing.T) {
+	tests := []struct {
+		r    functionResult
+		want string
+	}{
+		{functionResult{Runtime: "python3.12", UpdateRuntimeOn: "Auto"}, "Auto"},
+		{functionResult{Runtime: "python3.12", UpdateRuntimeOn: "Manual", RuntimeVersion: "arn:aws:lambda:us-east-1::runtime:0cdcfbdefbc5e7d3343f73c2e2dd3cba17d61dea0686b404502a0c9ce83931b9"}, "Manual (0cdcfbdefbc5)"},
+		{functionResult{Runtime: "python3.12"}, "?"},
+		{functionResult{}, "-"},
+	}
+	for _, tt := range tests {
+		if got := runtimeUpdates(tt.r); got != tt.want {
+			t.Errorf("runtimeUpdates(%+v) = %q, want %q", tt.r, got, tt.want)
+		}
+	}
+}
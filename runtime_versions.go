@@ -0,0 +1,78 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+)
+
+// runRuntimeVersions prints the runtime version each selected function
+// runs on, then, per runtime and region, how many functions run each
+// version, so a fleet can be checked for convergence after AWS ships a
+// runtime patch.
+// ListFunctions does not carry the version, so every function with a
+// managed runtime costs one GetFunctionConfiguration call.
+func runRuntimeVersions(ctx context.Context, opts *AWSOpts, w io.Writer) error {
+	if err := validateCommon(opts); err != nil {
+		return err
+	}
+	tbl := newFunctionTable(w, opts, runtimeNameWidth)
+	printHeader(tbl, opts.ShowProfile, "RuntimeVersionArn")
+	// Version ARNs are regional, so convergence is judged per region.
+	counts := make(map[string]map[string]int) // "runtime in region" → version ARN → functions
+	err := eachFunction(ctx, opts, "runtime-versions", func(ctx context.Context, cli *lambda.Client, r functionResult) {
+		version := "-"
+		if r.Runtime != "" {
+			cfg, err := cli.GetFunctionConfiguration(ctx, &lambda.GetFunctionConfigurationInput{
+				FunctionName: aws.String(r.Name),
+			})
+			switch {
+			case err != nil:
+				version = "error: " + err.Error()
+			case cfg.RuntimeVersionConfig == nil:
+				version = "unknown"
+			case cfg.RuntimeVersionConfig.Error != nil:
+				version = "error: " + aws.ToString(cfg.RuntimeVersionConfig.Error.Message)
+			default:
+				version = aws.ToString(cfg.RuntimeVersionConfig.RuntimeVersionArn)
+				key := r.Runtime + " in " + r.Region
+				if counts[key] == nil {
+					counts[key] = make(map[string]int)
+				}
+				counts[key][version]++
+			}
+		}
+		printRow(tbl, r.AccountID, r.Profile, r.Region, r.Name, r.Runtime, opts.ShowProfile, version)
+	})
+	printVersionSummary(w, counts)
+	return err
+}
+
+// printVersionSummary lists, for each runtime and region, its versions
+// from most to least used.
+func printVersionSummary(w io.Writer, counts map[string]map[string]int) {
+	if len(counts) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "\nVersions per runtime and region:")
+	for _, key := range slices.Sorted(maps.Keys(counts)) {
+		versions := counts[key]
+		state := "converged"
+		if len(versions) > 1 {
+			state = fmt.Sprintf("%d versions", len(versions))
+		}
+		fmt.Fprintf(w, "  %s (%s)\n", key, state)
+		arns := slices.SortedFunc(maps.Keys(versions), func(a, b string) int {
+			return cmp.Or(cmp.Compare(versions[b], versions[a]), cmp.Compare(a, b))
+		})
+		for _, arn := range arns {
+			fmt.Fprintf(w, "    %4d  %s\n", versions[arn], arn)
+		}
+	}
+}
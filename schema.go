@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"go.yaml.in/yaml/v3"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// schemaFiles are the published JSON Schemas of the files the tool reads:
+// policy (--policy), map (--map) and eligibility (--eligibility); and of
+// what it writes for other programs, versioned in their names (run.v1):
+// run reports, lifecycle events and inventory reports, whole (inventory)
+// or a line at a time (inventory-row). An output schema only gains
+// optional fields within a version.
+//
+//go:embed schemas/*.schema.json
+var schemaFiles embed.FS
+
+const schemaBaseURL = "https://raw.githubusercontent.com/anelhaman/update-lambda-runtime/main/schemas/"
+
+var compileSchemas = sync.OnceValues(func() (map[string]*jsonschema.Schema, error) {
+	c := jsonschema.NewCompiler()
+	entries, err := schemaFiles.ReadDir("schemas")
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		doc, err := schemaFiles.ReadFile("schemas/" + e.Name())
+		if err != nil {
+			return nil, err
+		}
+		v, err := jsonschema.UnmarshalJSON(bytes.NewReader(doc))
+		if err != nil {
+			return nil, fmt.Errorf("schema %s: %w", e.Name(), err)
+		}
+		if err := c.AddResource(schemaBaseURL+e.Name(), v); err != nil {
+			return nil, err
+		}
+	}
+	out := make(map[string]*jsonschema.Schema)
+	for _, e := range entries {
+		name := strings.TrimSuffix(e.Name(), ".schema.json")
+		if out[name], err = c.Compile(schemaBaseURL + e.Name()); err != nil {
+			return nil, fmt.Errorf("schema %s: %w", e.Name(), err)
+		}
+	}
+	return out, nil
+})
+
+// decodeDocument checks doc, YAML or JSON, against the named schema before
+// decoding it into v, so a malformed file fails the run before anything is
+// changed. Every violation is reported with its line. The parsed document
+// is returned for callers that report their own checks by line.
+func decodeDocument(schema string, doc []byte, v any) (*yaml.Node, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(doc, &root); err != nil {
+		return nil, err
+	}
+	var inst any
+	if err := root.Decode(&inst); err != nil {
+		return nil, err
+	}
+	// Validate and decode what JSON would hold, so numbers and mappings
+	// look the same whichever syntax the file is in.
+	js, err := json.Marshal(inst)
+	if err != nil {
+		return nil, err
+	}
+	if inst, err = jsonschema.UnmarshalJSON(bytes.NewReader(js)); err != nil {
+		return nil, err
+	}
+	schemas, err := compileSchemas()
+	if err != nil {
+		return nil, err
+	}
+	var verr *jsonschema.ValidationError
+	if err := schemas[schema].Validate(inst); errors.As(err, &verr) {
+		return nil, schemaViolations(&root, verr)
+	} else if err != nil {
+		return nil, err
+	}
+	return &root, json.Unmarshal(js, v)
+}
+
+// schemaError lists a document's schema violations, one per line of it.
+type schemaError []string
+
+func (e schemaError) Error() string {
+	if len(e) == 1 {
+		return e[0]
+	}
+	return fmt.Sprintf("%d problems:\n  %s", len(e), strings.Join(e, "\n  "))
+}
+
+type violation struct {
+	line int
+	msg  string
+}
+
+func schemaViolations(root *yaml.Node, verr *jsonschema.ValidationError) schemaError {
+	p := message.NewPrinter(language.English)
+	var found []violation
+	var walk func(e *jsonschema.ValidationError)
+	walk = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) > 0 {
+			for _, c := range e.Causes {
+				walk(c)
+			}
+			return
+		}
+		n := nodeAt(root, e.InstanceLocation)
+		if ap, ok := e.ErrorKind.(*kind.AdditionalProperties); ok && len(ap.Properties) > 0 {
+			n = keyNode(n, ap.Properties[0])
+		}
+		msg := e.ErrorKind.LocalizedString(p)
+		if len(e.InstanceLocation) > 0 {
+			msg = "/" + strings.Join(e.InstanceLocation, "/") + ": " + msg
+		}
+		found = append(found, violation{n.Line, msg})
+	}
+	walk(verr)
+	slices.SortStableFunc(found, func(a, b violation) int { return cmp.Compare(a.line, b.line) })
+	out := make(schemaError, 0, len(found))
+	for _, v := range found {
+		out = append(out, fmt.Sprintf("line %d: %s", max(v.line, 1), v.msg))
+	}
+	return slices.Compact(out)
+}
+
+// nodeAt follows a JSON pointer's tokens through a parsed document, as far
+// as they go.
+func nodeAt(n *yaml.Node, path []string) *yaml.Node {
+	for {
+		switch n.Kind {
+		case yaml.DocumentNode:
+			if len(n.Content) == 0 {
+				return n
+			}
+			n = n.Content[0]
+			continue
+		case yaml.AliasNode:
+			n = n.Alias
+			continue
+		}
+		if len(path) == 0 {
+			return n
+		}
+		next := n
+		switch n.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				if n.Content[i].Value == path[0] {
+					next = n.Content[i+1]
+					break
+				}
+			}
+		case yaml.SequenceNode:
+			if i, err := strconv.Atoi(path[0]); err == nil && i < len(n.Content) {
+				next = n.Content[i]
+			}
+		}
+		if next == n {
+			return n
+		}
+		n, path = next, path[1:]
+	}
+}
+
+// keyNode is the key of the mapping n named key, or n itself.
+func keyNode(n *yaml.Node, key string) *yaml.Node {
+	if n.Kind == yaml.MappingNode {
+		for i := 0; i < len(n.Content); i += 2 {
+			if n.Content[i].Value == key {
+				return n.Content[i]
+			}
+		}
+	}
+	return n
+}
+
+// runSchema prints the embedded schema name, or with no name lists every
+// schema with its title.
+func runSchema(w io.Writer, name string) error {
+	if name != "" {
+		doc, err := schemaFiles.ReadFile("schemas/" + name + ".schema.json")
+		if err != nil {
+			return fmt.Errorf("no schema %q; run schema without arguments to list them", name)
+		}
+		_, err = w.Write(doc)
+		return err
+	}
+	entries, err := schemaFiles.ReadDir("schemas")
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		doc, err := schemaFiles.ReadFile("schemas/" + e.Name())
+		if err != nil {
+			return err
+		}
+		var meta struct {
+			Title string `json:"title"`
+		}
+		if err := json.Unmarshal(doc, &meta); err != nil {
+			return fmt.Errorf("schema %s: %w", e.Name(), err)
+		}
+		fmt.Fprintf(w, "%-20s  %s\n", strings.TrimSuffix(e.Name(), ".schema.json"), meta.Title)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+
+	"update-lambda-runtime/pkg/bump"
+	"update-lambda-runtime/pkg/inventory"
+	"update-lambda-runtime/pkg/report"
+)
+
+func TestDecodeDocument(t *testing.T) {
+	var p bump.Policy
+	_, err := decodeDocument("policy", []byte(`{
+  "mappings": {"python3.9": "python3.12"},
+  "exclude": ["legacy-*"],
+  "layers": {"arn:aws:lambda:us-east-1:123456789012:layer:deps": "arn:aws:lambda:us-east-1:123456789012:layer:deps-py312:3"},
+  "env": {"set": {"PYTHONPATH": "/opt/python"}}
+}`), &p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Mappings["python3.9"] != "python3.12" || len(p.Exclude) != 1 || len(p.Layers) != 1 || p.Env.Set["PYTHONPATH"] != "/opt/python" {
+		t.Errorf("policy = %+v", p)
+	}
+
+	var pairs map[string]string
+	if _, err := decodeDocument("map", []byte("python3.9: python3.12\nnodejs16.x: latest-nodejs\n"), &pairs); err != nil || len(pairs) != 2 {
+		t.Errorf("map = %v, %v", pairs, err)
+	}
+}
+
+func TestDecodeDocumentViolations(t *testing.T) {
+	tests := []struct {
+		schema, doc string
+		want        []string
+	}{
+		{"policy", `{
+  "mapping": {"python3.9": "python3.12"},
+  "exclude": "legacy-*"
+}`, []string{
+			`line 1: missing property 'mappings'`,
+			`line 2: additional properties 'mapping' not allowed`,
+			`line 3: /exclude: got string, want array`,
+		}},
+		{"policy", `{
+  "mappings": {"python3.9": 3.12},
+  "layers": {
+    "arn:aws:lambda:us-east-1:123456789012:layer:deps": "arn:aws:lambda:us-east-1:123456789012:layer:deps-py312"
+  }
+}`, []string{
+			`line 2: /mappings/python3.9: got number, want string`,
+			`line 4: /layers/arn:aws:lambda:us-east-1:123456789012:layer:deps: `,
+		}},
+		{"map", "", []string{"line 1: got null, want object"}},
+		{"map", "python3.9:\n  target: python3.12\n", []string{"line 2: /python3.9: got object, want string"}},
+		{"eligibility", "rules:\n  - name: x\n    allw: name == 'a'\n", []string{"line 3: /rules/0: additional properties 'allw' not allowed"}},
+	}
+	for _, tt := range tests {
+		var v any
+		_, err := decodeDocument(tt.schema, []byte(tt.doc), &v)
+		if err == nil {
+			t.Errorf("%s %q: want an error", tt.schema, tt.doc)
+			continue
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("%s %q: error\n%v\nlacks %q", tt.schema, tt.doc, err, want)
+			}
+		}
+	}
+}
+
+// TestOutputSchemas checks what the tool writes against the schemas it
+// publishes for it.
+func TestOutputSchemas(t *testing.T) {
+	schemas, err := compileSchemas()
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := func(name string, v any) {
+		t.Helper()
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := schemas[name].Validate(doc); err != nil {
+			t.Errorf("%s: %v\n%s", name, err, b)
+		}
+	}
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	opts := &AWSOpts{Profile: "dev", Regions: []string{"us-east-1"}, Policy: &bump.Policy{Mappings: map[string]string{"python3.9": "python3.12"}}}
+	updated := functionResult{AccountID: "123456789012", Profile: "dev", Region: "us-east-1", Name: "api", Runtime: "python3.9", Edge: edgeOrigin, TargetRuntime: "python3.12", Outcome: bump.Updated}
+	rep := newRunReport(opts, "123456789012", now, false, []functionResult{
+		updated,
+		{AccountID: "123456789012", Profile: "dev", Region: "us-east-1", Name: "jobs", Runtime: "python3.12", Tags: map[string]string{"team": "platform"}},
+	})
+	rep.RunID = "20260301T120000Z-abc123"
+	rep.Errors = []discoveryError{{AccountID: "123456789012", Region: "eu-west-1", Error: "access denied"}}
+	rep.Stale = []staleVersion{{AccountID: "123456789012", Region: "us-east-1", Function: "api", Version: "3", Runtime: "python3.9", Aliases: []string{"prod"}}}
+	rep.Preflight = []preflightFinding{{Region: "us-east-1", Function: "api", Check: checkIaC, Message: "resource Api of CloudFormation stack app"}}
+	valid("run.v1", rep)
+	valid("run.v1", newRunReport(&AWSOpts{Policy: &bump.Policy{}}, "", now, true, nil))
+
+	valid("event.v1", lifecycleEvent{Type: eventStarted, Time: now, AccountID: "123456789012", Region: "us-east-1", FunctionName: "api", SourceRuntime: "python3.9", TargetRuntime: "python3.12"})
+	valid("event.v1", lifecycleEvent{Type: eventUpdated, Time: now, AccountID: "123456789012", Region: "us-east-1", FunctionName: "api", SourceRuntime: "python3.9", TargetRuntime: "python3.12", Outcome: bump.Updated})
+
+	inv := report.New([]string{"us-east-1"}, now)
+	inv.Add(report.Account{AccountID: "123456789012", Profile: "dev", Regions: []report.Region{{
+		Region:   "us-east-1",
+		Runtimes: []report.Runtime{{Runtime: "python3.9", Status: inventory.Deprecated, Functions: []string{"api"}}},
+	}}})
+	inv.Add(report.Account{Profile: "broken", Error: "no credentials"})
+	valid("inventory.v1", inv)
+	for _, row := range report.Rows(inv) {
+		valid("inventory-row.v1", row)
+	}
+}
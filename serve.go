@@ -0,0 +1,449 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"update-lambda-runtime/pkg/inventory"
+)
+
+// Bump job states reported by the API.
+const (
+	jobRunning   = "running"
+	jobSucceeded = "succeeded"
+	jobFailed    = "failed"
+)
+
+// Finished jobs are kept in memory for finishedJobTTL, and at most
+// maxFinishedJobs of them, the newest.
+const (
+	finishedJobTTL  = 24 * time.Hour
+	maxFinishedJobs = 100
+)
+
+// errJobOverlaps refuses a job selecting functions a running job selects:
+// both would update them at once, failing each other's updates with
+// ResourceConflictException.
+var errJobOverlaps = errors.New("a running job selects the same functions")
+
+// apiServer is the REST API behind serve. Bump jobs run in the background
+// with the same flow as the bump command, one report and output per job,
+// and are kept in memory until evicted (see finishedJobTTL).
+type apiServer struct {
+	ctx     context.Context // cancelled on shutdown; jobs run under it
+	opts    *AWSOpts
+	clients *clientFactory
+	metrics *runMetrics
+
+	mu   sync.Mutex
+	jobs map[string]*bumpJobStatus
+	wg   sync.WaitGroup
+}
+
+// bumpJobRequest starts a bump. Unset fields fall back to the server's
+// flags; giving runtimes replaces the server's --policy for that job.
+type bumpJobRequest struct {
+	Regions       []string `json:"regions"`
+	Function      string   `json:"function"`
+	All           bool     `json:"all"`
+	SourceRuntime string   `json:"sourceRuntime"`
+	TargetRuntime string   `json:"targetRuntime"`
+}
+
+type bumpJobStatus struct {
+	ID         string         `json:"id"`
+	State      string         `json:"state"`
+	Request    bumpJobRequest `json:"request"`
+	StartedAt  time.Time      `json:"startedAt"`
+	FinishedAt *time.Time     `json:"finishedAt,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	Report     *runReport     `json:"report,omitempty"`
+	// Output is the job's progress and, once done, its rendered report,
+	// as the bump command would print them.
+	Output string `json:"output,omitempty"`
+
+	opts *AWSOpts // the job's selection, for overlapping jobs
+	out  *jobOutput
+
+	// Guarded by apiServer.mu: the job's lifecycle events so far, and a
+	// channel closed (then replaced) whenever the job changes, for the
+	// gRPC streams following it.
+	events  []lifecycleEvent
+	changed chan struct{}
+}
+
+// jobOutput collects what a job's bump writes, for the job's Output while
+// it runs.
+type jobOutput struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (o *jobOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.buf.Write(p)
+}
+
+func (o *jobOutput) String() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.buf.String()
+}
+
+// jobEvents records a job's lifecycle events as the bump emits them.
+type jobEvents struct {
+	s   *apiServer
+	job *bumpJobStatus
+}
+
+func (j jobEvents) emit(_ context.Context, ev lifecycleEvent) error {
+	j.s.mu.Lock()
+	defer j.s.mu.Unlock()
+	j.job.events = append(j.job.events, ev)
+	j.job.notify()
+	return nil
+}
+
+// notify wakes whoever follows the job; the caller holds apiServer.mu.
+func (j *bumpJobStatus) notify() {
+	close(j.changed)
+	j.changed = make(chan struct{})
+}
+
+// runServe serves the API on opts.ServeAddr until interrupted, then waits
+// for running jobs to wind down (they stop like an interrupted bump).
+func runServe(ctx context.Context, opts *AWSOpts) error {
+	if opts.Profile == "" {
+		return fmt.Errorf("--profile is required")
+	}
+	if opts.Pick {
+		return fmt.Errorf("--pick cannot be used with serve")
+	}
+	if opts.APIToken == "" {
+		opts.APIToken = os.Getenv("SERVE_API_TOKEN")
+	}
+	if err := checkServeToken(opts); err != nil {
+		return err
+	}
+	metrics, stopMetrics, err := startMetrics(ctx, opts.MetricsAddr)
+	if err != nil {
+		return err
+	}
+	defer stopMetrics()
+
+	s := &apiServer{
+		ctx:     ctx,
+		opts:    opts,
+		clients: newClientFactory(opts.Profile, opts.APITimeout, opts.MaxRPS),
+		metrics: metrics,
+		jobs:    make(map[string]*bumpJobStatus),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/functions", s.listFunctions)
+	mux.HandleFunc("POST /v1/jobs", s.postJob)
+	mux.HandleFunc("GET /v1/jobs", s.listJobs)
+	mux.HandleFunc("GET /v1/jobs/{id}", s.getJob)
+
+	if opts.GRPCAddr != "" {
+		stopGRPC, err := s.serveGRPC(opts.GRPCAddr)
+		if err != nil {
+			return err
+		}
+		defer stopGRPC()
+	}
+
+	ln, err := net.Listen("tcp", opts.ServeAddr)
+	if err != nil {
+		return fmt.Errorf("api listener: %w", err)
+	}
+	srv := &http.Server{
+		Handler:           s.authenticate(mux),
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	fmt.Fprintf(os.Stderr, "Serving the API on %s\n", ln.Addr())
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	s.wg.Wait()
+	return nil
+}
+
+// checkServeToken refuses to serve without a token on anything but a
+// loopback address: whoever can reach the API starts bumps with the
+// server's AWS profile.
+func checkServeToken(opts *AWSOpts) error {
+	if opts.APIToken != "" {
+		return nil
+	}
+	for _, addr := range []string{opts.ServeAddr, opts.GRPCAddr} {
+		if addr != "" && !isLoopback(addr) {
+			return fmt.Errorf("--api-token (or SERVE_API_TOKEN) is required to listen on %s, which is not a loopback address", addr)
+		}
+	}
+	return nil
+}
+
+// isLoopback reports whether the listen address addr only accepts local
+// connections; an empty host means every interface.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// authenticate requires "Authorization: Bearer <token>" when a token is set.
+func (s *apiServer) authenticate(next http.Handler) http.Handler {
+	if s.opts.APIToken == "" {
+		return next
+	}
+	want := []byte("Bearer " + s.opts.APIToken)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) != 1 {
+			writeError(w, http.StatusUnauthorized, errors.New("missing or wrong bearer token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// listFunctions returns every function in the server's regions, or those
+// in ?regions=a,b.
+func (s *apiServer) listFunctions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	opts := *s.opts
+	opts.All, opts.FunctionName = true, ""
+	if v := r.URL.Query().Get("regions"); v != "" {
+		opts.Regions = strings.Split(v, ",")
+	}
+	if err := validateCommon(&opts); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	acctID, err := resolveAccountID(ctx, s.clients)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Errorf("resolve account id: %w", err))
+		return
+	}
+	inv, err := newDiscoverer(ctx, s.clients, &opts)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	out := []functionResult{}
+	for _, region := range opts.Regions {
+		cli, err := s.clients.Lambda(ctx, region)
+		if err == nil {
+			err = inv.stream(ctx, cli, region, func(f inventory.Function) {
+				out = append(out, functionResult{AccountID: acctID, Profile: opts.Profile, Region: region, Name: f.Name, Runtime: f.Runtime})
+			})
+		}
+		if err != nil {
+			writeError(w, http.StatusBadGateway, fmt.Errorf("%s: %w", region, err))
+			return
+		}
+	}
+	sortResults(out)
+	writeJSON(w, http.StatusOK, out)
+}
+
+// postJob validates a bump request and runs it in the background,
+// answering 202 with the job to poll.
+func (s *apiServer) postJob(w http.ResponseWriter, r *http.Request) {
+	var req bumpJobRequest
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	opts, err := s.jobOpts(req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	job, err := s.startJob(opts, req)
+	if errors.Is(err, errJobOverlaps) {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Location", "/v1/jobs/"+job.ID)
+	s.writeJob(w, http.StatusAccepted, job)
+}
+
+// jobOpts is the server's flags overridden by req, validated for a bump.
+func (s *apiServer) jobOpts(req bumpJobRequest) (*AWSOpts, error) {
+	opts := *s.opts
+	if len(req.Regions) > 0 {
+		opts.Regions = req.Regions
+	}
+	opts.FunctionName, opts.All = req.Function, req.All
+	if req.SourceRuntime != "" || req.TargetRuntime != "" {
+		opts.SourceRuntime = cmp.Or(req.SourceRuntime, opts.SourceRuntime)
+		opts.TargetRuntime = cmp.Or(req.TargetRuntime, opts.TargetRuntime)
+		opts.PolicyFile = ""
+	}
+	if err := validateBump(&opts); err != nil {
+		return nil, err
+	}
+	return &opts, nil
+}
+
+// startJob runs a bump with opts in the background and returns its job,
+// or errJobOverlaps while a running job selects the same functions.
+func (s *apiServer) startJob(opts *AWSOpts, req bumpJobRequest) (*bumpJobStatus, error) {
+	id, err := newJobID()
+	if err != nil {
+		return nil, err
+	}
+	job := &bumpJobStatus{ID: id, State: jobRunning, Request: req, StartedAt: time.Now().UTC(), opts: opts, out: &jobOutput{}, changed: make(chan struct{})}
+	s.mu.Lock()
+	s.evictJobs(job.StartedAt)
+	for _, other := range s.jobs {
+		if other.FinishedAt == nil && other.opts != nil && overlaps(opts, other.opts) {
+			s.mu.Unlock()
+			return nil, fmt.Errorf("%w: job %s", errJobOverlaps, other.ID)
+		}
+	}
+	s.jobs[id] = job
+	s.mu.Unlock()
+
+	// The job outlives the request but not the server.
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		rep, err := bumpAndRender(s.ctx, job.out, job.out, opts, s.metrics, jobEvents{s, job})
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		now := time.Now().UTC()
+		job.FinishedAt, job.Report, job.State = &now, rep, jobSucceeded
+		if err != nil {
+			job.State, job.Error = jobFailed, err.Error()
+		} else if len(rep.failures()) > 0 {
+			job.State = jobFailed
+		}
+		job.notify()
+	}()
+	return job, nil
+}
+
+// overlaps reports whether jobs with a and b can select the same function:
+// they share a region, and one selects every function or both the same.
+func overlaps(a, b *AWSOpts) bool {
+	if !slices.ContainsFunc(a.Regions, func(r string) bool { return slices.Contains(b.Regions, r) }) {
+		return false
+	}
+	return a.All || b.All || a.FunctionName == b.FunctionName
+}
+
+// evictJobs forgets the jobs that finished more than finishedJobTTL before
+// now, then the oldest finished beyond maxFinishedJobs. Running jobs are
+// kept. The caller holds s.mu.
+func (s *apiServer) evictJobs(now time.Time) {
+	var finished []*bumpJobStatus
+	for id, j := range s.jobs {
+		switch {
+		case j.FinishedAt == nil:
+		case now.Sub(*j.FinishedAt) > finishedJobTTL:
+			delete(s.jobs, id)
+		default:
+			finished = append(finished, j)
+		}
+	}
+	if len(finished) <= maxFinishedJobs {
+		return
+	}
+	slices.SortFunc(finished, func(a, b *bumpJobStatus) int { return b.FinishedAt.Compare(*a.FinishedAt) })
+	for _, j := range finished[maxFinishedJobs:] {
+		delete(s.jobs, j.ID)
+	}
+}
+
+func (s *apiServer) listJobs(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evictJobs(time.Now())
+	out := []*bumpJobStatus{}
+	for _, j := range s.jobs {
+		j.readOutput()
+		out = append(out, j)
+	}
+	slices.SortFunc(out, func(a, b *bumpJobStatus) int { return b.StartedAt.Compare(a.StartedAt) })
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *apiServer) getJob(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	job, ok := s.jobs[r.PathValue("id")]
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("no such job"))
+		return
+	}
+	s.writeJob(w, http.StatusOK, job)
+}
+
+// writeJob writes job under the lock its goroutine updates it with.
+func (s *apiServer) writeJob(w http.ResponseWriter, code int, job *bumpJobStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job.readOutput()
+	writeJSON(w, code, job)
+}
+
+// readOutput brings the job's Output up to date; the caller holds
+// apiServer.mu.
+func (j *bumpJobStatus) readOutput() {
+	if j.out != nil {
+		j.Output = j.out.String()
+	}
+}
+
+func newJobID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
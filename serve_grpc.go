@@ -0,0 +1,324 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	apiv1 "update-lambda-runtime/pkg/api/v1"
+)
+
+// grpcServer is the gRPC face of apiServer: the same flows and jobs as the
+// REST API, with progress streamed instead of polled.
+type grpcServer struct {
+	apiv1.UnimplementedRuntimeServiceServer
+	api *apiServer
+}
+
+// serveGRPC serves the gRPC API on addr in the background. stop waits for
+// open streams, so call it once jobs have ended.
+func (s *apiServer) serveGRPC(addr string) (stop func(), err error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("grpc listener: %w", err)
+	}
+	srv := s.newGRPCServer()
+	fmt.Fprintf(os.Stderr, "Serving the gRPC API on %s\n", ln.Addr())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := srv.Serve(ln); err != nil {
+			fmt.Fprintln(os.Stderr, "warning: gRPC API stopped:", err)
+		}
+	}()
+	return func() {
+		srv.GracefulStop()
+		<-done
+	}, nil
+}
+
+func (s *apiServer) newGRPCServer() *grpc.Server {
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			if err := s.authorizeGRPC(ctx); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := s.authorizeGRPC(ss.Context()); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	)
+	apiv1.RegisterRuntimeServiceServer(srv, &grpcServer{api: s})
+	return srv
+}
+
+// authorizeGRPC requires "authorization: Bearer <token>" metadata when a
+// token is set, like the REST API's header.
+func (s *apiServer) authorizeGRPC(ctx context.Context) error {
+	if s.opts.APIToken == "" {
+		return nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	got := md.Get("authorization")
+	if len(got) != 1 || subtle.ConstantTimeCompare([]byte(got[0]), []byte("Bearer "+s.opts.APIToken)) != 1 {
+		return status.Error(codes.Unauthenticated, "missing or wrong bearer token")
+	}
+	return nil
+}
+
+func (g *grpcServer) Inventory(req *apiv1.InventoryRequest, stream apiv1.RuntimeService_InventoryServer) error {
+	opts := *g.api.opts
+	opts.All, opts.FunctionName = true, ""
+	if len(req.Regions) > 0 {
+		opts.Regions = req.Regions
+	}
+	if err := validateCommon(&opts); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return g.streamInventory(stream, &opts, false)
+}
+
+func (g *grpcServer) Plan(req *apiv1.BumpRequest, stream apiv1.RuntimeService_PlanServer) error {
+	opts, err := g.api.jobOpts(bumpRequestFromProto(req))
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return g.streamInventory(stream, opts, true)
+}
+
+// streamInventory runs the list flow with opts, sending what it finds; with
+// plan only the functions the runtime policy bumps, with their targets.
+// Discovery errors are sent like functions rather than failing the call.
+func (g *grpcServer) streamInventory(stream grpc.ServerStreamingServer[apiv1.InventoryEvent], opts *AWSOpts, plan bool) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	defer context.AfterFunc(g.api.ctx, cancel)()
+
+	sink := &inventoryStream{stream: stream, opts: opts, plan: plan, cancel: cancel}
+	_, err := listOnce(ctx, opts, g.api.metrics, sink)
+	switch {
+	case sink.err != nil:
+		return sink.err
+	case ctx.Err() != nil:
+		return status.FromContextError(ctx.Err()).Err()
+	case err != nil && !errors.Is(err, errDiscovery):
+		return err
+	}
+	return nil
+}
+
+// inventoryStream is the listSink of an Inventory or Plan call. It stops
+// the flow when the client has gone.
+type inventoryStream struct {
+	stream grpc.ServerStreamingServer[apiv1.InventoryEvent]
+	opts   *AWSOpts
+	plan   bool
+	cancel context.CancelFunc
+	err    error
+}
+
+func (s *inventoryStream) start() {}
+
+func (s *inventoryStream) listed(r functionResult) {
+	if s.plan {
+		target, ok := s.opts.Policy.Target(r.Name, r.Runtime)
+		if !ok || isEdgeReplica(r.Region, r.Name) {
+			return
+		}
+		r.TargetRuntime = target
+	}
+	s.send(&apiv1.InventoryEvent{Event: &apiv1.InventoryEvent_Function{Function: functionToProto(r)}})
+}
+
+func (s *inventoryStream) failed(e discoveryError) {
+	s.send(&apiv1.InventoryEvent{Event: &apiv1.InventoryEvent_Error{Error: discoveryErrorToProto(e)}})
+}
+
+func (s *inventoryStream) send(ev *apiv1.InventoryEvent) {
+	if s.err != nil {
+		return
+	}
+	if err := s.stream.Send(ev); err != nil {
+		s.err = err
+		s.cancel()
+	}
+}
+
+func (g *grpcServer) Apply(req *apiv1.BumpRequest, stream apiv1.RuntimeService_ApplyServer) error {
+	breq := bumpRequestFromProto(req)
+	opts, err := g.api.jobOpts(breq)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	job, err := g.api.startJob(opts, breq)
+	if errors.Is(err, errJobOverlaps) {
+		return status.Error(codes.Aborted, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+	return g.follow(stream, job, true)
+}
+
+func (g *grpcServer) Status(ctx context.Context, req *apiv1.JobRequest) (*apiv1.Job, error) {
+	job, err := g.job(req.Id)
+	if err != nil {
+		return nil, err
+	}
+	g.api.mu.Lock()
+	defer g.api.mu.Unlock()
+	return jobToProto(job), nil
+}
+
+func (g *grpcServer) Watch(req *apiv1.JobRequest, stream apiv1.RuntimeService_WatchServer) error {
+	job, err := g.job(req.Id)
+	if err != nil {
+		return err
+	}
+	return g.follow(stream, job, false)
+}
+
+func (g *grpcServer) job(id string) (*bumpJobStatus, error) {
+	g.api.mu.Lock()
+	defer g.api.mu.Unlock()
+	job, ok := g.api.jobs[id]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "no such job %q", id)
+	}
+	return job, nil
+}
+
+// follow sends the job as it is, then its lifecycle events as they happen,
+// then the job again once it has ended. With replay the events so far are
+// sent too, so a job just started loses none.
+func (g *grpcServer) follow(stream grpc.ServerStreamingServer[apiv1.JobEvent], job *bumpJobStatus, replay bool) error {
+	mu := &g.api.mu
+	mu.Lock()
+	first := jobToProto(job)
+	seen := len(job.events)
+	if replay {
+		seen = 0
+	}
+	mu.Unlock()
+	if err := stream.Send(&apiv1.JobEvent{Event: &apiv1.JobEvent_Job{Job: first}}); err != nil {
+		return err
+	}
+	if first.FinishedAt != nil && !replay {
+		return nil
+	}
+	for {
+		mu.Lock()
+		events := job.events[seen:]
+		seen = len(job.events)
+		changed := job.changed
+		var last *apiv1.Job
+		if job.FinishedAt != nil {
+			last = jobToProto(job)
+		}
+		mu.Unlock()
+
+		for _, ev := range events {
+			if err := stream.Send(&apiv1.JobEvent{Event: &apiv1.JobEvent_Function{Function: functionEventToProto(ev)}}); err != nil {
+				return err
+			}
+		}
+		if last != nil {
+			return stream.Send(&apiv1.JobEvent{Event: &apiv1.JobEvent_Job{Job: last}})
+		}
+		select {
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		case <-changed:
+		}
+	}
+}
+
+func bumpRequestFromProto(req *apiv1.BumpRequest) bumpJobRequest {
+	return bumpJobRequest{
+		Regions:       req.Regions,
+		Function:      req.Function,
+		All:           req.All,
+		SourceRuntime: req.SourceRuntime,
+		TargetRuntime: req.TargetRuntime,
+	}
+}
+
+func functionToProto(r functionResult) *apiv1.Function {
+	return &apiv1.Function{
+		AccountId:     r.AccountID,
+		Profile:       r.Profile,
+		Region:        r.Region,
+		Name:          r.Name,
+		Runtime:       r.Runtime,
+		Edge:          r.Edge,
+		TargetRuntime: r.TargetRuntime,
+		Outcome:       string(r.Outcome),
+	}
+}
+
+func discoveryErrorToProto(e discoveryError) *apiv1.DiscoveryError {
+	return &apiv1.DiscoveryError{AccountId: e.AccountID, Region: e.Region, Function: e.Function, Error: e.Error}
+}
+
+func functionEventToProto(ev lifecycleEvent) *apiv1.FunctionEvent {
+	return &apiv1.FunctionEvent{
+		Type: ev.Type,
+		Time: timestamppb.New(ev.Time),
+		Function: &apiv1.Function{
+			AccountId:     ev.AccountID,
+			Region:        ev.Region,
+			Name:          ev.FunctionName,
+			Runtime:       ev.SourceRuntime,
+			TargetRuntime: ev.TargetRuntime,
+			Outcome:       string(ev.Outcome),
+		},
+	}
+}
+
+// jobToProto converts job; the caller holds apiServer.mu.
+func jobToProto(job *bumpJobStatus) *apiv1.Job {
+	req := job.Request
+	out := &apiv1.Job{
+		Id:    job.ID,
+		State: job.State,
+		Request: &apiv1.BumpRequest{
+			Regions:       req.Regions,
+			Function:      req.Function,
+			All:           req.All,
+			SourceRuntime: req.SourceRuntime,
+			TargetRuntime: req.TargetRuntime,
+		},
+		StartedAt: timestamppb.New(job.StartedAt),
+		Error:     job.Error,
+	}
+	if job.FinishedAt != nil {
+		out.FinishedAt = timestamppb.New(*job.FinishedAt)
+	}
+	if rep := job.Report; rep != nil {
+		out.RunId = rep.RunID
+		for _, r := range rep.Results {
+			out.Results = append(out.Results, functionToProto(r))
+		}
+		for _, e := range rep.Errors {
+			out.Errors = append(out.Errors, discoveryErrorToProto(e))
+		}
+		out.Counts = make(map[string]int32, len(rep.Counts))
+		for k, n := range rep.Counts {
+			out.Counts[k] = int32(n)
+		}
+	}
+	return out
+}
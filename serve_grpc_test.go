@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	apiv1 "update-lambda-runtime/pkg/api/v1"
+	"update-lambda-runtime/pkg/bump"
+)
+
+// dialGRPC serves s's gRPC API in memory and returns a client for it.
+func dialGRPC(t *testing.T, s *apiServer) apiv1.RuntimeServiceClient {
+	t.Helper()
+	ln := bufconn.Listen(1 << 20)
+	srv := s.newGRPCServer()
+	go srv.Serve(ln)
+	t.Cleanup(srv.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return apiv1.NewRuntimeServiceClient(conn)
+}
+
+func TestGRPCWatch(t *testing.T) {
+	job := &bumpJobStatus{ID: "j1", State: jobRunning, StartedAt: time.Now(), changed: make(chan struct{})}
+	s := &apiServer{ctx: context.Background(), opts: &AWSOpts{}, jobs: map[string]*bumpJobStatus{"j1": job}}
+	cli := dialGRPC(t, s)
+
+	stream, err := cli.Watch(context.Background(), &apiv1.JobRequest{Id: "j1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev, err := stream.Recv()
+	if err != nil || ev.GetJob().GetState() != jobRunning {
+		t.Fatalf("first event = %v, %v; want the running job", ev, err)
+	}
+
+	r := functionResult{AccountID: "123456789012", Region: "us-east-1", Name: "api", Runtime: "python3.9", TargetRuntime: "python3.12", Outcome: bump.Updated}
+	(&lifecycle{sinks: []eventSink{jobEvents{s, job}}}).finished(context.Background(), r)
+	s.mu.Lock()
+	now := time.Now()
+	job.State, job.FinishedAt = jobSucceeded, &now
+	job.Report = &runReport{RunID: "run-1", Results: []functionResult{r}, Counts: map[string]int{string(bump.Updated): 1}}
+	job.notify()
+	s.mu.Unlock()
+
+	ev, err = stream.Recv()
+	if err != nil || ev.GetFunction().GetType() != eventUpdated || ev.GetFunction().GetFunction().GetName() != "api" {
+		t.Fatalf("second event = %v, %v; want api updated", ev, err)
+	}
+	ev, err = stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j := ev.GetJob(); j.GetState() != jobSucceeded || j.GetRunId() != "run-1" || j.GetCounts()["updated"] != 1 || len(j.GetResults()) != 1 {
+		t.Errorf("last event = %v, want the finished job", ev)
+	}
+	if _, err := stream.Recv(); err == nil {
+		t.Error("stream did not end with the job")
+	}
+
+	if _, err := cli.Status(context.Background(), &apiv1.JobRequest{Id: "nope"}); status.Code(err) != codes.NotFound {
+		t.Errorf("Status of an unknown job: %v", err)
+	}
+}
+
+func TestGRPCToken(t *testing.T) {
+	s := &apiServer{ctx: context.Background(), opts: &AWSOpts{APIToken: "s3cret"}, jobs: map[string]*bumpJobStatus{}}
+	cli := dialGRPC(t, s)
+
+	if _, err := cli.Status(context.Background(), &apiv1.JobRequest{Id: "j1"}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("without a token: %v", err)
+	}
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer s3cret")
+	if _, err := cli.Status(ctx, &apiv1.JobRequest{Id: "j1"}); status.Code(err) != codes.NotFound {
+		t.Errorf("with the token: %v", err)
+	}
+	stream, err := cli.Watch(context.Background(), &apiv1.JobRequest{Id: "j1"})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("streaming without a token: %v", err)
+	}
+}
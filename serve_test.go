@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStartJobOverlaps(t *testing.T) {
+	running := &bumpJobStatus{ID: "j1", State: jobRunning, opts: &AWSOpts{Regions: []string{"us-east-1", "eu-west-1"}, FunctionName: "api"}}
+	s := &apiServer{opts: &AWSOpts{}, jobs: map[string]*bumpJobStatus{"j1": running}}
+	for _, opts := range []*AWSOpts{
+		{Regions: []string{"eu-west-1"}, FunctionName: "api"},
+		{Regions: []string{"us-east-1"}, All: true},
+	} {
+		if _, err := s.startJob(opts, bumpJobRequest{}); !errors.Is(err, errJobOverlaps) {
+			t.Errorf("job %+v: %v, want errJobOverlaps", opts, err)
+		}
+	}
+	for _, opts := range []*AWSOpts{
+		{Regions: []string{"us-east-1"}, FunctionName: "worker"},
+		{Regions: []string{"ap-south-1"}, All: true},
+	} {
+		if overlaps(opts, running.opts) {
+			t.Errorf("job %+v overlaps %+v", opts, running.opts)
+		}
+	}
+	if len(s.jobs) != 1 {
+		t.Errorf("%d jobs, want the refused ones not kept", len(s.jobs))
+	}
+}
+
+func TestEvictJobs(t *testing.T) {
+	now := time.Now()
+	s := &apiServer{jobs: map[string]*bumpJobStatus{"running": {ID: "running", State: jobRunning}}}
+	old := now.Add(-finishedJobTTL - time.Minute)
+	s.jobs["old"] = &bumpJobStatus{ID: "old", State: jobSucceeded, FinishedAt: &old}
+	for i := range maxFinishedJobs + 2 {
+		at := now.Add(-time.Duration(i) * time.Minute)
+		id := fmt.Sprint("done-", i)
+		s.jobs[id] = &bumpJobStatus{ID: id, State: jobSucceeded, FinishedAt: &at}
+	}
+	s.evictJobs(now)
+	if _, ok := s.jobs["running"]; !ok {
+		t.Error("running job evicted")
+	}
+	if _, ok := s.jobs["old"]; ok {
+		t.Error("job finished beyond finishedJobTTL kept")
+	}
+	if len(s.jobs) != maxFinishedJobs+1 {
+		t.Errorf("%d jobs kept, want the running one and %d finished", len(s.jobs), maxFinishedJobs)
+	}
+	for i := range 2 {
+		if id := fmt.Sprint("done-", maxFinishedJobs+i); s.jobs[id] != nil {
+			t.Errorf("%s kept over newer jobs", id)
+		}
+	}
+}
+
+func TestServeToken(t *testing.T) {
+	s := &apiServer{opts: &AWSOpts{APIToken: "s3cret"}, jobs: map[string]*bumpJobStatus{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/jobs", s.listJobs)
+	srv := httptest.NewServer(s.authenticate(mux))
+	defer srv.Close()
+	for token, want := range map[string]int{"": http.StatusUnauthorized, "Bearer wrong": http.StatusUnauthorized, "Bearer s3cret": http.StatusOK} {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/v1/jobs", nil)
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("Authorization %q: %d, want %d", token, resp.StatusCode, want)
+		}
+	}
+
+	for _, tc := range []struct {
+		opts AWSOpts
+		ok   bool
+	}{
+		{AWSOpts{ServeAddr: "127.0.0.1:8080"}, true},
+		{AWSOpts{ServeAddr: "localhost:8080", GRPCAddr: "[::1]:9443"}, true},
+		{AWSOpts{ServeAddr: ":8080"}, false},
+		{AWSOpts{ServeAddr: "127.0.0.1:8080", GRPCAddr: ":9443"}, false},
+		{AWSOpts{ServeAddr: "0.0.0.0:8080"}, false},
+		{AWSOpts{ServeAddr: ":8080", APIToken: "s3cret"}, true},
+	} {
+		if err := checkServeToken(&tc.opts); (err == nil) != tc.ok {
+			t.Errorf("listen %q, grpc %q, token %q: %v", tc.opts.ServeAddr, tc.opts.GRPCAddr, tc.opts.APIToken, err)
+		}
+	}
+}
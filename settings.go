@@ -0,0 +1,155 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// settingsEnvPrefix prefixes the environment variable of every flag:
+// --wait-timeout is ULR_WAIT_TIMEOUT.
+const settingsEnvPrefix = "ULR"
+
+// renamedFlags maps the deprecated names of flags to their new ones: the
+// old name still works, but never over the new one given on the command
+// line.
+var renamedFlags = map[string]string{"config": "policy"}
+
+// defaultSettingsPath is the settings file read when --config-file is not
+// given, e.g. ~/.config/update-lambda-runtime/config.yaml on Linux.
+func defaultSettingsPath() (string, error) {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "update-lambda-runtime", "config.yaml"), nil
+}
+
+// applySettings fills in every flag of cmd not given on the command line
+// from ULR_* environment variables, then from the environment preset
+// --env names, then from the settings file, whose keys are flag names.
+// Presets are sets of such keys under environments, e.g.
+//
+//	environments:
+//	  prod: {profile: prod-admin, regions: [us-east-1, eu-west-1]}
+//	  dev: {profile: dev-admin, regions: [us-east-1]}
+//
+// path names the file; when empty, the default one is read if it exists.
+func applySettings(cmd *cobra.Command, path string) error {
+	v := viper.New()
+	v.SetEnvPrefix(settingsEnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	v.AutomaticEnv()
+
+	explicit := path != ""
+	if !explicit {
+		path, _ = defaultSettingsPath()
+	}
+	if path != "" {
+		v.SetConfigFile(path)
+		v.SetConfigType("yaml") // JSON too, whatever the extension
+		if err := v.ReadInConfig(); err != nil && (explicit || !errors.Is(err, fs.ErrNotExist)) {
+			return fmt.Errorf("--config-file %s: %w", path, err)
+		}
+	}
+
+	preset, name, err := environmentPreset(cmd, v, path)
+	if err != nil {
+		return err
+	}
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if err != nil || f.Changed || f.Name == "config-file" {
+			return
+		}
+		if renamed, ok := renamedFlags[f.Name]; ok && cmd.Flags().Changed(renamed) {
+			return
+		}
+		var val any
+		var from string
+		envVar := settingsEnvPrefix + "_" + envKey(f.Name)
+		if _, ok := os.LookupEnv(envVar); ok {
+			val, from = v.Get(f.Name), envVar
+		} else if preset != nil && preset.IsSet(f.Name) {
+			val, from = preset.Get(f.Name), fmt.Sprintf("environment %s in %s", name, path)
+		} else if v.IsSet(f.Name) {
+			val, from = v.Get(f.Name), path
+		} else {
+			return
+		}
+		if serr := setFlag(f, val); serr != nil {
+			err = fmt.Errorf("--%s from %s: %w", f.Name, from, serr)
+		} else if f.Deprecated != "" {
+			fmt.Fprintf(os.Stderr, "warning: %s sets --%s, which is deprecated: %s\n", from, f.Name, f.Deprecated)
+		}
+	})
+	return err
+}
+
+// environmentPreset returns the settings of the environment named by
+// --env (or ULR_ENV, or env in the settings file), and its name; nil when
+// none is named.
+func environmentPreset(cmd *cobra.Command, v *viper.Viper, path string) (*viper.Viper, string, error) {
+	name := v.GetString("env")
+	if f := cmd.Flags().Lookup("env"); f != nil && f.Changed {
+		name = f.Value.String()
+	}
+	if name == "" {
+		return nil, "", nil
+	}
+	// Viper lowercases keys, so names match whatever their case.
+	preset := v.Sub("environments." + strings.ToLower(name))
+	if preset == nil {
+		have := slices.Sorted(maps.Keys(v.GetStringMap("environments")))
+		return nil, "", fmt.Errorf("--env %s: no such environment in %s (have: %s)", name, cmp.Or(path, "the settings file"), cmp.Or(strings.Join(have, ", "), "none"))
+	}
+	fmt.Fprintf(os.Stderr, "Using environment %s from %s\n", name, path)
+	return preset, name, nil
+}
+
+// setFlag sets f to val as if it had been given on the command line. val
+// is a string from the environment or a YAML value, so lists and maps may
+// also be sequences and mappings.
+func setFlag(f *pflag.Flag, val any) error {
+	switch val := val.(type) {
+	case []any:
+		items := make([]string, len(val))
+		for i, item := range val {
+			items[i] = fmt.Sprint(item)
+		}
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			if err := sv.Replace(items); err != nil {
+				return err
+			}
+			break
+		}
+		// Each item of a list for a map flag is one key=value pair.
+		for _, item := range items {
+			if err := f.Value.Set(item); err != nil {
+				return err
+			}
+		}
+	case map[string]any:
+		// Viper lowercases mapping keys, which would mangle layer ARNs.
+		return errors.New("want a list of key=value strings, not a mapping")
+	default:
+		if err := f.Value.Set(fmt.Sprint(val)); err != nil {
+			return err
+		}
+	}
+	f.Changed = true
+	return nil
+}
+
+func envKey(flag string) string {
+	return strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func writeSettings(t *testing.T, doc string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(doc), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestApplySettings(t *testing.T) {
+	path := writeSettings(t, `
+profile: from-file
+regions: [us-east-1, eu-west-1]
+wait-timeout: 2m
+concurrency: 4
+all: true
+layer-map:
+  - arn:aws:lambda:us-east-1:123456789012:layer:Deps=arn:aws:lambda:us-east-1:123456789012:layer:Deps312:1
+`)
+	t.Setenv("ULR_CONCURRENCY", "8")
+	t.Setenv("ULR_PROFILE", "from-env")
+
+	opts := &AWSOpts{Timeout: 5 * time.Minute, Concurrency: 1}
+	cmd := &cobra.Command{Use: "bump", RunE: func(*cobra.Command, []string) error { return nil }}
+	cmd.Flags().StringVar(&opts.Profile, "profile", "", "")
+	cmd.Flags().StringSliceVar(&opts.Regions, "regions", nil, "")
+	cmd.Flags().DurationVar(&opts.Timeout, "wait-timeout", opts.Timeout, "")
+	cmd.Flags().IntVar(&opts.Concurrency, "concurrency", opts.Concurrency, "")
+	cmd.Flags().BoolVar(&opts.All, "all", false, "")
+	cmd.Flags().StringToStringVar(&opts.LayerMap, "layer-map", nil, "")
+	cmd.Flags().DurationVar(&opts.PollEvery, "wait-interval", time.Second, "")
+	if err := cmd.ParseFlags([]string{"--profile", "from-flag"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := applySettings(cmd, path); err != nil {
+		t.Fatal(err)
+	}
+
+	if opts.Profile != "from-flag" {
+		t.Errorf("profile = %q, want the flag to win", opts.Profile)
+	}
+	if opts.Concurrency != 8 {
+		t.Errorf("concurrency = %d, want ULR_CONCURRENCY to win over the file", opts.Concurrency)
+	}
+	if !slices.Equal(opts.Regions, []string{"us-east-1", "eu-west-1"}) || opts.Timeout != 2*time.Minute || !opts.All {
+		t.Errorf("regions %v, wait-timeout %s, all %t not read from the file", opts.Regions, opts.Timeout, opts.All)
+	}
+	if got := opts.LayerMap["arn:aws:lambda:us-east-1:123456789012:layer:Deps"]; !strings.HasSuffix(got, "layer:Deps312:1") {
+		t.Errorf("layer-map = %v", opts.LayerMap)
+	}
+	if opts.PollEvery != time.Second {
+		t.Errorf("wait-interval = %s, want its default", opts.PollEvery)
+	}
+}
+
+func TestApplySettingsEnvironment(t *testing.T) {
+	path := writeSettings(t, `
+profile: default-profile
+wait-timeout: 2m
+environments:
+  prod:
+    profile: prod-admin
+    regions: [us-east-1, eu-west-1]
+  dev:
+    profile: dev-admin
+`)
+	t.Setenv("ULR_WAIT_TIMEOUT", "3m")
+	newCmd := func(opts *AWSOpts) *cobra.Command {
+		cmd := &cobra.Command{Use: "bump"}
+		cmd.Flags().StringVar(&opts.Environment, "env", "", "")
+		cmd.Flags().StringVar(&opts.Profile, "profile", "", "")
+		cmd.Flags().StringSliceVar(&opts.Regions, "regions", nil, "")
+		cmd.Flags().DurationVar(&opts.Timeout, "wait-timeout", time.Minute, "")
+		return cmd
+	}
+
+	var opts AWSOpts
+	cmd := newCmd(&opts)
+	if err := cmd.ParseFlags([]string{"--env", "Prod"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := applySettings(cmd, path); err != nil {
+		t.Fatal(err)
+	}
+	if opts.Profile != "prod-admin" || !slices.Equal(opts.Regions, []string{"us-east-1", "eu-west-1"}) {
+		t.Errorf("profile %q, regions %v not read from the prod preset", opts.Profile, opts.Regions)
+	}
+	if opts.Timeout != 3*time.Minute {
+		t.Errorf("wait-timeout = %s, want ULR_WAIT_TIMEOUT to win over the file", opts.Timeout)
+	}
+
+	opts = AWSOpts{}
+	cmd = newCmd(&opts)
+	if err := cmd.ParseFlags([]string{"--env", "staging"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := applySettings(cmd, path); err == nil || !strings.Contains(err.Error(), "have: dev, prod") {
+		t.Errorf("err = %v, want the environments listed", err)
+	}
+}
+
+func TestApplySettingsErrors(t *testing.T) {
+	tests := []struct {
+		name, doc, env, want string
+	}{
+		{name: "bad duration", doc: "wait-timeout: soon", want: "--wait-timeout"},
+		{name: "bad env", env: "x", want: "ULR_CONCURRENCY"},
+		{name: "mapping for a map flag", doc: "layer-map: {a: b}", want: "key=value"},
+		{name: "missing file", want: "no such file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "missing.yaml")
+			if tt.doc != "" {
+				path = writeSettings(t, tt.doc)
+			}
+			if tt.env != "" {
+				t.Setenv("ULR_CONCURRENCY", tt.env)
+				path = writeSettings(t, "{}")
+			}
+			cmd := &cobra.Command{Use: "bump"}
+			cmd.Flags().Duration("wait-timeout", time.Minute, "")
+			cmd.Flags().Int("concurrency", 1, "")
+			cmd.Flags().StringToString("layer-map", nil, "")
+			err := applySettings(cmd, path)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplySettingsRenamedFlag(t *testing.T) {
+	path := writeSettings(t, "all: true\n")
+	t.Setenv("ULR_CONFIG", "old.json")
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{nil, "old.json"},
+		{[]string{"--policy", "new.json"}, "new.json"},
+	} {
+		opts := &AWSOpts{}
+		cmd := &cobra.Command{Use: "bump", RunE: func(*cobra.Command, []string) error { return nil }}
+		addPolicyFileFlag(cmd.Flags(), opts)
+		if err := cmd.ParseFlags(tc.args); err != nil {
+			t.Fatal(err)
+		}
+		if err := applySettings(cmd, path); err != nil {
+			t.Fatal(err)
+		}
+		if opts.PolicyFile != tc.want {
+			t.Errorf("args %v: policy %q, want %q", tc.args, opts.PolicyFile, tc.want)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"update-lambda-runtime/pkg/bump"
+	"update-lambda-runtime/pkg/inventory"
+)
+
+// staleVersion is a published version of a function bump updated that
+// still runs another runtime than the one $LATEST moved to. It stays
+// invocable, and keeps serving the aliases listed, until a new version is
+// published and they are moved to it.
+type staleVersion struct {
+	AccountID string   `json:"accountId"`
+	Region    string   `json:"region"`
+	Function  string   `json:"functionName"`
+	Version   string   `json:"version"`
+	Runtime   string   `json:"runtime"`
+	Aliases   []string `json:"aliases,omitempty"`
+}
+
+// findStaleVersions looks up the published versions and aliases of every
+// updated function in results. A function whose versions cannot be listed
+// is left out with a warning: the update itself went through.
+func findStaleVersions(ctx context.Context, clients *clientFactory, results []functionResult) []staleVersion {
+	var stale []staleVersion
+	for _, r := range results {
+		if r.Outcome != bump.Updated || ctx.Err() != nil {
+			continue
+		}
+		found, err := functionStaleVersions(ctx, clients, r)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: stale versions of %s not checked: %v\n", r.Name, err)
+			continue
+		}
+		stale = append(stale, found...)
+	}
+	return stale
+}
+
+func functionStaleVersions(ctx context.Context, clients *clientFactory, r functionResult) ([]staleVersion, error) {
+	cli, err := clients.Lambda(ctx, r.Region)
+	if err != nil {
+		return nil, err
+	}
+	var stale []staleVersion
+	err = inventory.Versions(ctx, cli, r.Name, func(v inventory.Function) {
+		if v.Runtime != r.TargetRuntime {
+			stale = append(stale, staleVersion{AccountID: r.AccountID, Region: r.Region, Function: r.Name, Version: v.Version, Runtime: v.Runtime})
+		}
+	})
+	if err != nil || len(stale) == 0 {
+		return nil, err
+	}
+	aliases, err := functionAliases(ctx, cli, r.Name)
+	if err != nil {
+		return nil, err
+	}
+	for i := range stale {
+		for _, a := range aliases {
+			for _, v := range a.versions {
+				if v.version == stale[i].Version {
+					stale[i].Aliases = append(stale[i].Aliases, a.name)
+				}
+			}
+		}
+	}
+	return stale, nil
+}
+
+// writeStaleVersions prints what findStaleVersions found, after the bump
+// table.
+func writeStaleVersions(w io.Writer, stale []staleVersion) {
+	if len(stale) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\nPublished versions still on the old runtime: %d. Before the deprecation date, publish a new version, move their aliases to it and delete the versions nothing needs:\n", len(stale))
+	for _, s := range stale {
+		aliases := "no alias"
+		if len(s.Aliases) > 0 {
+			aliases = "alias " + strings.Join(s.Aliases, ", ")
+		}
+		fmt.Fprintf(w, "  %s/%s:%s  %s  (%s)\n", s.Region, s.Function, s.Version, s.Runtime, aliases)
+	}
+}
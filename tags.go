@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+)
+
+// tagWidth sizes each tag column; longer values push the row out.
+const tagWidth = 16
+
+// tagCacheFile is a region's cached tags, by function name, kept next to
+// the inventory cache.
+type tagCacheFile struct {
+	SavedAt time.Time                    `json:"savedAt"`
+	Tags    map[string]map[string]string `json:"tags"`
+}
+
+// tagLookup fetches the tags of listed functions for --show-tags, a batch
+// at a time, and keeps them on disk with --cache so repeated listings skip
+// the calls.
+type tagLookup struct {
+	clients *clientFactory
+	cache   *inventoryCache // nil without --cache or --offline
+	ttl     time.Duration
+	offline bool
+
+	regions map[string]*tagCacheFile
+}
+
+func newTagLookup(clients *clientFactory, cache *inventoryCache, opts *AWSOpts) *tagLookup {
+	return &tagLookup{clients: clients, cache: cache, ttl: opts.CacheTTL, offline: opts.Offline, regions: make(map[string]*tagCacheFile)}
+}
+
+func (l *tagLookup) path(region string) string {
+	return filepath.Join(l.cache.dir, region+".tags.json")
+}
+
+// cached returns region's tags, loaded from disk the first time when they
+// are fresh enough to use.
+func (l *tagLookup) cached(region string) *tagCacheFile {
+	if c, ok := l.regions[region]; ok {
+		return c
+	}
+	c := &tagCacheFile{SavedAt: time.Now().UTC(), Tags: make(map[string]map[string]string)}
+	if l.cache != nil {
+		var disk tagCacheFile
+		if b, err := os.ReadFile(l.path(region)); err == nil && json.Unmarshal(b, &disk) == nil &&
+			disk.Tags != nil && (l.offline || time.Since(disk.SavedAt) < l.ttl) {
+			c = &disk
+		}
+	}
+	l.regions[region] = c
+	return c
+}
+
+// fill sets the Tags of every result in rs, all from one region. Versions
+// and aliases share their function's tags. Functions not in the cache are
+// looked up listLookups at a time; one that cannot be is left untagged with
+// a warning.
+func (l *tagLookup) fill(ctx context.Context, region string, rs []functionResult) error {
+	c := l.cached(region)
+	var missing []string
+	for _, r := range rs {
+		name, _, _ := strings.Cut(r.Name, ":")
+		if _, ok := c.Tags[name]; !ok && !l.offline && !slices.Contains(missing, name) {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		cli, err := l.clients.Lambda(ctx, region)
+		if err != nil {
+			return err
+		}
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, listLookups)
+		for _, name := range missing {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func() {
+				defer func() { <-sem; wg.Done() }()
+				r := functionResult{AccountID: rs[0].AccountID, Region: region, Name: name}
+				out, err := cli.ListTags(ctx, &lambda.ListTagsInput{Resource: aws.String(functionARN(r))})
+				if err != nil {
+					if ctx.Err() == nil {
+						fmt.Fprintf(os.Stderr, "warning: tags for %s: %v\n", name, err)
+					}
+					return
+				}
+				mu.Lock()
+				c.Tags[name] = out.Tags
+				mu.Unlock()
+			}()
+		}
+		wg.Wait()
+	}
+	for i := range rs {
+		name, _, _ := strings.Cut(rs[i].Name, ":")
+		rs[i].Tags = c.Tags[name]
+	}
+	return nil
+}
+
+// save writes the tags looked up in region back to the cache, when there
+// is one and they did not come from it.
+func (l *tagLookup) save(region string) error {
+	c, ok := l.regions[region]
+	if l.cache == nil || l.offline || !ok {
+		return nil
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(l.cache.dir, 0o700); err != nil {
+		return err
+	}
+	tmp := l.path(region) + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, l.path(region))
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTagLookupOffline(t *testing.T) {
+	cache := &inventoryCache{dir: t.TempDir()}
+	b, _ := json.Marshal(tagCacheFile{
+		SavedAt: time.Now().Add(-48 * time.Hour),
+		Tags:    map[string]map[string]string{"api": {"team": "payments"}},
+	})
+	if err := os.WriteFile(filepath.Join(cache.dir, "us-east-1.tags.json"), b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	l := newTagLookup(nil, cache, &AWSOpts{Offline: true})
+	rs := []functionResult{
+		{Region: "us-east-1", Name: "api"},
+		{Region: "us-east-1", Name: "api:3"},
+		{Region: "us-east-1", Name: "worker"},
+	}
+	if err := l.fill(context.Background(), "us-east-1", rs); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range rs[:2] {
+		if r.Tags["team"] != "payments" {
+			t.Errorf("%s tags = %v, want team=payments", r.Name, r.Tags)
+		}
+	}
+	if rs[2].Tags != nil {
+		t.Errorf("uncached function tags = %v, want none offline", rs[2].Tags)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"update-lambda-runtime/pkg/inventory"
+)
+
+// tfState is the part of a Terraform state file (format version 4) that
+// holds Lambda functions.
+type tfState struct {
+	Version   int `json:"version"`
+	Resources []struct {
+		Module    string `json:"module"`
+		Mode      string `json:"mode"`
+		Type      string `json:"type"`
+		Name      string `json:"name"`
+		Instances []struct {
+			IndexKey   any `json:"index_key"`
+			Attributes struct {
+				FunctionName string `json:"function_name"`
+				Runtime      string `json:"runtime"`
+				ARN          string `json:"arn"`
+			} `json:"attributes"`
+		} `json:"instances"`
+	} `json:"resources"`
+}
+
+// scanTerraformState returns the runtime of every aws_lambda_function in
+// the state at src, a local path or s3://bucket/key, with the Terraform
+// address that owns it.
+func scanTerraformState(ctx context.Context, clients *clientFactory, regions []string, src string) ([]iacFinding, error) {
+	doc, err := readTerraformState(ctx, clients, regions, src)
+	if err != nil {
+		return nil, err
+	}
+	var st tfState
+	if err := json.Unmarshal(doc, &st); err != nil {
+		return nil, fmt.Errorf("not a Terraform state file: %w", err)
+	}
+	if st.Version != 4 {
+		return nil, fmt.Errorf("unsupported Terraform state version %d", st.Version)
+	}
+	var out []iacFinding
+	now := time.Now()
+	for _, r := range st.Resources {
+		if r.Mode != "managed" || r.Type != "aws_lambda_function" {
+			continue
+		}
+		addr := r.Type + "." + r.Name
+		if r.Module != "" {
+			addr = r.Module + "." + addr
+		}
+		for _, in := range r.Instances {
+			rt := in.Attributes.Runtime
+			if rt == "" { // container image
+				continue
+			}
+			entry, _ := inventory.Entry(rt)
+			entry.Runtime = rt
+			out = append(out, iacFinding{
+				File:     src,
+				Resource: addr + tfIndex(in.IndexKey),
+				Function: in.Attributes.FunctionName,
+				Region:   arnRegion(in.Attributes.ARN),
+				Runtime:  rt,
+				Status:   runtimePhase(entry, now),
+			})
+		}
+	}
+	return out, nil
+}
+
+// tfIndex renders an instance key the way Terraform addresses it: [0] for
+// count, ["name"] for for_each.
+func tfIndex(key any) string {
+	switch k := key.(type) {
+	case float64:
+		return "[" + strconv.FormatFloat(k, 'f', -1, 64) + "]"
+	case string:
+		return "[" + strconv.Quote(k) + "]"
+	}
+	return ""
+}
+
+// readTerraformState reads src from S3 (in the profile's region, or the
+// first of regions) or from disk.
+func readTerraformState(ctx context.Context, clients *clientFactory, regions []string, src string) ([]byte, error) {
+	loc, ok := strings.CutPrefix(src, "s3://")
+	if !ok {
+		return os.ReadFile(src)
+	}
+	bucket, key, ok := strings.Cut(loc, "/")
+	if !ok || bucket == "" || key == "" {
+		return nil, errors.New("want s3://bucket/key")
+	}
+	cli, err := newS3Client(ctx, clients, regions)
+	if err != nil {
+		return nil, err
+	}
+	out, err := cli.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer out.Body.Close()
+	return io.ReadAll(out.Body)
+}
+
+// newS3Client returns an S3 client in the profile's region, or the first
+// of regions when the profile has none.
+func newS3Client(ctx context.Context, clients *clientFactory, regions []string) (*s3.Client, error) {
+	cfg, err := clients.Config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Region == "" && len(regions) > 0 {
+		cfg.Region = regions[0]
+	}
+	return s3.NewFromConfig(cfg), nil
+}
+
+// arnRegion is the region of ARN s, or "" when s is not an ARN.
+func arnRegion(s string) string {
+	a, err := arn.Parse(s)
+	if err != nil {
+		return ""
+	}
+	return a.Region
+}
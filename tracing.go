@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"update-lambda-runtime/pkg/bump"
+)
+
+const serviceName = "update-lambda-runtime"
+
+// tracer is resolved through the global provider, so spans are no-ops unless
+// startTracing installed an exporter.
+var tracer = otel.Tracer(serviceName)
+
+// startTracing exports spans over OTLP/HTTP when the standard
+// OTEL_EXPORTER_OTLP_ENDPOINT or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT variable
+// is set; the exporter reads the rest of its settings from the environment
+// too. The returned func flushes pending spans and must run before exit.
+func startTracing(ctx context.Context) (func(), error) {
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+		return func() {}, nil
+	}
+	exp, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("otlp exporter: %w", err)
+	}
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(serviceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("otel resource: %w", err)
+	}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exp), sdktrace.WithResource(res))
+	otel.SetTracerProvider(tp)
+	return func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+		defer cancel()
+		if err := tp.Shutdown(ctx); err != nil {
+			fmt.Fprintln(os.Stderr, "warning: flush traces:", err)
+		}
+	}, nil
+}
+
+// functionAttrs identifies the function a span is about.
+func functionAttrs(r functionResult) trace.SpanStartOption {
+	return trace.WithAttributes(
+		semconv.CloudAccountID(r.AccountID),
+		semconv.CloudRegion(r.Region),
+		semconv.FaaSName(r.Name),
+		attribute.String("lambda.runtime", r.Runtime),
+	)
+}
+
+// endSpan records how a function's bump ended on span and closes it.
+func endSpan(span trace.Span, o bump.Outcome) {
+	span.SetAttributes(attribute.String("outcome", string(o)))
+	if o != bump.Updated && o != bump.Started {
+		span.SetStatus(codes.Error, string(o))
+	}
+	span.End()
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lamtypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+// functionTrigger is something that invokes a function: an event source
+// mapping Lambda polls for it, or a service its resource-based policy lets
+// invoke it. Type is the source's service, such as sqs, kinesis, dynamodb,
+// events (EventBridge), s3 or sns; Source is its ARN, "" when the policy
+// lets any resource of the service in; State is a mapping's state.
+type functionTrigger struct {
+	Type   string `json:"type"`
+	Source string `json:"source,omitempty"`
+	State  string `json:"state,omitempty"`
+}
+
+// String names t briefly for a table cell, e.g. "sqs:orders" or
+// "dynamodb:orders (Disabled)" for a table's stream.
+func (t functionTrigger) String() string {
+	name := "*"
+	if t.Source != "" {
+		name = t.Source
+	}
+	if parts := strings.SplitN(t.Source, ":", 6); len(parts) == 6 && strings.HasPrefix(t.Source, "arn:") {
+		segs := strings.Split(parts[5], "/")
+		name = segs[len(segs)-1]
+		if parts[2] == "dynamodb" && len(segs) > 1 {
+			name = segs[1]
+		}
+	}
+	s := t.Type + ":" + name
+	if t.State != "" && t.State != "Enabled" {
+		s += " (" + t.State + ")"
+	}
+	return s
+}
+
+// triggersWidth sizes the Triggers column; longer lists push the row out.
+const triggersWidth = 24
+
+// triggerLookup finds the triggers of listed functions for --show-triggers:
+// a region's event source mappings are listed once, and each function's
+// resource-based policy read.
+type triggerLookup struct {
+	clients *clientFactory
+
+	mu       sync.Mutex
+	mappings map[string]map[string][]functionTrigger // region → function name → mappings
+}
+
+func newTriggerLookup(clients *clientFactory) *triggerLookup {
+	return &triggerLookup{clients: clients, mappings: make(map[string]map[string][]functionTrigger)}
+}
+
+// regionMappings lists region's event source mappings by function name,
+// the first time they are needed.
+func (l *triggerLookup) regionMappings(ctx context.Context, cli *lambda.Client, region string) (map[string][]functionTrigger, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if m, ok := l.mappings[region]; ok {
+		return m, nil
+	}
+	m := make(map[string][]functionTrigger)
+	p := lambda.NewListEventSourceMappingsPaginator(cli, &lambda.ListEventSourceMappingsInput{})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, esm := range page.EventSourceMappings {
+			name := functionNameFromARN(aws.ToString(esm.FunctionArn))
+			m[name] = append(m[name], mappingTrigger(esm))
+		}
+	}
+	l.mappings[region] = m
+	return m, nil
+}
+
+// fill sets the triggers of every function in rs, all from region, reading
+// policies listLookups at a time. A policy that cannot be read is warned
+// about and the function keeps its mappings.
+func (l *triggerLookup) fill(ctx context.Context, region string, rs []functionResult) error {
+	cli, err := l.clients.Lambda(ctx, region)
+	if err != nil {
+		return err
+	}
+	mappings, err := l.regionMappings(ctx, cli, region)
+	if err != nil {
+		return fmt.Errorf("list event source mappings: %w", err)
+	}
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, listLookups)
+	for i := range rs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			name, _, _ := strings.Cut(rs[i].Name, ":")
+			triggers := slices.Clone(mappings[name])
+			policy, err := functionPolicy(ctx, cli, rs[i].Name)
+			if err != nil {
+				if ctx.Err() == nil {
+					fmt.Fprintf(os.Stderr, "warning: policy of %s: %v\n", rs[i].Name, err)
+				}
+			} else {
+				triggers = append(triggers, policyTriggers(policy)...)
+			}
+			// Empty rather than nil once looked up, so "none" is told
+			// apart from "not looked up".
+			rs[i].Triggers = append([]functionTrigger{}, triggers...)
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+// functionPolicy reads fn's resource-based policy document, "" when it has
+// none.
+func functionPolicy(ctx context.Context, cli *lambda.Client, fn string) (string, error) {
+	out, err := cli.GetPolicy(ctx, &lambda.GetPolicyInput{FunctionName: aws.String(fn)})
+	var notFound *lamtypes.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(out.Policy), nil
+}
+
+// mappingTrigger describes an event source mapping. Self-managed Kafka has
+// no source ARN; its bootstrap servers stand in.
+func mappingTrigger(esm lamtypes.EventSourceMappingConfiguration) functionTrigger {
+	t := functionTrigger{Source: aws.ToString(esm.EventSourceArn), State: aws.ToString(esm.State)}
+	if parts := strings.SplitN(t.Source, ":", 4); len(parts) == 4 {
+		t.Type = parts[2]
+	}
+	if t.Source == "" && esm.SelfManagedEventSource != nil {
+		t.Type = "kafka"
+		t.Source = strings.Join(esm.SelfManagedEventSource.Endpoints["KAFKA_BOOTSTRAP_SERVERS"], ",")
+	}
+	t.Type = cmp.Or(t.Type, "unknown")
+	return t
+}
+
+// policyTriggers lists the services a resource-based policy allows to
+// invoke the function, with the resource each is limited to. Grants to
+// accounts and roles are left out: they are callers, not triggers.
+func policyTriggers(policy string) []functionTrigger {
+	if policy == "" {
+		return nil
+	}
+	var doc struct {
+		Statement []struct {
+			Effect    string
+			Principal json.RawMessage
+			Condition map[string]map[string]json.RawMessage
+		}
+	}
+	if err := json.Unmarshal([]byte(policy), &doc); err != nil {
+		return nil
+	}
+	var out []functionTrigger
+	for _, st := range doc.Statement {
+		var p struct{ Service json.RawMessage }
+		if st.Effect != "Allow" || json.Unmarshal(st.Principal, &p) != nil {
+			continue
+		}
+		var source string
+		for _, op := range []string{"ArnLike", "ArnEquals", "StringEquals", "StringLike"} {
+			if v, ok := st.Condition[op]["AWS:SourceArn"]; ok {
+				source = firstString(v)
+			}
+		}
+		for _, svc := range stringOrList(p.Service) {
+			t := strings.TrimSuffix(svc, ".amazonaws.com")
+			out = append(out, functionTrigger{Type: t, Source: source})
+		}
+	}
+	return out
+}
+
+// stringOrList reads an IAM policy value that is a string or a list of
+// them.
+func stringOrList(raw json.RawMessage) []string {
+	var one string
+	if json.Unmarshal(raw, &one) == nil {
+		if one == "" {
+			return nil
+		}
+		return []string{one}
+	}
+	var many []string
+	json.Unmarshal(raw, &many)
+	return many
+}
+
+func firstString(raw json.RawMessage) string {
+	if l := stringOrList(raw); len(l) > 0 {
+		return l[0]
+	}
+	return ""
+}
+
+// functionNameFromARN is the name in a function ARN, without any
+// qualifier.
+func functionNameFromARN(arn string) string {
+	parts := strings.Split(arn, ":")
+	if len(parts) < 7 {
+		return arn
+	}
+	return parts[6]
+}
+
+// triggerList is r's triggers for a table cell: "-" when it has none, "?"
+// when they could not be looked up.
+func triggerList(r functionResult) string {
+	switch {
+	case r.Triggers == nil:
+		return "?"
+	case len(r.Triggers) == 0:
+		return "-"
+	}
+	names := make([]string, len(r.Triggers))
+	for i, t := range r.Triggers {
+		names[i] = t.String()
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	lamtypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+func TestPolicyTriggers(t *testing.T) {
+	policy := `{"Version":"2012-10-17","Statement":[
+		{"Effect":"Allow","Principal":{"Service":"events.amazonaws.com"},"Action":"lambda:InvokeFunction",
+		 "Condition":{"ArnLike":{"AWS:SourceArn":"arn:aws:events:us-east-1:123456789012:rule/nightly"}}},
+		{"Effect":"Allow","Principal":{"Service":["s3.amazonaws.com"]},"Action":"lambda:InvokeFunction"},
+		{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::210987654321:root"},"Action":"lambda:InvokeFunction"},
+		{"Effect":"Deny","Principal":{"Service":"sns.amazonaws.com"},"Action":"lambda:InvokeFunction"}]}`
+	got := policyTriggers(policy)
+	want := []functionTrigger{
+		{Type: "events", Source: "arn:aws:events:us-east-1:123456789012:rule/nightly"},
+		{Type: "s3"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("policyTriggers = %+v, want %+v", got, want)
+	}
+	if got := policyTriggers(""); got != nil {
+		t.Errorf("no policy gave %+v", got)
+	}
+}
+
+func TestTriggerList(t *testing.T) {
+	sqs := mappingTrigger(lamtypes.EventSourceMappingConfiguration{
+		EventSourceArn: aws.String("arn:aws:sqs:us-east-1:123456789012:orders"),
+		State:          aws.String("Enabled"),
+	})
+	stream := mappingTrigger(lamtypes.EventSourceMappingConfiguration{
+		EventSourceArn: aws.String("arn:aws:dynamodb:us-east-1:123456789012:table/orders/stream/2024-01-01T00:00:00.000"),
+		State:          aws.String("Disabled"),
+	})
+	tests := []struct {
+		triggers []functionTrigger
+		want     string
+	}{
+		{nil, "?"},
+		{[]functionTrigger{}, "-"},
+		{[]functionTrigger{sqs, stream, {Type: "s3"}}, "sqs:orders, dynamodb:orders (Disabled), s3:*"},
+	}
+	for _, tt := range tests {
+		if got := triggerList(functionResult{Triggers: tt.triggers}); got != tt.want {
+			t.Errorf("triggerList(%+v) = %q, want %q", tt.triggers, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"update-lambda-runtime/pkg/bump"
+	"update-lambda-runtime/pkg/inventory"
+)
+
+// runUndo reverts every function bump run id updated to the runtime it had
+// before that run, from the run's record. Functions whose runtime has
+// changed again since are left alone, and layer and environment changes
+// the run made are not reverted; the handlers of go1.x functions moved to
+// bootstrap are. Lambda@Edge functions are republished to
+// their distributions as in bump. The undo is itself a run with its own ID,
+// so it can be undone in turn.
+func runUndo(ctx context.Context, opts *AWSOpts, id string) error {
+	rec, err := loadRunRecord(id)
+	if err != nil {
+		return err
+	}
+	ctx, span := tracer.Start(ctx, "undo", trace.WithAttributes(attribute.String("run.id", id)))
+	defer span.End()
+
+	started := time.Now()
+	undoOpts := *opts
+	undoOpts.Profile = cmp.Or(opts.Profile, rec.Profile)
+	undoOpts.Regions = rec.Regions
+	undoOpts.AssumeRole = rec.Role
+	clients := newClientFactory(undoOpts.Profile, opts.APITimeout, opts.MaxRPS).assuming(rec.Role)
+	acctID, err := resolveAccountID(ctx, clients)
+	if err != nil {
+		return fmt.Errorf("resolve account id: %w", err)
+	}
+	if acctID != rec.AccountID {
+		return fmt.Errorf("run %s was in account %s, but profile %s is account %s", id, rec.AccountID, undoOpts.Profile, acctID)
+	}
+
+	cf, edge := loadEdgeFunctions(ctx, clients, rec.Regions)
+	waitCtx, stopPolling := waitContext(ctx)
+	defer stopPolling()
+	poller := newUpdateWaiter(opts)
+	go poller.run(waitCtx)
+
+	results := newResultCollector(os.Stdout)
+	results.progressf("Undoing run %s (%s, finished %s)\n", id, bump.FormatMappings(rec.Mappings), inZone(rec.FinishedAt, time.Local).Format(time.DateTime))
+	type waiting struct {
+		span trace.Span
+		j    bumpJob
+		p    *pendingUpdate
+		done <-chan bump.Outcome
+	}
+	var pending []waiting
+	finish := func(span trace.Span, r functionResult, o bump.Outcome) {
+		endSpan(span, o)
+		r.Outcome = o
+		results.add(r)
+	}
+	pace := newUpdatePace(opts.UpdatesPerMinute)
+	mappings := make(map[string]string)
+	for _, was := range rec.Results {
+		if was.Outcome != bump.Updated && was.Outcome != bump.Started {
+			continue
+		}
+		r := functionResult{
+			AccountID:     acctID,
+			Profile:       undoOpts.Profile,
+			Region:        was.Region,
+			Name:          was.Name,
+			Runtime:       was.TargetRuntime,
+			Edge:          was.Edge,
+			TargetRuntime: was.Runtime,
+		}
+		ctx, span := tracer.Start(ctx, "update", functionAttrs(r))
+		if ctx.Err() != nil {
+			finish(span, r, bump.NotAttempted)
+			continue
+		}
+		cli, err := clients.Lambda(ctx, r.Region)
+		if err != nil {
+			return err
+		}
+		cur, err := inventory.Describe(ctx, cli, r.Name)
+		if err != nil {
+			results.progressf("  lookup error for %s: %v\n", r.Name, err)
+			r.Failure = bump.Classify(err)
+			finish(span, r, bump.Failed)
+			continue
+		}
+		if cur.Runtime != was.TargetRuntime {
+			r.Runtime = cur.Runtime
+			results.progressf("Skipping %s: now on %s, not %s as run %s left it\n", r.Name, cur.Runtime, was.TargetRuntime, id)
+			finish(span, r, bump.Skipped)
+			continue
+		}
+		mappings[r.Runtime] = r.TargetRuntime
+		if was.Handler != "" {
+			// Recorded in turn, so this undo can be undone.
+			r.Handler = cur.Handler
+		}
+		j := bumpJob{cli: cli, result: r, handler: was.Handler, distributions: edge[functionARN(r)]}
+		// A note of the run's change would be wrong once it is undone.
+		if desc, ok := bump.Unnote(cur.Description, was.Runtime, was.TargetRuntime); ok {
+			j.description = &desc
+		}
+		if awaitPace(ctx, pace) != nil {
+			finish(span, r, bump.NotAttempted)
+			continue
+		}
+		p, attempts, o, failure := startUpdate(ctx, results, j, opts, pace)
+		j.result.Attempts, j.result.Failure = attempts, failure
+		if p == nil {
+			finish(span, j.result, o)
+			continue
+		}
+		pending = append(pending, waiting{span, j, p, poller.track(p)})
+	}
+	for _, w := range pending {
+		o := <-w.done
+		w.j.result.Failure = w.p.Failure(o)
+		if o == bump.Updated && len(w.j.distributions) > 0 {
+			o, w.j.result.Detail = deployEdge(waitCtx, results, cf, w.j, opts.PollEvery, opts.EdgeTimeout)
+		}
+		finish(w.span, w.j.result, o)
+	}
+
+	undoOpts.Policy = &bump.Policy{Mappings: mappings}
+	rep := newRunReport(&undoOpts, acctID, started, ctx.Err() != nil, results.snapshot())
+	rep.RunID = newRunID(started)
+	writeBumpTable(os.Stdout, &undoOpts, rep)
+	if err := saveRunRecord(rep); err != nil {
+		fmt.Fprintln(os.Stderr, "warning: run record not saved:", err)
+	} else {
+		results.progressf("Undo run %s\n", rep.RunID)
+	}
+	if ctx.Err() != nil {
+		return stopped(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+)
+
+// goldenSuffix marks the recorded response to a fixture, next to it.
+const goldenSuffix = ".golden.json"
+
+// How a fixture fared, as shown in the Result column.
+const (
+	verifyRecorded   = "recorded"
+	verifyMatch      = "match"
+	verifyRegression = "regression"
+	verifyError      = "error"
+)
+
+// goldenResponse is what a function returned for one fixture.
+type goldenResponse struct {
+	StatusCode    int32           `json:"statusCode"`
+	FunctionError string          `json:"functionError,omitempty"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+// runVerify invokes every selected function that has fixtures, one call
+// per payload in <fixtures>/<function>/*.json. With opts.RecordGolden the
+// responses are stored as golden files; otherwise they are compared with
+// the stored ones and any difference in status, error or body is reported
+// as a regression. Record before a bump and verify after it.
+func runVerify(ctx context.Context, opts *AWSOpts, w io.Writer) error {
+	if err := validateCommon(opts); err != nil {
+		return err
+	}
+	if _, err := os.Stat(opts.Fixtures); err != nil {
+		return fmt.Errorf("--fixtures: %w", err)
+	}
+	all, err := filepath.Glob(filepath.Join(opts.Fixtures, "*", "*.json"))
+	if err != nil {
+		return err
+	}
+	fixtureWidth := len("Fixture")
+	for _, f := range all {
+		if !strings.HasSuffix(f, goldenSuffix) {
+			fixtureWidth = max(fixtureWidth, len(filepath.Base(f)))
+		}
+	}
+	tbl := newFunctionTable(w, opts, runtimeNameWidth, fixtureWidth)
+	printHeader(tbl, opts.ShowProfile, "Fixture", "Result")
+	counts := make(map[string]int)
+	var errs []error
+	err = eachFunction(ctx, opts, "verify", func(ctx context.Context, cli *lambda.Client, r functionResult) {
+		fixtures, err := filepath.Glob(filepath.Join(opts.Fixtures, r.Name, "*.json"))
+		if err != nil {
+			errs = append(errs, err)
+			return
+		}
+		fixtures = slices.DeleteFunc(fixtures, func(f string) bool { return strings.HasSuffix(f, goldenSuffix) })
+		for _, fixture := range fixtures {
+			result, detail := verifyFixture(ctx, cli, opts, r, fixture)
+			counts[result]++
+			if detail != "" {
+				result += ": " + detail
+			}
+			printRow(tbl, r.AccountID, r.Profile, r.Region, r.Name, r.Runtime, opts.ShowProfile, filepath.Base(fixture), result)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	if opts.RecordGolden {
+		fmt.Fprintf(w, "\nSummary: %d recorded, %d failed\n", counts[verifyRecorded], counts[verifyError])
+	} else {
+		fmt.Fprintf(w, "\nSummary: %d match, %d regressions, %d failed\n", counts[verifyMatch], counts[verifyRegression], counts[verifyError])
+	}
+	if counts[verifyRegression]+counts[verifyError] > 0 {
+		return errors.New("verification failed")
+	}
+	return nil
+}
+
+// verifyFixture invokes r with the payload in fixture and records or
+// compares the response, returning how it fared and why.
+func verifyFixture(ctx context.Context, cli *lambda.Client, opts *AWSOpts, r functionResult, fixture string) (string, string) {
+	payload, err := os.ReadFile(fixture)
+	if err != nil {
+		return verifyError, err.Error()
+	}
+	out, err := cli.Invoke(ctx, &lambda.InvokeInput{
+		FunctionName: aws.String(r.Name),
+		Payload:      payload,
+	})
+	if err != nil {
+		return verifyError, err.Error()
+	}
+	got := goldenResponse{
+		StatusCode:    out.StatusCode,
+		FunctionError: aws.ToString(out.FunctionError),
+		Payload:       rawJSON(out.Payload),
+	}
+	golden := strings.TrimSuffix(fixture, ".json") + "." + r.Region + goldenSuffix
+	if opts.RecordGolden {
+		b, err := json.MarshalIndent(got, "", "  ")
+		if err == nil {
+			err = os.WriteFile(golden, append(b, '\n'), 0o644)
+		}
+		if err != nil {
+			return verifyError, err.Error()
+		}
+		return verifyRecorded, ""
+	}
+	b, err := os.ReadFile(golden)
+	if err != nil {
+		return verifyError, "no golden response; run verify --record before the bump"
+	}
+	var want goldenResponse
+	if err := json.Unmarshal(b, &want); err != nil {
+		return verifyError, fmt.Sprintf("%s: %v", golden, err)
+	}
+	if diff := compareResponses(want, got, opts.IgnoreFields); diff != "" {
+		return verifyRegression, diff
+	}
+	return verifyMatch, ""
+}
+
+// rawJSON keeps a JSON payload as is and wraps anything else as a string,
+// so every response can be stored in a golden file.
+func rawJSON(b []byte) json.RawMessage {
+	if len(bytes.TrimSpace(b)) == 0 {
+		return json.RawMessage("null")
+	}
+	if json.Valid(b) {
+		return b
+	}
+	s, _ := json.Marshal(string(b))
+	return s
+}
+
+// compareResponses describes how got differs from want, or returns "".
+// Bodies are compared as JSON values, without the object keys in ignore
+// (at any depth), so fields such as request IDs or timestamps can be left
+// out.
+func compareResponses(want, got goldenResponse, ignore []string) string {
+	var diffs []string
+	if want.StatusCode != got.StatusCode {
+		diffs = append(diffs, fmt.Sprintf("status %d → %d", want.StatusCode, got.StatusCode))
+	}
+	if want.FunctionError != got.FunctionError {
+		diffs = append(diffs, fmt.Sprintf("error %q → %q", want.FunctionError, got.FunctionError))
+	}
+	var wantBody, gotBody any
+	if json.Unmarshal(want.Payload, &wantBody) != nil || json.Unmarshal(got.Payload, &gotBody) != nil {
+		if !bytes.Equal(want.Payload, got.Payload) {
+			diffs = append(diffs, "body differs")
+		}
+	} else if wantBody, gotBody = dropFields(wantBody, ignore), dropFields(gotBody, ignore); !reflect.DeepEqual(wantBody, gotBody) {
+		w, _ := json.Marshal(wantBody)
+		g, _ := json.Marshal(gotBody)
+		diffs = append(diffs, fmt.Sprintf("body %s → %s", truncate(string(w), 80), truncate(string(g), 80)))
+	}
+	return strings.Join(diffs, "; ")
+}
+
+// dropFields removes the keys in ignore from every object in v.
+func dropFields(v any, ignore []string) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, e := range v {
+			if slices.Contains(ignore, k) {
+				delete(v, k)
+				continue
+			}
+			v[k] = dropFields(e, ignore)
+		}
+	case []any:
+		for i, e := range v {
+			v[i] = dropFields(e, ignore)
+		}
+	}
+	return v
+}
+
+// truncate shortens s to n runes.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
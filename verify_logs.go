@@ -0,0 +1,148 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	logtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+)
+
+// logErrorPatterns are log lines that mean a function cannot run on its
+// new runtime: it fails to import its handler or its dependencies, or its
+// process dies.
+var logErrorPatterns = []string{"ImportModuleError", "Runtime.ExitError", "Runtime.UserCodeSyntaxError", "Runtime.HandlerNotFound"}
+
+// logPollEvery is how often a function's log group is read while
+// --verify-logs watches it.
+const logPollEvery = 15 * time.Second
+
+// logsAPI is the part of CloudWatch Logs log verification reads.
+type logsAPI interface {
+	FilterLogEvents(ctx context.Context, in *cloudwatchlogs.FilterLogEventsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.FilterLogEventsOutput, error)
+}
+
+// invokeAPI is the part of the Lambda API the test invoke uses.
+type invokeAPI interface {
+	Invoke(ctx context.Context, in *lambda.InvokeInput, optFns ...func(*lambda.Options)) (*lambda.InvokeOutput, error)
+}
+
+// logVerifier watches a function's log group for --verify-logs-window
+// after its update, optionally after invoking it with a test payload. It
+// fails the function on a line with one of logErrorPatterns, or when no
+// line matches the required pattern by the end of the window.
+type logVerifier struct {
+	pattern *regexp.Regexp
+	payload []byte // test invoke payload, or nil for none
+	window  time.Duration
+	every   time.Duration
+	logs    func(ctx context.Context, region string) (logsAPI, error)
+}
+
+func newLogVerifier(opts *AWSOpts, clients *clientFactory) (*logVerifier, error) {
+	pattern, err := regexp.Compile(opts.VerifyLogs)
+	if err != nil {
+		return nil, fmt.Errorf("--verify-logs: %w", err)
+	}
+	v := &logVerifier{pattern: pattern, window: opts.VerifyLogsWindow, every: logPollEvery}
+	if opts.VerifyLogsPayload != "" {
+		if v.payload, err = os.ReadFile(opts.VerifyLogsPayload); err != nil {
+			return nil, fmt.Errorf("--verify-logs-payload: %w", err)
+		}
+	}
+	var mu sync.Mutex
+	clis := make(map[string]logsAPI)
+	v.logs = func(ctx context.Context, region string) (logsAPI, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if cli, ok := clis[region]; ok {
+			return cli, nil
+		}
+		cfg, err := clients.Config(ctx)
+		if err != nil {
+			return nil, err
+		}
+		cli := cloudwatchlogs.NewFromConfig(cfg, func(o *cloudwatchlogs.Options) {
+			o.Region = region
+		})
+		clis[region] = cli
+		return cli, nil
+	}
+	return v, nil
+}
+
+// verify checks the logs j's function writes from now on, returning why
+// it failed, or "" when it passed.
+func (v *logVerifier) verify(ctx context.Context, cli invokeAPI, j bumpJob) (string, error) {
+	since := time.Now()
+	if v.payload != nil {
+		out, err := cli.Invoke(ctx, &lambda.InvokeInput{FunctionName: aws.String(j.result.Name), Payload: v.payload})
+		if err != nil {
+			return "", fmt.Errorf("test invoke: %w", err)
+		}
+		if out.FunctionError != nil {
+			return fmt.Sprintf("logs: test invoke failed: %s: %s", aws.ToString(out.FunctionError), truncate(string(out.Payload), 200)), nil
+		}
+	}
+	logs, err := v.logs(ctx, j.result.Region)
+	if err != nil {
+		return "", err
+	}
+	group := cmp.Or(j.logGroup, "/aws/lambda/"+j.result.Name)
+	deadline := since.Add(v.window)
+	seen := make(map[string]bool) // event IDs, as late lines are read again
+	matched := false
+	for {
+		// Lines arrive late, so each pass reads the whole window so far.
+		in := &cloudwatchlogs.FilterLogEventsInput{LogGroupName: aws.String(group), StartTime: aws.Int64(since.UnixMilli())}
+		for {
+			out, err := logs.FilterLogEvents(ctx, in)
+			var missing *logtypes.ResourceNotFoundException
+			if errors.As(err, &missing) {
+				break // the log group is made on the first invocation
+			}
+			if err != nil {
+				return "", fmt.Errorf("%s: %w", group, err)
+			}
+			for _, e := range out.Events {
+				id := aws.ToString(e.EventId)
+				if seen[id] {
+					continue
+				}
+				seen[id] = true
+				msg := aws.ToString(e.Message)
+				for _, p := range logErrorPatterns {
+					if strings.Contains(msg, p) {
+						return fmt.Sprintf("logs: %s in %s: %s", p, group, truncate(strings.TrimSpace(msg), 200)), nil
+					}
+				}
+				matched = matched || v.pattern.MatchString(msg)
+			}
+			if out.NextToken == nil {
+				break
+			}
+			in.NextToken = out.NextToken
+		}
+		if !time.Now().Before(deadline) {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return "", context.Cause(ctx)
+		case <-time.After(min(v.every, time.Until(deadline))):
+		}
+	}
+	if !matched {
+		return fmt.Sprintf("logs: no line of %s matched %q within %s", group, v.pattern, v.window), nil
+	}
+	return "", nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	logtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+)
+
+// fakeLogs serves a log group's lines, two per page, the later ones only
+// from the second read on, as lines arrive late.
+type fakeLogs struct {
+	group string
+	lines []string
+	late  []string
+	reads int
+}
+
+func (f *fakeLogs) FilterLogEvents(_ context.Context, in *cloudwatchlogs.FilterLogEventsInput, _ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.FilterLogEventsOutput, error) {
+	if aws.ToString(in.LogGroupName) != f.group {
+		return nil, &logtypes.ResourceNotFoundException{Message: aws.String("no such group")}
+	}
+	lines := f.lines
+	if in.NextToken == nil {
+		f.reads++
+	}
+	if f.reads > 1 {
+		lines = append(lines, f.late...)
+	}
+	start := 0
+	if in.NextToken != nil {
+		fmt.Sscan(*in.NextToken, &start)
+	}
+	out := &cloudwatchlogs.FilterLogEventsOutput{}
+	for i := start; i < len(lines) && i < start+2; i++ {
+		out.Events = append(out.Events, logtypes.FilteredLogEvent{EventId: aws.String(fmt.Sprint(i)), Message: aws.String(lines[i])})
+	}
+	if start+2 < len(lines) {
+		out.NextToken = aws.String(fmt.Sprint(start + 2))
+	}
+	return out, nil
+}
+
+type fakeInvoke struct {
+	payload       string
+	functionError string
+}
+
+func (f *fakeInvoke) Invoke(_ context.Context, in *lambda.InvokeInput, _ ...func(*lambda.Options)) (*lambda.InvokeOutput, error) {
+	f.payload = string(in.Payload)
+	out := &lambda.InvokeOutput{StatusCode: 200, Payload: []byte(`{"errorMessage": "boom"}`)}
+	if f.functionError != "" {
+		out.FunctionError = aws.String(f.functionError)
+	}
+	return out, nil
+}
+
+func TestLogVerifier(t *testing.T) {
+	job := bumpJob{result: functionResult{Region: "us-east-1", Name: "orders"}}
+	start := "START RequestId: 1 Version: $LATEST"
+	tests := []struct {
+		name       string
+		group      string // the log group the function writes to
+		lines      []string
+		late       []string
+		invokeFail string
+		want       string // in the reason; "" passes
+	}{
+		{name: "required line", lines: []string{start, "processed 3 orders", "END RequestId: 1"}},
+		{name: "required line arriving late", lines: []string{start}, late: []string{"processed 3 orders"}},
+		{name: "import error", lines: []string{start, "processed 3 orders", `[ERROR] Runtime.ImportModuleError: Unable to import module 'app': No module named 'distutils'`}, want: "ImportModuleError in /aws/lambda/orders"},
+		{name: "exit error arriving late", lines: []string{start, "processed 3 orders"}, late: []string{"RequestId: 1 Error: Runtime exited with error: signal: killed Runtime.ExitError"}, want: "Runtime.ExitError"},
+		{name: "no required line", lines: []string{start, "END RequestId: 1"}, want: `matched "processed \\d+ orders"`},
+		{name: "no log group yet", group: "/aws/lambda/other", want: "no line of /aws/lambda/orders matched"},
+		{name: "custom log group", group: "/custom/orders", lines: []string{"processed 1 orders"}},
+		{name: "failing test invoke", lines: []string{"processed 1 orders"}, invokeFail: "Unhandled", want: "test invoke failed: Unhandled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := &fakeLogs{group: cmp.Or(tt.group, "/aws/lambda/orders"), lines: tt.lines, late: tt.late}
+			v := &logVerifier{
+				pattern: regexp.MustCompile(`processed \d+ orders`),
+				payload: []byte(`{"ping": true}`),
+				window:  20 * time.Millisecond,
+				every:   5 * time.Millisecond,
+				logs:    func(context.Context, string) (logsAPI, error) { return logs, nil },
+			}
+			j := job
+			if tt.group == "/custom/orders" {
+				j.logGroup = tt.group
+			}
+			inv := &fakeInvoke{functionError: tt.invokeFail}
+			reason, err := v.verify(context.Background(), inv, j)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if inv.payload != `{"ping": true}` {
+				t.Errorf("invoked with %q, want the test payload", inv.payload)
+			}
+			if tt.want == "" && reason != "" || !strings.Contains(reason, tt.want) {
+				t.Errorf("reason %q, want %q", reason, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// runWatch rescans the fleet every opts.WatchInterval until interrupted,
+// keeping one metrics endpoint up across passes. Each pass is a list run,
+// or with --auto-bump a bump run, so notifications go out every pass and
+// --policy is re-read each time, picking up policy changes. With
+// --alert-new a list pass also alerts on functions new on deprecated
+// runtimes, so detection can be rolled out before enforcement. A failed
+// pass is reported and retried at the next interval rather than ending the
+// watch, as is a bump the kill switch stopped; only a signal ends it, and
+// that is a clean exit.
+func runWatch(ctx context.Context, opts *AWSOpts) error {
+	if opts.AlertNew {
+		if opts.AutoBump {
+			return fmt.Errorf("--alert-new never changes anything, so it cannot be used with --auto-bump")
+		}
+		if !opts.All {
+			return fmt.Errorf("--alert-new needs --all: what is new is told from the whole fleet")
+		}
+		if opts.SlackWebhook == "" && opts.SNSTopicARN == "" && len(opts.EmailTo) == 0 {
+			return fmt.Errorf("--alert-new needs --notify-slack, --notify-sns or --email-report")
+		}
+		if len(opts.EmailTo) > 0 && opts.EmailFrom == "" {
+			return fmt.Errorf("--email-report needs --email-from")
+		}
+	}
+	validate := validateList
+	pass := func(ctx context.Context, opts *AWSOpts, metrics *runMetrics) error {
+		res, err := listOnce(ctx, opts, metrics, newListTable(os.Stdout, os.Stderr, opts))
+		if opts.AlertNew && res != nil && ctx.Err() == nil {
+			alertNewDeprecated(ctx, opts, res)
+		}
+		return err
+	}
+	if opts.AutoBump {
+		validate = validateBump
+		pass = func(ctx context.Context, opts *AWSOpts, metrics *runMetrics) error {
+			_, err := bumpAndRender(ctx, os.Stdout, os.Stderr, opts, metrics)
+			return err
+		}
+	}
+	if err := validate(opts); err != nil {
+		return err
+	}
+	if opts.WatchInterval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+	metrics, stopMetrics, err := startMetrics(ctx, opts.MetricsAddr)
+	if err != nil {
+		return err
+	}
+	defer stopMetrics()
+
+	return watch(ctx, opts.WatchInterval, func(ctx context.Context) error {
+		return pass(ctx, opts, metrics)
+	})
+}
+
+// watch runs pass now and then every interval until ctx is done or a pass
+// is interrupted. A pass the kill switch stopped, or kept from starting,
+// does not end the watch: the switch is read again at the next pass.
+func watch(ctx context.Context, every time.Duration, pass func(context.Context) error) error {
+	tick := time.NewTicker(every)
+	defer tick.Stop()
+	for {
+		fmt.Fprintf(os.Stderr, "Scan started at %s\n", inZone(time.Now(), time.Local).Format(time.RFC3339))
+		err := pass(ctx)
+		switch {
+		case ctx.Err() != nil:
+			return nil
+		case errors.Is(err, errKillSwitch):
+			fmt.Fprintln(os.Stderr, "warning: bump skipped:", err)
+		case errors.Is(err, errInterrupted):
+			return nil
+		case err != nil:
+			fmt.Fprintln(os.Stderr, "warning: scan failed:", err)
+		}
+		fmt.Fprintf(os.Stderr, "Next scan at %s\n", inZone(time.Now().Add(every), time.Local).Format(time.RFC3339))
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-tick.C:
+		}
+	}
+}
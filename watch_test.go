@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestWatchKillSwitch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	passes := 0
+	err := watch(ctx, time.Millisecond, func(context.Context) error {
+		passes++
+		if passes == 3 {
+			cancel()
+			return errInterrupted
+		}
+		return fmt.Errorf("%w: /lambda-bump/enabled is %q", errKillSwitch, "false")
+	})
+	if err != nil {
+		t.Errorf("err = %v, want nil", err)
+	}
+	if passes != 3 {
+		t.Errorf("passes = %d, want 3: the kill switch skips a pass, not the watch", passes)
+	}
+}
+
+func TestWatchInterrupted(t *testing.T) {
+	passes := 0
+	err := watch(context.Background(), time.Millisecond, func(context.Context) error {
+		passes++
+		return errInterrupted
+	})
+	if err != nil || passes != 1 {
+		t.Errorf("err, passes = %v, %d; want nil, 1", err, passes)
+	}
+}